@@ -26,7 +26,7 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 	"github.com/sh-miyoshi/hekate/pkg/login"
 	defaultrole "github.com/sh-miyoshi/hekate/pkg/role"
-	"github.com/sh-miyoshi/hekate/pkg/util"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
 )
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -69,6 +69,13 @@ func setAPI(r *mux.Router) {
 	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/auth", oidcapiv1.AuthPOSTHandler).Methods("POST")
 	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/userinfo", oidcapiv1.UserInfoHandler).Methods("GET", "POST")
 	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/revoke", oidcapiv1.RevokeHandler).Methods("POST")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/introspect", oidcapiv1.IntrospectHandler).Methods("POST")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/logout", oidcapiv1.LogoutHandler).Methods("GET", "POST")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/par", oidcapiv1.PushedAuthRequestHandler).Methods("POST")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/register", oidcapiv1.ClientRegistrationHandler).Methods("POST")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/register/{clientID}", oidcapiv1.RegisteredClientGetHandler).Methods("GET")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/register/{clientID}", oidcapiv1.RegisteredClientUpdateHandler).Methods("PUT")
+	r.HandleFunc(basePath+"/project/{projectName}/openid-connect/register/{clientID}", oidcapiv1.RegisteredClientDeleteHandler).Methods("DELETE")
 
 	// OAuth
 	r.HandleFunc(basePath+"/project/{projectName}/oauth/device", oauthapiv1.DeviceRegisterHandler).Methods("POST")
@@ -89,6 +96,8 @@ func setAPI(r *mux.Router) {
 	r.HandleFunc(basePath+"/project/{projectName}", adminprojectapiv1.ProjectDeleteHandler).Methods("DELETE")
 	r.HandleFunc(basePath+"/project/{projectName}", adminprojectapiv1.ProjectGetHandler).Methods("GET")
 	r.HandleFunc(basePath+"/project/{projectName}", adminprojectapiv1.ProjectUpdateHandler).Methods("PUT")
+	r.HandleFunc(basePath+"/project/{projectName}/export", adminprojectapiv1.ProjectExportHandler).Methods("GET")
+	r.HandleFunc(basePath+"/project/import", adminprojectapiv1.ProjectImportHandler).Methods("POST")
 
 	// Keys API
 	r.HandleFunc(basePath+"/project/{projectName}/keys", adminkeysapiv1.KeysGetHandler).Methods("GET")
@@ -111,6 +120,7 @@ func setAPI(r *mux.Router) {
 	r.HandleFunc(basePath+"/project/{projectName}/client/{clientID}", adminclientapiv1.ClientDeleteHandler).Methods("DELETE")
 	r.HandleFunc(basePath+"/project/{projectName}/client/{clientID}", adminclientapiv1.ClientGetHandler).Methods("GET")
 	r.HandleFunc(basePath+"/project/{projectName}/client/{clientID}", adminclientapiv1.ClientUpdateHandler).Methods("PUT")
+	r.HandleFunc(basePath+"/project/{projectName}/client-registration-token", adminclientapiv1.RegistrationTokenCreateHandler).Methods("POST")
 
 	// Custom Role API
 	r.HandleFunc(basePath+"/project/{projectName}/role", adminroleapiv1.AllRoleGetHandler).Methods("GET")
@@ -131,6 +141,7 @@ func setAPI(r *mux.Router) {
 	//------------------------------
 	basePath = "/userapi/v1"
 	r.HandleFunc(basePath+"/project/{projectName}/user/{userID}", userapiv1.GetHandler).Methods("GET")
+	r.HandleFunc(basePath+"/project/{projectName}/user/{userID}", userapiv1.UpdateHandler).Methods("PUT")
 	r.HandleFunc(basePath+"/project/{projectName}/user/{userID}/change-password", userapiv1.ChangePasswordHandler)
 	r.HandleFunc(basePath+"/project/{projectName}/user/{userID}/logout", userapiv1.LogoutHandler).Methods("POST")
 	r.HandleFunc(basePath+"/project/{projectName}/user/{userID}/otp", userapiv1.OTPGenerateHandler).Methods("POST")
@@ -170,6 +181,20 @@ func initDB(dbType, connStr, adminName, adminPassword string) *errors.Error {
 		return errors.Append(err, "Failed to init database manager")
 	}
 
+	// Refuse to start with the database migrated by the newer server
+	if err := db.GetInst().CheckSchemaVersion(); err != nil {
+		return errors.Append(err, "Failed to check schema version")
+	}
+	if err := db.GetInst().MigrateUp(0); err != nil {
+		return errors.Append(err, "Failed to migrate database schema")
+	}
+	if err := db.GetInst().ProjectProtectSignKeys(); err != nil {
+		return errors.Append(err, "Failed to protect private keys of project")
+	}
+	if err := checkClientCAConfig(); err != nil {
+		return errors.Append(err, "Failed to check client CA config")
+	}
+
 	// Set Master Project if not exsits
 	err := db.GetInst().ProjectAdd(&model.ProjectInfo{
 		Name:         "master",
@@ -205,17 +230,16 @@ func initDB(dbType, connStr, adminName, adminPassword string) *errors.Error {
 	}
 
 	err = db.GetInst().UserAdd("master", &model.UserInfo{
-		ID:           uuid.New().String(),
-		ProjectName:  "master",
-		Name:         adminName,
-		CreatedAt:    time.Now(),
-		PasswordHash: util.CreateHash(adminPassword),
+		ID:          uuid.New().String(),
+		ProjectName: "master",
+		Name:        adminName,
+		CreatedAt:   time.Now(),
 		SystemRoles: []string{
 			// append cluster admin role
 			"read-cluster",
 			"write-cluster",
 		},
-	})
+	}, adminPassword)
 
 	if err != nil {
 		if errors.Contains(err, model.ErrUserAlreadyExists) {
@@ -230,6 +254,30 @@ func initDB(dbType, connStr, adminName, adminPassword string) *errors.Error {
 	return nil
 }
 
+// checkClientCAConfig refuses to start without the client CA file if any client uses tls_client_auth
+func checkClientCAConfig() *errors.Error {
+	if config.Get().HTTPSConfig.ClientCAFile != "" {
+		return nil
+	}
+
+	prjs, err := db.GetInst().ProjectGetList(nil)
+	if err != nil {
+		return errors.Append(err, "Failed to get project list")
+	}
+	for _, prj := range prjs {
+		clis, err := db.GetInst().ClientGetList(prj.Name, nil)
+		if err != nil {
+			return errors.Append(err, "Failed to get client list")
+		}
+		for _, c := range clis {
+			if c.TokenEndpointAuthMethod == "tls_client_auth" {
+				return errors.New("Invalid config", "client CA file is required because client %s in project %s uses tls_client_auth", c.ID, prj.Name)
+			}
+		}
+	}
+	return nil
+}
+
 func initAll() *errors.Error {
 	cfg := config.Get()
 
@@ -243,6 +291,26 @@ func initAll() *errors.Error {
 	}
 	logger.Debug("Successfully initialize system role")
 
+	// Initialize key provider before creating the project keys
+	if err := secret.InitKeyProvider(cfg.KeyProvider.Type, cfg.KeyProvider.MasterKeyFile); err != nil {
+		return errors.Append(err, "Failed to initialize key provider")
+	}
+	logger.Debug("Successfully initialize key provider")
+
+	// Restore memory database from the snapshot if enabled
+	if cfg.DB.Snapshot.File != "" {
+		var key []byte
+		if cfg.DB.Snapshot.Encrypt {
+			var err *errors.Error
+			key, err = secret.LoadMasterKey(cfg.KeyProvider.MasterKeyFile)
+			if err != nil {
+				return errors.Append(err, "Failed to load master key for snapshot")
+			}
+		}
+		db.InitSnapshot(cfg.DB.Snapshot.File, key)
+		logger.Debug("Enable snapshot of memory database: %s", cfg.DB.Snapshot.File)
+	}
+
 	// Initalize Database
 	if err := initDB(cfg.DB.Type, cfg.DB.ConnectionString, cfg.AdminName, cfg.AdminPassword); err != nil {
 		return errors.Append(err, "Failed to initialize database")
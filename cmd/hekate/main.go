@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -13,13 +18,37 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 )
 
+// shutdownTimeout is a max time to wait for the running requests on shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	// hekate migrate <up|down|status> [version] [flags]
+	var migrateCmd *migrateCommand
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		var err *errors.Error
+		migrateCmd, err = parseMigrateCommand(os.Args)
+		if err != nil {
+			errors.Print(errors.Append(err, "Failed to parse migrate command"))
+			os.Exit(1)
+		}
+		// remove subcommand to parse the remaining flags as the server
+		os.Args = append([]string{os.Args[0]}, migrateCmd.flags...)
+	}
+
 	// initialize config
 	if err := config.InitConfig(os.Args); err != nil {
 		errors.Print(errors.Append(err, "Failed to init config"))
 		os.Exit(1)
 	}
 
+	if migrateCmd != nil {
+		if err := migrateCmd.run(); err != nil {
+			errors.Print(errors.Append(err, "Failed to run migrate command"))
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize server
 	if err := initAll(); err != nil {
 		errors.Print(errors.Append(err, "Failed to init server"))
@@ -53,17 +82,56 @@ func main() {
 		},
 	})
 
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: corsOpts.Handler(r),
+	}
+	if cfg.HTTPSConfig.ClientCertRequest {
+		// the certificate is verified in the client authentication
+		// because self_signed_tls_client_auth accepts the certificate which is not issued by CA
+		srv.TLSConfig = &tls.Config{
+			ClientAuth: tls.RequestClientCert,
+		}
+	}
+
+	// Run snapshot of memory database
+	if cfg.DB.Snapshot.File != "" && cfg.DB.Snapshot.Interval > 0 {
+		go db.RunSnapshot(cfg.DB.Snapshot.Interval)
+	}
+
+	// Shutdown server gracefully by signal
+	shutdown := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+
+		logger.Info("Shutdown server")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shutdown server: %v", err)
+		}
+		close(shutdown)
+	}()
+
+	var err error
 	if cfg.HTTPSConfig.Enabled {
 		logger.Info("Run server as https")
-		if err := http.ListenAndServeTLS(addr, cfg.HTTPSConfig.CertFile, cfg.HTTPSConfig.KeyFile, corsOpts.Handler(r)); err != nil {
-			logger.Error("Failed to run server: %v", err)
-			os.Exit(1)
-		}
+		err = srv.ListenAndServeTLS(cfg.HTTPSConfig.CertFile, cfg.HTTPSConfig.KeyFile)
 	} else {
 		logger.Info("Run server as http")
-		if err := http.ListenAndServe(addr, corsOpts.Handler(r)); err != nil {
-			logger.Error("Failed to run server: %v", err)
-			os.Exit(1)
-		}
+		err = srv.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		logger.Error("Failed to run server: %v", err)
+		os.Exit(1)
+	}
+	<-shutdown
+
+	// Save the latest data of memory database
+	if err := db.GetInst().SaveSnapshot(); err != nil {
+		errors.Print(errors.Append(err, "Failed to save snapshot"))
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
+)
+
+const (
+	migrateActionUp     = "up"
+	migrateActionDown   = "down"
+	migrateActionStatus = "status"
+)
+
+// migrateCommand is a subcommand to manage the schema version of database
+type migrateCommand struct {
+	action  string
+	version int
+	flags   []string
+}
+
+// parseMigrateCommand parses the args such as "hekate migrate up [version] [flags]"
+func parseMigrateCommand(args []string) (*migrateCommand, *errors.Error) {
+	if len(args) < 3 || strings.HasPrefix(args[2], "-") {
+		return nil, errors.New("Invalid args", "Usage: %s migrate <up|down|status> [version] [flags]", args[0])
+	}
+
+	res := &migrateCommand{
+		action: args[2],
+	}
+	switch res.action {
+	case migrateActionUp, migrateActionDown, migrateActionStatus:
+	default:
+		return nil, errors.New("Invalid args", "Unknown migrate action %s", res.action)
+	}
+
+	rest := args[3:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		if res.action == migrateActionStatus {
+			return nil, errors.New("Invalid args", "migrate status does not accept version")
+		}
+		v, err := strconv.Atoi(rest[0])
+		if err != nil || v < 0 {
+			return nil, errors.New("Invalid args", "Invalid version %s", rest[0])
+		}
+		res.version = v
+		rest = rest[1:]
+	} else if res.action == migrateActionDown {
+		return nil, errors.New("Invalid args", "migrate down requires the target version")
+	}
+	res.flags = rest
+
+	return res, nil
+}
+
+func (c *migrateCommand) run() *errors.Error {
+	cfg := config.Get()
+
+	logger.InitLogger(cfg.ModeDebug, cfg.LogFile)
+
+	// migration steps may read or write the private keys of project
+	if err := secret.InitKeyProvider(cfg.KeyProvider.Type, cfg.KeyProvider.MasterKeyFile); err != nil {
+		return errors.Append(err, "Failed to initialize key provider")
+	}
+	if err := db.InitDBManager(cfg.DB.Type, cfg.DB.ConnectionString); err != nil {
+		return errors.Append(err, "Failed to init database manager")
+	}
+
+	switch c.action {
+	case migrateActionUp:
+		if err := db.GetInst().MigrateUp(c.version); err != nil {
+			return err
+		}
+		if err := db.GetInst().ProjectProtectSignKeys(); err != nil {
+			return errors.Append(err, "Failed to protect private keys of project")
+		}
+	case migrateActionDown:
+		if err := db.GetInst().MigrateDown(c.version); err != nil {
+			return err
+		}
+	}
+
+	st, err := db.GetInst().MigrationStatus()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Current Version: %d\n", st.CurrentVersion)
+	fmt.Printf("Latest Version:  %d\n", st.LatestVersion)
+	for _, step := range st.Steps {
+		state := "pending"
+		if step.Version <= st.CurrentVersion {
+			state = "applied"
+		}
+		fmt.Printf("  %4d  %-8s %s\n", step.Version, state, step.Description)
+	}
+	if st.CurrentVersion > st.LatestVersion {
+		fmt.Println("Database schema is newer than this server supports")
+	}
+	return nil
+}
@@ -199,3 +199,45 @@ func (h *Handler) ClientUpdate(projectName, clientID string, req *clientapi.Clie
 	}
 	return fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
 }
+
+// ClientRegistrationTokenCreate ...
+func (h *Handler) ClientRegistrationTokenCreate(projectName string, req *clientapi.RegistrationTokenCreateRequest) (*clientapi.RegistrationTokenCreateResponse, error) {
+	url := fmt.Sprintf("%s/adminapi/v1/project/%s/client-registration-token", h.serverAddr, projectName)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRes, err := h.request("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode == http.StatusOK {
+		var res clientapi.RegistrationTokenCreateResponse
+		if err := json.NewDecoder(httpRes.Body).Decode(&res); err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+
+	message := ""
+	var res errors.HTTPResponse
+	if err := json.NewDecoder(httpRes.Body).Decode(&res); err == nil {
+		message = res.Error
+	} else {
+		message = "No messages."
+	}
+
+	switch httpRes.StatusCode {
+	case 400:
+		return nil, fmt.Errorf("Invalid request. Message: %s", message)
+	case 403:
+		return nil, fmt.Errorf("Loggined user did not have permission. Please login with other user")
+	case 500:
+		return nil, fmt.Errorf("Internal server error occuered. Message: %s", message)
+	}
+	return nil, fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
+}
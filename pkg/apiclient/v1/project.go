@@ -230,3 +230,84 @@ func (h *Handler) ProjectKeysGet(projectName string) (*keysapi.KeysGetResponse,
 	}
 	return nil, fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
 }
+
+// ProjectExport ...
+func (h *Handler) ProjectExport(projectName string, includeKeys bool) (*projectapi.ProjectExportDocument, error) {
+	url := fmt.Sprintf("%s/adminapi/v1/project/%s/export", h.serverAddr, projectName)
+	if includeKeys {
+		url += "?keys=true"
+	}
+	httpRes, err := h.request("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode == http.StatusOK {
+		var res projectapi.ProjectExportDocument
+		if err := json.NewDecoder(httpRes.Body).Decode(&res); err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+	message := ""
+	var res errors.HTTPResponse
+	if err := json.NewDecoder(httpRes.Body).Decode(&res); err == nil {
+		message = res.Error
+	} else {
+		message = "No messages."
+	}
+
+	switch httpRes.StatusCode {
+	case 403:
+		return nil, fmt.Errorf("Loggined user did not have permission. Please login with other user")
+	case 404:
+		return nil, fmt.Errorf("Project %s is not found", projectName)
+	case 500:
+		return nil, fmt.Errorf("Internal server error occuered. Message: %s", message)
+	}
+	return nil, fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
+}
+
+// ProjectImport ...
+func (h *Handler) ProjectImport(doc *projectapi.ProjectExportDocument, strategy string) (*projectapi.ProjectImportResponse, error) {
+	url := fmt.Sprintf("%s/adminapi/v1/project/import?strategy=%s", h.serverAddr, strategy)
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	httpRes, err := h.request("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode == http.StatusOK {
+		var res projectapi.ProjectImportResponse
+		if err := json.NewDecoder(httpRes.Body).Decode(&res); err != nil {
+			return nil, err
+		}
+
+		return &res, nil
+	}
+	message := ""
+	var res errors.HTTPResponse
+	if err := json.NewDecoder(httpRes.Body).Decode(&res); err == nil {
+		message = res.Error
+	} else {
+		message = "No messages."
+	}
+
+	switch httpRes.StatusCode {
+	case 400:
+		return nil, fmt.Errorf("Invalid request. Message: %s", message)
+	case 403:
+		return nil, fmt.Errorf("Loggined user did not have permission. Please login with other user")
+	case 409:
+		return nil, fmt.Errorf("Entry already exists in project %s. Message: %s", doc.Project.Name, message)
+	case 500:
+		return nil, fmt.Errorf("Internal server error occuered. Message: %s", message)
+	}
+	return nil, fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
+}
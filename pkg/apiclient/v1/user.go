@@ -165,6 +165,49 @@ func (h *Handler) UserGetList(projectName string, userName string) ([]*userapi.U
 	return nil, fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
 }
 
+// UserUpdate ...
+func (h *Handler) UserUpdate(projectName string, userName string, req *userapi.UserPutRequest) error {
+	userID, err := h.getUserID(projectName, userName)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/adminapi/v1/project/%s/user/%s", h.serverAddr, projectName, userID)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpRes, err := h.request("PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	message := ""
+	var res errors.HTTPResponse
+	if err := json.NewDecoder(httpRes.Body).Decode(&res); err == nil {
+		message = res.Error
+	} else {
+		message = "No messages."
+	}
+
+	switch httpRes.StatusCode {
+	case 400:
+		return fmt.Errorf("Invalid request. Message: %s", message)
+	case 403:
+		return fmt.Errorf("Loggined user did not have permission. Please login with other user")
+	case 404:
+		return fmt.Errorf("User %s in project %s is not found", userName, projectName)
+	case 500:
+		return fmt.Errorf("Internal server error occuered. Message: %s", message)
+	}
+	return fmt.Errorf("Unexpected http response got. Message: %s", httpRes.Status)
+}
+
 // UserRoleAdd ...
 func (h *Handler) UserRoleAdd(projectName string, userName string, roleName string, roleType model.RoleType) error {
 	userID, err := h.getUserID(projectName, userName)
@@ -12,9 +12,13 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	jwthttp "github.com/sh-miyoshi/hekate/pkg/http"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 	"github.com/sh-miyoshi/hekate/pkg/role"
 )
 
+// defaultRegistrationTokenExpiresIn is a default lifetime of the initial access token in seconds
+const defaultRegistrationTokenExpiresIn = 24 * 60 * 60
+
 // AllClientGetHandler ...
 //   require role: read-project
 func AllClientGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -38,11 +42,24 @@ func AllClientGetHandler(w http.ResponseWriter, r *http.Request) {
 	res := []*ClientGetResponse{}
 	for _, client := range clients {
 		res = append(res, &ClientGetResponse{
-			ID:                  client.ID,
-			Secret:              client.Secret,
-			AccessType:          client.AccessType,
-			CreatedAt:           client.CreatedAt.Format(time.RFC3339),
-			AllowedCallbackURLs: client.AllowedCallbackURLs,
+			ID:                         client.ID,
+			Secret:                     client.Secret,
+			AccessType:                 client.AccessType,
+			CreatedAt:                  client.CreatedAt.Format(time.RFC3339),
+			AllowedCallbackURLs:        client.AllowedCallbackURLs,
+			JWKS:                       client.JWKS,
+			JWKSURI:                    client.JWKSURI,
+			RequestURIs:                client.RequestURIs,
+			PostLogoutRedirectURIs:     client.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:       client.BackchannelLogoutURI,
+			FrontchannelLogoutURI:      client.FrontchannelLogoutURI,
+			RequestObjectSigningAlg:    client.RequestObjectSigningAlg,
+			RequirePushedAuthRequests:  client.RequirePushedAuthRequests,
+			TokenEndpointAuthMethod:    client.TokenEndpointAuthMethod,
+			TLSClientAuthSubjectDN:     client.TLSClientAuthSubjectDN,
+			RequireDPoP:                client.RequireDPoP,
+			TokenExchangeAudiences:     client.TokenExchangeAudiences,
+			TokenExchangeImpersonation: client.TokenExchangeImpersonation,
 		})
 	}
 
@@ -84,12 +101,25 @@ func ClientCreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create Client Entry
 	client := model.ClientInfo{
-		ID:                  request.ID,
-		ProjectName:         projectName,
-		Secret:              request.Secret,
-		AccessType:          request.AccessType,
-		CreatedAt:           time.Now(),
-		AllowedCallbackURLs: request.AllowedCallbackURLs,
+		ID:                         request.ID,
+		ProjectName:                projectName,
+		Secret:                     request.Secret,
+		AccessType:                 request.AccessType,
+		CreatedAt:                  time.Now(),
+		AllowedCallbackURLs:        request.AllowedCallbackURLs,
+		JWKS:                       request.JWKS,
+		JWKSURI:                    request.JWKSURI,
+		RequestURIs:                request.RequestURIs,
+		PostLogoutRedirectURIs:     request.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:       request.BackchannelLogoutURI,
+		FrontchannelLogoutURI:      request.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:    request.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:  request.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:    request.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:     request.TLSClientAuthSubjectDN,
+		RequireDPoP:                request.RequireDPoP,
+		TokenExchangeAudiences:     request.TokenExchangeAudiences,
+		TokenExchangeImpersonation: request.TokenExchangeImpersonation,
 	}
 
 	if err = db.GetInst().ClientAdd(projectName, &client); err != nil {
@@ -108,11 +138,24 @@ func ClientCreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Return Response
 	res := ClientGetResponse{
-		ID:                  client.ID,
-		Secret:              client.Secret,
-		AccessType:          client.AccessType,
-		CreatedAt:           client.CreatedAt.Format(time.RFC3339),
-		AllowedCallbackURLs: client.AllowedCallbackURLs,
+		ID:                         client.ID,
+		Secret:                     client.Secret,
+		AccessType:                 client.AccessType,
+		CreatedAt:                  client.CreatedAt.Format(time.RFC3339),
+		AllowedCallbackURLs:        client.AllowedCallbackURLs,
+		JWKS:                       client.JWKS,
+		JWKSURI:                    client.JWKSURI,
+		RequestURIs:                client.RequestURIs,
+		PostLogoutRedirectURIs:     client.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:       client.BackchannelLogoutURI,
+		FrontchannelLogoutURI:      client.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:    client.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:  client.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:    client.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:     client.TLSClientAuthSubjectDN,
+		RequireDPoP:                client.RequireDPoP,
+		TokenExchangeAudiences:     client.TokenExchangeAudiences,
+		TokenExchangeImpersonation: client.TokenExchangeImpersonation,
 	}
 
 	jwthttp.ResponseWrite(w, "ClientCreateHandler", &res)
@@ -186,11 +229,24 @@ func ClientGetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	res := ClientGetResponse{
-		ID:                  client.ID,
-		Secret:              client.Secret,
-		AccessType:          client.AccessType,
-		CreatedAt:           client.CreatedAt.Format(time.RFC3339),
-		AllowedCallbackURLs: client.AllowedCallbackURLs,
+		ID:                         client.ID,
+		Secret:                     client.Secret,
+		AccessType:                 client.AccessType,
+		CreatedAt:                  client.CreatedAt.Format(time.RFC3339),
+		AllowedCallbackURLs:        client.AllowedCallbackURLs,
+		JWKS:                       client.JWKS,
+		JWKSURI:                    client.JWKSURI,
+		RequestURIs:                client.RequestURIs,
+		PostLogoutRedirectURIs:     client.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:       client.BackchannelLogoutURI,
+		FrontchannelLogoutURI:      client.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:    client.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:  client.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:    client.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:     client.TLSClientAuthSubjectDN,
+		RequireDPoP:                client.RequireDPoP,
+		TokenExchangeAudiences:     client.TokenExchangeAudiences,
+		TokenExchangeImpersonation: client.TokenExchangeImpersonation,
 	}
 
 	jwthttp.ResponseWrite(w, "ClientGetHandler", &res)
@@ -248,6 +304,19 @@ func ClientUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	client.Secret = request.Secret
 	client.AccessType = request.AccessType
 	client.AllowedCallbackURLs = request.AllowedCallbackURLs
+	client.JWKS = request.JWKS
+	client.JWKSURI = request.JWKSURI
+	client.RequestURIs = request.RequestURIs
+	client.PostLogoutRedirectURIs = request.PostLogoutRedirectURIs
+	client.BackchannelLogoutURI = request.BackchannelLogoutURI
+	client.FrontchannelLogoutURI = request.FrontchannelLogoutURI
+	client.RequestObjectSigningAlg = request.RequestObjectSigningAlg
+	client.RequirePushedAuthRequests = request.RequirePushedAuthRequests
+	client.TokenEndpointAuthMethod = request.TokenEndpointAuthMethod
+	client.TLSClientAuthSubjectDN = request.TLSClientAuthSubjectDN
+	client.RequireDPoP = request.RequireDPoP
+	client.TokenExchangeAudiences = request.TokenExchangeAudiences
+	client.TokenExchangeImpersonation = request.TokenExchangeImpersonation
 
 	// Update DB
 	if err = db.GetInst().ClientUpdate(projectName, client); err != nil {
@@ -264,3 +333,65 @@ func ClientUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	logger.Info("ClientUpdateHandler method successfully finished")
 }
+
+// RegistrationTokenCreateHandler issues an initial access token for the dynamic client registration
+//   require role: write-project
+func RegistrationTokenCreateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "CLIENT", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	// Authorize API Request
+	if err = jwthttp.Authorize(r, projectName, role.ResProject, role.TypeWrite); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to authorize header"))
+		errors.WriteToHTTP(w, errors.ErrUnpermitted, 0, "")
+		return
+	}
+
+	// Parse Request
+	var request RegistrationTokenCreateRequest
+	if e := json.NewDecoder(r.Body).Decode(&request); e != nil {
+		err = errors.Append(errors.ErrInvalidRequest, "Failed to decode registration token create request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		return
+	}
+	if request.ExpiresIn < 0 {
+		err = errors.Append(errors.ErrInvalidRequest, "Invalid expires_in %d", request.ExpiresIn)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		return
+	}
+	if request.ExpiresIn == 0 {
+		request.ExpiresIn = defaultRegistrationTokenExpiresIn
+	}
+
+	var tkn string
+	tkn, err = token.GenerateInitialAccessToken(token.Request{
+		Issuer:      token.GetExpectIssuer(r) + "/authapi/v1/project/" + projectName,
+		ExpiresIn:   request.ExpiresIn,
+		ProjectName: projectName,
+	})
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to generate initial access token"))
+		errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	res := RegistrationTokenCreateResponse{
+		Token:     tkn,
+		ExpiresIn: request.ExpiresIn,
+	}
+
+	jwthttp.ResponseWrite(w, "RegistrationTokenCreateHandler", &res)
+}
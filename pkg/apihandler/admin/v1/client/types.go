@@ -2,24 +2,74 @@ package clientapi
 
 // ClientCreateRequest ...
 type ClientCreateRequest struct {
-	ID                  string   `json:"id"`
-	Secret              string   `json:"secret"`
-	AccessType          string   `json:"access_type"`
-	AllowedCallbackURLs []string `json:"allowed_callback_urls"`
+	ID                         string   `json:"id"`
+	Secret                     string   `json:"secret"`
+	AccessType                 string   `json:"access_type"`
+	AllowedCallbackURLs        []string `json:"allowed_callback_urls"`
+	JWKS                       string   `json:"jwks,omitempty"`
+	JWKSURI                    string   `json:"jwks_uri,omitempty"`
+	RequestURIs                []string `json:"request_uris,omitempty"`
+	PostLogoutRedirectURIs     []string `json:"post_logout_redirect_uris,omitempty"`
+	BackchannelLogoutURI       string   `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI      string   `json:"frontchannel_logout_uri,omitempty"`
+	RequestObjectSigningAlg    string   `json:"request_object_signing_alg,omitempty"`
+	RequirePushedAuthRequests  bool     `json:"require_pushed_authorization_requests"`
+	TokenEndpointAuthMethod    string   `json:"token_endpoint_auth_method,omitempty"`
+	TLSClientAuthSubjectDN     string   `json:"tls_client_auth_subject_dn,omitempty"`
+	RequireDPoP                bool     `json:"require_dpop"`
+	TokenExchangeAudiences     []string `json:"token_exchange_audiences,omitempty"`
+	TokenExchangeImpersonation bool     `json:"token_exchange_impersonation"`
 }
 
 // ClientGetResponse ...
 type ClientGetResponse struct {
-	ID                  string   `json:"id"`
-	Secret              string   `json:"secret"`
-	AccessType          string   `json:"access_type"`
-	CreatedAt           string   `json:"created_at"`
-	AllowedCallbackURLs []string `json:"allowed_callback_urls"`
+	ID                         string   `json:"id"`
+	Secret                     string   `json:"secret"`
+	AccessType                 string   `json:"access_type"`
+	CreatedAt                  string   `json:"created_at"`
+	AllowedCallbackURLs        []string `json:"allowed_callback_urls"`
+	JWKS                       string   `json:"jwks,omitempty"`
+	JWKSURI                    string   `json:"jwks_uri,omitempty"`
+	RequestURIs                []string `json:"request_uris,omitempty"`
+	PostLogoutRedirectURIs     []string `json:"post_logout_redirect_uris,omitempty"`
+	BackchannelLogoutURI       string   `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI      string   `json:"frontchannel_logout_uri,omitempty"`
+	RequestObjectSigningAlg    string   `json:"request_object_signing_alg,omitempty"`
+	RequirePushedAuthRequests  bool     `json:"require_pushed_authorization_requests"`
+	TokenEndpointAuthMethod    string   `json:"token_endpoint_auth_method,omitempty"`
+	TLSClientAuthSubjectDN     string   `json:"tls_client_auth_subject_dn,omitempty"`
+	RequireDPoP                bool     `json:"require_dpop"`
+	TokenExchangeAudiences     []string `json:"token_exchange_audiences,omitempty"`
+	TokenExchangeImpersonation bool     `json:"token_exchange_impersonation"`
 }
 
 // ClientPutRequest ...
 type ClientPutRequest struct {
-	Secret              string   `json:"secret"`
-	AccessType          string   `json:"access_type"`
-	AllowedCallbackURLs []string `json:"allowed_callback_urls"`
+	Secret                     string   `json:"secret"`
+	AccessType                 string   `json:"access_type"`
+	AllowedCallbackURLs        []string `json:"allowed_callback_urls"`
+	JWKS                       string   `json:"jwks,omitempty"`
+	JWKSURI                    string   `json:"jwks_uri,omitempty"`
+	RequestURIs                []string `json:"request_uris,omitempty"`
+	PostLogoutRedirectURIs     []string `json:"post_logout_redirect_uris,omitempty"`
+	BackchannelLogoutURI       string   `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI      string   `json:"frontchannel_logout_uri,omitempty"`
+	RequestObjectSigningAlg    string   `json:"request_object_signing_alg,omitempty"`
+	RequirePushedAuthRequests  bool     `json:"require_pushed_authorization_requests"`
+	TokenEndpointAuthMethod    string   `json:"token_endpoint_auth_method,omitempty"`
+	TLSClientAuthSubjectDN     string   `json:"tls_client_auth_subject_dn,omitempty"`
+	RequireDPoP                bool     `json:"require_dpop"`
+	TokenExchangeAudiences     []string `json:"token_exchange_audiences,omitempty"`
+	TokenExchangeImpersonation bool     `json:"token_exchange_impersonation"`
+}
+
+// RegistrationTokenCreateRequest ...
+type RegistrationTokenCreateRequest struct {
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// RegistrationTokenCreateResponse ...
+type RegistrationTokenCreateResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"`
 }
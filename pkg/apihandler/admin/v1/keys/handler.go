@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sh-miyoshi/hekate/pkg/audit"
 	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	jwthttp "github.com/sh-miyoshi/hekate/pkg/http"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
@@ -35,12 +36,27 @@ func KeysGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicKey := base64.StdEncoding.EncodeToString(project.TokenConfig.SignPublicKey)
-
 	// Return Response
 	res := KeysGetResponse{
-		Type:      project.TokenConfig.SigningAlgorithm,
-		PublicKey: publicKey,
+		Type: project.TokenConfig.SigningAlgorithm,
+		Keys: []KeyInfo{},
+	}
+	if active := project.TokenConfig.ActiveSignKey(); active != nil {
+		res.PublicKey = base64.StdEncoding.EncodeToString(active.PublicKey)
+	}
+
+	for _, key := range project.TokenConfig.PublishedSignKeys(time.Now()) {
+		info := KeyInfo{
+			KeyID:     key.KeyID,
+			State:     string(key.State),
+			Type:      key.SigningAlgorithm,
+			PublicKey: base64.StdEncoding.EncodeToString(key.PublicKey),
+			CreatedAt: key.CreatedAt.Format(time.RFC3339),
+		}
+		if key.State == model.SignKeyStateRetired {
+			info.ExpiresAt = key.ExpiresAt.Format(time.RFC3339)
+		}
+		res.Keys = append(res.Keys, info)
 	}
 
 	jwthttp.ResponseWrite(w, "KeysGetHandler", &res)
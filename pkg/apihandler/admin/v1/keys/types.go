@@ -1,7 +1,18 @@
 package keysapi
 
-// KeysGetResponse ...
-type KeysGetResponse struct {
+// KeyInfo ...
+type KeyInfo struct {
+	KeyID     string `json:"kid"`
+	State     string `json:"state"`
 	Type      string `json:"type"`
 	PublicKey string `json:"publicKey"`
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// KeysGetResponse ...
+type KeysGetResponse struct {
+	Type      string    `json:"type"`
+	PublicKey string    `json:"publicKey"`
+	Keys      []KeyInfo `json:"keys"`
 }
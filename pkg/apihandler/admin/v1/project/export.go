@@ -0,0 +1,538 @@
+package projectapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jwthttp "github.com/sh-miyoshi/hekate/pkg/http"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/role"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectExportHandler ...
+//   require role: write-cluster
+//   query: keys=true to include sign keys, format=yaml to output as YAML
+func ProjectExportHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "PROJECT", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	// Authorize API Request
+	// the document contains the private keys, the password hashes and the client secrets,
+	// so require the cluster write permission as same as import
+	if err = jwthttp.Authorize(r, "", role.ResCluster, role.TypeWrite); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to authorize header"))
+		errors.WriteToHTTP(w, errors.ErrUnpermitted, 0, "")
+		return
+	}
+
+	data, err := db.GetInst().ProjectExport(projectName)
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to export project"))
+		errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	includeKeys := r.URL.Query().Get("keys") == "true"
+	doc, err := toExportDocument(data, includeKeys)
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to convert to export document"))
+		errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	if r.URL.Query().Get("format") == "yaml" {
+		b, e := yaml.Marshal(doc)
+		if e != nil {
+			err = errors.New("Internal server error", "Failed to marshal export document to yaml: %v", e)
+			errors.Print(err)
+			errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+			return
+		}
+		w.Header().Add("Content-Type", "application/x-yaml")
+		w.Write(b)
+		logger.Info("ProjectExportHandler method successfully finished")
+		return
+	}
+
+	jwthttp.ResponseWrite(w, "ProjectExportHandler", doc)
+}
+
+// ProjectImportHandler ...
+//   require role: write-cluster
+//   query: strategy=skip|overwrite|fail (default: fail)
+func ProjectImportHandler(w http.ResponseWriter, r *http.Request) {
+	var err *errors.Error
+	projectName := ""
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "PROJECT", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	// Authorize API Request
+	if err = jwthttp.Authorize(r, "", role.ResCluster, role.TypeWrite); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to authorize header"))
+		errors.WriteToHTTP(w, errors.ErrUnpermitted, 0, "")
+		return
+	}
+
+	strategy := model.ImportStrategyFail
+	if s := r.URL.Query().Get("strategy"); s != "" {
+		if strategy, err = model.GetImportStrategy(s); err != nil {
+			errors.PrintAsInfo(errors.Append(err, "Invalid import strategy"))
+			errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+			return
+		}
+	}
+
+	// Parse Request
+	body, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		err = errors.New("Invalid request", "Failed to read project import request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		return
+	}
+	var doc ProjectExportDocument
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		e = yaml.Unmarshal(body, &doc)
+	} else {
+		e = json.Unmarshal(body, &doc)
+	}
+	if e != nil {
+		err = errors.New("Invalid request", "Failed to decode project import request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		return
+	}
+	projectName = doc.Project.Name
+
+	data, err := fromExportDocument(&doc)
+	if err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Invalid import document"))
+		errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		return
+	}
+
+	res, err := db.GetInst().ProjectImport(data, strategy)
+	if err != nil {
+		if errors.Contains(err, model.ErrImportConflict) {
+			errors.PrintAsInfo(errors.Append(err, "Failed to import project"))
+			errors.WriteToHTTP(w, err, http.StatusConflict, "")
+		} else if errors.Contains(err, model.ErrImportValidateFailed) ||
+			errors.Contains(err, model.ErrProjectValidateFailed) ||
+			errors.Contains(err, model.ErrClientValidateFailed) ||
+			errors.Contains(err, model.ErrCustomRoleValidateFailed) ||
+			errors.Contains(err, model.ErrUserValidateFailed) {
+			errors.PrintAsInfo(errors.Append(err, "Invalid import document"))
+			errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		} else {
+			errors.Print(errors.Append(err, "Failed to import project"))
+			errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	jwthttp.ResponseWrite(w, "ProjectImportHandler", &ProjectImportResponse{
+		Created: res.Created,
+		Updated: res.Updated,
+		Skipped: res.Skipped,
+	})
+}
+
+func toExportDocument(data *model.ProjectData, includeKeys bool) (*ProjectExportDocument, *errors.Error) {
+	prj := data.Project
+	grantTypes := []string{}
+	for _, t := range prj.AllowGrantTypes {
+		grantTypes = append(grantTypes, string(t))
+	}
+
+	res := &ProjectExportDocument{
+		Version: ExportFormatVersion,
+		Project: ExportProject{
+			Name:      prj.Name,
+			CreatedAt: prj.CreatedAt.Format(time.RFC3339),
+			TokenConfig: ExportTokenConfig{
+				AccessTokenLifeSpan:  prj.TokenConfig.AccessTokenLifeSpan,
+				RefreshTokenLifeSpan: prj.TokenConfig.RefreshTokenLifeSpan,
+				RefreshTokenRotation: prj.TokenConfig.RefreshTokenRotation,
+				SigningAlgorithm:     prj.TokenConfig.SigningAlgorithm,
+				KeyRotationInterval:  prj.TokenConfig.KeyRotationInterval,
+			},
+			PasswordPolicy: ExportPasswordPolicy{
+				MinimumLength:       prj.PasswordPolicy.MinimumLength,
+				NotUserName:         prj.PasswordPolicy.NotUserName,
+				BlackList:           prj.PasswordPolicy.BlackList,
+				UseCharacter:        string(prj.PasswordPolicy.UseCharacter),
+				UseDigit:            prj.PasswordPolicy.UseDigit,
+				UseSpecialCharacter: prj.PasswordPolicy.UseSpecialCharacter,
+			},
+			AllowGrantTypes: grantTypes,
+			UserLock: ExportUserLock{
+				Enabled:          prj.UserLock.Enabled,
+				MaxLoginFailure:  prj.UserLock.MaxLoginFailure,
+				LockDuration:     prj.UserLock.LockDuration,
+				FailureResetTime: prj.UserLock.FailureResetTime,
+			},
+			PasswordHashConfig: ExportPasswordHashConfig{
+				Algorithm:         string(prj.PasswordHashConfig.Algorithm),
+				Argon2Iterations:  prj.PasswordHashConfig.Argon2Iterations,
+				Argon2Memory:      prj.PasswordHashConfig.Argon2Memory,
+				Argon2Parallelism: prj.PasswordHashConfig.Argon2Parallelism,
+				BcryptCost:        prj.PasswordHashConfig.BcryptCost,
+			},
+		},
+		Clients:     []ExportClient{},
+		CustomRoles: []ExportCustomRole{},
+		Users:       []ExportUser{},
+	}
+
+	for _, t := range prj.TrustedIssuers {
+		issuer := ExportTrustedIssuer{
+			Issuer:          t.Issuer,
+			JWKS:            t.JWKS,
+			Audience:        t.Audience,
+			SubjectMappings: []ExportSubjectMapping{},
+		}
+		for _, m := range t.SubjectMappings {
+			issuer.SubjectMappings = append(issuer.SubjectMappings, ExportSubjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res.Project.TrustedIssuers = append(res.Project.TrustedIssuers, issuer)
+	}
+
+	if includeKeys {
+		for _, k := range prj.TokenConfig.SignKeys {
+			// the key protected by the provider can not be used in other servers
+			priv, err := secret.GetKeyProvider().Unprotect(k.SigningAlgorithm, k.PrivateKey)
+			if err != nil {
+				return nil, errors.Append(err, "Failed to unprotect private key %s", k.KeyID)
+			}
+			key := ExportSignKey{
+				KeyID:            k.KeyID,
+				State:            string(k.State),
+				SigningAlgorithm: k.SigningAlgorithm,
+				PublicKey:        base64.StdEncoding.EncodeToString(k.PublicKey),
+				PrivateKey:       base64.StdEncoding.EncodeToString(priv),
+				CreatedAt:        k.CreatedAt.Format(time.RFC3339),
+			}
+			if !k.ActivatedAt.IsZero() {
+				key.ActivatedAt = k.ActivatedAt.Format(time.RFC3339)
+			}
+			if !k.ExpiresAt.IsZero() {
+				key.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+			}
+			res.Project.TokenConfig.SignKeys = append(res.Project.TokenConfig.SignKeys, key)
+		}
+	}
+
+	for _, c := range data.Clients {
+		res.Clients = append(res.Clients, ExportClient{
+			ID:                          c.ID,
+			Secret:                      c.Secret,
+			AccessType:                  c.AccessType,
+			CreatedAt:                   c.CreatedAt.Format(time.RFC3339),
+			AllowedCallbackURLs:         c.AllowedCallbackURLs,
+			JWKS:                        c.JWKS,
+			JWKSURI:                     c.JWKSURI,
+			RequestURIs:                 c.RequestURIs,
+			PostLogoutRedirectURIs:      c.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:        c.BackchannelLogoutURI,
+			FrontchannelLogoutURI:       c.FrontchannelLogoutURI,
+			RequestObjectSigningAlg:     c.RequestObjectSigningAlg,
+			RequirePushedAuthRequests:   c.RequirePushedAuthRequests,
+			TokenEndpointAuthMethod:     c.TokenEndpointAuthMethod,
+			TLSClientAuthSubjectDN:      c.TLSClientAuthSubjectDN,
+			RequireDPoP:                 c.RequireDPoP,
+			TokenExchangeAudiences:      c.TokenExchangeAudiences,
+			TokenExchangeImpersonation:  c.TokenExchangeImpersonation,
+			RegistrationAccessTokenHash: c.RegistrationAccessTokenHash,
+		})
+	}
+
+	for _, r := range data.CustomRoles {
+		res.CustomRoles = append(res.CustomRoles, ExportCustomRole{
+			ID:        r.ID,
+			Name:      r.Name,
+			CreatedAt: r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	for _, u := range data.Users {
+		res.Users = append(res.Users, ExportUser{
+			ID:           u.ID,
+			Name:         u.Name,
+			CreatedAt:    u.CreatedAt.Format(time.RFC3339),
+			PasswordHash: u.PasswordHash,
+			SystemRoles:  u.SystemRoles,
+			CustomRoles:  u.CustomRoles,
+			Locked:       u.LockState.Locked,
+			OTP: ExportOTP{
+				ID:         u.OTPInfo.ID,
+				PrivateKey: u.OTPInfo.PrivateKey,
+				Enabled:    u.OTPInfo.Enabled,
+			},
+			Profile: ExportUserProfile{
+				Name:                u.Profile.FullName,
+				GivenName:           u.Profile.GivenName,
+				FamilyName:          u.Profile.FamilyName,
+				MiddleName:          u.Profile.MiddleName,
+				Nickname:            u.Profile.Nickname,
+				Profile:             u.Profile.ProfileURL,
+				Picture:             u.Profile.Picture,
+				Website:             u.Profile.Website,
+				Gender:              u.Profile.Gender,
+				Birthdate:           u.Profile.Birthdate,
+				Zoneinfo:            u.Profile.Zoneinfo,
+				Locale:              u.Profile.Locale,
+				Email:               u.Profile.Email,
+				EmailVerified:       u.Profile.EmailVerified,
+				PhoneNumber:         u.Profile.PhoneNumber,
+				PhoneNumberVerified: u.Profile.PhoneNumberVerified,
+				Address:             ExportAddress(u.Profile.Address),
+			},
+			Attributes: u.Attributes,
+		})
+	}
+
+	return res, nil
+}
+
+func fromExportDocument(doc *ProjectExportDocument) (*model.ProjectData, *errors.Error) {
+	if doc.Version != ExportFormatVersion {
+		return nil, errors.Append(model.ErrImportValidateFailed, "Document version %s is not supported", doc.Version)
+	}
+
+	grantTypes := []model.GrantType{}
+	for _, t := range doc.Project.AllowGrantTypes {
+		v, err := model.GetGrantType(t)
+		if err != nil {
+			return nil, errors.Append(model.ErrImportValidateFailed, "Invalid grant type %s", t)
+		}
+		grantTypes = append(grantTypes, v)
+	}
+
+	createdAt, err := parseTime(doc.Project.CreatedAt)
+	if err != nil {
+		return nil, errors.Append(err, "Invalid created time of project")
+	}
+
+	tc := doc.Project.TokenConfig
+	res := &model.ProjectData{
+		Project: &model.ProjectInfo{
+			Name:         doc.Project.Name,
+			CreatedAt:    createdAt,
+			PermitDelete: true,
+			TokenConfig: &model.TokenConfig{
+				AccessTokenLifeSpan:  tc.AccessTokenLifeSpan,
+				RefreshTokenLifeSpan: tc.RefreshTokenLifeSpan,
+				RefreshTokenRotation: tc.RefreshTokenRotation,
+				SigningAlgorithm:     tc.SigningAlgorithm,
+				KeyRotationInterval:  tc.KeyRotationInterval,
+				SignKeys:             []*model.SignKey{},
+			},
+			PasswordPolicy: model.PasswordPolicy{
+				MinimumLength:       doc.Project.PasswordPolicy.MinimumLength,
+				NotUserName:         doc.Project.PasswordPolicy.NotUserName,
+				BlackList:           doc.Project.PasswordPolicy.BlackList,
+				UseCharacter:        model.CharacterType(doc.Project.PasswordPolicy.UseCharacter),
+				UseDigit:            doc.Project.PasswordPolicy.UseDigit,
+				UseSpecialCharacter: doc.Project.PasswordPolicy.UseSpecialCharacter,
+			},
+			AllowGrantTypes: grantTypes,
+			UserLock: model.UserLock{
+				Enabled:          doc.Project.UserLock.Enabled,
+				MaxLoginFailure:  doc.Project.UserLock.MaxLoginFailure,
+				LockDuration:     doc.Project.UserLock.LockDuration,
+				FailureResetTime: doc.Project.UserLock.FailureResetTime,
+			},
+			PasswordHashConfig: model.PasswordHashConfig{
+				Algorithm:         model.PasswordHashAlgorithm(doc.Project.PasswordHashConfig.Algorithm),
+				Argon2Iterations:  doc.Project.PasswordHashConfig.Argon2Iterations,
+				Argon2Memory:      doc.Project.PasswordHashConfig.Argon2Memory,
+				Argon2Parallelism: doc.Project.PasswordHashConfig.Argon2Parallelism,
+				BcryptCost:        doc.Project.PasswordHashConfig.BcryptCost,
+			},
+		},
+		Clients:     []*model.ClientInfo{},
+		CustomRoles: []*model.CustomRole{},
+		Users:       []*model.UserInfo{},
+	}
+
+	for _, t := range doc.Project.TrustedIssuers {
+		issuer := model.TrustedIssuer{
+			Issuer:   t.Issuer,
+			JWKS:     t.JWKS,
+			Audience: t.Audience,
+		}
+		for _, m := range t.SubjectMappings {
+			issuer.SubjectMappings = append(issuer.SubjectMappings, model.SubjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res.Project.TrustedIssuers = append(res.Project.TrustedIssuers, issuer)
+	}
+
+	for _, k := range tc.SignKeys {
+		pub, e := base64.StdEncoding.DecodeString(k.PublicKey)
+		if e != nil {
+			return nil, errors.Append(model.ErrImportValidateFailed, "Failed to decode public key %s: %v", k.KeyID, e)
+		}
+		raw, e := base64.StdEncoding.DecodeString(k.PrivateKey)
+		if e != nil {
+			return nil, errors.Append(model.ErrImportValidateFailed, "Failed to decode private key %s: %v", k.KeyID, e)
+		}
+		priv, err := secret.GetKeyProvider().Protect(k.SigningAlgorithm, raw)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to protect private key %s", k.KeyID)
+		}
+		key := &model.SignKey{
+			KeyID:            k.KeyID,
+			State:            model.SignKeyState(k.State),
+			SigningAlgorithm: k.SigningAlgorithm,
+			PublicKey:        pub,
+			PrivateKey:       priv,
+		}
+		if key.CreatedAt, err = parseTime(k.CreatedAt); err != nil {
+			return nil, errors.Append(err, "Invalid created time of key %s", k.KeyID)
+		}
+		if key.ActivatedAt, err = parseTime(k.ActivatedAt); err != nil {
+			return nil, errors.Append(err, "Invalid activated time of key %s", k.KeyID)
+		}
+		if key.ExpiresAt, err = parseTime(k.ExpiresAt); err != nil {
+			return nil, errors.Append(err, "Invalid expires time of key %s", k.KeyID)
+		}
+		res.Project.TokenConfig.SignKeys = append(res.Project.TokenConfig.SignKeys, key)
+	}
+
+	for _, c := range doc.Clients {
+		t, err := parseTime(c.CreatedAt)
+		if err != nil {
+			return nil, errors.Append(err, "Invalid created time of client %s", c.ID)
+		}
+		res.Clients = append(res.Clients, &model.ClientInfo{
+			ID:                          c.ID,
+			ProjectName:                 doc.Project.Name,
+			Secret:                      c.Secret,
+			AccessType:                  c.AccessType,
+			CreatedAt:                   t,
+			AllowedCallbackURLs:         c.AllowedCallbackURLs,
+			JWKS:                        c.JWKS,
+			JWKSURI:                     c.JWKSURI,
+			RequestURIs:                 c.RequestURIs,
+			PostLogoutRedirectURIs:      c.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:        c.BackchannelLogoutURI,
+			FrontchannelLogoutURI:       c.FrontchannelLogoutURI,
+			RequestObjectSigningAlg:     c.RequestObjectSigningAlg,
+			RequirePushedAuthRequests:   c.RequirePushedAuthRequests,
+			TokenEndpointAuthMethod:     c.TokenEndpointAuthMethod,
+			TLSClientAuthSubjectDN:      c.TLSClientAuthSubjectDN,
+			RequireDPoP:                 c.RequireDPoP,
+			TokenExchangeAudiences:      c.TokenExchangeAudiences,
+			TokenExchangeImpersonation:  c.TokenExchangeImpersonation,
+			RegistrationAccessTokenHash: c.RegistrationAccessTokenHash,
+		})
+	}
+
+	for _, r := range doc.CustomRoles {
+		t, err := parseTime(r.CreatedAt)
+		if err != nil {
+			return nil, errors.Append(err, "Invalid created time of custom role %s", r.Name)
+		}
+		res.CustomRoles = append(res.CustomRoles, &model.CustomRole{
+			ID:          r.ID,
+			Name:        r.Name,
+			CreatedAt:   t,
+			ProjectName: doc.Project.Name,
+		})
+	}
+
+	for _, u := range doc.Users {
+		t, err := parseTime(u.CreatedAt)
+		if err != nil {
+			return nil, errors.Append(err, "Invalid created time of user %s", u.Name)
+		}
+		res.Users = append(res.Users, &model.UserInfo{
+			ID:           u.ID,
+			ProjectName:  doc.Project.Name,
+			Name:         u.Name,
+			CreatedAt:    t,
+			PasswordHash: u.PasswordHash,
+			SystemRoles:  u.SystemRoles,
+			CustomRoles:  u.CustomRoles,
+			LockState: model.LockState{
+				Locked: u.Locked,
+			},
+			OTPInfo: model.OTPInfo{
+				ID:         u.OTP.ID,
+				PrivateKey: u.OTP.PrivateKey,
+				Enabled:    u.OTP.Enabled,
+			},
+			Profile: model.UserProfile{
+				FullName:            u.Profile.Name,
+				GivenName:           u.Profile.GivenName,
+				FamilyName:          u.Profile.FamilyName,
+				MiddleName:          u.Profile.MiddleName,
+				Nickname:            u.Profile.Nickname,
+				ProfileURL:          u.Profile.Profile,
+				Picture:             u.Profile.Picture,
+				Website:             u.Profile.Website,
+				Gender:              u.Profile.Gender,
+				Birthdate:           u.Profile.Birthdate,
+				Zoneinfo:            u.Profile.Zoneinfo,
+				Locale:              u.Profile.Locale,
+				Email:               u.Profile.Email,
+				EmailVerified:       u.Profile.EmailVerified,
+				PhoneNumber:         u.Profile.PhoneNumber,
+				PhoneNumberVerified: u.Profile.PhoneNumberVerified,
+				Address:             model.Address(u.Profile.Address),
+			},
+			Attributes: u.Attributes,
+		})
+	}
+
+	return res, nil
+}
+
+// parseTime parses RFC3339 time, empty string means zero time
+func parseTime(s string) (time.Time, *errors.Error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, e := time.Parse(time.RFC3339, s)
+	if e != nil {
+		return time.Time{}, errors.Append(model.ErrImportValidateFailed, "Failed to parse time %s: %v", s, e)
+	}
+	return t, nil
+}
@@ -0,0 +1,140 @@
+package projectapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+	"github.com/sh-miyoshi/hekate/pkg/role"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
+)
+
+func TestExportSignKeys(t *testing.T) {
+	os.Setenv("HEKATE_MASTER_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	defer func() {
+		os.Unsetenv("HEKATE_MASTER_KEY")
+		secret.InitKeyProvider(secret.KeyProviderNone, "")
+	}()
+	if err := secret.InitKeyProvider(secret.KeyProviderLocal, ""); err != nil {
+		t.Fatalf("Failed to initialize local key provider: %v", err)
+	}
+
+	keys, _ := secret.GetSignKey("RS256")
+	protected, _ := secret.GetKeyProvider().Protect("RS256", keys.Private)
+	now := time.Now()
+	data := &model.ProjectData{
+		Project: &model.ProjectInfo{
+			Name:      "export",
+			CreatedAt: now,
+			TokenConfig: &model.TokenConfig{
+				AccessTokenLifeSpan:  1,
+				RefreshTokenLifeSpan: 1,
+				SigningAlgorithm:     "RS256",
+				SignKeys: []*model.SignKey{
+					{
+						KeyID:            "key1",
+						State:            model.SignKeyStateActive,
+						SigningAlgorithm: "RS256",
+						PublicKey:        keys.Public,
+						PrivateKey:       protected,
+						CreatedAt:        now,
+						ActivatedAt:      now,
+					},
+				},
+			},
+		},
+	}
+
+	// Exported key is a raw key which can be imported to the server with other master key
+	doc, err := toExportDocument(data, true)
+	if err != nil {
+		t.Fatalf("Failed to export project: %v", err)
+	}
+	if doc.Project.TokenConfig.SignKeys[0].PrivateKey != base64.StdEncoding.EncodeToString(keys.Private) {
+		t.Errorf("Exported private key should be unprotected")
+	}
+
+	// Imported key is protected by the provider
+	res, err := fromExportDocument(doc)
+	if err != nil {
+		t.Fatalf("Failed to import project: %v", err)
+	}
+	priv := res.Project.TokenConfig.SignKeys[0].PrivateKey
+	if !secret.GetKeyProvider().IsProtected(priv) {
+		t.Errorf("Imported private key should be protected")
+	}
+	if raw, _ := secret.GetKeyProvider().Unprotect("RS256", priv); !bytes.Equal(raw, keys.Private) {
+		t.Errorf("Imported private key should be the exported key")
+	}
+}
+
+func TestProjectExportHandlerAuthz(t *testing.T) {
+	const projectName = "export-authz"
+	const issuer = "http://localhost/authapi/v1/project/export-authz"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	audit.Init("memory", "")
+	role.InitHandler()
+	if err := db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	}); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	tt := []struct {
+		name       string
+		roles      []string
+		expectCode int
+	}{
+		{"project admin", []string{"read-project", "write-project"}, http.StatusForbidden},
+		{"cluster admin", []string{"read-cluster", "write-cluster"}, http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		user := &model.UserInfo{
+			ID:          uuid.New().String(),
+			ProjectName: projectName,
+			Name:        tc.name,
+			CreatedAt:   time.Now(),
+			SystemRoles: tc.roles,
+		}
+		if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+			t.Fatalf("Test %s: Failed to add user: %v", tc.name, err)
+		}
+		tkn, err := token.GenerateAccessToken([]string{user.ID}, token.Request{
+			Issuer:      issuer,
+			ExpiresIn:   model.DefaultAccessTokenExpiresInSec,
+			ProjectName: projectName,
+			UserID:      user.ID,
+		})
+		if err != nil {
+			t.Fatalf("Test %s: Failed to generate access token: %v", tc.name, err)
+		}
+
+		r := httptest.NewRequest("GET", "http://localhost/adminapi/v1/project/"+projectName+"/export?keys=true", nil)
+		r.Header.Set("Authorization", "Bearer "+tkn)
+		r = mux.SetURLVars(r, map[string]string{"projectName": projectName})
+		w := httptest.NewRecorder()
+		ProjectExportHandler(w, r)
+		if w.Code != tc.expectCode {
+			t.Errorf("Test %s: Expect status %d, but got %d", tc.name, tc.expectCode, w.Code)
+		}
+	}
+}
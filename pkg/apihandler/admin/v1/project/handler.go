@@ -45,7 +45,9 @@ func AllProjectGetHandler(w http.ResponseWriter, r *http.Request) {
 			TokenConfig: TokenConfig{
 				AccessTokenLifeSpan:  prj.TokenConfig.AccessTokenLifeSpan,
 				RefreshTokenLifeSpan: prj.TokenConfig.RefreshTokenLifeSpan,
+				RefreshTokenRotation: prj.TokenConfig.RefreshTokenRotation,
 				SigningAlgorithm:     prj.TokenConfig.SigningAlgorithm,
+				KeyRotationInterval:  prj.TokenConfig.KeyRotationInterval,
 			},
 			PasswordPolicy: PasswordPolicy{
 				MinimumLength:       prj.PasswordPolicy.MinimumLength,
@@ -62,6 +64,14 @@ func AllProjectGetHandler(w http.ResponseWriter, r *http.Request) {
 				LockDuration:     prj.UserLock.LockDuration,
 				FailureResetTime: prj.UserLock.FailureResetTime,
 			},
+			PasswordHashConfig: PasswordHashConfig{
+				Algorithm:         string(prj.PasswordHashConfig.Algorithm),
+				Argon2Iterations:  prj.PasswordHashConfig.Argon2Iterations,
+				Argon2Memory:      prj.PasswordHashConfig.Argon2Memory,
+				Argon2Parallelism: prj.PasswordHashConfig.Argon2Parallelism,
+				BcryptCost:        prj.PasswordHashConfig.BcryptCost,
+			},
+			TrustedIssuers: toTrustedIssuers(prj.TrustedIssuers),
 		})
 	}
 	logger.Debug("Project List: %v", res)
@@ -125,7 +135,9 @@ func ProjectCreateHandler(w http.ResponseWriter, r *http.Request) {
 		TokenConfig: &model.TokenConfig{
 			AccessTokenLifeSpan:  request.TokenConfig.AccessTokenLifeSpan,
 			RefreshTokenLifeSpan: request.TokenConfig.RefreshTokenLifeSpan,
+			RefreshTokenRotation: request.TokenConfig.RefreshTokenRotation,
 			SigningAlgorithm:     request.TokenConfig.SigningAlgorithm,
+			KeyRotationInterval:  request.TokenConfig.KeyRotationInterval,
 		},
 		PasswordPolicy: model.PasswordPolicy{
 			MinimumLength:       request.PasswordPolicy.MinimumLength,
@@ -142,6 +154,14 @@ func ProjectCreateHandler(w http.ResponseWriter, r *http.Request) {
 			LockDuration:     request.UserLock.LockDuration,
 			FailureResetTime: request.UserLock.FailureResetTime,
 		},
+		PasswordHashConfig: model.PasswordHashConfig{
+			Algorithm:         model.PasswordHashAlgorithm(request.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  request.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      request.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: request.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        request.PasswordHashConfig.BcryptCost,
+		},
+		TrustedIssuers: fromTrustedIssuers(request.TrustedIssuers),
 	}
 
 	// Create New Project
@@ -166,7 +186,9 @@ func ProjectCreateHandler(w http.ResponseWriter, r *http.Request) {
 		TokenConfig: TokenConfig{
 			AccessTokenLifeSpan:  project.TokenConfig.AccessTokenLifeSpan,
 			RefreshTokenLifeSpan: project.TokenConfig.RefreshTokenLifeSpan,
+			RefreshTokenRotation: project.TokenConfig.RefreshTokenRotation,
 			SigningAlgorithm:     project.TokenConfig.SigningAlgorithm,
+			KeyRotationInterval:  project.TokenConfig.KeyRotationInterval,
 		},
 		PasswordPolicy: PasswordPolicy{
 			MinimumLength:       project.PasswordPolicy.MinimumLength,
@@ -183,6 +205,14 @@ func ProjectCreateHandler(w http.ResponseWriter, r *http.Request) {
 			LockDuration:     project.UserLock.LockDuration,
 			FailureResetTime: project.UserLock.FailureResetTime,
 		},
+		PasswordHashConfig: PasswordHashConfig{
+			Algorithm:         string(project.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  project.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      project.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: project.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        project.PasswordHashConfig.BcryptCost,
+		},
+		TrustedIssuers: toTrustedIssuers(project.TrustedIssuers),
 	}
 
 	jwthttp.ResponseWrite(w, "ProjectCreateHandler", &res)
@@ -261,7 +291,9 @@ func ProjectGetHandler(w http.ResponseWriter, r *http.Request) {
 		TokenConfig: TokenConfig{
 			AccessTokenLifeSpan:  project.TokenConfig.AccessTokenLifeSpan,
 			RefreshTokenLifeSpan: project.TokenConfig.RefreshTokenLifeSpan,
+			RefreshTokenRotation: project.TokenConfig.RefreshTokenRotation,
 			SigningAlgorithm:     project.TokenConfig.SigningAlgorithm,
+			KeyRotationInterval:  project.TokenConfig.KeyRotationInterval,
 		},
 		PasswordPolicy: PasswordPolicy{
 			MinimumLength:       project.PasswordPolicy.MinimumLength,
@@ -278,6 +310,14 @@ func ProjectGetHandler(w http.ResponseWriter, r *http.Request) {
 			LockDuration:     project.UserLock.LockDuration,
 			FailureResetTime: project.UserLock.FailureResetTime,
 		},
+		PasswordHashConfig: PasswordHashConfig{
+			Algorithm:         string(project.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  project.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      project.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: project.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        project.PasswordHashConfig.BcryptCost,
+		},
+		TrustedIssuers: toTrustedIssuers(project.TrustedIssuers),
 	}
 
 	jwthttp.ResponseWrite(w, "ProjectGetHandler", &res)
@@ -327,7 +367,9 @@ func ProjectUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	// Update Parameters
 	project.TokenConfig.AccessTokenLifeSpan = request.TokenConfig.AccessTokenLifeSpan
 	project.TokenConfig.RefreshTokenLifeSpan = request.TokenConfig.RefreshTokenLifeSpan
+	project.TokenConfig.RefreshTokenRotation = request.TokenConfig.RefreshTokenRotation
 	project.TokenConfig.SigningAlgorithm = request.TokenConfig.SigningAlgorithm
+	project.TokenConfig.KeyRotationInterval = request.TokenConfig.KeyRotationInterval
 	project.PasswordPolicy.MinimumLength = request.PasswordPolicy.MinimumLength
 	project.PasswordPolicy.NotUserName = request.PasswordPolicy.NotUserName
 	project.PasswordPolicy.BlackList = request.PasswordPolicy.BlackList
@@ -350,6 +392,18 @@ func ProjectUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		LockDuration:     request.UserLock.LockDuration,
 		FailureResetTime: request.UserLock.FailureResetTime,
 	}
+	if request.PasswordHashConfig != nil {
+		project.PasswordHashConfig = model.PasswordHashConfig{
+			Algorithm:         model.PasswordHashAlgorithm(request.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  request.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      request.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: request.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        request.PasswordHashConfig.BcryptCost,
+		}
+	}
+	if request.TrustedIssuers != nil {
+		project.TrustedIssuers = fromTrustedIssuers(request.TrustedIssuers)
+	}
 
 	// Update DB
 	if err = db.GetInst().ProjectUpdate(project); err != nil {
@@ -366,3 +420,44 @@ func ProjectUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	logger.Info("ProjectUpdateHandler method successfully finished")
 }
+
+func toTrustedIssuers(issuers []model.TrustedIssuer) []TrustedIssuer {
+	res := []TrustedIssuer{}
+	for _, t := range issuers {
+		v := TrustedIssuer{
+			Issuer:          t.Issuer,
+			JWKS:            t.JWKS,
+			Audience:        t.Audience,
+			SubjectMappings: []SubjectMapping{},
+		}
+		for _, m := range t.SubjectMappings {
+			v.SubjectMappings = append(v.SubjectMappings, SubjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+func fromTrustedIssuers(issuers []TrustedIssuer) []model.TrustedIssuer {
+	res := []model.TrustedIssuer{}
+	for _, t := range issuers {
+		v := model.TrustedIssuer{
+			Issuer:   t.Issuer,
+			JWKS:     t.JWKS,
+			Audience: t.Audience,
+		}
+		for _, m := range t.SubjectMappings {
+			v.SubjectMappings = append(v.SubjectMappings, model.SubjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res = append(res, v)
+	}
+	return res
+}
@@ -4,7 +4,9 @@ package projectapi
 type TokenConfig struct {
 	AccessTokenLifeSpan  uint   `json:"accessTokenLifeSpan"`
 	RefreshTokenLifeSpan uint   `json:"refreshTokenLifeSpan"`
+	RefreshTokenRotation bool   `json:"refreshTokenRotation"`
 	SigningAlgorithm     string `json:"signingAlgorithm"`
+	KeyRotationInterval  uint   `json:"keyRotationInterval"`
 }
 
 // PasswordPolicy ...
@@ -25,23 +27,51 @@ type UserLock struct {
 	FailureResetTime uint `json:"failureResetTime"`
 }
 
+// PasswordHashConfig ...
+type PasswordHashConfig struct {
+	Algorithm         string `json:"algorithm"`
+	Argon2Iterations  uint   `json:"argon2Iterations"`
+	Argon2Memory      uint   `json:"argon2Memory"` // [KiB]
+	Argon2Parallelism uint   `json:"argon2Parallelism"`
+	BcryptCost        uint   `json:"bcryptCost"`
+}
+
+// SubjectMapping ...
+type SubjectMapping struct {
+	Subject  string `json:"subject"`
+	UserName string `json:"userName,omitempty"`
+	ClientID string `json:"clientID,omitempty"`
+}
+
+// TrustedIssuer ...
+type TrustedIssuer struct {
+	Issuer          string           `json:"issuer"`
+	JWKS            string           `json:"jwks"`
+	Audience        string           `json:"audience,omitempty"`
+	SubjectMappings []SubjectMapping `json:"subjectMappings"`
+}
+
 // ProjectCreateRequest ...
 type ProjectCreateRequest struct {
-	Name            string         `json:"name"`
-	TokenConfig     TokenConfig    `json:"tokenConfig"`
-	PasswordPolicy  PasswordPolicy `json:"passwordPolicy"`
-	AllowGrantTypes []string       `json:"allowGrantTypes"`
-	UserLock        UserLock       `json:"userLock"`
+	Name               string             `json:"name"`
+	TokenConfig        TokenConfig        `json:"tokenConfig"`
+	PasswordPolicy     PasswordPolicy     `json:"passwordPolicy"`
+	AllowGrantTypes    []string           `json:"allowGrantTypes"`
+	UserLock           UserLock           `json:"userLock"`
+	PasswordHashConfig PasswordHashConfig `json:"passwordHashConfig"`
+	TrustedIssuers     []TrustedIssuer    `json:"trustedIssuers"`
 }
 
 // ProjectGetResponse ...
 type ProjectGetResponse struct {
-	Name            string         `json:"name"`
-	CreatedAt       string         `json:"createdAt"`
-	TokenConfig     TokenConfig    `json:"tokenConfig"`
-	PasswordPolicy  PasswordPolicy `json:"passwordPolicy"`
-	AllowGrantTypes []string       `json:"allowGrantTypes"`
-	UserLock        UserLock       `json:"userLock"`
+	Name               string             `json:"name"`
+	CreatedAt          string             `json:"createdAt"`
+	TokenConfig        TokenConfig        `json:"tokenConfig"`
+	PasswordPolicy     PasswordPolicy     `json:"passwordPolicy"`
+	AllowGrantTypes    []string           `json:"allowGrantTypes"`
+	UserLock           UserLock           `json:"userLock"`
+	PasswordHashConfig PasswordHashConfig `json:"passwordHashConfig"`
+	TrustedIssuers     []TrustedIssuer    `json:"trustedIssuers"`
 }
 
 // ProjectPutRequest ...
@@ -50,4 +80,188 @@ type ProjectPutRequest struct {
 	PasswordPolicy  PasswordPolicy `json:"passwordPolicy"`
 	AllowGrantTypes []string       `json:"allowGrantTypes"`
 	UserLock        UserLock       `json:"userLock"`
+
+	// PasswordHashConfig is optional, the current config is kept if nil
+	PasswordHashConfig *PasswordHashConfig `json:"passwordHashConfig,omitempty"`
+
+	// TrustedIssuers is optional, the current issuers are kept if nil
+	TrustedIssuers []TrustedIssuer `json:"trustedIssuers,omitempty"`
+}
+
+// ExportFormatVersion is a version of ProjectExportDocument
+const ExportFormatVersion = "v1"
+
+// ExportSignKey ...
+type ExportSignKey struct {
+	KeyID            string `json:"keyID" yaml:"keyID"`
+	State            string `json:"state" yaml:"state"`
+	SigningAlgorithm string `json:"signingAlgorithm" yaml:"signingAlgorithm"`
+	PublicKey        string `json:"publicKey" yaml:"publicKey"`   // base64 encoded
+	PrivateKey       string `json:"privateKey" yaml:"privateKey"` // base64 encoded value in the format of the key provider
+	CreatedAt        string `json:"createdAt" yaml:"createdAt"`
+	ActivatedAt      string `json:"activatedAt,omitempty" yaml:"activatedAt,omitempty"`
+	ExpiresAt        string `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+}
+
+// ExportTokenConfig ...
+type ExportTokenConfig struct {
+	AccessTokenLifeSpan  uint            `json:"accessTokenLifeSpan" yaml:"accessTokenLifeSpan"`
+	RefreshTokenLifeSpan uint            `json:"refreshTokenLifeSpan" yaml:"refreshTokenLifeSpan"`
+	RefreshTokenRotation bool            `json:"refreshTokenRotation" yaml:"refreshTokenRotation"`
+	SigningAlgorithm     string          `json:"signingAlgorithm" yaml:"signingAlgorithm"`
+	KeyRotationInterval  uint            `json:"keyRotationInterval" yaml:"keyRotationInterval"`
+	SignKeys             []ExportSignKey `json:"signKeys,omitempty" yaml:"signKeys,omitempty"`
+}
+
+// ExportPasswordPolicy ...
+type ExportPasswordPolicy struct {
+	MinimumLength       uint     `json:"length" yaml:"length"`
+	NotUserName         bool     `json:"notUserName" yaml:"notUserName"`
+	BlackList           []string `json:"blackList" yaml:"blackList"`
+	UseCharacter        string   `json:"useCharacter" yaml:"useCharacter"`
+	UseDigit            bool     `json:"useDigit" yaml:"useDigit"`
+	UseSpecialCharacter bool     `json:"useSpecialCharacter" yaml:"useSpecialCharacter"`
+}
+
+// ExportUserLock ...
+type ExportUserLock struct {
+	Enabled          bool `json:"enabled" yaml:"enabled"`
+	MaxLoginFailure  uint `json:"maxLoginFailure" yaml:"maxLoginFailure"`
+	LockDuration     uint `json:"lockDuration" yaml:"lockDuration"`
+	FailureResetTime uint `json:"failureResetTime" yaml:"failureResetTime"`
+}
+
+// ExportPasswordHashConfig ...
+type ExportPasswordHashConfig struct {
+	Algorithm         string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	Argon2Iterations  uint   `json:"argon2Iterations,omitempty" yaml:"argon2Iterations,omitempty"`
+	Argon2Memory      uint   `json:"argon2Memory,omitempty" yaml:"argon2Memory,omitempty"`
+	Argon2Parallelism uint   `json:"argon2Parallelism,omitempty" yaml:"argon2Parallelism,omitempty"`
+	BcryptCost        uint   `json:"bcryptCost,omitempty" yaml:"bcryptCost,omitempty"`
+}
+
+// ExportSubjectMapping ...
+type ExportSubjectMapping struct {
+	Subject  string `json:"subject" yaml:"subject"`
+	UserName string `json:"userName,omitempty" yaml:"userName,omitempty"`
+	ClientID string `json:"clientID,omitempty" yaml:"clientID,omitempty"`
+}
+
+// ExportTrustedIssuer ...
+type ExportTrustedIssuer struct {
+	Issuer          string                 `json:"issuer" yaml:"issuer"`
+	JWKS            string                 `json:"jwks" yaml:"jwks"`
+	Audience        string                 `json:"audience,omitempty" yaml:"audience,omitempty"`
+	SubjectMappings []ExportSubjectMapping `json:"subjectMappings" yaml:"subjectMappings"`
+}
+
+// ExportProject ...
+type ExportProject struct {
+	Name               string                   `json:"name" yaml:"name"`
+	CreatedAt          string                   `json:"createdAt" yaml:"createdAt"`
+	TokenConfig        ExportTokenConfig        `json:"tokenConfig" yaml:"tokenConfig"`
+	PasswordPolicy     ExportPasswordPolicy     `json:"passwordPolicy" yaml:"passwordPolicy"`
+	AllowGrantTypes    []string                 `json:"allowGrantTypes" yaml:"allowGrantTypes"`
+	UserLock           ExportUserLock           `json:"userLock" yaml:"userLock"`
+	PasswordHashConfig ExportPasswordHashConfig `json:"passwordHashConfig" yaml:"passwordHashConfig"`
+	TrustedIssuers     []ExportTrustedIssuer    `json:"trustedIssuers,omitempty" yaml:"trustedIssuers,omitempty"`
+}
+
+// ExportClient ...
+type ExportClient struct {
+	ID                  string   `json:"id" yaml:"id"`
+	Secret              string   `json:"secret" yaml:"secret"`
+	AccessType          string   `json:"accessType" yaml:"accessType"`
+	CreatedAt           string   `json:"createdAt" yaml:"createdAt"`
+	AllowedCallbackURLs []string `json:"allowedCallbackURLs" yaml:"allowedCallbackURLs"`
+
+	JWKS                        string   `json:"jwks,omitempty" yaml:"jwks,omitempty"`
+	JWKSURI                     string   `json:"jwksURI,omitempty" yaml:"jwksURI,omitempty"`
+	RequestURIs                 []string `json:"requestURIs,omitempty" yaml:"requestURIs,omitempty"`
+	PostLogoutRedirectURIs      []string `json:"postLogoutRedirectURIs,omitempty" yaml:"postLogoutRedirectURIs,omitempty"`
+	BackchannelLogoutURI        string   `json:"backchannelLogoutURI,omitempty" yaml:"backchannelLogoutURI,omitempty"`
+	FrontchannelLogoutURI       string   `json:"frontchannelLogoutURI,omitempty" yaml:"frontchannelLogoutURI,omitempty"`
+	RequestObjectSigningAlg     string   `json:"requestObjectSigningAlg,omitempty" yaml:"requestObjectSigningAlg,omitempty"`
+	RequirePushedAuthRequests   bool     `json:"requirePushedAuthRequests,omitempty" yaml:"requirePushedAuthRequests,omitempty"`
+	TokenEndpointAuthMethod     string   `json:"tokenEndpointAuthMethod,omitempty" yaml:"tokenEndpointAuthMethod,omitempty"`
+	TLSClientAuthSubjectDN      string   `json:"tlsClientAuthSubjectDN,omitempty" yaml:"tlsClientAuthSubjectDN,omitempty"`
+	RequireDPoP                 bool     `json:"requireDPoP,omitempty" yaml:"requireDPoP,omitempty"`
+	TokenExchangeAudiences      []string `json:"tokenExchangeAudiences,omitempty" yaml:"tokenExchangeAudiences,omitempty"`
+	TokenExchangeImpersonation  bool     `json:"tokenExchangeImpersonation,omitempty" yaml:"tokenExchangeImpersonation,omitempty"`
+	RegistrationAccessTokenHash string   `json:"registrationAccessTokenHash,omitempty" yaml:"registrationAccessTokenHash,omitempty"`
+}
+
+// ExportCustomRole ...
+type ExportCustomRole struct {
+	ID        string `json:"id" yaml:"id"`
+	Name      string `json:"name" yaml:"name"`
+	CreatedAt string `json:"createdAt" yaml:"createdAt"`
+}
+
+// ExportOTP ...
+type ExportOTP struct {
+	ID         string `json:"id" yaml:"id"`
+	PrivateKey string `json:"privateKey" yaml:"privateKey"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// ExportAddress ...
+type ExportAddress struct {
+	Formatted     string `json:"formatted,omitempty" yaml:"formatted,omitempty"`
+	StreetAddress string `json:"streetAddress,omitempty" yaml:"streetAddress,omitempty"`
+	Locality      string `json:"locality,omitempty" yaml:"locality,omitempty"`
+	Region        string `json:"region,omitempty" yaml:"region,omitempty"`
+	PostalCode    string `json:"postalCode,omitempty" yaml:"postalCode,omitempty"`
+	Country       string `json:"country,omitempty" yaml:"country,omitempty"`
+}
+
+// ExportUserProfile ...
+type ExportUserProfile struct {
+	Name                string        `json:"name,omitempty" yaml:"name,omitempty"`
+	GivenName           string        `json:"givenName,omitempty" yaml:"givenName,omitempty"`
+	FamilyName          string        `json:"familyName,omitempty" yaml:"familyName,omitempty"`
+	MiddleName          string        `json:"middleName,omitempty" yaml:"middleName,omitempty"`
+	Nickname            string        `json:"nickname,omitempty" yaml:"nickname,omitempty"`
+	Profile             string        `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Picture             string        `json:"picture,omitempty" yaml:"picture,omitempty"`
+	Website             string        `json:"website,omitempty" yaml:"website,omitempty"`
+	Gender              string        `json:"gender,omitempty" yaml:"gender,omitempty"`
+	Birthdate           string        `json:"birthdate,omitempty" yaml:"birthdate,omitempty"`
+	Zoneinfo            string        `json:"zoneinfo,omitempty" yaml:"zoneinfo,omitempty"`
+	Locale              string        `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Email               string        `json:"email,omitempty" yaml:"email,omitempty"`
+	EmailVerified       bool          `json:"emailVerified,omitempty" yaml:"emailVerified,omitempty"`
+	PhoneNumber         string        `json:"phoneNumber,omitempty" yaml:"phoneNumber,omitempty"`
+	PhoneNumberVerified bool          `json:"phoneNumberVerified,omitempty" yaml:"phoneNumberVerified,omitempty"`
+	Address             ExportAddress `json:"address" yaml:"address"`
+}
+
+// ExportUser ...
+type ExportUser struct {
+	ID           string            `json:"id" yaml:"id"`
+	Name         string            `json:"name" yaml:"name"`
+	CreatedAt    string            `json:"createdAt" yaml:"createdAt"`
+	PasswordHash string            `json:"passwordHash" yaml:"passwordHash"`
+	SystemRoles  []string          `json:"systemRoles" yaml:"systemRoles"`
+	CustomRoles  []string          `json:"customRoles" yaml:"customRoles"` // list of custom role ID
+	Locked       bool              `json:"locked" yaml:"locked"`
+	OTP          ExportOTP         `json:"otp" yaml:"otp"`
+	Profile      ExportUserProfile `json:"profile" yaml:"profile"`
+	Attributes   map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// ProjectExportDocument is a portable document of all resources in the project
+type ProjectExportDocument struct {
+	Version     string             `json:"version" yaml:"version"`
+	Project     ExportProject      `json:"project" yaml:"project"`
+	Clients     []ExportClient     `json:"clients" yaml:"clients"`
+	CustomRoles []ExportCustomRole `json:"customRoles" yaml:"customRoles"`
+	Users       []ExportUser       `json:"users" yaml:"users"`
+}
+
+// ProjectImportResponse ...
+type ProjectImportResponse struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	jwthttp "github.com/sh-miyoshi/hekate/pkg/http"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 	"github.com/sh-miyoshi/hekate/pkg/role"
 )
 
@@ -39,7 +41,10 @@ func SessionDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = db.GetInst().SessionDelete(projectName, sessionID); err != nil {
+	// the deleted session is used to notify the client
+	var s *model.Session
+	s, err = db.GetInst().SessionRevoke(projectName, sessionID)
+	if err != nil {
 		if errors.Contains(err, model.ErrNoSuchSession) || errors.Contains(err, model.ErrSessionValidateFailed) {
 			errors.PrintAsInfo(errors.Append(err, "Failed to delete session"))
 			errors.WriteToHTTP(w, err, http.StatusNotFound, "")
@@ -50,6 +55,11 @@ func SessionDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	issuer := token.GetExpectIssuer(r) + "/authapi/v1/project/" + projectName
+	if e := oidc.NotifyBackchannelLogout(projectName, issuer, []*model.Session{s}); e != nil {
+		errors.Print(errors.Append(e, "Failed to notify back-channel logout"))
+	}
+
 	// Return 204 (No content) for success
 	w.WriteHeader(http.StatusNoContent)
 	logger.Info("SessionDeleteHandler method successfully finished")
@@ -15,7 +15,6 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 	"github.com/sh-miyoshi/hekate/pkg/role"
 	"github.com/sh-miyoshi/hekate/pkg/secret"
-	"github.com/sh-miyoshi/hekate/pkg/util"
 )
 
 // AllUserGetHandler ...
@@ -80,6 +79,8 @@ func AllUserGetHandler(w http.ResponseWriter, r *http.Request) {
 			SystemRoles: user.SystemRoles,
 			CustomRoles: roles,
 			Locked:      user.LockState.Locked,
+			Profile:     toUserProfile(&user.Profile),
+			Attributes:  toAttributes(user.Attributes),
 		}
 		sessions, err := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
 		if err != nil {
@@ -147,16 +148,17 @@ func UserCreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create User Entry
 	user := model.UserInfo{
-		ID:           uuid.New().String(),
-		ProjectName:  projectName,
-		Name:         request.Name,
-		CreatedAt:    time.Now(),
-		PasswordHash: util.CreateHash(request.Password),
-		SystemRoles:  request.SystemRoles,
-		CustomRoles:  request.CustomRoles,
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        request.Name,
+		CreatedAt:   time.Now(),
+		SystemRoles: request.SystemRoles,
+		CustomRoles: request.CustomRoles,
+		Profile:     fromUserProfile(&request.Profile),
+		Attributes:  request.Attributes,
 	}
 
-	if err = db.GetInst().UserAdd(projectName, &user); err != nil {
+	if err = db.GetInst().UserAdd(projectName, &user, request.Password); err != nil {
 		if errors.Contains(err, model.ErrUserValidateFailed) {
 			errors.PrintAsInfo(errors.Append(err, "user validation failed"))
 			errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
@@ -192,6 +194,8 @@ func UserCreateHandler(w http.ResponseWriter, r *http.Request) {
 		SystemRoles: user.SystemRoles,
 		CustomRoles: roles,
 		Locked:      user.LockState.Locked,
+		Profile:     toUserProfile(&user.Profile),
+		Attributes:  toAttributes(user.Attributes),
 	}
 
 	jwthttp.ResponseWrite(w, "UserGetAllUserGetHandlerHandler", &res)
@@ -286,6 +290,8 @@ func UserGetHandler(w http.ResponseWriter, r *http.Request) {
 		SystemRoles: user.SystemRoles,
 		CustomRoles: roles,
 		Locked:      user.LockState.Locked,
+		Profile:     toUserProfile(&user.Profile),
+		Attributes:  toAttributes(user.Attributes),
 	}
 
 	sessions, err := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
@@ -353,10 +359,16 @@ func UserUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update Parameters
-	// name, roles
+	// name, roles, profile, attributes
 	user.Name = request.Name
 	user.SystemRoles = request.SystemRoles
 	user.CustomRoles = request.CustomRoles
+	if request.Profile != nil {
+		user.Profile = fromUserProfile(request.Profile)
+	}
+	if request.Attributes != nil {
+		user.Attributes = request.Attributes
+	}
 
 	// Update DB
 	if err = db.GetInst().UserUpdate(projectName, user); err != nil {
@@ -595,3 +607,54 @@ func UserUnlockHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	logger.Info("UserUnlockHandler method successfully finished")
 }
+
+func toUserProfile(p *model.UserProfile) UserProfile {
+	return UserProfile{
+		Name:                p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		Profile:             p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             Address(p.Address),
+	}
+}
+
+func fromUserProfile(p *UserProfile) model.UserProfile {
+	return model.UserProfile{
+		FullName:            p.Name,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.Profile,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             model.Address(p.Address),
+	}
+}
+
+func toAttributes(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return map[string]string{}
+	}
+	return attrs
+}
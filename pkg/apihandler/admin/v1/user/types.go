@@ -6,12 +6,45 @@ type CustomRole struct {
 	Name string `json:"name"`
 }
 
+// Address ...
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"street_address,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+}
+
+// UserProfile is a set of the standard claims of the user
+type UserProfile struct {
+	Name                string  `json:"name,omitempty"`
+	GivenName           string  `json:"given_name,omitempty"`
+	FamilyName          string  `json:"family_name,omitempty"`
+	MiddleName          string  `json:"middle_name,omitempty"`
+	Nickname            string  `json:"nickname,omitempty"`
+	Profile             string  `json:"profile,omitempty"`
+	Picture             string  `json:"picture,omitempty"`
+	Website             string  `json:"website,omitempty"`
+	Gender              string  `json:"gender,omitempty"`
+	Birthdate           string  `json:"birthdate,omitempty"`
+	Zoneinfo            string  `json:"zoneinfo,omitempty"`
+	Locale              string  `json:"locale,omitempty"`
+	Email               string  `json:"email,omitempty"`
+	EmailVerified       bool    `json:"email_verified"`
+	PhoneNumber         string  `json:"phone_number,omitempty"`
+	PhoneNumberVerified bool    `json:"phone_number_verified"`
+	Address             Address `json:"address"`
+}
+
 // UserCreateRequest ...
 type UserCreateRequest struct {
-	Name        string   `json:"name"`
-	Password    string   `json:"password"`
-	SystemRoles []string `json:"system_roles"`
-	CustomRoles []string `json:"custom_roles"`
+	Name        string            `json:"name"`
+	Password    string            `json:"password"`
+	SystemRoles []string          `json:"system_roles"`
+	CustomRoles []string          `json:"custom_roles"`
+	Profile     UserProfile       `json:"profile"`
+	Attributes  map[string]string `json:"attributes"`
 }
 
 // UserGetResponse ...
@@ -24,6 +57,8 @@ type UserGetResponse struct {
 	Sessions    []string     `json:"sessions"` // Array of session IDs
 	Locked      bool         `json:"locked"`
 	// TODO OTP Info
+	Profile    UserProfile       `json:"profile"`
+	Attributes map[string]string `json:"attributes"`
 }
 
 // UserPutRequest ...
@@ -31,6 +66,9 @@ type UserPutRequest struct {
 	Name        string   `json:"name"`
 	SystemRoles []string `json:"system_roles"`
 	CustomRoles []string `json:"custom_roles"`
+	// Profile and Attributes are optional, the current values are kept if nil
+	Profile    *UserProfile      `json:"profile,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // UserResetPasswordRequest ...
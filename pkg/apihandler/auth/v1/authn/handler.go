@@ -131,7 +131,7 @@ func UserLoginHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	http.Redirect(w, req, req.URL.String(), http.StatusFound)
+	oidc.WriteLoggedInResponse(w, req)
 }
 
 // OTPVerifyHandler ...
@@ -219,7 +219,7 @@ func OTPVerifyHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	http.Redirect(w, req, req.URL.String(), http.StatusFound)
+	oidc.WriteLoggedInResponse(w, req)
 }
 
 // ConsentHandler ...
@@ -272,10 +272,10 @@ func ConsentHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-		http.Redirect(w, req, req.URL.String(), http.StatusFound)
+		oidc.WriteLoggedInResponse(w, req)
 	case "no":
 		err = errors.ErrConsentRequired
-		errors.RedirectWithOAuthError(w, err, r.Method, s.RedirectURI, state)
+		oidc.WriteErrorResponse(w, err, s.ResponseMode, s.RedirectURI, state)
 	default:
 		err = errors.ErrServerError
 		logger.Error("Invalid select type %s. consent page maybe broken.", sel)
@@ -297,6 +297,11 @@ func redirectToCallback(w http.ResponseWriter, r *http.Request, projectName stri
 		return req, errSessionEnd
 	}
 
+	// save the authorization code to exchange it in the token endpoint
+	if err := db.GetInst().LoginSessionUpdate(projectName, session); err != nil {
+		return nil, errors.Append(err, "Failed to save authorization code")
+	}
+
 	if err := sso.SetSSOSessionToCookie(w, projectName, session.UserID, issuer); err != nil {
 		return nil, errors.Append(err, "Failed to set cookie")
 	}
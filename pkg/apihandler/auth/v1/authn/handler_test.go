@@ -0,0 +1,103 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/authn"
+)
+
+func TestUserLoginAndCodeExchange(t *testing.T) {
+	const projectName = "login"
+	const clientID = "login-app"
+	const callback = "http://localhost:3000/callback"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	audit.Init("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	if err := db.GetInst().ClientAdd(projectName, &model.ClientInfo{ID: clientID, ProjectName: projectName, AccessType: "public", AllowedCallbackURLs: []string{callback}}); err != nil {
+		t.Fatalf("Failed to add client: %v", err)
+	}
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	session := &model.LoginSession{
+		SessionID:    uuid.New().String(),
+		ExpiresDate:  time.Now().Add(time.Minute),
+		ClientID:     clientID,
+		RedirectURI:  callback,
+		Scope:        "openid",
+		ProjectName:  projectName,
+		ResponseType: []string{"code"},
+		ResponseMode: "query",
+	}
+	if err := db.GetInst().LoginSessionAdd(projectName, session); err != nil {
+		t.Fatalf("Failed to add login session: %v", err)
+	}
+
+	// Login by password
+	form := url.Values{
+		"login_session_id": {session.SessionID},
+		"username":         {"user"},
+		"password":         {"password"},
+		"state":            {"xyz"},
+	}
+	r := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/login/openid-connect/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"projectName": projectName})
+	w := httptest.NewRecorder()
+
+	UserLoginHandler(w, r)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("Login should redirect to the callback, but got status %d: %s", w.Code, w.Body.String())
+	}
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Failed to parse redirect URL: %v", err)
+	}
+	code := loc.Query().Get("code")
+	if code == "" || loc.Query().Get("state") != "xyz" {
+		t.Fatalf("Redirect URL should have code and state, but got %s", loc.String())
+	}
+
+	// Exchange the code for the tokens
+	project, _ := db.GetInst().ProjectGet(projectName)
+	tr := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/login/openid-connect/token", nil)
+	res, e := authn.ReqAuthByCode(project, clientID, code, "", tr)
+	if e != nil {
+		t.Fatalf("Failed to exchange the code: %v", e)
+	}
+	if res.AccessToken == "" || res.IDToken == "" || res.RefreshToken == "" {
+		t.Errorf("Token response should have access, id and refresh tokens, but got %v", res)
+	}
+
+	// The code can be used only once
+	if _, e := authn.ReqAuthByCode(project, clientID, code, "", tr); e == nil {
+		t.Errorf("Used code should be rejected")
+	}
+}
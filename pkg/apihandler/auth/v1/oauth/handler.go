@@ -15,6 +15,7 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 	"github.com/sh-miyoshi/hekate/pkg/login"
 	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 	"github.com/sh-miyoshi/hekate/pkg/util"
 	"github.com/stretchr/stew/slice"
 )
@@ -48,28 +49,22 @@ func DeviceRegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	logger.Debug("Form: %v", r.Form)
 
-	clientID := r.Form.Get("client_id")
-	clientSecret := r.Form.Get("client_secret")
-
-	if clientID == "" {
-		// maybe basic authentication
-		i, s, ok := r.BasicAuth()
-		if !ok {
-			logger.Info("Failed to get client ID from request, Request header: %v", r.Header)
-			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, "")
-			return
-		}
-		clientID = i
-		clientSecret = s
+	var cred *oidc.ClientCredentials
+	cred, err = oidc.GetClientCredentials(r)
+	if err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to get client credentials, Request header: %v", r.Header))
+		errors.WriteToHTTP(w, err, 0, "")
+		return
 	}
+	clientID := cred.ClientID
 
-	if err = oidc.ClientAuth(projectName, clientID, clientSecret); err != nil {
-		if errors.Contains(err, errors.ErrInvalidClient) {
-			errors.PrintAsInfo(errors.Append(err, "Failed to authenticate client %s", clientID))
-			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, "")
-		} else {
+	if err = oidc.ClientAuth(projectName, token.GetFullIssuer(r), cred); err != nil {
+		if err.StatusCode() == 0 {
 			errors.Print(errors.Append(err, "Failed to authenticate client"))
 			errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to authenticate client %s", clientID))
+			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, "")
 		}
 		return
 	}
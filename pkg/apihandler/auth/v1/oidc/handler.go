@@ -1,6 +1,7 @@
 package oidc
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"time"
@@ -47,13 +48,15 @@ func ConfigGetHandler(w http.ResponseWriter, r *http.Request) {
 		TokenEndpoint:          issuer + "/openid-connect/token",
 		UserinfoEndpoint:       issuer + "/openid-connect/userinfo",
 		JwksURI:                issuer + "/openid-connect/certs",
+		IntrospectionEndpoint:  issuer + "/openid-connect/introspect",
+		EndSessionEndpoint:     issuer + "/openid-connect/logout",
 		ScopesSupported:        cfg.SupportedScope,
 		ResponseTypesSupported: cfg.SupportedResponseType,
 		SubjectTypesSupported:  []string{"public"},
 		IDTokenSigningAlgValuesSupported: []string{
-			"RS256",
+			prj.TokenConfig.SigningAlgorithm,
 		},
-		ClaimsSupported: []string{
+		ClaimsSupported: append([]string{
 			"iss",
 			"aud",
 			"sub",
@@ -61,16 +64,44 @@ func ConfigGetHandler(w http.ResponseWriter, r *http.Request) {
 			"jti",
 			"iat",
 			"nbf",
-		},
+			"sid",
+			"preferred_username",
+		}, token.StandardClaimsSupported...),
 		ResponseModesSupported: []string{
 			"query",
 			"fragment",
+			"form_post",
 		},
 		GrantTypesSupported: grantTypes,
 		TokenEndpointAuthMethodsSupported: []string{
 			"client_secret_basic",
 			"client_secret_post",
+			"client_secret_jwt",
+			"private_key_jwt",
 		},
+		RequestParameterSupported:                 true,
+		RequestURIParameterSupported:              true,
+		RequireRequestURIRegistration:             true,
+		RequestObjectSigningAlgValuesSupported:    oidc.RequestObjectSigningAlgs,
+		RequestObjectEncryptionAlgValuesSupported: oidc.RequestObjectEncryptionAlgs,
+		RequestObjectEncryptionEncValuesSupported: oidc.RequestObjectEncryptionEncs,
+		PushedAuthorizationRequestEndpoint:        issuer + "/openid-connect/par",
+		// PAR is required per client
+		RequirePushedAuthorizationRequests:         false,
+		RegistrationEndpoint:                       registrationURI(r),
+		TokenEndpointAuthSigningAlgValuesSupported: oidc.ClientAssertionSigningAlgs,
+		DPoPSigningAlgValuesSupported:              token.DPoPSigningAlgs,
+		BackchannelLogoutSupported:                 true,
+		BackchannelLogoutSessionSupported:          true,
+		FrontchannelLogoutSupported:                true,
+		FrontchannelLogoutSessionSupported:         true,
+	}
+	if cfg.HTTPSConfig.ClientCertRequest {
+		if cfg.HTTPSConfig.ClientCAFile != "" {
+			res.TokenEndpointAuthMethodsSupported = append(res.TokenEndpointAuthMethodsSupported, "tls_client_auth")
+		}
+		res.TokenEndpointAuthMethodsSupported = append(res.TokenEndpointAuthMethodsSupported, "self_signed_tls_client_auth")
+		res.TLSClientCertificateBoundAccessTokens = true
 	}
 
 	jwthttp.ResponseWrite(w, "ConfigGetHandler", &res)
@@ -109,31 +140,43 @@ func TokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientID := r.Form.Get("client_id")
-	clientSecret := r.Form.Get("client_secret")
-
-	if clientID == "" {
-		// maybe basic authentication
-		i, s, ok := r.BasicAuth()
-		if !ok {
-			logger.Info("Failed to get client ID from request, Request header: %v", r.Header)
-			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, state)
-			return
-		}
-		clientID = i
-		clientSecret = s
+	var cred *oidc.ClientCredentials
+	cred, err = authenticateClient(w, r, projectName, state)
+	if err != nil {
+		return
 	}
+	clientID := cred.ClientID
 
-	if err = oidc.ClientAuth(projectName, clientID, clientSecret); err != nil {
-		if errors.Contains(err, errors.ErrInvalidClient) {
-			errors.PrintAsInfo(errors.Append(err, "Failed to authenticate client %s", clientID))
-			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, state)
-		} else {
-			errors.Print(errors.Append(err, "Failed to authenticate client"))
+	// the issued token is bound to the key of the DPoP proof
+	var jkt string
+	jkt, err = token.VerifyDPoPProof(projectName, r, "")
+	if err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to verify DPoP proof"))
 			errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to verify DPoP proof"))
+			errors.WriteToHTTP(w, errors.ErrInvalidDPoPProof, 0, state)
 		}
 		return
 	}
+	if jkt == "" {
+		// existence of client is already checked in oidc.ClientAuth
+		var client *model.ClientInfo
+		client, err = db.GetInst().ClientGet(projectName, clientID)
+		if err != nil {
+			errors.Print(errors.Append(err, "Failed to get client"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+			return
+		}
+		if client.RequireDPoP {
+			err = errors.Append(errors.ErrInvalidDPoPProof, "Client %s requires DPoP proof", clientID)
+			errors.PrintAsInfo(err)
+			errors.WriteToHTTP(w, errors.ErrInvalidDPoPProof, 0, state)
+			return
+		}
+	}
+	r = token.WithDPoPKey(r, jkt)
 
 	var tkn *oidc.TokenResponse
 
@@ -181,6 +224,11 @@ func TokenHandler(w http.ResponseWriter, r *http.Request) {
 			errors.WriteToHTTP(w, errors.ErrInvalidGrant, 0, state)
 			return
 		}
+		if errors.Contains(err, errors.ErrInvalidDPoPProof) {
+			errors.PrintAsInfo(errors.Append(err, "Failed to verify refresh token"))
+			errors.WriteToHTTP(w, err, 0, state)
+			return
+		}
 	case model.GrantTypeAuthorizationCode:
 		code := r.Form.Get("code")
 		codeVerifier := r.Form.Get("code_verifier")
@@ -188,6 +236,22 @@ func TokenHandler(w http.ResponseWriter, r *http.Request) {
 	case model.GrantTypeDevice:
 		deviceCode := r.Form.Get("device_code")
 		tkn, err = authn.ReqAuthByDeviceCode(project, clientID, deviceCode, r)
+	case model.GrantTypeTokenExchange:
+		tkn, err = authn.ReqAuthByTokenExchange(project, clientID, r)
+
+		if err != nil && err.StatusCode() != 0 {
+			errors.PrintAsInfo(errors.Append(err, "Failed to exchange token"))
+			errors.WriteToHTTP(w, err, 0, state)
+			return
+		}
+	case model.GrantTypeJWTBearer:
+		tkn, err = authn.ReqAuthByJWTBearer(project, clientID, r)
+
+		if err != nil && err.StatusCode() != 0 {
+			errors.PrintAsInfo(errors.Append(err, "Failed to verify assertion"))
+			errors.WriteToHTTP(w, err, 0, state)
+			return
+		}
 	}
 
 	if err != nil {
@@ -208,6 +272,7 @@ func TokenHandler(w http.ResponseWriter, r *http.Request) {
 		RefreshToken:     tkn.RefreshToken,
 		RefreshExpiresIn: tkn.RefreshExpiresIn,
 		IDToken:          tkn.IDToken,
+		IssuedTokenType:  tkn.IssuedTokenType,
 	}
 
 	w.Header().Add("Cache-Control", "no-store")
@@ -227,7 +292,8 @@ func CertsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := oidc.GenerateJWKSet(project.TokenConfig.SigningAlgorithm, project.TokenConfig.SignPublicKey)
+	// Publish all non-expired keys so that relying parties can verify tokens through the key rotation
+	res, err := oidc.GenerateJWKSet(project.TokenConfig.PublishedSignKeys(time.Now()))
 	if err != nil {
 		errors.Print(errors.Append(err, "Failed to generate JWT set"))
 		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
@@ -275,6 +341,11 @@ func UserInfoHandler(w http.ResponseWriter, r *http.Request) {
 	claims, err := jwthttp.ValidateAPIToken(r)
 	if err != nil {
 		errors.PrintAsInfo(errors.Append(err, "Failed to validate header"))
+		if errors.Contains(err, errors.ErrInvalidDPoPProof) {
+			w.Header().Add("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+			errors.WriteToHTTP(w, errors.ErrInvalidDPoPProof, http.StatusUnauthorized, "")
+			return
+		}
 		errors.WriteToHTTP(w, errors.ErrInvalidRequest, 0, "")
 		return
 	}
@@ -287,10 +358,9 @@ func UserInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res := &UserInfo{
-		Subject:  claims.Subject,
-		UserName: user.Name,
-	}
+	res := UserInfo(token.UserClaims(user, claims.Scope))
+	res["sub"] = claims.Subject
+	res["preferred_username"] = user.Name
 
 	w.Header().Add("Cache-Control", "no-store")
 	w.Header().Add("Pragma", "no-cache")
@@ -309,6 +379,11 @@ func RevokeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cred, err := authenticateClient(w, r, projectName, r.Form.Get("state"))
+	if err != nil {
+		return
+	}
+
 	tokenType := r.Form.Get("token_type_hint")
 	if tokenType == "" {
 		tokenType = "refresh_token" // default is refresh token
@@ -316,7 +391,32 @@ func RevokeHandler(w http.ResponseWriter, r *http.Request) {
 
 	switch tokenType {
 	case "access_token":
-		errors.WriteToHTTP(w, errors.ErrUnsupportedTokenType, 0, r.Form.Get("state"))
+		accessToken := r.Form.Get("token")
+		claims := &token.AccessTokenClaims{}
+		issuer := token.GetExpectIssuer(r)
+		if err := token.ValidateAccessToken(claims, accessToken, issuer); err != nil {
+			// invalid or already revoked token is also returned ok
+			errors.PrintAsInfo(errors.Append(err, "Failed to validate access token"))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if claims.Project != projectName {
+			logger.Info("Access token is for project %s, but revoke in %s", claims.Project, projectName)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if claims.ClientID != "" && claims.ClientID != cred.ClientID {
+			logger.Info("Access token is issued to client %s, but revoked by %s", claims.ClientID, cred.ClientID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if err := token.RevokeAccessToken(claims); err != nil {
+			errors.Print(errors.Append(err, "Failed to revoke access token"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, r.Form.Get("state"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
 	case "refresh_token":
 		refreshToken := r.Form.Get("token")
 		claims := &token.RefreshTokenClaims{}
@@ -326,6 +426,11 @@ func RevokeHandler(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
+		if claims.ClientID != "" && claims.ClientID != cred.ClientID {
+			logger.Info("Refresh token is issued to client %s, but revoked by %s", claims.ClientID, cred.ClientID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 
 		if err := db.GetInst().SessionDelete(projectName, claims.SessionID); err != nil {
 			if errors.Contains(err, model.ErrNoSuchSession) || errors.Contains(err, model.ErrSessionValidateFailed) {
@@ -343,6 +448,125 @@ func RevokeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// IntrospectHandler ...
+func IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	// Get data form Form
+	if err := r.ParseForm(); err != nil {
+		logger.Info("Failed to parse form: %v", err)
+		errors.WriteToHTTP(w, errors.ErrInvalidRequestObject, 0, "")
+		return
+	}
+
+	cred, err := authenticateClient(w, r, projectName, "")
+	if err != nil {
+		return
+	}
+	clientID := cred.ClientID
+
+	// Only confidential clients such as resource servers can introspect tokens
+	var cli *model.ClientInfo
+	cli, err = db.GetInst().ClientGet(projectName, clientID)
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to get client"))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		return
+	}
+	if cli.AccessType != "confidential" {
+		logger.Info("Client %s is not confidential, so can not introspect token", clientID)
+		errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, "")
+		return
+	}
+
+	tkn := r.Form.Get("token")
+	issuer := token.GetExpectIssuer(r)
+
+	var res *IntrospectResponse
+	switch r.Form.Get("token_type_hint") {
+	case "refresh_token":
+		res, err = introspectRefreshToken(projectName, tkn, issuer)
+		if err == nil && !res.Active {
+			res, err = introspectAccessToken(projectName, tkn, issuer)
+		}
+	default:
+		// try access token at first
+		res, err = introspectAccessToken(projectName, tkn, issuer)
+		if err == nil && !res.Active {
+			res, err = introspectRefreshToken(projectName, tkn, issuer)
+		}
+	}
+
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to introspect token"))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		return
+	}
+
+	w.Header().Add("Cache-Control", "no-store")
+	w.Header().Add("Pragma", "no-cache")
+	jwthttp.ResponseWrite(w, "IntrospectHandler", res)
+}
+
+// PushedAuthRequestHandler ...
+func PushedAuthRequestHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "PUSHED_AUTHORIZATION_REQUEST", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	if err := r.ParseForm(); err != nil {
+		logger.Info("Failed to parse form: %v", err)
+		errors.WriteToHTTP(w, errors.ErrInvalidRequest, 0, "")
+		return
+	}
+
+	var cred *oidc.ClientCredentials
+	cred, err = authenticateClient(w, r, projectName, "")
+	if err != nil {
+		return
+	}
+	clientID := cred.ClientID
+
+	// the parameters must be sent in the body
+	params := r.PostForm
+	params.Del("client_secret")
+	params.Del("client_assertion_type")
+	params.Del("client_assertion")
+
+	var res *oidc.PushedAuthResponse
+	res, err = oidc.PushAuthRequest(projectName, clientID, token.GetFullIssuer(r), params)
+	if err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to push authorization request"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to push authorization request"))
+			errors.WriteToHTTP(w, err, 0, "")
+		}
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if e := json.NewEncoder(w).Encode(res); e != nil {
+		logger.Error("Failed to encode a response for PushedAuthRequestHandler: %+v", e)
+		return
+	}
+	logger.Info("PushedAuthRequestHandler method successfully finished")
+}
+
 func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req url.Values) {
 	var err *errors.Error
 	defer func() {
@@ -356,6 +580,34 @@ func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req
 	}()
 
 	tokenIssuer := token.GetExpectIssuer(r)
+
+	// Use the parameters pushed by the client if request_uri is issued by the PAR endpoint
+	req, err = oidc.ResolvePushedAuthRequest(projectName, req)
+	if err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to resolve pushed authorization request"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to resolve pushed authorization request"))
+			errors.WriteToHTTP(w, err, 0, "")
+		}
+		return
+	}
+
+	// Resolve request object before validating redirect uri, because it may be in the request object
+	// the audience of request object is the issuer in discovery
+	req, err = oidc.ResolveRequestObject(projectName, token.GetFullIssuer(r), req)
+	if err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to resolve request object"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to resolve request object"))
+			errors.WriteToHTTP(w, err, 0, "")
+		}
+		return
+	}
+
 	authReq := oidc.NewAuthRequest(req)
 	logger.Debug("Auth Request: %v", authReq)
 
@@ -379,7 +631,7 @@ func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req
 		if err.StatusCode() == 0 {
 			errors.WriteToHTTP(w, errors.ErrServerError, 0, authReq.State)
 		} else {
-			errors.RedirectWithOAuthError(w, err, r.Method, authReq.RedirectURI, authReq.State)
+			oidc.WriteErrorResponse(w, err, authReq.ResponseMode, authReq.RedirectURI, authReq.State)
 		}
 		return
 	}
@@ -415,7 +667,7 @@ func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req
 		var claims token.IDTokenClaims
 		if err = token.ValidateIDToken(&claims, authReq.IDTokenHint, projectName, tokenIssuer); err != nil {
 			errors.PrintAsInfo(errors.Append(err, "Failed to validate id_token_hint"))
-			errors.RedirectWithOAuthError(w, errors.ErrInvalidRequest, r.Method, authReq.RedirectURI, authReq.State)
+			oidc.WriteErrorResponse(w, errors.ErrInvalidRequest, authReq.ResponseMode, authReq.RedirectURI, authReq.State)
 			return
 		}
 		userID = claims.Subject
@@ -431,19 +683,19 @@ func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req
 	if userID != "" {
 		req, err := sso.Handle(r.Method, projectName, userID, tokenIssuer, authReq)
 		if err == nil {
-			http.Redirect(w, req, req.URL.String(), http.StatusFound)
+			oidc.WriteLoggedInResponse(w, req)
 			return
-		} else if !errors.Contains(err, errors.ErrLoginRequired) {
+		} else if err.Error() != errors.ErrLoginRequired.Error() {
 			// Internal Server Error
 			errors.Print(errors.Append(err, "Failed to handler SSO"))
-			errors.RedirectWithOAuthError(w, errors.ErrServerError, r.Method, authReq.RedirectURI, authReq.State)
+			oidc.WriteErrorResponse(w, errors.ErrServerError, authReq.ResponseMode, authReq.RedirectURI, authReq.State)
 			return
 		}
 	}
 
 	if slice.Contains(authReq.Prompt, "none") {
 		logger.Info("request is prompt=none, but no valid sessions")
-		errors.RedirectWithOAuthError(w, errors.ErrLoginRequired, r.Method, authReq.RedirectURI, authReq.State)
+		oidc.WriteErrorResponse(w, errors.ErrLoginRequired, authReq.ResponseMode, authReq.RedirectURI, authReq.State)
 		return // if prompt=none, never return login page
 	}
 
@@ -458,3 +710,214 @@ func authHandler(w http.ResponseWriter, r *http.Request, projectName string, req
 	// Return login page
 	login.WriteUserLoginPage(projectName, lsID, "", authReq.State, w)
 }
+
+// ClientRegistrationHandler registers a new client with the initial access token
+func ClientRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "CLIENT_REGISTRATION", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	var tokenString string
+	tokenString, err = jwthttp.GetBearerToken(r)
+	if err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to get initial access token"))
+		writeInvalidTokenError(w)
+		return
+	}
+	claims := &token.InitialAccessTokenClaims{}
+	if err = token.ValidateInitialAccessToken(claims, tokenString, token.GetExpectIssuer(r)); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to validate initial access token"))
+		writeInvalidTokenError(w)
+		return
+	}
+	if claims.Project != projectName {
+		err = errors.New("Invalid request", "Initial access token is for project %s, but used in %s", claims.Project, projectName)
+		errors.PrintAsInfo(err)
+		writeInvalidTokenError(w)
+		return
+	}
+
+	var request oidc.ClientMetadata
+	if e := json.NewDecoder(r.Body).Decode(&request); e != nil {
+		err = errors.Append(errors.ErrInvalidClientMetadata, "Failed to decode client registration request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, 0, "")
+		return
+	}
+
+	var res *oidc.ClientInformation
+	res, err = oidc.RegisterClient(projectName, registrationURI(r), &request)
+	if err != nil {
+		writeRegistrationError(w, err, "Failed to register client")
+		return
+	}
+
+	writeClientInformation(w, http.StatusCreated, res)
+	logger.Info("ClientRegistrationHandler method successfully finished")
+}
+
+// RegisteredClientGetHandler returns the metadata of the client registered dynamically
+func RegisteredClientGetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+	clientID := vars["clientID"]
+
+	client, err := authRegisteredClient(r, projectName, clientID)
+	if err != nil {
+		writeRegistrationError(w, err, "Failed to authenticate registered client")
+		return
+	}
+
+	writeClientInformation(w, http.StatusOK, oidc.NewClientInformation(client, registrationURI(r)))
+	logger.Info("RegisteredClientGetHandler method successfully finished")
+}
+
+// RegisteredClientUpdateHandler replaces the metadata of the client registered dynamically
+func RegisteredClientUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+	clientID := vars["clientID"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "CLIENT_REGISTRATION", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	var client *model.ClientInfo
+	client, err = authRegisteredClient(r, projectName, clientID)
+	if err != nil {
+		writeRegistrationError(w, err, "Failed to authenticate registered client")
+		return
+	}
+
+	var request oidc.ClientInformation
+	if e := json.NewDecoder(r.Body).Decode(&request); e != nil {
+		err = errors.Append(errors.ErrInvalidClientMetadata, "Failed to decode client update request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, 0, "")
+		return
+	}
+
+	var res *oidc.ClientInformation
+	res, err = oidc.UpdateRegisteredClient(projectName, client, registrationURI(r), &request)
+	if err != nil {
+		writeRegistrationError(w, err, "Failed to update registered client")
+		return
+	}
+
+	writeClientInformation(w, http.StatusOK, res)
+	logger.Info("RegisteredClientUpdateHandler method successfully finished")
+}
+
+// RegisteredClientDeleteHandler deletes the client registered dynamically
+func RegisteredClientDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+	clientID := vars["clientID"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if err = audit.GetInst().Save(projectName, time.Now(), "CLIENT_REGISTRATION", r.Method, r.URL.String(), msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	if _, err = authRegisteredClient(r, projectName, clientID); err != nil {
+		writeRegistrationError(w, err, "Failed to authenticate registered client")
+		return
+	}
+
+	if err = db.GetInst().ClientDelete(projectName, clientID); err != nil {
+		errors.Print(errors.Append(err, "Failed to delete client"))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		return
+	}
+
+	w.Header().Add("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("RegisteredClientDeleteHandler method successfully finished")
+}
+
+func registrationURI(r *http.Request) string {
+	return token.GetFullIssuer(r) + "/openid-connect/register"
+}
+
+func authRegisteredClient(r *http.Request, projectName string, clientID string) (*model.ClientInfo, *errors.Error) {
+	accessToken, err := jwthttp.GetBearerToken(r)
+	if err != nil {
+		return nil, errors.Append(errors.ErrInvalidToken, err.Error())
+	}
+	return oidc.GetRegisteredClient(projectName, clientID, accessToken)
+}
+
+func writeInvalidTokenError(w http.ResponseWriter) {
+	w.Header().Add("WWW-Authenticate", `Bearer error="invalid_token"`)
+	errors.WriteToHTTP(w, errors.ErrInvalidToken, http.StatusUnauthorized, "")
+}
+
+func writeRegistrationError(w http.ResponseWriter, err *errors.Error, msg string) {
+	if err.StatusCode() == 0 {
+		errors.Print(errors.Append(err, msg))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		return
+	}
+
+	errors.PrintAsInfo(errors.Append(err, msg))
+	if err.Error() == errors.ErrInvalidToken.Error() {
+		writeInvalidTokenError(w)
+		return
+	}
+	errors.WriteToHTTP(w, err, 0, "")
+}
+
+func writeClientInformation(w http.ResponseWriter, statusCode int, res *oidc.ClientInformation) {
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if e := json.NewEncoder(w).Encode(res); e != nil {
+		logger.Error("Failed to encode client information: %+v", e)
+	}
+}
+
+// authenticateClient gets the credentials from the request and authenticates the client
+// the error response is already written when it returns an error
+func authenticateClient(w http.ResponseWriter, r *http.Request, projectName string, state string) (*oidc.ClientCredentials, *errors.Error) {
+	cred, err := oidc.GetClientCredentials(r)
+	if err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to get client credentials, Request header: %v", r.Header))
+		errors.WriteToHTTP(w, err, 0, state)
+		return nil, err
+	}
+
+	if err := oidc.ClientAuth(projectName, token.GetFullIssuer(r), cred); err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to authenticate client"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to authenticate client %s", cred.ClientID))
+			errors.WriteToHTTP(w, errors.ErrInvalidClient, 0, state)
+		}
+		return nil, err
+	}
+	return cred, nil
+}
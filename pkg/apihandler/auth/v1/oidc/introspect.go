@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+func introspectAccessToken(projectName, tokenString, issuer string) (*IntrospectResponse, *errors.Error) {
+	claims := &token.AccessTokenClaims{}
+	if err := token.ValidateAccessToken(claims, tokenString, issuer); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to validate access token"))
+		return &IntrospectResponse{Active: false}, nil
+	}
+	if claims.Project != projectName {
+		errors.PrintAsInfo(errors.New("", "Access token is for project %s, but introspected in %s", claims.Project, projectName))
+		return &IntrospectResponse{Active: false}, nil
+	}
+
+	if claims.SessionID != "" {
+		active, err := sessionExists(projectName, claims.SessionID)
+		if err != nil || !active {
+			return &IntrospectResponse{Active: false}, err
+		}
+	}
+
+	tokenType := "Bearer"
+	if claims.Confirmation != nil && claims.Confirmation.JKT != "" {
+		tokenType = "DPoP"
+	}
+
+	roles := claims.ResourceAccess
+	return &IntrospectResponse{
+		Active:       true,
+		Scope:        claims.Scope,
+		ClientID:     claims.ClientID,
+		UserName:     claims.UserName,
+		TokenType:    tokenType,
+		ExpiresAt:    claims.ExpiresAt,
+		IssuedAt:     claims.IssuedAt,
+		Subject:      claims.Subject,
+		Audience:     claims.Audience,
+		Issuer:       claims.Issuer,
+		JTI:          claims.Id,
+		Roles:        &roles,
+		Confirmation: claims.Confirmation,
+		Actor:        claims.Actor,
+	}, nil
+}
+
+func introspectRefreshToken(projectName, tokenString, issuer string) (*IntrospectResponse, *errors.Error) {
+	claims := &token.RefreshTokenClaims{}
+	if err := token.ValidateRefreshToken(claims, tokenString, issuer); err != nil {
+		errors.PrintAsInfo(errors.Append(err, "Failed to validate refresh token"))
+		return &IntrospectResponse{Active: false}, nil
+	}
+	if claims.Project != projectName {
+		errors.PrintAsInfo(errors.New("", "Refresh token is for project %s, but introspected in %s", claims.Project, projectName))
+		return &IntrospectResponse{Active: false}, nil
+	}
+
+	active, err := sessionExists(projectName, claims.SessionID)
+	if err != nil || !active {
+		return &IntrospectResponse{Active: false}, err
+	}
+
+	return &IntrospectResponse{
+		Active:       true,
+		Scope:        claims.Scope,
+		ClientID:     claims.ClientID,
+		ExpiresAt:    claims.ExpiresAt,
+		IssuedAt:     claims.IssuedAt,
+		Subject:      claims.Subject,
+		Audience:     claims.Audience,
+		Issuer:       claims.Issuer,
+		JTI:          claims.Id,
+		Confirmation: claims.Confirmation,
+	}, nil
+}
+
+func sessionExists(projectName, sessionID string) (bool, *errors.Error) {
+	if _, err := db.GetInst().SessionGet(projectName, sessionID); err != nil {
+		if errors.Contains(err, model.ErrNoSuchSession) || errors.Contains(err, model.ErrSessionValidateFailed) {
+			errors.PrintAsInfo(errors.Append(err, "Session %s is already revoked", sessionID))
+			return false, nil
+		}
+		return false, errors.Append(err, "Failed to get session")
+	}
+	return true, nil
+}
@@ -0,0 +1,150 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+const (
+	testProjectName = "introspect"
+	testIssuer      = "http://localhost/authapi/v1/project/introspect"
+	testSecret      = "0123456789abcdef0123456789abcdef"
+)
+
+func introspect(t *testing.T, clientID, secret string, form url.Values) (int, *IntrospectResponse) {
+	t.Helper()
+
+	r := httptest.NewRequest("POST", testIssuer+"/openid-connect/token/introspect", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(clientID, secret)
+	r = mux.SetURLVars(r, map[string]string{"projectName": testProjectName})
+	w := httptest.NewRecorder()
+
+	IntrospectHandler(w, r)
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	var res IntrospectResponse
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode introspect response: %v", err)
+	}
+	return w.Code, &res
+}
+
+func TestIntrospectHandler(t *testing.T) {
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      testProjectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	clients := []*model.ClientInfo{
+		{ID: "resource-server", ProjectName: testProjectName, AccessType: "confidential", Secret: testSecret},
+		{ID: "public-app", ProjectName: testProjectName, AccessType: "public"},
+	}
+	for _, c := range clients {
+		if err := db.GetInst().ClientAdd(testProjectName, c); err != nil {
+			t.Fatalf("Failed to add client %s: %v", c.ID, err)
+		}
+	}
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: testProjectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(testProjectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	session := &model.Session{
+		SessionID:   uuid.New().String(),
+		ProjectName: testProjectName,
+		UserID:      user.ID,
+		CreatedAt:   time.Now(),
+		ExpiresIn:   model.DefaultRefreshTokenExpiresInSec,
+		FromIP:      "127.0.0.1",
+	}
+	if err := db.GetInst().SessionAdd(testProjectName, session); err != nil {
+		t.Fatalf("Failed to add session: %v", err)
+	}
+
+	req := token.Request{
+		Issuer:      testIssuer,
+		ExpiresIn:   model.DefaultAccessTokenExpiresInSec,
+		ProjectName: testProjectName,
+		UserID:      user.ID,
+		ClientID:    "public-app",
+		SessionID:   session.SessionID,
+		Scope:       "openid",
+	}
+	accessToken, err := token.GenerateAccessToken([]string{user.ID}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	refreshToken, err := token.GenerateRefreshToken(session.SessionID, []string{user.ID}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+
+	// active access token
+	code, res := introspect(t, "resource-server", testSecret, url.Values{"token": {accessToken}})
+	if code != http.StatusOK {
+		t.Fatalf("Introspection should succeed, but got status %d", code)
+	}
+	if !res.Active || res.Subject != user.ID || res.ClientID != "public-app" || res.UserName != "user" || res.TokenType != "Bearer" {
+		t.Errorf("Wrong introspect response of access token: %v", res)
+	}
+
+	// active refresh token with the hint
+	_, res = introspect(t, "resource-server", testSecret, url.Values{"token": {refreshToken}, "token_type_hint": {"refresh_token"}})
+	if res == nil || !res.Active || res.Subject != user.ID {
+		t.Errorf("Wrong introspect response of refresh token: %v", res)
+	}
+
+	// invalid token is inactive
+	_, res = introspect(t, "resource-server", testSecret, url.Values{"token": {"invalid-token"}})
+	if res == nil || res.Active {
+		t.Errorf("Invalid token should be inactive, but got %v", res)
+	}
+
+	// wrong client credentials and public client are rejected
+	if code, _ := introspect(t, "resource-server", "wrong-secret-value", url.Values{"token": {accessToken}}); code == http.StatusOK {
+		t.Errorf("Client with wrong secret should be rejected")
+	}
+	if code, _ := introspect(t, "public-app", "", url.Values{"token": {accessToken}}); code == http.StatusOK {
+		t.Errorf("Public client should not introspect tokens")
+	}
+	if code, _ := introspect(t, "unknown-client", testSecret, url.Values{"token": {accessToken}}); code == http.StatusOK {
+		t.Errorf("Unknown client should be rejected")
+	}
+
+	// tokens of the deleted session are inactive
+	if err := db.GetInst().SessionDelete(testProjectName, session.SessionID); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	_, res = introspect(t, "resource-server", testSecret, url.Values{"token": {accessToken}})
+	if res == nil || res.Active {
+		t.Errorf("Access token of the deleted session should be inactive, but got %v", res)
+	}
+	_, res = introspect(t, "resource-server", testSecret, url.Values{"token": {refreshToken}, "token_type_hint": {"refresh_token"}})
+	if res == nil || res.Active {
+		t.Errorf("Refresh token of the deleted session should be inactive, but got %v", res)
+	}
+}
@@ -0,0 +1,286 @@
+package oidc
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/login"
+	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+	"github.com/sh-miyoshi/hekate/pkg/sso"
+	"github.com/stretchr/stew/slice"
+)
+
+type logoutRequest struct {
+	userID      string
+	sessionID   string
+	clientID    string
+	redirectURI string
+}
+
+// LogoutHandler ends the SSO session by the RP-initiated logout defined in OpenID Connect RP-Initiated Logout 1.0
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+
+	var err *errors.Error
+	defer func() {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		// the query is not saved because it contains the id_token_hint
+		if err = audit.GetInst().Save(projectName, time.Now(), "LOGOUT", r.Method, r.URL.Path, msg); err != nil {
+			errors.Print(errors.Append(err, "Failed to save audit event"))
+		}
+	}()
+
+	if err := r.ParseForm(); err != nil {
+		logger.Info("Failed to parse form: %v", err)
+		errors.WriteToHTTP(w, errors.ErrInvalidRequest, 0, "")
+		return
+	}
+	state := r.Form.Get("state")
+
+	var req *logoutRequest
+	req, err = parseLogoutRequest(projectName, token.GetFullIssuer(r), r.Form)
+	if err != nil {
+		if err.StatusCode() == 0 {
+			errors.Print(errors.Append(err, "Failed to parse logout request"))
+			errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+		} else {
+			errors.PrintAsInfo(errors.Append(err, "Failed to parse logout request"))
+			errors.WriteToHTTP(w, err, 0, state)
+		}
+		return
+	}
+
+	// all sessions are ended only for the user logged in the browser
+	userID := ""
+	if cookie, e := r.Cookie("HEKATE_LOGIN_SESSION"); e == nil {
+		if userID, err = sso.GetLoginUserIDFromSSOSessionCookie(cookie, projectName); err != nil {
+			logger.Debug("Failed to get user id from cookie: %v", err)
+			userID = ""
+			err = nil
+		}
+	}
+
+	// confirm to the user if the logout request may not be sent by the RP of the logged in user
+	selected := ""
+	if config.Get().LoginResource.LogoutPage != "" {
+		if selected, err = getLogoutConfirmation(r, projectName, userID); err != nil {
+			if err.StatusCode() == 0 {
+				errors.Print(errors.Append(err, "Failed to get logout confirmation"))
+				errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+			} else {
+				errors.PrintAsInfo(errors.Append(err, "Failed to get logout confirmation"))
+				errors.WriteToHTTP(w, err, 0, state)
+			}
+			return
+		}
+
+		if selected == "" && (userID == "" || req.userID == "" || userID != req.userID) {
+			params := url.Values{}
+			for k, v := range r.Form {
+				if k != "select" && k != "confirm_token" {
+					params[k] = v
+				}
+			}
+			login.WriteLogoutPage(projectName, token.GetFullIssuer(r), userID, params, w)
+			return
+		}
+	}
+
+	redirectURI := ""
+	if req.redirectURI != "" {
+		u, _ := url.Parse(req.redirectURI)
+		if state != "" {
+			q := u.Query()
+			q.Set("state", state)
+			u.RawQuery = q.Encode()
+		}
+		redirectURI = u.String()
+	}
+
+	frontchannelURIs := []string{}
+	if selected != "no" {
+		if userID != "" {
+			if frontchannelURIs, err = logoutUser(projectName, userID, token.GetFullIssuer(r)); err != nil {
+				errors.Print(errors.Append(err, "Failed to logout"))
+				errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+				return
+			}
+			logger.Info("User %s is logged out by client %s", userID, req.clientID)
+		} else if req.sessionID != "" {
+			// without the login cookie, only the session of the id_token_hint is ended
+			if frontchannelURIs, err = logoutSession(projectName, req.userID, req.sessionID, token.GetFullIssuer(r)); err != nil {
+				errors.Print(errors.Append(err, "Failed to logout"))
+				errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+				return
+			}
+			logger.Info("Session %s of user %s is logged out by client %s", req.sessionID, req.userID, req.clientID)
+		}
+		sso.ClearSSOSessionCookie(w, projectName)
+	}
+
+	if len(frontchannelURIs) > 0 {
+		oidc.WriteFrontchannelLogoutPage(w, frontchannelURIs, redirectURI)
+		return
+	}
+
+	if redirectURI == "" {
+		w.Header().Add("Content-Type", "text/plain; charset=UTF-8")
+		w.Write([]byte("Logged out"))
+		return
+	}
+	http.Redirect(w, r, redirectURI, http.StatusFound)
+}
+
+// getLogoutConfirmation returns the answer of the user in the logout page
+// the answer is accepted only by POST with the one-time token issued by login.WriteLogoutPage,
+// so the cross-site request can not skip the confirmation
+func getLogoutConfirmation(r *http.Request, projectName, userID string) (string, *errors.Error) {
+	if r.Method != http.MethodPost {
+		return "", nil
+	}
+	selected := r.PostForm.Get("select")
+	if selected == "" {
+		return "", nil
+	}
+
+	var claims token.LogoutConfirmTokenClaims
+	if err := token.ValidateLogoutConfirmToken(&claims, r.PostForm.Get("confirm_token"), token.GetFullIssuer(r)); err != nil {
+		return "", errors.Append(errors.ErrInvalidRequest, "Failed to validate confirm token: %v", err)
+	}
+	if claims.Project != projectName || claims.Subject != userID {
+		return "", errors.Append(errors.ErrInvalidRequest, "Confirm token is not issued to the user in the browser")
+	}
+
+	// the token can be used only once
+	ent := &model.UsedJTI{
+		JTI:         claims.Id,
+		Issuer:      claims.Issuer,
+		ProjectName: projectName,
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0),
+	}
+	if err := db.GetInst().UsedJTIAdd(projectName, ent); err != nil {
+		if errors.Contains(err, model.ErrJTIAlreadyUsed) || errors.Contains(err, model.ErrUsedJTIValidateFailed) {
+			return "", errors.Append(errors.ErrInvalidRequest, "Confirm token %s is already used", claims.Id)
+		}
+		return "", errors.Append(err, "Failed to add used jti")
+	}
+	return selected, nil
+}
+
+// logoutUser deletes all sessions of the user and notifies the clients which received tokens in the sessions
+// it returns the front-channel logout URIs which must be loaded in the browser
+func logoutUser(projectName, userID, issuer string) ([]string, *errors.Error) {
+	sessions, err := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: userID})
+	if err != nil {
+		return nil, errors.Append(err, "Failed to get sessions of user")
+	}
+	if err := db.GetInst().UserLogout(projectName, userID); err != nil {
+		return nil, errors.Append(err, "Failed to delete sessions of user")
+	}
+	return notifyLogout(projectName, issuer, sessions), nil
+}
+
+// logoutSession deletes the sessions identified by the sid claim of the ID token and notifies the clients
+// the sid is the family id of the refresh token, or the session id of the session without family
+func logoutSession(projectName, userID, sid, issuer string) ([]string, *errors.Error) {
+	if !model.ValidateSessionID(sid) {
+		logger.Info("Invalid sid %s in id_token_hint", sid)
+		return []string{}, nil
+	}
+
+	sessions := []*model.Session{}
+	for _, f := range []*model.SessionFilter{{FamilyID: sid}, {SessionID: sid}} {
+		all, err := db.GetInst().SessionGetList(projectName, f)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get sessions of sid %s", sid)
+		}
+		for _, s := range all {
+			// the session of the other user is not ended by the id_token_hint
+			if s.UserID == userID {
+				sessions = append(sessions, s)
+			}
+		}
+	}
+
+	for _, s := range sessions {
+		if err := db.GetInst().SessionDelete(projectName, s.SessionID); err != nil && !errors.Contains(err, model.ErrNoSuchSession) {
+			return nil, errors.Append(err, "Failed to delete session %s", s.SessionID)
+		}
+	}
+	return notifyLogout(projectName, issuer, sessions), nil
+}
+
+// notifyLogout notifies the clients which received tokens in the deleted sessions
+// it returns the front-channel logout URIs which must be loaded in the browser
+func notifyLogout(projectName, issuer string, sessions []*model.Session) []string {
+	// the user is already logged out, so the notification failure does not fail the logout
+	if err := oidc.NotifyBackchannelLogout(projectName, issuer, sessions); err != nil {
+		errors.Print(errors.Append(err, "Failed to notify back-channel logout"))
+	}
+	uris, err := oidc.FrontchannelLogoutURIs(projectName, issuer, sessions)
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to get front-channel logout URIs"))
+		return []string{}
+	}
+	return uris
+}
+
+func parseLogoutRequest(projectName, issuer string, form url.Values) (*logoutRequest, *errors.Error) {
+	res := &logoutRequest{
+		clientID:    form.Get("client_id"),
+		redirectURI: form.Get("post_logout_redirect_uri"),
+	}
+
+	if hint := form.Get("id_token_hint"); hint != "" {
+		var claims token.IDTokenClaims
+		if err := token.ValidateIDTokenHint(&claims, hint, projectName, issuer); err != nil {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Failed to validate id_token_hint: %v", err)
+		}
+		res.userID = claims.Subject
+		res.sessionID = claims.SessionID
+
+		if res.clientID != "" && !slice.Contains(claims.Audience, res.clientID) {
+			return nil, errors.Append(errors.ErrInvalidRequest, "id_token_hint is not issued to client %s", res.clientID)
+		}
+		if res.clientID == "" {
+			// the audiences of the ID token are the user and the client
+			for _, aud := range claims.Audience {
+				if aud != claims.Subject {
+					res.clientID = aud
+					break
+				}
+			}
+		}
+	}
+
+	if res.redirectURI != "" {
+		if res.clientID == "" {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Client is required to redirect after logout")
+		}
+		cli, err := db.GetInst().ClientGet(projectName, res.clientID)
+		if err != nil {
+			if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
+				return nil, errors.Append(errors.ErrInvalidRequest, "No such client %s", res.clientID)
+			}
+			return nil, errors.Append(err, "Failed to get client")
+		}
+		if !slice.Contains(cli.PostLogoutRedirectURIs, res.redirectURI) {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Post logout redirect URI %s is not allowed in client %s", res.redirectURI, res.clientID)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,212 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+func TestLogoutHandlerWithoutCookie(t *testing.T) {
+	const projectName = "logout"
+	const issuer = "http://localhost/authapi/v1/project/logout"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	audit.Init("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	// the user logs in from two browsers
+	families := []string{uuid.New().String(), uuid.New().String()}
+	for _, f := range families {
+		s := &model.Session{
+			SessionID:   f,
+			FamilyID:    f,
+			ProjectName: projectName,
+			UserID:      user.ID,
+			CreatedAt:   time.Now(),
+			ExpiresIn:   model.DefaultRefreshTokenExpiresInSec,
+			FromIP:      "127.0.0.1",
+		}
+		if err := db.GetInst().SessionAdd(projectName, s); err != nil {
+			t.Fatalf("Failed to add session: %v", err)
+		}
+	}
+
+	logout := func(expiresIn int64) int {
+		hint, err := token.GenerateIDToken([]string{user.ID, "app"}, token.Request{
+			Issuer:      issuer,
+			ExpiresIn:   expiresIn,
+			ProjectName: projectName,
+			UserID:      user.ID,
+			SessionID:   families[0],
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate ID token: %v", err)
+		}
+
+		q := url.Values{}
+		q.Set("id_token_hint", hint)
+		r := httptest.NewRequest("GET", issuer+"/openid-connect/logout?"+q.Encode(), nil)
+		r = mux.SetURLVars(r, map[string]string{"projectName": projectName})
+		w := httptest.NewRecorder()
+		LogoutHandler(w, r)
+		return w.Code
+	}
+
+	// the too old id_token_hint is rejected
+	if code := logout(-int64(48 * time.Hour / time.Second)); code != http.StatusBadRequest {
+		t.Errorf("Logout with too old hint expects status %d, but got %d", http.StatusBadRequest, code)
+	}
+	if sessions, _ := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID}); len(sessions) != 2 {
+		t.Errorf("Sessions expect to be kept by the rejected logout, but got %d sessions", len(sessions))
+	}
+
+	if code := logout(model.DefaultAccessTokenExpiresInSec); code != http.StatusOK {
+		t.Fatalf("Logout expects status %d, but got %d", http.StatusOK, code)
+	}
+	// only the session of the sid in the hint is ended without the login cookie
+	sessions, err := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
+	if err != nil {
+		t.Fatalf("Failed to get sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].FamilyID != families[1] {
+		t.Errorf("Only the session %s expects to be kept, but got %v", families[1], sessions)
+	}
+}
+
+func TestLogoutHandlerConfirmation(t *testing.T) {
+	const projectName = "logoutconfirm"
+	const issuer = "http://localhost/authapi/v1/project/logoutconfirm"
+
+	org := config.Get().LoginResource.LogoutPage
+	defer func() {
+		config.Get().LoginResource.LogoutPage = org
+	}()
+	config.Get().LoginResource.LogoutPage = "../../../../../cmd/hekate/_data/login/logout.html"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	audit.Init("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	sid := uuid.New().String()
+	if err := db.GetInst().SessionAdd(projectName, &model.Session{
+		SessionID:   sid,
+		FamilyID:    sid,
+		ProjectName: projectName,
+		UserID:      user.ID,
+		CreatedAt:   time.Now(),
+		ExpiresIn:   model.DefaultRefreshTokenExpiresInSec,
+		FromIP:      "127.0.0.1",
+	}); err != nil {
+		t.Fatalf("Failed to add session: %v", err)
+	}
+	hint, err := token.GenerateIDToken([]string{user.ID, "app"}, token.Request{
+		Issuer:      issuer,
+		ExpiresIn:   model.DefaultAccessTokenExpiresInSec,
+		ProjectName: projectName,
+		UserID:      user.ID,
+		SessionID:   sid,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate ID token: %v", err)
+	}
+
+	logout := func(method string, form url.Values) *httptest.ResponseRecorder {
+		q := url.Values{}
+		q.Set("id_token_hint", hint)
+		if method == "GET" {
+			for k, v := range form {
+				q[k] = v
+			}
+		}
+		r := httptest.NewRequest(method, issuer+"/openid-connect/logout?"+q.Encode(), strings.NewReader(form.Encode()))
+		if method == "POST" {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		r = mux.SetURLVars(r, map[string]string{"projectName": projectName})
+		w := httptest.NewRecorder()
+		LogoutHandler(w, r)
+		return w
+	}
+	sessionNum := func() int {
+		sessions, _ := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
+		return len(sessions)
+	}
+
+	// the answer in the query of the cross-site request does not skip the confirmation
+	w := logout("GET", url.Values{"select": {"yes"}})
+	m := regexp.MustCompile(`name="confirm_token" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+	if w.Code != http.StatusOK || m == nil {
+		t.Fatalf("Logout with select in the query expects the confirmation page, but got status %d", w.Code)
+	}
+	if sessionNum() != 1 {
+		t.Errorf("Session expects to be kept before the confirmation")
+	}
+	confirmToken := m[1]
+
+	// the answer without the confirm token is rejected
+	if w := logout("POST", url.Values{"select": {"yes"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Logout without confirm token expects status %d, but got %d", http.StatusBadRequest, w.Code)
+	}
+	if sessionNum() != 1 {
+		t.Errorf("Session expects to be kept by the rejected logout")
+	}
+
+	if w := logout("POST", url.Values{"select": {"yes"}, "confirm_token": {confirmToken}}); w.Code != http.StatusOK {
+		t.Errorf("Logout with confirm token expects status %d, but got %d", http.StatusOK, w.Code)
+	}
+	if sessionNum() != 0 {
+		t.Errorf("Session expects to be ended by the confirmed logout")
+	}
+
+	// the confirm token can be used only once
+	if w := logout("POST", url.Values{"select": {"yes"}, "confirm_token": {confirmToken}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Logout with used confirm token expects status %d, but got %d", http.StatusBadRequest, w.Code)
+	}
+}
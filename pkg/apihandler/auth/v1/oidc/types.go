@@ -1,20 +1,42 @@
 package oidc
 
+import (
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
 // Config ...
 type Config struct {
-	Issuer                            string   `json:"issuer"`
-	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
-	TokenEndpoint                     string   `json:"token_endpoint"`
-	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
-	JwksURI                           string   `json:"jwks_uri"`
-	ScopesSupported                   []string `json:"scopes_supported"`
-	ResponseTypesSupported            []string `json:"response_types_supported"`
-	SubjectTypesSupported             []string `json:"subject_types_supported"`
-	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
-	ClaimsSupported                   []string `json:"claims_supported"`
-	ResponseModesSupported            []string `json:"response_modes_supported"`
-	GrantTypesSupported               []string `json:"grant_types_supported"`
-	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	UserinfoEndpoint                           string   `json:"userinfo_endpoint"`
+	JwksURI                                    string   `json:"jwks_uri"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+	EndSessionEndpoint                         string   `json:"end_session_endpoint"`
+	ScopesSupported                            []string `json:"scopes_supported"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                            []string `json:"claims_supported"`
+	ResponseModesSupported                     []string `json:"response_modes_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	RequestParameterSupported                  bool     `json:"request_parameter_supported"`
+	RequestURIParameterSupported               bool     `json:"request_uri_parameter_supported"`
+	RequireRequestURIRegistration              bool     `json:"require_request_uri_registration"`
+	RequestObjectSigningAlgValuesSupported     []string `json:"request_object_signing_alg_values_supported"`
+	RequestObjectEncryptionAlgValuesSupported  []string `json:"request_object_encryption_alg_values_supported"`
+	RequestObjectEncryptionEncValuesSupported  []string `json:"request_object_encryption_enc_values_supported"`
+	PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint"`
+	RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests"`
+	RegistrationEndpoint                       string   `json:"registration_endpoint"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	TLSClientCertificateBoundAccessTokens      bool     `json:"tls_client_certificate_bound_access_tokens"`
+	DPoPSigningAlgValuesSupported              []string `json:"dpop_signing_alg_values_supported"`
+	BackchannelLogoutSupported                 bool     `json:"backchannel_logout_supported"`
+	BackchannelLogoutSessionSupported          bool     `json:"backchannel_logout_session_supported"`
+	FrontchannelLogoutSupported                bool     `json:"frontchannel_logout_supported"`
+	FrontchannelLogoutSessionSupported         bool     `json:"frontchannel_logout_session_supported"`
 }
 
 // TokenResponse ...
@@ -25,12 +47,28 @@ type TokenResponse struct {
 	RefreshToken     string `json:"refresh_token"`
 	RefreshExpiresIn uint   `json:"refresh_expires_in"`
 	IDToken          string `json:"id_token"`
+	IssuedTokenType  string `json:"issued_token_type,omitempty"`
 }
 
-// UserInfo ...
-type UserInfo struct {
-	Subject  string `json:"sub"`
-	UserName string `json:"preferred_username"`
+// UserInfo is a set of the claims of the user, the claims other than sub and preferred_username are released by the scope
+type UserInfo map[string]interface{}
+
+// IntrospectResponse ...
+type IntrospectResponse struct {
+	Active       bool                `json:"active"`
+	Scope        string              `json:"scope,omitempty"`
+	ClientID     string              `json:"client_id,omitempty"`
+	UserName     string              `json:"username,omitempty"`
+	TokenType    string              `json:"token_type,omitempty"`
+	ExpiresAt    int64               `json:"exp,omitempty"`
+	IssuedAt     int64               `json:"iat,omitempty"`
+	Subject      string              `json:"sub,omitempty"`
+	Audience     []string            `json:"aud,omitempty"`
+	Issuer       string              `json:"iss,omitempty"`
+	JTI          string              `json:"jti,omitempty"`
+	Roles        *token.RoleSet      `json:"resource_access,omitempty"`
+	Confirmation *token.Confirmation `json:"cnf,omitempty"`
+	Actor        *token.Actor        `json:"act,omitempty"`
 }
 
 // ErrorResponse ...
@@ -11,6 +11,8 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	jwthttp "github.com/sh-miyoshi/hekate/pkg/http"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 	"github.com/sh-miyoshi/hekate/pkg/otp"
 	"github.com/sh-miyoshi/hekate/pkg/secret"
 )
@@ -50,10 +52,76 @@ func GetHandler(w http.ResponseWriter, r *http.Request) {
 			ID:      user.OTPInfo.ID,
 			Enabled: user.OTPInfo.Enabled,
 		},
+		Profile:    toProfile(&user.Profile),
+		Attributes: user.Attributes,
+	}
+	if res.Attributes == nil {
+		res.Attributes = map[string]string{}
 	}
 	jwthttp.ResponseWrite(w, "GetHandler", res)
 }
 
+// UpdateHandler ...
+func UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectName := vars["projectName"]
+	userID := vars["userID"]
+
+	// Authorize API Request
+	claims, err := jwthttp.ValidateAPIToken(r)
+	if err != nil || claims.Subject != userID {
+		errors.PrintAsInfo(errors.Append(err, "Failed to authorize header"))
+		errors.WriteToHTTP(w, errors.ErrUnpermitted, 0, "")
+		return
+	}
+
+	var req UpdateRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		err = errors.Append(errors.ErrInvalidRequest, "Failed to decode user update request: %v", e)
+		errors.PrintAsInfo(err)
+		errors.WriteToHTTP(w, err, 0, "")
+		return
+	}
+
+	user, err := db.GetInst().UserGet(projectName, userID)
+	if err != nil {
+		if errors.Contains(err, model.ErrNoSuchUser) || errors.Contains(err, model.ErrUserValidateFailed) {
+			errors.PrintAsInfo(errors.Append(err, "User %s is not found", userID))
+			errors.WriteToHTTP(w, err, http.StatusNotFound, "")
+		} else {
+			errors.Print(errors.Append(err, "Failed to get user"))
+			errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	if req.Profile != nil {
+		prev := user.Profile
+		user.Profile = fromProfile(req.Profile)
+
+		// the user can not verify own email and phone number
+		user.Profile.EmailVerified = prev.EmailVerified && prev.Email == user.Profile.Email
+		user.Profile.PhoneNumberVerified = prev.PhoneNumberVerified && prev.PhoneNumber == user.Profile.PhoneNumber
+	}
+	if req.Attributes != nil {
+		user.Attributes = req.Attributes
+	}
+
+	if err = db.GetInst().UserUpdate(projectName, user); err != nil {
+		if errors.Contains(err, model.ErrUserValidateFailed) {
+			errors.PrintAsInfo(errors.Append(err, "Invalid user request format"))
+			errors.WriteToHTTP(w, err, http.StatusBadRequest, "")
+		} else {
+			errors.Print(errors.Append(err, "Failed to update user"))
+			errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("UpdateHandler method successfully finished")
+}
+
 // ChangePasswordHandler ...
 func ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -116,6 +184,14 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// get the sessions before logout to notify the clients
+	sessions, err := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: userID})
+	if err != nil {
+		errors.Print(errors.Append(err, "Failed to get sessions of user"))
+		errors.WriteToHTTP(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
 	if err = db.GetInst().UserLogout(projectName, userID); err != nil {
 		if errors.Contains(err, model.ErrUserValidateFailed) {
 			logger.Info("User ID %s is invalid", userID)
@@ -127,6 +203,11 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	issuer := token.GetExpectIssuer(r) + "/authapi/v1/project/" + projectName
+	if err := oidc.NotifyBackchannelLogout(projectName, issuer, sessions); err != nil {
+		errors.Print(errors.Append(err, "Failed to notify back-channel logout"))
+	}
+
 	w.WriteHeader(http.StatusOK)
 	logger.Info("UserLogoutHandler method successfully finished")
 }
@@ -251,3 +332,47 @@ func OTPDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	logger.Info("OTPDeleteHandler method successfully finished")
 }
+
+func toProfile(p *model.UserProfile) Profile {
+	return Profile{
+		Name:                p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		Profile:             p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             Address(p.Address),
+	}
+}
+
+func fromProfile(p *Profile) model.UserProfile {
+	return model.UserProfile{
+		FullName:            p.Name,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.Profile,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             model.Address(p.Address),
+	}
+}
@@ -6,12 +6,53 @@ type OTPInfo struct {
 	Enabled bool   `json:"enabled"`
 }
 
+// Address ...
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"street_address,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+}
+
+// Profile is a set of the standard claims of the user
+type Profile struct {
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+	MiddleName string `json:"middle_name,omitempty"`
+	Nickname   string `json:"nickname,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+	Picture    string `json:"picture,omitempty"`
+	Website    string `json:"website,omitempty"`
+	Gender     string `json:"gender,omitempty"`
+	Birthdate  string `json:"birthdate,omitempty"`
+	Zoneinfo   string `json:"zoneinfo,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	Email      string `json:"email,omitempty"`
+	// EmailVerified and PhoneNumberVerified are only changed by the administrator
+	EmailVerified       bool    `json:"email_verified"`
+	PhoneNumber         string  `json:"phone_number,omitempty"`
+	PhoneNumberVerified bool    `json:"phone_number_verified"`
+	Address             Address `json:"address"`
+}
+
 // GetResponse ...
 type GetResponse struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	CreatedAt string  `json:"created_at"`
-	OPTInfo   OTPInfo `json:"otp_info"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	CreatedAt  string            `json:"created_at"`
+	OPTInfo    OTPInfo           `json:"otp_info"`
+	Profile    Profile           `json:"profile"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// UpdateRequest ...
+type UpdateRequest struct {
+	// Profile and Attributes are optional, the current values are kept if nil
+	Profile    *Profile          `json:"profile,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // ChangePasswordRequest ...
@@ -7,6 +7,7 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/audit/model"
 	"github.com/sh-miyoshi/hekate/pkg/audit/mongo"
 	"github.com/sh-miyoshi/hekate/pkg/audit/none"
+	"github.com/sh-miyoshi/hekate/pkg/audit/sql"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 	"github.com/sh-miyoshi/hekate/pkg/util"
@@ -41,6 +42,16 @@ func Init(dbType string, connStr string) *errors.Error {
 		inst = &Manager{
 			handler: mongo.NewHandler(dbClient),
 		}
+	case sql.TypePostgres, sql.TypeSQLite:
+		logger.Info("Initialize AuditManager with %s DB", dbType)
+		handler, err := sql.NewHandler(dbType, connStr)
+		if err != nil {
+			return errors.Append(err, "Failed to create sql handler")
+		}
+
+		inst = &Manager{
+			handler: handler,
+		}
 	case "none":
 		logger.Info("Initialize AuditManager with none DB")
 		inst = &Manager{
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"sync"
 	"time"
 
 	"github.com/sh-miyoshi/hekate/pkg/audit/model"
@@ -9,6 +10,8 @@ import (
 
 // Handler ...
 type Handler struct {
+	// mu protects data from the concurrent requests
+	mu   sync.Mutex
 	data []model.Audit
 }
 
@@ -24,6 +27,9 @@ func (h *Handler) Ping() *errors.Error {
 
 // Save ...
 func (h *Handler) Save(projectName string, tm time.Time, resType, method, path, message string) *errors.Error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	h.data = append(h.data, model.Audit{
 		ProjectName:  projectName,
 		Time:         tm,
@@ -39,6 +45,9 @@ func (h *Handler) Save(projectName string, tm time.Time, resType, method, path,
 
 // Get ...
 func (h *Handler) Get(projectName string, fromDate, toDate time.Time, offset uint) ([]model.Audit, *errors.Error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	res := []model.Audit{}
 
 	// if we want to get logs whose date are from "2019-09-19",
@@ -0,0 +1,146 @@
+package sql
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	// register database drivers
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sh-miyoshi/hekate/pkg/audit/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+const (
+	// TypePostgres is a database type of PostgreSQL
+	TypePostgres = "postgres"
+	// TypeSQLite is a database type of SQLite
+	TypeSQLite = "sqlite"
+)
+
+// Handler ...
+type Handler struct {
+	db     *sql.DB
+	dbType string
+}
+
+// NewHandler opens the database and creates the table for audit events
+func NewHandler(dbType string, connStr string) (*Handler, *errors.Error) {
+	var driver string
+	switch dbType {
+	case TypePostgres:
+		driver = "postgres"
+	case TypeSQLite:
+		driver = "sqlite3"
+	default:
+		return nil, errors.New("DB failed", "SQL database type %s is not supported", dbType)
+	}
+
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to open %s: %v", dbType, err)
+	}
+	if dbType == TypeSQLite {
+		// SQLite allows only one writer, and in-memory database is per connection
+		db.SetMaxOpenConns(1)
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS audit_events (
+			project_name TEXT NOT NULL,
+			time TIMESTAMP NOT NULL,
+			unixtime BIGINT NOT NULL,
+			resource_type TEXT NOT NULL,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			is_success BOOLEAN NOT NULL,
+			message TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_events_time_idx ON audit_events (project_name, unixtime)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, errors.New("DB failed", "Failed to create audit table: %v", err)
+		}
+	}
+
+	return &Handler{
+		db:     db,
+		dbType: dbType,
+	}, nil
+}
+
+// Ping ...
+func (h *Handler) Ping() *errors.Error {
+	if err := h.db.Ping(); err != nil {
+		return errors.New("DB failed", "DB Ping failed: %v", err)
+	}
+	return nil
+}
+
+// Save ...
+func (h *Handler) Save(projectName string, tm time.Time, resType, method, path, message string) *errors.Error {
+	q := `INSERT INTO audit_events (project_name, time, unixtime, resource_type, method, path, is_success, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := h.db.Exec(h.rebind(q), projectName, tm.UTC(), tm.Unix(), resType, method, path, message == "", message)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert audit to database: %v", err)
+	}
+
+	return nil
+}
+
+// Get ...
+func (h *Handler) Get(projectName string, fromDate, toDate time.Time, offset uint) ([]model.Audit, *errors.Error) {
+	// if we want to get logs whose date are from "2019-09-19",
+	// we have to pass "2019-09-20 00:00:00.000" to database.
+	toDate = toDate.AddDate(0, 0, 1)
+	toDate = toDate.Add(-time.Nanosecond)
+
+	q := `SELECT time, resource_type, method, path, is_success, message FROM audit_events
+		WHERE project_name = ? AND unixtime >= ? AND unixtime < ?
+		ORDER BY unixtime LIMIT ? OFFSET ?`
+	rows, err := h.db.Query(h.rebind(q), projectName, fromDate.Unix(), toDate.Unix(), model.AuditGetMaxNum, offset*model.AuditGetMaxNum)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get audit list from database: %v", err)
+	}
+	defer rows.Close()
+
+	res := []model.Audit{}
+	for rows.Next() {
+		a := model.Audit{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&a.Time, &a.ResourceType, &a.Method, &a.Path, &a.IsSuccess, &a.Message); err != nil {
+			return nil, errors.New("DB failed", "Failed to parse audit list from database: %v", err)
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("DB failed", "Failed to get audit list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// rebind converts the placeholder "?" to the format of the database
+func (h *Handler) rebind(query string) string {
+	if h.dbType != TypePostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
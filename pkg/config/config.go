@@ -41,6 +41,14 @@ func (c *GlobalConfig) Validate() *errors.Error {
 		return errors.New("Invalid config", "interval of db gc is 0")
 	}
 
+	if c.HTTPSConfig.ClientCertRequest && !c.HTTPSConfig.Enabled {
+		return errors.New("Invalid config", "client certificate can be requested only in https")
+	}
+
+	if c.DB.Snapshot.File != "" && c.DB.Type != "memory" {
+		return errors.New("Invalid config", "snapshot is supported only in memory db, but db type is %s", c.DB.Type)
+	}
+
 	finfo, err := os.Stat(c.UserLoginResourceDir)
 	if err != nil {
 		return errors.New("Invalid config", "Failed to get login resource info: %v", err)
@@ -58,6 +66,7 @@ func (c *GlobalConfig) setLoginResource() *errors.Error {
 	// ├── consent.html    : consent page
 	// ├── otp_verify.html : OTP verify page
 	// ├── index.html      : login page
+	// ├── logout.html     : logout confirmation page (option)
 	// └── static          : directory of static assets
 
 	dir := c.UserLoginResourceDir
@@ -82,6 +91,11 @@ func (c *GlobalConfig) setLoginResource() *errors.Error {
 	if _, err := os.Stat(c.LoginResource.DeviceLoginCompletePage); err != nil {
 		return errors.New(pubMsg, "Failed to get device login complete page: %v", err)
 	}
+	// logout confirmation page is option
+	c.LoginResource.LogoutPage = path.Join(dir, "/logout.html")
+	if _, err := os.Stat(c.LoginResource.LogoutPage); err != nil {
+		c.LoginResource.LogoutPage = ""
+	}
 	// static directory is option, so does not require check
 
 	return nil
@@ -121,6 +135,10 @@ func InitConfig(osArgs []string) *errors.Error {
 	}
 	setEnvVar("HEKATE_DB_TYPE", &inst.DB.Type)
 	setEnvVar("HEKATE_DB_CONNECT_STRING", &inst.DB.ConnectionString)
+	setEnvVar("HEKATE_DB_SNAPSHOT_FILE", &inst.DB.Snapshot.File)
+	if err := setEnvUint("HEKATE_DB_SNAPSHOT_INTERVAL", &inst.DB.Snapshot.Interval); err != nil {
+		return errors.New("Invalid os env", "Failed to get db snapshot interval: %v", err)
+	}
 	setEnvVar("HEKATE_AUDIT_DB_TYPE", &inst.AuditDB.Type)
 	setEnvVar("HEKATE_AUDIT_DB_CONNECT_STRING", &inst.AuditDB.ConnectionString)
 	setEnvVar("HEKATE_LOGIN_PAGE_RES", &inst.UserLoginResourceDir)
@@ -134,6 +152,8 @@ func InitConfig(osArgs []string) *errors.Error {
 	if err := setEnvUint("HEKATE_DBGC_INTERVAL", &inst.DBGCInterval); err != nil {
 		return errors.New("Invalid os env", "Failed to get db gc interval: %v", err)
 	}
+	setEnvVar("HEKATE_KEY_PROVIDER_TYPE", &inst.KeyProvider.Type)
+	setEnvVar("HEKATE_MASTER_KEY_FILE", &inst.KeyProvider.MasterKeyFile)
 
 	// Set by command line args
 
@@ -148,16 +168,23 @@ func InitConfig(osArgs []string) *errors.Error {
 	flag.BoolVar(&inst.HTTPSConfig.Enabled, "https", inst.HTTPSConfig.Enabled, "start server with https")
 	flag.StringVar(&inst.HTTPSConfig.CertFile, "https-cert-file", inst.HTTPSConfig.CertFile, "cert file path of https")
 	flag.StringVar(&inst.HTTPSConfig.KeyFile, "https-key-file", inst.HTTPSConfig.KeyFile, "key file path of https")
+	flag.BoolVar(&inst.HTTPSConfig.ClientCertRequest, "https-client-cert-request", inst.HTTPSConfig.ClientCertRequest, "request client certificate for mutual-TLS client authentication")
+	flag.StringVar(&inst.HTTPSConfig.ClientCAFile, "https-client-ca-file", inst.HTTPSConfig.ClientCAFile, "CA file path to verify client certificate, it is required to use tls_client_auth")
 	flag.StringVar(&inst.LogFile, "logfile", inst.LogFile, "file path for log, output to STDOUT if empty")
 	flag.BoolVar(&inst.ModeDebug, "debug", inst.ModeDebug, "output debug log")
 	flag.StringVar(&inst.DB.Type, "db-type", inst.DB.Type, "type of database")
 	flag.StringVar(&inst.DB.ConnectionString, "db-conn-str", inst.DB.ConnectionString, "database connection string")
+	flag.StringVar(&inst.DB.Snapshot.File, "db-snapshot-file", inst.DB.Snapshot.File, "file path of snapshot for memory database")
+	flag.Uint64Var(&inst.DB.Snapshot.Interval, "db-snapshot-interval", inst.DB.Snapshot.Interval, "interval time of saving snapshot [sec], saved only on shutdown if 0")
+	flag.BoolVar(&inst.DB.Snapshot.Encrypt, "db-snapshot-encrypt", inst.DB.Snapshot.Encrypt, "encrypt snapshot by the master key")
 	flag.StringVar(&inst.AuditDB.Type, "audit-db-type", inst.AuditDB.Type, "type of audit events database")
 	flag.StringVar(&inst.AuditDB.ConnectionString, "audit-db-conn-str", inst.AuditDB.ConnectionString, "audit database connection string")
 	flag.Uint64Var(&inst.LoginSessionExpiresIn, "login-session-expires", inst.LoginSessionExpiresIn, "expires time of login session [sec]")
 	flag.Uint64Var(&inst.SSOExpiresIn, "sso-expires", inst.SSOExpiresIn, "expires time of single sign on [sec]")
 	flag.StringVar(&inst.UserLoginResourceDir, "login-res", inst.UserLoginResourceDir, "directory path for user login")
 	flag.Uint64Var(&inst.DBGCInterval, "dbgc-interval", inst.DBGCInterval, "interval time of garbage collector for expired sessions [sec]")
+	flag.StringVar(&inst.KeyProvider.Type, "key-provider", inst.KeyProvider.Type, "type of key provider to protect private keys of project")
+	flag.StringVar(&inst.KeyProvider.MasterKeyFile, "master-key-file", inst.KeyProvider.MasterKeyFile, "file path of master key for local key provider")
 	flag.Parse()
 
 	// Set supported type
@@ -171,7 +198,7 @@ func InitConfig(osArgs []string) *errors.Error {
 		"code id_token token",
 		// TODO(support type "none")
 	}
-	inst.SupportedScope = []string{"openid"}
+	inst.SupportedScope = []string{"openid", "profile", "email", "phone", "address"}
 	inst.LoginStaticResourceURL = "/resource/login"
 
 	// Validate config
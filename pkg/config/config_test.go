@@ -185,6 +185,20 @@ func TestCheckLoginResDirStruct(t *testing.T) {
 	if err := c.setLoginResource(); err != nil {
 		t.Errorf("CheckLoginResDirStruct returns error %v, but expect is nil", err)
 	}
+	if c.LoginResource.LogoutPage != "" {
+		t.Errorf("Logout page is %s, but expect is empty", c.LoginResource.LogoutPage)
+	}
+
+	// Test ok with optional logout page
+	logoutFile := filepath.Join(dir, "logout.html")
+	ioutil.WriteFile(logoutFile, data, 0644)
+	if err := c.setLoginResource(); err != nil {
+		t.Errorf("CheckLoginResDirStruct returns error %v, but expect is nil", err)
+	}
+	if c.LoginResource.LogoutPage != logoutFile {
+		t.Errorf("Logout page is %s, but expect is %s", c.LoginResource.LogoutPage, logoutFile)
+	}
+	os.Remove(logoutFile)
 	os.Remove(consentFile)
 	os.Remove(otpVerifyFile)
 	os.Remove(indexFile)
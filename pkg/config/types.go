@@ -1,9 +1,17 @@
 package config
 
+// SnapshotConfig ...
+type SnapshotConfig struct {
+	File     string `yaml:"file"`
+	Interval uint64 `yaml:"interval"`
+	Encrypt  bool   `yaml:"encrypt"`
+}
+
 // DBInfo ...
 type DBInfo struct {
-	Type             string `yaml:"type"`
-	ConnectionString string `yaml:"connection_string"`
+	Type             string         `yaml:"type"`
+	ConnectionString string         `yaml:"connection_string"`
+	Snapshot         SnapshotConfig `yaml:"snapshot"`
 }
 
 // HTTPSConfig ...
@@ -11,6 +19,18 @@ type HTTPSConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	CertFile string `yaml:"cert-file"`
 	KeyFile  string `yaml:"key-file"`
+	// ClientCertRequest requests the client certificate for mutual-TLS client authentication
+	// the certificate is not required, and it is verified when the client is authenticated
+	ClientCertRequest bool `yaml:"client-cert-request"`
+	// ClientCAFile is a file of CA certificates to verify the certificate in tls_client_auth
+	// the system certificate pool is used if empty
+	ClientCAFile string `yaml:"client-ca-file"`
+}
+
+// KeyProviderConfig ...
+type KeyProviderConfig struct {
+	Type          string `yaml:"type"`
+	MasterKeyFile string `yaml:"master_key_file"`
 }
 
 // LoginResource ...
@@ -20,23 +40,26 @@ type LoginResource struct {
 	ConsentPage             string
 	DeviceLoginPage         string
 	DeviceLoginCompletePage string
+	// LogoutPage is a confirmation page of the RP-initiated logout, the confirmation is skipped if empty
+	LogoutPage string
 }
 
 // GlobalConfig ...
 type GlobalConfig struct {
-	AdminName             string      `yaml:"admin_name"`
-	AdminPassword         string      `yaml:"admin_password"`
-	Port                  int         `yaml:"server_port"`
-	BindAddr              string      `yaml:"server_bind_address"`
-	HTTPSConfig           HTTPSConfig `yaml:"https"`
-	LogFile               string      `yaml:"logfile"`
-	ModeDebug             bool        `yaml:"debug_mode"`
-	DB                    DBInfo      `yaml:"db"`
-	AuditDB               DBInfo      `yaml:"audit_db"`
-	LoginSessionExpiresIn uint64      `yaml:"login_session_expires_in"`
-	SSOExpiresIn          uint64      `yaml:"sso_expires_in"`
-	UserLoginResourceDir  string      `yaml:"user_login_page_res"`
-	DBGCInterval          uint64      `yaml:"dbgc_interval"`
+	AdminName             string            `yaml:"admin_name"`
+	AdminPassword         string            `yaml:"admin_password"`
+	Port                  int               `yaml:"server_port"`
+	BindAddr              string            `yaml:"server_bind_address"`
+	HTTPSConfig           HTTPSConfig       `yaml:"https"`
+	LogFile               string            `yaml:"logfile"`
+	ModeDebug             bool              `yaml:"debug_mode"`
+	DB                    DBInfo            `yaml:"db"`
+	AuditDB               DBInfo            `yaml:"audit_db"`
+	LoginSessionExpiresIn uint64            `yaml:"login_session_expires_in"`
+	SSOExpiresIn          uint64            `yaml:"sso_expires_in"`
+	UserLoginResourceDir  string            `yaml:"user_login_page_res"`
+	DBGCInterval          uint64            `yaml:"dbgc_interval"`
+	KeyProvider           KeyProviderConfig `yaml:"key_provider"`
 
 	SupportedResponseType  []string
 	SupportedScope         []string
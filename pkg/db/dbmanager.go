@@ -5,14 +5,16 @@ import (
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/config"
 	"github.com/sh-miyoshi/hekate/pkg/db/memory"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/db/mongo"
+	"github.com/sh-miyoshi/hekate/pkg/db/sql"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
 	"github.com/sh-miyoshi/hekate/pkg/role"
 	"github.com/sh-miyoshi/hekate/pkg/secret"
-	"github.com/sh-miyoshi/hekate/pkg/util"
 )
 
 // Manager ...
@@ -26,6 +28,17 @@ type Manager struct {
 	transaction  model.TransactionManager
 	ping         model.PingHandler
 	device       model.DeviceHandler
+	revokedToken model.RevokedTokenHandler
+	migration    model.MigrationHandler
+	pushedAuth   model.PushedAuthRequestHandler
+	usedJTI      model.UsedJTIHandler
+
+	// bindTx returns the manager whose handlers execute the statements in the transaction
+	// it is nil if the handlers join the transaction by themselves and can read in it as usual
+	bindTx func(tx model.Tx) *Manager
+
+	// snapshot is set only when the snapshot of memory database is enabled
+	snapshot *memory.Snapshot
 
 	portalAddr string
 }
@@ -41,16 +54,14 @@ func InitDBManager(dbType string, connStr string) *errors.Error {
 	switch dbType {
 	case "memory":
 		logger.Info("Initialize with local memory DB")
-		inst = &Manager{
-			project:      memory.NewProjectHandler(),
-			user:         memory.NewUserHandler(),
-			session:      memory.NewSessionHandler(),
-			client:       memory.NewClientHandler(),
-			customRole:   memory.NewCustomRoleHandler(),
-			loginSession: memory.NewLoginSessionHandler(),
-			transaction:  memory.NewTransactionManager(),
-			ping:         memory.NewPingHandler(),
-			device:       memory.NewDeviceHandler(),
+		store := memory.NewStore()
+		inst = newMemoryManager(store)
+		if snapshotFile != "" {
+			inst.snapshot = memory.NewSnapshot(store, snapshotFile, snapshotKey)
+			if err := inst.snapshot.Restore(); err != nil {
+				inst = nil
+				return errors.Append(err, "Failed to restore snapshot")
+			}
 		}
 	case "mongo":
 		logger.Info("Initialize with mongo DB")
@@ -87,6 +98,18 @@ func InitDBManager(dbType string, connStr string) *errors.Error {
 		if err != nil {
 			return errors.Append(err, "Failed to create device handler")
 		}
+		revokedTokenHandler, err := mongo.NewRevokedTokenHandler(dbClient)
+		if err != nil {
+			return errors.Append(err, "Failed to create revoked token handler")
+		}
+		pushedAuthHandler, err := mongo.NewPushedAuthRequestHandler(dbClient)
+		if err != nil {
+			return errors.Append(err, "Failed to create pushed authorization request handler")
+		}
+		usedJTIHandler, err := mongo.NewUsedJTIHandler(dbClient)
+		if err != nil {
+			return errors.Append(err, "Failed to create used jti handler")
+		}
 
 		inst = &Manager{
 			project:      prjHandler,
@@ -98,6 +121,21 @@ func InitDBManager(dbType string, connStr string) *errors.Error {
 			transaction:  mongo.NewTransactionManager(dbClient),
 			ping:         mongo.NewPingHandler(dbClient),
 			device:       deviceHandler,
+			revokedToken: revokedTokenHandler,
+			migration:    mongo.NewMigrationHandler(dbClient),
+			pushedAuth:   pushedAuthHandler,
+			usedJTI:      usedJTIHandler,
+		}
+	case sql.TypePostgres, sql.TypeSQLite:
+		logger.Info("Initialize with %s DB", dbType)
+		dbClient, err := sql.NewClient(dbType, connStr)
+		if err != nil {
+			return errors.Append(err, "Failed to create db client")
+		}
+
+		inst = newSQLManager(dbClient)
+		inst.bindTx = func(tx model.Tx) *Manager {
+			return newSQLManager(dbClient.WithTx(tx))
 		}
 	default:
 		return errors.New("Internal server error", "Database Type %s is not implemented yet", dbType)
@@ -114,6 +152,80 @@ func InitDBManager(dbType string, connStr string) *errors.Error {
 	return nil
 }
 
+func newMemoryManager(store *memory.Store) *Manager {
+	project := memory.NewProjectHandler(store)
+	user := memory.NewUserHandler(store)
+	session := memory.NewSessionHandler(store)
+	client := memory.NewClientHandler(store)
+	customRole := memory.NewCustomRoleHandler(store)
+	loginSession := memory.NewLoginSessionHandler(store)
+	device := memory.NewDeviceHandler(store)
+	revokedToken := memory.NewRevokedTokenHandler(store)
+	migration := memory.NewMigrationHandler(store)
+	pushedAuth := memory.NewPushedAuthRequestHandler(store)
+	usedJTI := memory.NewUsedJTIHandler(store)
+
+	res := &Manager{
+		project:      project,
+		user:         user,
+		session:      session,
+		client:       client,
+		customRole:   customRole,
+		loginSession: loginSession,
+		transaction:  memory.NewTransactionManager(store),
+		ping:         memory.NewPingHandler(),
+		device:       device,
+		revokedToken: revokedToken,
+		migration:    migration,
+		pushedAuth:   pushedAuth,
+		usedJTI:      usedJTI,
+	}
+	// the reads outside the transaction wait for the running transaction,
+	// so the handlers in the transaction must be bound to it
+	res.bindTx = func(tx model.Tx) *Manager {
+		m := *res
+		m.project = project.InTx()
+		m.user = user.InTx()
+		m.session = session.InTx()
+		m.client = client.InTx()
+		m.customRole = customRole.InTx()
+		m.loginSession = loginSession.InTx()
+		m.device = device.InTx()
+		m.revokedToken = revokedToken.InTx()
+		m.migration = migration.InTx()
+		m.pushedAuth = pushedAuth.InTx()
+		m.usedJTI = usedJTI.InTx()
+		return &m
+	}
+	return res
+}
+
+func newSQLManager(dbClient *sql.Client) *Manager {
+	return &Manager{
+		project:      sql.NewProjectHandler(dbClient),
+		user:         sql.NewUserHandler(dbClient),
+		session:      sql.NewSessionHandler(dbClient),
+		client:       sql.NewClientHandler(dbClient),
+		customRole:   sql.NewCustomRoleHandler(dbClient),
+		loginSession: sql.NewLoginSessionHandler(dbClient),
+		transaction:  sql.NewTransactionManager(dbClient),
+		ping:         sql.NewPingHandler(dbClient),
+		device:       sql.NewDeviceHandler(dbClient),
+		revokedToken: sql.NewRevokedTokenHandler(dbClient),
+		migration:    sql.NewMigrationHandler(dbClient),
+		pushedAuth:   sql.NewPushedAuthRequestHandler(dbClient),
+		usedJTI:      sql.NewUsedJTIHandler(dbClient),
+	}
+}
+
+// inTx returns the manager to access the database in the transaction tx
+func (m *Manager) inTx(tx model.Tx) *Manager {
+	if m.bindTx == nil {
+		return m
+	}
+	return m.bindTx(tx)
+}
+
 // GetInst returns an instance of DB Manager
 func GetInst() *Manager {
 	return inst
@@ -130,15 +242,14 @@ func (m *Manager) ProjectAdd(ent *model.ProjectInfo) *errors.Error {
 		return errors.Append(err, "Validate failed")
 	}
 
-	keys, err := secret.GetSignKey(ent.TokenConfig.SigningAlgorithm)
-	if err != nil {
-		return err
+	ent.TokenConfig.SignKeys = []*model.SignKey{}
+	if err := rotateSignKeys(ent.TokenConfig, time.Now()); err != nil {
+		return errors.Append(err, "Failed to generate sign keys")
 	}
-	ent.TokenConfig.SignSecretKey = keys.Private
-	ent.TokenConfig.SignPublicKey = keys.Public
 
-	return m.transaction.Transaction(func() *errors.Error {
-		prjs, err := m.project.GetList(&model.ProjectFilter{Name: ent.Name})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: ent.Name})
 		if err != nil {
 			return errors.Append(err, "Failed to get current project list")
 		}
@@ -147,7 +258,7 @@ func (m *Manager) ProjectAdd(ent *model.ProjectInfo) *errors.Error {
 			return model.ErrProjectAlreadyExists
 		}
 
-		if err := m.project.Add(ent); err != nil {
+		if err := h.project.Add(ent); err != nil {
 			return errors.Append(err, "Failed to add project")
 		}
 
@@ -163,7 +274,7 @@ func (m *Manager) ProjectAdd(ent *model.ProjectInfo) *errors.Error {
 			CreatedAt:           ent.CreatedAt,
 			AllowedCallbackURLs: callbacks,
 		}
-		if err := m.client.Add(ent.Name, clientEnt); err != nil {
+		if err := h.client.Add(ent.Name, clientEnt); err != nil {
 			return errors.Append(err, "Failed to add client for portal login")
 		}
 
@@ -177,8 +288,9 @@ func (m *Manager) ProjectDelete(name string) *errors.Error {
 		return errors.Append(model.ErrProjectValidateFailed, "Invalid project name format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		prjs, err := m.project.GetList(&model.ProjectFilter{Name: name})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: name})
 		if err != nil {
 			return errors.Append(err, "Failed to get delete project info")
 		}
@@ -192,31 +304,43 @@ func (m *Manager) ProjectDelete(name string) *errors.Error {
 			return errors.Append(model.ErrDeleteBlockedProject, "the project can not delete")
 		}
 
-		if err := m.loginSession.DeleteAll(name); err != nil {
+		if err := h.loginSession.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete login session data")
 		}
 
-		if err := m.session.DeleteAll(name); err != nil {
+		if err := h.session.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete session data")
 		}
 
-		if err := m.customRole.DeleteAll(name); err != nil {
+		if err := h.customRole.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete custom role data")
 		}
 
-		if err := m.client.DeleteAll(name); err != nil {
+		if err := h.client.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete client data")
 		}
 
-		if err := m.user.DeleteAll(name); err != nil {
+		if err := h.user.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete user data")
 		}
 
-		if err := m.device.DeleteAll(name); err != nil {
+		if err := h.device.DeleteAll(name); err != nil {
 			return errors.Append(err, "Failed to delete device data")
 		}
 
-		if err := m.project.Delete(name); err != nil {
+		if err := h.revokedToken.DeleteAll(name); err != nil {
+			return errors.Append(err, "Failed to delete revoked token data")
+		}
+
+		if err := h.pushedAuth.DeleteAll(name); err != nil {
+			return errors.Append(err, "Failed to delete pushed authorization request data")
+		}
+
+		if err := h.usedJTI.DeleteAll(name); err != nil {
+			return errors.Append(err, "Failed to delete used jti data")
+		}
+
+		if err := h.project.Delete(name); err != nil {
 			return errors.Append(err, "Failed to delete project")
 		}
 
@@ -254,8 +378,30 @@ func (m *Manager) ProjectUpdate(ent *model.ProjectInfo) *errors.Error {
 		return errors.Append(err, "Failed to validate")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.project.Update(ent); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: ent.Name})
+		if err != nil {
+			return errors.Append(err, "Failed to get current project")
+		}
+		if len(prjs) == 0 {
+			return model.ErrNoSuchProject
+		}
+
+		// Sign keys are managed by the server, so use the current keys
+		// to avoid overwriting the keys rotated after ent was read
+		ent.TokenConfig.SignKeys = prjs[0].TokenConfig.SignKeys
+
+		// Rotate sign keys if signing algorithm is changed
+		// Tokens signed by old key can be verified until the key expires
+		active := ent.TokenConfig.ActiveSignKey()
+		if active == nil || active.SigningAlgorithm != ent.TokenConfig.SigningAlgorithm {
+			if err := rotateSignKeys(ent.TokenConfig, time.Now()); err != nil {
+				return errors.Append(err, "Failed to rotate sign keys")
+			}
+		}
+
+		if err := h.project.Update(ent); err != nil {
 			return errors.Append(err, "Failed to update project")
 		}
 		return nil
@@ -264,23 +410,180 @@ func (m *Manager) ProjectUpdate(ent *model.ProjectInfo) *errors.Error {
 
 // ProjectSecretReset ...
 func (m *Manager) ProjectSecretReset(name string) *errors.Error {
-	prj, err := m.ProjectGet(name)
-	if err != nil {
-		return err
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: name})
+		if err != nil {
+			return errors.Append(err, "Failed to get current project")
+		}
+		if len(prjs) == 0 {
+			return model.ErrNoSuchProject
+		}
+
+		prj := prjs[0]
+		if err := rotateSignKeys(prj.TokenConfig, time.Now()); err != nil {
+			return errors.Append(err, "Failed to rotate sign keys")
+		}
+		if err := h.project.Update(prj); err != nil {
+			return errors.Append(err, "Failed to update project")
+		}
+		return nil
+	})
+}
+
+// ProjectRotateSignKeys rotates the sign keys of all projects in which rotation interval has passed,
+// and removes expired retired keys
+func (m *Manager) ProjectRotateSignKeys() *errors.Error {
+	now := time.Now()
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(nil)
+		if err != nil {
+			return errors.Append(err, "Failed to get project list")
+		}
+
+		for _, prj := range prjs {
+			tc := prj.TokenConfig
+			updated := false
+
+			active := tc.ActiveSignKey()
+			if active == nil || (tc.KeyRotationInterval > 0 && now.After(active.ActivatedAt.Add(time.Duration(tc.KeyRotationInterval)*time.Second))) {
+				logger.Info("Rotate sign keys in project %s", prj.Name)
+				if err := rotateSignKeys(tc, now); err != nil {
+					return errors.Append(err, "Failed to rotate sign keys")
+				}
+				updated = true
+			} else {
+				keys := tc.PublishedSignKeys(now)
+				if len(keys) != len(tc.SignKeys) {
+					tc.SignKeys = keys
+					updated = true
+				}
+			}
+
+			if updated {
+				if err := h.project.Update(prj); err != nil {
+					return errors.Append(err, "Failed to update project")
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ProjectProtectSignKeys protects the raw private keys which were created before enabling the key provider
+// it returns an error if the key is protected by the other key provider, e.g. the provider was changed from local to none
+func (m *Manager) ProjectProtectSignKeys() *errors.Error {
+	provider := secret.GetKeyProvider()
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(nil)
+		if err != nil {
+			return errors.Append(err, "Failed to get project list")
+		}
+
+		for _, prj := range prjs {
+			updated := false
+			for _, k := range prj.TokenConfig.SignKeys {
+				if err := provider.Validate(k.PrivateKey); err != nil {
+					return errors.Append(err, "Failed to use private key %s in project %s", k.KeyID, prj.Name)
+				}
+				if provider.IsProtected(k.PrivateKey) {
+					continue
+				}
+				key, err := provider.Protect(k.SigningAlgorithm, k.PrivateKey)
+				if err != nil {
+					return errors.Append(err, "Failed to protect private key %s", k.KeyID)
+				}
+				k.PrivateKey = key
+				updated = true
+			}
+
+			if updated {
+				logger.Info("Protect raw private keys in project %s", prj.Name)
+				if err := h.project.Update(prj); err != nil {
+					return errors.Append(err, "Failed to update project")
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// rotateSignKeys retires current active key, activates next key and prepares new next key
+func rotateSignKeys(tc *model.TokenConfig, now time.Time) *errors.Error {
+	// Retired key must verify tokens signed before rotation until they expire
+	// ID tokens used as id_token_hint and SSO session tokens can live longer than access and refresh tokens
+	lifeSpan := tc.AccessTokenLifeSpan
+	if tc.RefreshTokenLifeSpan > lifeSpan {
+		lifeSpan = tc.RefreshTokenLifeSpan
+	}
+	if sso := uint(config.Get().SSOExpiresIn); sso > lifeSpan {
+		lifeSpan = sso
 	}
 
-	keys, err := secret.GetSignKey(prj.TokenConfig.SigningAlgorithm)
+	var next *model.SignKey
+	keys := []*model.SignKey{}
+	for _, k := range tc.PublishedSignKeys(now) {
+		switch k.State {
+		case model.SignKeyStateActive:
+			k.State = model.SignKeyStateRetired
+			k.ExpiresAt = now.Add(time.Duration(lifeSpan) * time.Second)
+		case model.SignKeyStateNext:
+			if k.SigningAlgorithm != tc.SigningAlgorithm {
+				// no token is signed by this key, so remove it
+				continue
+			}
+			next = k
+		}
+		keys = append(keys, k)
+	}
+
+	if next == nil {
+		var err *errors.Error
+		next, err = newSignKey(tc.SigningAlgorithm, now)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, next)
+	}
+	next.State = model.SignKeyStateActive
+	next.ActivatedAt = now
+
+	// Prepare next key to be published before used
+	newNext, err := newSignKey(tc.SigningAlgorithm, now)
 	if err != nil {
 		return err
 	}
-	prj.TokenConfig.SignSecretKey = keys.Private
-	prj.TokenConfig.SignPublicKey = keys.Public
+	keys = append(keys, newNext)
 
-	return m.ProjectUpdate(prj)
+	tc.SignKeys = keys
+	return nil
 }
 
-// UserAdd ...
-func (m *Manager) UserAdd(projectName string, ent *model.UserInfo) *errors.Error {
+func newSignKey(alg string, now time.Time) (*model.SignKey, *errors.Error) {
+	keys, err := secret.GetSignKey(alg)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to generate sign key")
+	}
+	privateKey, err := secret.GetKeyProvider().Protect(alg, keys.Private)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to protect private key")
+	}
+	return &model.SignKey{
+		KeyID:            uuid.New().String(),
+		State:            model.SignKeyStateNext,
+		SigningAlgorithm: alg,
+		PublicKey:        keys.Public,
+		PrivateKey:       privateKey,
+		CreatedAt:        now,
+	}, nil
+}
+
+// UserAdd adds the user whose password hash is created from password by the project config
+func (m *Manager) UserAdd(projectName string, ent *model.UserInfo, password string) *errors.Error {
 	if err := ent.Validate(); err != nil {
 		return errors.Append(err, "Failed to validate entry")
 	}
@@ -300,9 +603,10 @@ func (m *Manager) UserAdd(projectName string, ent *model.UserInfo) *errors.Error
 		}
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
 		for _, r := range ent.CustomRoles {
-			roles, err := m.customRole.GetList(projectName, &model.CustomRoleFilter{ID: r})
+			roles, err := h.customRole.GetList(projectName, &model.CustomRoleFilter{ID: r})
 			if err != nil {
 				return errors.Append(err, "Custom role get error")
 			}
@@ -311,7 +615,7 @@ func (m *Manager) UserAdd(projectName string, ent *model.UserInfo) *errors.Error
 			}
 		}
 
-		users, err := m.user.GetList(projectName, &model.UserFilter{ID: ent.ID})
+		users, err := h.user.GetList(projectName, &model.UserFilter{ID: ent.ID})
 		if err != nil {
 			return errors.Append(err, "Failed to get user info")
 		}
@@ -320,7 +624,7 @@ func (m *Manager) UserAdd(projectName string, ent *model.UserInfo) *errors.Error
 		}
 
 		// Check duplicate user by name
-		users, err = m.user.GetList(ent.ProjectName, &model.UserFilter{Name: ent.Name})
+		users, err = h.user.GetList(ent.ProjectName, &model.UserFilter{Name: ent.Name})
 		if err != nil {
 			return errors.Append(err, "Failed to get user info by name")
 		}
@@ -328,7 +632,18 @@ func (m *Manager) UserAdd(projectName string, ent *model.UserInfo) *errors.Error
 			return model.ErrUserAlreadyExists
 		}
 
-		if err := m.user.Add(projectName, ent); err != nil {
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: projectName})
+		if err != nil {
+			return errors.Append(err, "Failed to get project associated with the user")
+		}
+		if len(prjs) == 0 {
+			return model.ErrNoSuchProject
+		}
+		if ent.PasswordHash, err = secret.HashPassword(password, prjs[0].PasswordHashConfig); err != nil {
+			return errors.Append(err, "Failed to create password hash")
+		}
+
+		if err := h.user.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add user")
 		}
 		return nil
@@ -341,20 +656,21 @@ func (m *Manager) UserDelete(projectName string, userID string) *errors.Error {
 		return errors.Append(model.ErrUserValidateFailed, "invalid user id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		if _, err := m.UserGet(projectName, userID); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if _, err := h.UserGet(projectName, userID); err != nil {
 			return errors.Append(err, "Failed to get user info")
 		}
 
-		if err := m.loginSession.Delete(projectName, &model.LoginSessionFilter{UserID: userID}); err != nil {
+		if err := h.loginSession.Delete(projectName, &model.LoginSessionFilter{UserID: userID}); err != nil {
 			return errors.Append(err, "Delete authoriation code failed")
 		}
 
-		if err := m.session.Delete(projectName, &model.SessionFilter{UserID: userID}); err != nil {
+		if err := h.session.Delete(projectName, &model.SessionFilter{UserID: userID}); err != nil {
 			return errors.Append(err, "Delete user session failed")
 		}
 
-		if err := m.user.Delete(projectName, userID); err != nil {
+		if err := h.user.Delete(projectName, userID); err != nil {
 			return errors.Append(err, "Failed to delete user")
 		}
 		return nil
@@ -409,9 +725,10 @@ func (m *Manager) UserUpdate(projectName string, ent *model.UserInfo) *errors.Er
 		}
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
 		for _, r := range ent.CustomRoles {
-			roles, err := m.customRole.GetList(projectName, &model.CustomRoleFilter{ID: r})
+			roles, err := h.customRole.GetList(projectName, &model.CustomRoleFilter{ID: r})
 			if err != nil {
 				return errors.Append(err, "Custom role get error")
 			}
@@ -421,7 +738,7 @@ func (m *Manager) UserUpdate(projectName string, ent *model.UserInfo) *errors.Er
 		}
 
 		// check duplicate user name
-		users, err := m.user.GetList(ent.ProjectName, &model.UserFilter{Name: ent.Name})
+		users, err := h.user.GetList(ent.ProjectName, &model.UserFilter{Name: ent.Name})
 		if err != nil {
 			return errors.Append(err, "Failed to get user for checking name duplication")
 		}
@@ -429,7 +746,7 @@ func (m *Manager) UserUpdate(projectName string, ent *model.UserInfo) *errors.Er
 			return errors.Append(model.ErrUserAlreadyExists, "new user name is already used")
 		}
 
-		if err := m.user.Update(projectName, ent); err != nil {
+		if err := h.user.Update(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to update user")
 		}
 		return nil
@@ -442,8 +759,9 @@ func (m *Manager) UserAddRole(projectName string, userID string, roleType model.
 		return errors.Append(model.ErrUserValidateFailed, "invalid user id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		users, err := m.user.GetList(projectName, &model.UserFilter{ID: userID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		users, err := h.user.GetList(projectName, &model.UserFilter{ID: userID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current user list")
 		}
@@ -475,7 +793,7 @@ func (m *Manager) UserAddRole(projectName string, userID string, roleType model.
 				}
 			}
 		} else if roleType == model.RoleCustom {
-			roles, err := m.customRole.GetList(projectName, &model.CustomRoleFilter{ID: roleID})
+			roles, err := h.customRole.GetList(projectName, &model.CustomRoleFilter{ID: roleID})
 			if err != nil {
 				return errors.Append(err, "Custom role get error")
 			}
@@ -484,7 +802,7 @@ func (m *Manager) UserAddRole(projectName string, userID string, roleType model.
 			}
 		}
 
-		if err := m.user.AddRole(projectName, userID, roleType, roleID); err != nil {
+		if err := h.user.AddRole(projectName, userID, roleType, roleID); err != nil {
 			return errors.Append(err, "Failed to add role to user")
 		}
 		return nil
@@ -497,8 +815,9 @@ func (m *Manager) UserDeleteRole(projectName string, userID string, roleID strin
 		return errors.Append(model.ErrUserValidateFailed, "invalid user id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		users, err := m.user.GetList(projectName, &model.UserFilter{ID: userID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		users, err := h.user.GetList(projectName, &model.UserFilter{ID: userID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current user list")
 		}
@@ -518,7 +837,7 @@ func (m *Manager) UserDeleteRole(projectName string, userID string, roleID strin
 		}
 		// If not ok, roleID maybe Custom Role
 
-		if err := m.user.DeleteRole(projectName, userID, roleID); err != nil {
+		if err := h.user.DeleteRole(projectName, userID, roleID); err != nil {
 			return errors.Append(err, "Failed to delete role from user")
 		}
 		return nil
@@ -531,8 +850,9 @@ func (m *Manager) UserChangePassword(projectName string, userID string, password
 		return errors.Append(model.ErrUserValidateFailed, "invalid user id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		prjs, err := m.project.GetList(&model.ProjectFilter{Name: projectName})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: projectName})
 		if err != nil {
 			return errors.Append(err, "Failed to get project associated with the user")
 		}
@@ -541,7 +861,7 @@ func (m *Manager) UserChangePassword(projectName string, userID string, password
 		}
 		prj := prjs[0]
 
-		users, err := m.user.GetList(projectName, &model.UserFilter{ID: userID})
+		users, err := h.user.GetList(projectName, &model.UserFilter{ID: userID})
 		if err != nil {
 			return errors.Append(err, "Failed to get user of change password")
 		}
@@ -554,9 +874,11 @@ func (m *Manager) UserChangePassword(projectName string, userID string, password
 			return errors.Append(err, "Failed to check password")
 		}
 
-		usr.PasswordHash = util.CreateHash(password)
+		if usr.PasswordHash, err = secret.HashPassword(password, prj.PasswordHashConfig); err != nil {
+			return errors.Append(err, "Failed to create password hash")
+		}
 
-		if err := m.user.Update(projectName, usr); err != nil {
+		if err := h.user.Update(projectName, usr); err != nil {
 			return errors.Append(err, "Failed to update user password")
 		}
 
@@ -570,8 +892,9 @@ func (m *Manager) UserLogout(projectName string, userID string) *errors.Error {
 		return errors.Append(model.ErrUserValidateFailed, "invalid user id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.session.Delete(projectName, &model.SessionFilter{UserID: userID}); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.session.Delete(projectName, &model.SessionFilter{UserID: userID}); err != nil {
 			return errors.Append(err, "Delete user session failed")
 		}
 
@@ -582,8 +905,9 @@ func (m *Manager) UserLogout(projectName string, userID string) *errors.Error {
 // LoginSessionAdd ...
 func (m *Manager) LoginSessionAdd(projectName string, ent *model.LoginSession) *errors.Error {
 	// login session is in internal only, so validation is not required
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.loginSession.Add(projectName, ent); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.loginSession.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add login session")
 		}
 		return nil
@@ -593,8 +917,9 @@ func (m *Manager) LoginSessionAdd(projectName string, ent *model.LoginSession) *
 // LoginSessionUpdate ...
 func (m *Manager) LoginSessionUpdate(projectName string, ent *model.LoginSession) *errors.Error {
 	// login session is in internal only, so validation is not required
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.loginSession.Update(projectName, ent); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.loginSession.Update(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to update login session")
 		}
 		return nil
@@ -604,8 +929,9 @@ func (m *Manager) LoginSessionUpdate(projectName string, ent *model.LoginSession
 // LoginSessionDelete ...
 func (m *Manager) LoginSessionDelete(projectName string, sessionID string) *errors.Error {
 	// login session is in internal only, so validation is not required
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.loginSession.Delete(projectName, &model.LoginSessionFilter{SessionID: sessionID}); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.loginSession.Delete(projectName, &model.LoginSessionFilter{SessionID: sessionID}); err != nil {
 			return errors.Append(err, "Failed to delete login session")
 		}
 
@@ -635,8 +961,9 @@ func (m *Manager) SessionAdd(projectName string, ent *model.Session) *errors.Err
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		sessions, err := m.session.GetList(projectName, &model.SessionFilter{SessionID: ent.SessionID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		sessions, err := h.session.GetList(projectName, &model.SessionFilter{SessionID: ent.SessionID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current session list")
 		}
@@ -644,7 +971,7 @@ func (m *Manager) SessionAdd(projectName string, ent *model.Session) *errors.Err
 			return model.ErrSessionAlreadyExists
 		}
 
-		if err := m.session.Add(projectName, ent); err != nil {
+		if err := h.session.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add session")
 		}
 		return nil
@@ -660,6 +987,9 @@ func (m *Manager) SessionGetList(projectName string, filter *model.SessionFilter
 		if filter.UserID != "" && !model.ValidateUserID(filter.UserID) {
 			return nil, model.ErrSessionValidateFailed
 		}
+		if filter.FamilyID != "" && !model.ValidateSessionID(filter.FamilyID) {
+			return nil, model.ErrSessionValidateFailed
+		}
 	}
 
 	return m.session.GetList(projectName, filter)
@@ -679,12 +1009,20 @@ func (m *Manager) SessionGet(projectName string, sessionID string) (*model.Sessi
 
 // SessionDelete ...
 func (m *Manager) SessionDelete(projectName string, sessionID string) *errors.Error {
+	_, err := m.SessionRevoke(projectName, sessionID)
+	return err
+}
+
+// SessionRevoke deletes the session and returns the deleted session in the same transaction
+func (m *Manager) SessionRevoke(projectName string, sessionID string) (*model.Session, *errors.Error) {
 	if !model.ValidateSessionID(sessionID) {
-		return errors.Append(model.ErrSessionValidateFailed, "invalid session id format")
+		return nil, errors.Append(model.ErrSessionValidateFailed, "invalid session id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		sessions, err := m.SessionGetList(projectName, &model.SessionFilter{SessionID: sessionID})
+	var res *model.Session
+	err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		sessions, err := h.SessionGetList(projectName, &model.SessionFilter{SessionID: sessionID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current session list")
 		}
@@ -693,21 +1031,102 @@ func (m *Manager) SessionDelete(projectName string, sessionID string) *errors.Er
 			return model.ErrNoSuchSession
 		}
 
-		if err := m.session.Delete(projectName, &model.SessionFilter{SessionID: sessionID}); err != nil {
+		if err := h.session.Delete(projectName, &model.SessionFilter{SessionID: sessionID}); err != nil {
 			return errors.Append(err, "Failed to revoke session")
 		}
+		res = sessions[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SessionDeleteFamily deletes all sessions which belong to the refresh token family
+func (m *Manager) SessionDeleteFamily(projectName string, familyID string) *errors.Error {
+	if !model.ValidateSessionID(familyID) {
+		return errors.Append(model.ErrSessionValidateFailed, "invalid family id format")
+	}
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.session.Delete(projectName, &model.SessionFilter{FamilyID: familyID}); err != nil {
+			return errors.Append(err, "Failed to revoke session family")
+		}
 		return nil
 	})
 }
 
+// SessionRefresh deletes the session of the refresh token and returns it in the same transaction
+// if rotated is not nil, it is remembered to detect the reuse of the rotated refresh token
+// and model.ErrRefreshTokenReused is returned after revoking the whole family if it is already rotated
+func (m *Manager) SessionRefresh(projectName string, sessionID string, familyID string, rotated *model.RevokedToken) (*model.Session, *errors.Error) {
+	if !model.ValidateSessionID(sessionID) || !model.ValidateSessionID(familyID) {
+		return nil, errors.Append(model.ErrSessionValidateFailed, "invalid session id format")
+	}
+	if rotated != nil {
+		if err := rotated.Validate(); err != nil {
+			return nil, errors.Append(err, "Failed to validate entry")
+		}
+	}
+
+	var res *model.Session
+	reused := false
+	err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if rotated != nil {
+			tokens, err := h.revokedToken.GetList(projectName, &model.RevokedTokenFilter{TokenID: rotated.TokenID})
+			if err != nil {
+				return errors.Append(err, "Failed to get current revoked token list")
+			}
+			if len(tokens) > 0 {
+				// the family must be revoked, so the transaction is committed
+				reused = true
+				if err := h.session.Delete(projectName, &model.SessionFilter{FamilyID: familyID}); err != nil {
+					return errors.Append(err, "Failed to revoke session family")
+				}
+				return nil
+			}
+		}
+
+		sessions, err := h.session.GetList(projectName, &model.SessionFilter{SessionID: sessionID})
+		if err != nil {
+			return errors.Append(err, "Failed to get current session list")
+		}
+		if len(sessions) == 0 {
+			return model.ErrNoSuchSession
+		}
+		if err := h.session.Delete(projectName, &model.SessionFilter{SessionID: sessionID}); err != nil {
+			return errors.Append(err, "Failed to revoke session")
+		}
+
+		if rotated != nil {
+			if err := h.revokedToken.Add(projectName, rotated); err != nil {
+				return errors.Append(err, "Failed to add revoked token")
+			}
+		}
+		res = sessions[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, model.ErrRefreshTokenReused
+	}
+	return res, nil
+}
+
 // ClientAdd ...
 func (m *Manager) ClientAdd(projectName string, ent *model.ClientInfo) *errors.Error {
 	if err := ent.Validate(); err != nil {
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		prjs, err := m.project.GetList(&model.ProjectFilter{Name: projectName})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: projectName})
 		if err != nil {
 			return errors.Append(err, "Failed to get current project")
 		}
@@ -715,7 +1134,7 @@ func (m *Manager) ClientAdd(projectName string, ent *model.ClientInfo) *errors.E
 			return model.ErrNoSuchProject
 		}
 
-		clis, err := m.client.GetList(ent.ProjectName, &model.ClientFilter{ID: ent.ID})
+		clis, err := h.client.GetList(ent.ProjectName, &model.ClientFilter{ID: ent.ID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current client list")
 		}
@@ -723,7 +1142,7 @@ func (m *Manager) ClientAdd(projectName string, ent *model.ClientInfo) *errors.E
 			return model.ErrClientAlreadyExists
 		}
 
-		if err := m.client.Add(projectName, ent); err != nil {
+		if err := h.client.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add client")
 		}
 		return nil
@@ -736,8 +1155,9 @@ func (m *Manager) ClientDelete(projectName, clientID string) *errors.Error {
 		return errors.Append(model.ErrClientValidateFailed, "invalid client id format")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		clis, err := m.client.GetList(projectName, &model.ClientFilter{ID: clientID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		clis, err := h.client.GetList(projectName, &model.ClientFilter{ID: clientID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current client list")
 		}
@@ -745,11 +1165,11 @@ func (m *Manager) ClientDelete(projectName, clientID string) *errors.Error {
 			return model.ErrNoSuchClient
 		}
 
-		if err := m.loginSession.Delete(projectName, &model.LoginSessionFilter{ClientID: clientID}); err != nil {
+		if err := h.loginSession.Delete(projectName, &model.LoginSessionFilter{ClientID: clientID}); err != nil {
 			return errors.Append(err, "Failed to delete login session of the client")
 		}
 
-		if err := m.client.Delete(projectName, clientID); err != nil {
+		if err := h.client.Delete(projectName, clientID); err != nil {
 			return errors.Append(err, "Failed to delete client")
 		}
 		return nil
@@ -784,8 +1204,9 @@ func (m *Manager) ClientUpdate(projectName string, ent *model.ClientInfo) *error
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		clis, err := m.client.GetList(projectName, &model.ClientFilter{ID: ent.ID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		clis, err := h.client.GetList(projectName, &model.ClientFilter{ID: ent.ID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current client list")
 		}
@@ -793,7 +1214,7 @@ func (m *Manager) ClientUpdate(projectName string, ent *model.ClientInfo) *error
 			return model.ErrNoSuchClient
 		}
 
-		if err := m.client.Update(projectName, ent); err != nil {
+		if err := h.client.Update(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to update client")
 		}
 		return nil
@@ -806,8 +1227,9 @@ func (m *Manager) CustomRoleAdd(projectName string, ent *model.CustomRole) *erro
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		prjs, err := m.project.GetList(&model.ProjectFilter{Name: projectName})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: projectName})
 		if err != nil {
 			return errors.Append(err, "Failed to get current project")
 		}
@@ -815,7 +1237,7 @@ func (m *Manager) CustomRoleAdd(projectName string, ent *model.CustomRole) *erro
 			return model.ErrNoSuchProject
 		}
 
-		roles, err := m.customRole.GetList(projectName, &model.CustomRoleFilter{
+		roles, err := h.customRole.GetList(projectName, &model.CustomRoleFilter{
 			ID:   ent.ID,
 			Name: ent.Name,
 		})
@@ -826,7 +1248,7 @@ func (m *Manager) CustomRoleAdd(projectName string, ent *model.CustomRole) *erro
 			return model.ErrCustomRoleAlreadyExists
 		}
 
-		if err := m.customRole.Add(projectName, ent); err != nil {
+		if err := h.customRole.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add customRole")
 		}
 		return nil
@@ -839,8 +1261,9 @@ func (m *Manager) CustomRoleDelete(projectName string, customRoleID string) *err
 		return model.ErrCustomRoleValidateFailed
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		roles, err := m.customRole.GetList(projectName, &model.CustomRoleFilter{ID: customRoleID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		roles, err := h.customRole.GetList(projectName, &model.CustomRoleFilter{ID: customRoleID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current custom role list")
 		}
@@ -849,11 +1272,11 @@ func (m *Manager) CustomRoleDelete(projectName string, customRoleID string) *err
 		}
 
 		// Delete custom role from all user
-		if err := m.user.DeleteAllCustomRole(projectName, customRoleID); err != nil {
+		if err := h.user.DeleteAllCustomRole(projectName, customRoleID); err != nil {
 			return errors.Append(err, "Failed to delete custom role from user")
 		}
 
-		if err := m.customRole.Delete(projectName, customRoleID); err != nil {
+		if err := h.customRole.Delete(projectName, customRoleID); err != nil {
 			return errors.Append(err, "Failed to delete customRole")
 		}
 		return nil
@@ -892,8 +1315,9 @@ func (m *Manager) CustomRoleUpdate(projectName string, ent *model.CustomRole) *e
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		r, err := m.customRole.GetList(ent.ProjectName, &model.CustomRoleFilter{ID: ent.ID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		r, err := h.customRole.GetList(ent.ProjectName, &model.CustomRoleFilter{ID: ent.ID})
 		if err != nil {
 			return errors.Append(err, "Failed to get current role list by ID")
 		}
@@ -902,7 +1326,7 @@ func (m *Manager) CustomRoleUpdate(projectName string, ent *model.CustomRole) *e
 			return model.ErrNoSuchCustomRole
 		}
 
-		r, err = m.customRole.GetList(ent.ProjectName, &model.CustomRoleFilter{Name: ent.Name})
+		r, err = h.customRole.GetList(ent.ProjectName, &model.CustomRoleFilter{Name: ent.Name})
 		if err != nil {
 			return errors.Append(err, "Failed to get current role list by Name")
 		}
@@ -912,7 +1336,7 @@ func (m *Manager) CustomRoleUpdate(projectName string, ent *model.CustomRole) *e
 			return model.ErrCustomRoleAlreadyExists
 		}
 
-		if err := m.customRole.Update(projectName, ent); err != nil {
+		if err := h.customRole.Update(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to update client")
 		}
 		return nil
@@ -925,8 +1349,9 @@ func (m *Manager) DeviceAdd(projectName string, ent *model.Device) *errors.Error
 		return errors.Append(err, "Failed to validate entry")
 	}
 
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.device.Add(projectName, ent); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.device.Add(projectName, ent); err != nil {
 			return errors.Append(err, "Failed to add device")
 		}
 		return nil
@@ -937,8 +1362,9 @@ func (m *Manager) DeviceAdd(projectName string, ent *model.Device) *errors.Error
 func (m *Manager) DeviceDelete(projectName string, deviceCode string) *errors.Error {
 	// TODO validate deviceCode
 
-	return m.transaction.Transaction(func() *errors.Error {
-		devices, err := m.device.GetList(projectName, &model.DeviceFilter{DeviceCode: deviceCode})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		devices, err := h.device.GetList(projectName, &model.DeviceFilter{DeviceCode: deviceCode})
 		if err != nil {
 			return errors.Append(err, "Failed to get current device list")
 		}
@@ -946,10 +1372,10 @@ func (m *Manager) DeviceDelete(projectName string, deviceCode string) *errors.Er
 			return model.ErrNoSuchDevice
 		}
 
-		if err := m.loginSession.Delete(projectName, &model.LoginSessionFilter{SessionID: devices[0].LoginSessionID}); err != nil {
+		if err := h.loginSession.Delete(projectName, &model.LoginSessionFilter{SessionID: devices[0].LoginSessionID}); err != nil {
 			return errors.Append(err, "Failed to delete login session")
 		}
-		if err := m.device.Delete(projectName, deviceCode); err != nil {
+		if err := h.device.Delete(projectName, deviceCode); err != nil {
 			return errors.Append(err, "Failed to delete device")
 		}
 
@@ -963,11 +1389,114 @@ func (m *Manager) DeviceGetList(projectName string, filter *model.DeviceFilter)
 	return m.device.GetList(projectName, filter)
 }
 
+// PushedAuthRequestAdd ...
+func (m *Manager) PushedAuthRequestAdd(projectName string, ent *model.PushedAuthRequest) *errors.Error {
+	if err := ent.Validate(); err != nil {
+		return errors.Append(err, "Failed to validate entry")
+	}
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.pushedAuth.Add(projectName, ent); err != nil {
+			return errors.Append(err, "Failed to add pushed authorization request")
+		}
+		return nil
+	})
+}
+
+// PushedAuthRequestUse returns the pushed authorization request and deletes it
+// because request_uri can be used only once
+func (m *Manager) PushedAuthRequestUse(projectName string, requestURI string) (*model.PushedAuthRequest, *errors.Error) {
+	var res *model.PushedAuthRequest
+	err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		requests, err := h.pushedAuth.GetList(projectName, &model.PushedAuthRequestFilter{RequestURI: requestURI})
+		if err != nil {
+			return errors.Append(err, "Failed to get pushed authorization request")
+		}
+		if len(requests) == 0 {
+			return model.ErrNoSuchPushedAuthRequest
+		}
+
+		if err := h.pushedAuth.Delete(projectName, requestURI); err != nil {
+			return errors.Append(err, "Failed to delete pushed authorization request")
+		}
+		res = requests[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RevokedTokenAdd ...
+func (m *Manager) RevokedTokenAdd(projectName string, ent *model.RevokedToken) *errors.Error {
+	if err := ent.Validate(); err != nil {
+		return errors.Append(err, "Failed to validate entry")
+	}
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		tokens, err := h.revokedToken.GetList(projectName, &model.RevokedTokenFilter{TokenID: ent.TokenID})
+		if err != nil {
+			return errors.Append(err, "Failed to get current revoked token list")
+		}
+		if len(tokens) > 0 {
+			// already revoked
+			return nil
+		}
+
+		if err := h.revokedToken.Add(projectName, ent); err != nil {
+			return errors.Append(err, "Failed to add revoked token")
+		}
+		return nil
+	})
+}
+
+// IsTokenRevoked ...
+func (m *Manager) IsTokenRevoked(projectName string, tokenID string) (bool, *errors.Error) {
+	if !model.ValidateTokenID(tokenID) {
+		return false, errors.Append(model.ErrRevokedTokenValidateFailed, "Invalid token ID format")
+	}
+
+	tokens, err := m.revokedToken.GetList(projectName, &model.RevokedTokenFilter{TokenID: tokenID})
+	if err != nil {
+		return false, errors.Append(err, "Failed to get revoked token list")
+	}
+	return len(tokens) > 0, nil
+}
+
+// UsedJTIAdd records the jti of the one-time token
+// it returns model.ErrJTIAlreadyUsed if the jti is already used by the same issuer
+func (m *Manager) UsedJTIAdd(projectName string, ent *model.UsedJTI) *errors.Error {
+	if err := ent.Validate(); err != nil {
+		return errors.Append(err, "Failed to validate entry")
+	}
+
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		jtis, err := h.usedJTI.GetList(projectName, &model.UsedJTIFilter{JTI: ent.JTI, Issuer: ent.Issuer})
+		if err != nil {
+			return errors.Append(err, "Failed to get current used jti list")
+		}
+		if len(jtis) > 0 {
+			return model.ErrJTIAlreadyUsed
+		}
+
+		if err := h.usedJTI.Add(projectName, ent); err != nil {
+			return errors.Append(err, "Failed to add used jti")
+		}
+		return nil
+	})
+}
+
 // OTPAdd ...
 func (m *Manager) OTPAdd(projectName string, userID string, ent *model.OTPInfo) *errors.Error {
 	// otp add is used in internal only, so validation is not required
-	return m.transaction.Transaction(func() *errors.Error {
-		users, err := m.user.GetList(projectName, &model.UserFilter{ID: userID})
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		users, err := h.user.GetList(projectName, &model.UserFilter{ID: userID})
 		if err != nil {
 			return errors.Append(err, "Failed to get user of OTP add")
 		}
@@ -982,7 +1511,7 @@ func (m *Manager) OTPAdd(projectName string, userID string, ent *model.OTPInfo)
 
 		// update user
 		usr.OTPInfo = *ent
-		if err := m.user.Update(projectName, usr); err != nil {
+		if err := h.user.Update(projectName, usr); err != nil {
 			return errors.Append(err, "Failed to update user")
 		}
 		return nil
@@ -993,19 +1522,32 @@ func (m *Manager) OTPAdd(projectName string, userID string, ent *model.OTPInfo)
 func (m *Manager) DeleteExpiredSessions() *errors.Error {
 	now := time.Now()
 
-	return m.transaction.Transaction(func() *errors.Error {
-		if err := m.loginSession.Cleanup(now); err != nil {
+	return m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		if err := h.loginSession.Cleanup(now); err != nil {
 			return errors.Append(err, "Failed to cleanup login sessions")
 		}
 
-		if err := m.session.Cleanup(now); err != nil {
+		if err := h.session.Cleanup(now); err != nil {
 			return errors.Append(err, "Failed to cleanup sessions")
 		}
 
-		if err := m.device.Cleanup(now); err != nil {
+		if err := h.device.Cleanup(now); err != nil {
 			return errors.Append(err, "Failed to cleanup devices")
 		}
 
+		if err := h.revokedToken.Cleanup(now); err != nil {
+			return errors.Append(err, "Failed to cleanup revoked tokens")
+		}
+
+		if err := h.pushedAuth.Cleanup(now); err != nil {
+			return errors.Append(err, "Failed to cleanup pushed authorization requests")
+		}
+
+		if err := h.usedJTI.Cleanup(now); err != nil {
+			return errors.Append(err, "Failed to cleanup used jtis")
+		}
+
 		return nil
 	})
 }
@@ -1,20 +1,23 @@
 package db
 
 import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/sh-miyoshi/hekate/pkg/config"
 	"github.com/sh-miyoshi/hekate/pkg/db/memory"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/db/sql"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
 )
 
 func TestProjectAdd(t *testing.T) {
-	mgr := &Manager{
-		client:      memory.NewClientHandler(),
-		project:     memory.NewProjectHandler(),
-		transaction: memory.NewTransactionManager(),
-	}
+	mgr := newMemoryManager(memory.NewStore())
 
 	prjInfo := &model.ProjectInfo{
 		Name:      "test-project",
@@ -43,3 +46,341 @@ func TestProjectAdd(t *testing.T) {
 		t.Errorf("Expect error is %v, but got %v", model.ErrProjectAlreadyExists, err)
 	}
 }
+
+func TestProjectSecretReset(t *testing.T) {
+	mgr := newMemoryManager(memory.NewStore())
+
+	prjInfo := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  1,
+			RefreshTokenLifeSpan: 1,
+			SigningAlgorithm:     "RS256",
+		},
+	}
+	if err := mgr.ProjectAdd(prjInfo); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	prj, _ := mgr.ProjectGet(prjInfo.Name)
+	if len(prj.TokenConfig.SignKeys) != 2 {
+		t.Fatalf("Project should have active and next keys, but got %d keys", len(prj.TokenConfig.SignKeys))
+	}
+	active := prj.TokenConfig.ActiveSignKey()
+	next := prj.TokenConfig.SignKeys[1]
+	if active == nil || next.State != model.SignKeyStateNext {
+		t.Fatalf("Unexpected key states: %v", prj.TokenConfig.SignKeys)
+	}
+
+	if err := mgr.ProjectSecretReset(prjInfo.Name); err != nil {
+		t.Fatalf("Failed to reset secret: %v", err)
+	}
+
+	prj, _ = mgr.ProjectGet(prjInfo.Name)
+	if len(prj.TokenConfig.SignKeys) != 3 {
+		t.Errorf("Project should have retired, active and next keys, but got %d keys", len(prj.TokenConfig.SignKeys))
+	}
+	if prj.TokenConfig.ActiveSignKey().KeyID != next.KeyID {
+		t.Errorf("Next key should be activated")
+	}
+	if k := prj.TokenConfig.FindSignKey(active.KeyID, time.Now()); k == nil || k.State != model.SignKeyStateRetired {
+		t.Errorf("Previous active key should be retired and still valid")
+	}
+	if k := prj.TokenConfig.FindSignKey(active.KeyID, time.Now().Add(2*time.Second)); k != nil {
+		t.Errorf("Retired key should be expired after token life span")
+	}
+
+	// Change signing algorithm rotates keys immediately
+	prj.TokenConfig.SigningAlgorithm = "ES256"
+	if err := mgr.ProjectUpdate(prj); err != nil {
+		t.Fatalf("Failed to update project: %v", err)
+	}
+	prj, _ = mgr.ProjectGet(prjInfo.Name)
+	if prj.TokenConfig.ActiveSignKey().SigningAlgorithm != "ES256" {
+		t.Errorf("Active key should be ES256 key, but got %s", prj.TokenConfig.ActiveSignKey().SigningAlgorithm)
+	}
+}
+
+func TestProjectRotateSignKeys(t *testing.T) {
+	mgr := newMemoryManager(memory.NewStore())
+
+	prjInfo := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  1,
+			RefreshTokenLifeSpan: 1,
+			SigningAlgorithm:     "RS256",
+			KeyRotationInterval:  60,
+		},
+	}
+	if err := mgr.ProjectAdd(prjInfo); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	// Keys are not rotated before the rotation interval passes
+	prj, _ := mgr.ProjectGet(prjInfo.Name)
+	active := prj.TokenConfig.ActiveSignKey()
+	next := prj.TokenConfig.SignKeys[1]
+	if err := mgr.ProjectRotateSignKeys(); err != nil {
+		t.Fatalf("Failed to rotate sign keys: %v", err)
+	}
+	prj, _ = mgr.ProjectGet(prjInfo.Name)
+	if prj.TokenConfig.ActiveSignKey().KeyID != active.KeyID {
+		t.Errorf("Active key should not be rotated before the rotation interval")
+	}
+
+	// next -> active, active -> retired after the rotation interval
+	prj.TokenConfig.ActiveSignKey().ActivatedAt = time.Now().Add(-61 * time.Second)
+	mgr.project.Update(prj)
+	if err := mgr.ProjectRotateSignKeys(); err != nil {
+		t.Fatalf("Failed to rotate sign keys: %v", err)
+	}
+	prj, _ = mgr.ProjectGet(prjInfo.Name)
+	states := map[string]model.SignKeyState{}
+	for _, k := range prj.TokenConfig.SignKeys {
+		states[k.KeyID] = k.State
+	}
+	if len(states) != 3 {
+		t.Fatalf("Project should have retired, active and next keys, but got %d keys", len(states))
+	}
+	if states[active.KeyID] != model.SignKeyStateRetired {
+		t.Errorf("Previous active key should be retired, but got %s", states[active.KeyID])
+	}
+	if states[next.KeyID] != model.SignKeyStateActive {
+		t.Errorf("Previous next key should be activated, but got %s", states[next.KeyID])
+	}
+
+	// Expired retired key is removed
+	for _, k := range prj.TokenConfig.SignKeys {
+		if k.KeyID == active.KeyID {
+			k.ExpiresAt = time.Now().Add(-time.Second)
+		}
+	}
+	mgr.project.Update(prj)
+	if err := mgr.ProjectRotateSignKeys(); err != nil {
+		t.Fatalf("Failed to rotate sign keys: %v", err)
+	}
+	prj, _ = mgr.ProjectGet(prjInfo.Name)
+	if len(prj.TokenConfig.SignKeys) != 2 || prj.TokenConfig.ActiveSignKey().KeyID != next.KeyID {
+		t.Errorf("Expired retired key should be removed without rotation, but got %v", prj.TokenConfig.SignKeys)
+	}
+}
+
+func TestRotateSignKeysExpiry(t *testing.T) {
+	org := config.Get().SSOExpiresIn
+	defer func() {
+		config.Get().SSOExpiresIn = org
+	}()
+
+	tt := []struct {
+		name     string
+		access   uint
+		refresh  uint
+		sso      uint64
+		expectIn time.Duration
+	}{
+		{"access token", 100, 10, 50, 100 * time.Second},
+		{"refresh token", 10, 100, 50, 100 * time.Second},
+		{"sso token", 10, 50, 100, 100 * time.Second},
+	}
+
+	for _, tc := range tt {
+		config.Get().SSOExpiresIn = tc.sso
+		tcfg := &model.TokenConfig{
+			AccessTokenLifeSpan:  tc.access,
+			RefreshTokenLifeSpan: tc.refresh,
+			SigningAlgorithm:     "RS256",
+		}
+		now := time.Now()
+		active, err := newSignKey(tcfg.SigningAlgorithm, now)
+		if err != nil {
+			t.Fatalf("Test %s: Failed to generate sign key: %v", tc.name, err)
+		}
+		active.State = model.SignKeyStateActive
+		tcfg.SignKeys = []*model.SignKey{active}
+		if err := rotateSignKeys(tcfg, now); err != nil {
+			t.Fatalf("Test %s: Failed to rotate sign keys: %v", tc.name, err)
+		}
+		for _, k := range tcfg.SignKeys {
+			if k.KeyID == active.KeyID && !k.ExpiresAt.Equal(now.Add(tc.expectIn)) {
+				t.Errorf("Test %s: Retired key should expire at %v, but got %v", tc.name, now.Add(tc.expectIn), k.ExpiresAt)
+			}
+		}
+	}
+}
+
+func TestProjectUpdateKeepsRotatedKeys(t *testing.T) {
+	mgr := newMemoryManager(memory.NewStore())
+
+	prjInfo := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  1,
+			RefreshTokenLifeSpan: 1,
+			SigningAlgorithm:     "RS256",
+		},
+	}
+	if err := mgr.ProjectAdd(prjInfo); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	// Keys are rotated after the project was read
+	stale, _ := mgr.ProjectGet(prjInfo.Name)
+	if err := mgr.ProjectSecretReset(prjInfo.Name); err != nil {
+		t.Fatalf("Failed to reset secret: %v", err)
+	}
+	rotated, _ := mgr.ProjectGet(prjInfo.Name)
+
+	stale.TokenConfig.AccessTokenLifeSpan = 10
+	if err := mgr.ProjectUpdate(stale); err != nil {
+		t.Fatalf("Failed to update project: %v", err)
+	}
+	prj, _ := mgr.ProjectGet(prjInfo.Name)
+	if prj.TokenConfig.AccessTokenLifeSpan != 10 {
+		t.Errorf("Access token life span should be updated")
+	}
+	if prj.TokenConfig.ActiveSignKey().KeyID != rotated.TokenConfig.ActiveSignKey().KeyID || len(prj.TokenConfig.SignKeys) != 3 {
+		t.Errorf("Rotated keys should not be overwritten by the stale project info")
+	}
+}
+
+func TestProjectProtectSignKeys(t *testing.T) {
+	mgr := newMemoryManager(memory.NewStore())
+
+	// Keys are created before enabling the local key provider
+	secret.InitKeyProvider(secret.KeyProviderNone, "")
+	prjInfo := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  1,
+			RefreshTokenLifeSpan: 1,
+			SigningAlgorithm:     "RS256",
+		},
+	}
+	if err := mgr.ProjectAdd(prjInfo); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+	raw, _ := mgr.ProjectGet(prjInfo.Name)
+
+	os.Setenv("HEKATE_MASTER_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	defer func() {
+		os.Unsetenv("HEKATE_MASTER_KEY")
+		secret.InitKeyProvider(secret.KeyProviderNone, "")
+	}()
+	if err := secret.InitKeyProvider(secret.KeyProviderLocal, ""); err != nil {
+		t.Fatalf("Failed to initialize local key provider: %v", err)
+	}
+
+	if err := mgr.ProjectProtectSignKeys(); err != nil {
+		t.Fatalf("Failed to protect sign keys: %v", err)
+	}
+	prj, _ := mgr.ProjectGet(prjInfo.Name)
+	for i, k := range prj.TokenConfig.SignKeys {
+		if !secret.GetKeyProvider().IsProtected(k.PrivateKey) {
+			t.Errorf("Key %s should be protected", k.KeyID)
+		}
+		if bytes.Equal(k.PrivateKey, raw.TokenConfig.SignKeys[i].PrivateKey) {
+			t.Errorf("Key %s should not be stored as raw key", k.KeyID)
+		}
+		if _, err := secret.GetKeyProvider().Sign(k.SigningAlgorithm, k.PrivateKey, "header.payload"); err != nil {
+			t.Errorf("Failed to sign by protected key: %v", err)
+		}
+	}
+
+	// Already protected keys are not changed
+	if err := mgr.ProjectProtectSignKeys(); err != nil {
+		t.Fatalf("Failed to protect sign keys: %v", err)
+	}
+	again, _ := mgr.ProjectGet(prjInfo.Name)
+	if !bytes.Equal(again.TokenConfig.SignKeys[0].PrivateKey, prj.TokenConfig.SignKeys[0].PrivateKey) {
+		t.Errorf("Protected key should not be protected again")
+	}
+
+	// Encrypted keys can not be used after changing the provider to none
+	secret.InitKeyProvider(secret.KeyProviderNone, "")
+	if err := mgr.ProjectProtectSignKeys(); err == nil {
+		t.Errorf("Encrypted keys should be rejected by none key provider")
+	}
+}
+
+func TestSQLTransaction(t *testing.T) {
+	cli, err := sql.NewClient(sql.TypeSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create sqlite client: %v", err)
+	}
+	defer cli.Close()
+	mgr := newSQLManager(cli)
+	mgr.bindTx = func(tx model.Tx) *Manager {
+		return newSQLManager(cli.WithTx(tx))
+	}
+
+	// all statements in the transaction must use it, otherwise they wait for the only connection
+	if err := mgr.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	prjInfo := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  1,
+			RefreshTokenLifeSpan: 1,
+			SigningAlgorithm:     "RS256",
+		},
+	}
+	if err := mgr.ProjectAdd(prjInfo); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	// the failed transaction is rolled back
+	if err := mgr.ProjectAdd(prjInfo); !errors.Contains(err, model.ErrProjectAlreadyExists) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrProjectAlreadyExists, err)
+	}
+	clis, _ := mgr.ClientGetList(prjInfo.Name, nil)
+	if len(clis) != 1 {
+		t.Errorf("Project should have only portal client, but got %d clients", len(clis))
+	}
+}
+
+func TestSessionRevoke(t *testing.T) {
+	cli, err := sql.NewClient(sql.TypeSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create sqlite client: %v", err)
+	}
+	defer cli.Close()
+	mgr := newSQLManager(cli)
+	mgr.bindTx = func(tx model.Tx) *Manager {
+		return newSQLManager(cli.WithTx(tx))
+	}
+	if err := mgr.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	s := &model.Session{
+		SessionID:   "9c3c5a4e-2f7a-4a57-9a4b-2d1f3b1e8f10",
+		FamilyID:    "9c3c5a4e-2f7a-4a57-9a4b-2d1f3b1e8f10",
+		ProjectName: "test-project",
+		UserID:      "0e7f6a8c-5b1d-4c2e-8f3a-6d9b1c2e3f40",
+		CreatedAt:   time.Now(),
+		ExpiresIn:   60,
+		FromIP:      "127.0.0.1",
+		ClientID:    "client",
+	}
+	if err := mgr.SessionAdd(s.ProjectName, s); err != nil {
+		t.Fatalf("Failed to add session: %v", err)
+	}
+
+	res, err := mgr.SessionRevoke(s.ProjectName, s.SessionID)
+	if err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	if res.SessionID != s.SessionID || res.ClientID != s.ClientID {
+		t.Errorf("Revoked session should be %v, but got %v", s, res)
+	}
+	if _, err := mgr.SessionRevoke(s.ProjectName, s.SessionID); !errors.Contains(err, model.ErrNoSuchSession) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrNoSuchSession, err)
+	}
+}
@@ -0,0 +1,288 @@
+package db
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/role"
+)
+
+// ProjectExport returns all resources of the project
+func (m *Manager) ProjectExport(projectName string) (*model.ProjectData, *errors.Error) {
+	if !model.ValidateProjectName(projectName) {
+		return nil, errors.Append(model.ErrProjectValidateFailed, "Invalid project name format")
+	}
+
+	res := &model.ProjectData{}
+	err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: projectName})
+		if err != nil {
+			return errors.Append(err, "Failed to get project")
+		}
+		if len(prjs) == 0 {
+			return model.ErrNoSuchProject
+		}
+		res.Project = prjs[0]
+
+		if res.Clients, err = h.client.GetList(projectName, nil); err != nil {
+			return errors.Append(err, "Failed to get client list")
+		}
+		if res.CustomRoles, err = h.customRole.GetList(projectName, nil); err != nil {
+			return errors.Append(err, "Failed to get custom role list")
+		}
+		if res.Users, err = h.user.GetList(projectName, nil); err != nil {
+			return errors.Append(err, "Failed to get user list")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProjectImport creates or updates the project by data
+// the entry which already exists in the project is handled by strategy
+// if data.Project.TokenConfig has no sign keys, the keys of existing project are used, or new keys are generated
+func (m *Manager) ProjectImport(data *model.ProjectData, strategy model.ImportStrategy) (*model.ImportResult, *errors.Error) {
+	if _, err := model.GetImportStrategy(string(strategy)); err != nil {
+		return nil, err
+	}
+	if err := validateImportData(data); err != nil {
+		return nil, errors.Append(err, "Failed to validate import data")
+	}
+
+	prj := data.Project
+	res := &model.ImportResult{
+		Created: []string{},
+		Updated: []string{},
+		Skipped: []string{},
+	}
+
+	err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+		h := m.inTx(tx)
+		prjs, err := h.project.GetList(&model.ProjectFilter{Name: prj.Name})
+		if err != nil {
+			return errors.Append(err, "Failed to get current project list")
+		}
+
+		if len(prjs) == 0 {
+			if len(prj.TokenConfig.SignKeys) == 0 {
+				if err := rotateSignKeys(prj.TokenConfig, time.Now()); err != nil {
+					return errors.Append(err, "Failed to generate sign keys")
+				}
+			}
+			if err := h.project.Add(prj); err != nil {
+				return errors.Append(err, "Failed to add project")
+			}
+			res.Created = append(res.Created, "project/"+prj.Name)
+
+			if !hasPortalClient(data.Clients) {
+				callbacks := []string{}
+				if m.portalAddr != "" {
+					callbacks = append(callbacks, m.portalAddr)
+				}
+				data.Clients = append(data.Clients, &model.ClientInfo{
+					ID:                  "portal",
+					ProjectName:         prj.Name,
+					AccessType:          "public",
+					CreatedAt:           prj.CreatedAt,
+					AllowedCallbackURLs: callbacks,
+				})
+			}
+		} else {
+			switch strategy {
+			case model.ImportStrategyFail:
+				return errors.Append(model.ErrImportConflict, "Project %s already exists", prj.Name)
+			case model.ImportStrategySkip:
+				res.Skipped = append(res.Skipped, "project/"+prj.Name)
+			case model.ImportStrategyOverwrite:
+				// the deletion policy is a property of the environment, so keep it
+				prj.PermitDelete = prjs[0].PermitDelete
+				if len(prj.TokenConfig.SignKeys) == 0 {
+					prj.TokenConfig.SignKeys = prjs[0].TokenConfig.SignKeys
+				}
+				active := prj.TokenConfig.ActiveSignKey()
+				if active == nil || active.SigningAlgorithm != prj.TokenConfig.SigningAlgorithm {
+					if err := rotateSignKeys(prj.TokenConfig, time.Now()); err != nil {
+						return errors.Append(err, "Failed to rotate sign keys")
+					}
+				}
+				if err := h.project.Update(prj); err != nil {
+					return errors.Append(err, "Failed to update project")
+				}
+				res.Updated = append(res.Updated, "project/"+prj.Name)
+			}
+		}
+
+		for _, cli := range data.Clients {
+			name := "client/" + cli.ID
+			clis, err := h.client.GetList(prj.Name, &model.ClientFilter{ID: cli.ID})
+			if err != nil {
+				return errors.Append(err, "Failed to get client %s", cli.ID)
+			}
+			if len(clis) == 0 {
+				if err := h.client.Add(prj.Name, cli); err != nil {
+					return errors.Append(err, "Failed to add client %s", cli.ID)
+				}
+				res.Created = append(res.Created, name)
+				continue
+			}
+
+			switch strategy {
+			case model.ImportStrategyFail:
+				return errors.Append(model.ErrImportConflict, "Client %s already exists", cli.ID)
+			case model.ImportStrategySkip:
+				res.Skipped = append(res.Skipped, name)
+			case model.ImportStrategyOverwrite:
+				if err := h.client.Update(prj.Name, cli); err != nil {
+					return errors.Append(err, "Failed to update client %s", cli.ID)
+				}
+				res.Updated = append(res.Updated, name)
+			}
+		}
+
+		for _, r := range data.CustomRoles {
+			name := "role/" + r.Name
+			roles, err := h.customRole.GetList(prj.Name, &model.CustomRoleFilter{ID: r.ID})
+			if err != nil {
+				return errors.Append(err, "Failed to get custom role %s", r.ID)
+			}
+			if len(roles) == 0 {
+				// Check duplicate custom role by name
+				roles, err = h.customRole.GetList(prj.Name, &model.CustomRoleFilter{Name: r.Name})
+				if err != nil {
+					return errors.Append(err, "Failed to get custom role %s", r.Name)
+				}
+				if len(roles) > 0 {
+					return errors.Append(model.ErrImportConflict, "Custom role name %s is used by other role", r.Name)
+				}
+				if err := h.customRole.Add(prj.Name, r); err != nil {
+					return errors.Append(err, "Failed to add custom role %s", r.Name)
+				}
+				res.Created = append(res.Created, name)
+				continue
+			}
+
+			switch strategy {
+			case model.ImportStrategyFail:
+				return errors.Append(model.ErrImportConflict, "Custom role %s already exists", r.Name)
+			case model.ImportStrategySkip:
+				res.Skipped = append(res.Skipped, name)
+			case model.ImportStrategyOverwrite:
+				if err := h.customRole.Update(prj.Name, r); err != nil {
+					return errors.Append(err, "Failed to update custom role %s", r.Name)
+				}
+				res.Updated = append(res.Updated, name)
+			}
+		}
+
+		for _, u := range data.Users {
+			name := "user/" + u.Name
+			for _, r := range u.CustomRoles {
+				roles, err := h.customRole.GetList(prj.Name, &model.CustomRoleFilter{ID: r})
+				if err != nil {
+					return errors.Append(err, "Custom role get error")
+				}
+				if len(roles) == 0 {
+					return errors.Append(model.ErrImportValidateFailed, "User %s has unknown custom role %s", u.Name, r)
+				}
+			}
+
+			users, err := h.user.GetList(prj.Name, &model.UserFilter{ID: u.ID})
+			if err != nil {
+				return errors.Append(err, "Failed to get user %s", u.Name)
+			}
+			if len(users) == 0 {
+				// Check duplicate user by name
+				users, err = h.user.GetList(prj.Name, &model.UserFilter{Name: u.Name})
+				if err != nil {
+					return errors.Append(err, "Failed to get user %s", u.Name)
+				}
+				if len(users) > 0 {
+					return errors.Append(model.ErrImportConflict, "User name %s is used by other user", u.Name)
+				}
+				if err := h.user.Add(prj.Name, u); err != nil {
+					return errors.Append(err, "Failed to add user %s", u.Name)
+				}
+				res.Created = append(res.Created, name)
+				continue
+			}
+
+			switch strategy {
+			case model.ImportStrategyFail:
+				return errors.Append(model.ErrImportConflict, "User %s already exists", u.Name)
+			case model.ImportStrategySkip:
+				res.Skipped = append(res.Skipped, name)
+			case model.ImportStrategyOverwrite:
+				if err := h.user.Update(prj.Name, u); err != nil {
+					return errors.Append(err, "Failed to update user %s", u.Name)
+				}
+				res.Updated = append(res.Updated, name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func validateImportData(data *model.ProjectData) *errors.Error {
+	if data == nil || data.Project == nil || data.Project.TokenConfig == nil {
+		return errors.Append(model.ErrImportValidateFailed, "Project info is required")
+	}
+	prj := data.Project
+	if err := prj.Validate(); err != nil {
+		return err
+	}
+
+	for _, cli := range data.Clients {
+		cli.ProjectName = prj.Name
+		if err := cli.Validate(); err != nil {
+			return errors.Append(err, "Invalid client %s", cli.ID)
+		}
+	}
+
+	for _, r := range data.CustomRoles {
+		r.ProjectName = prj.Name
+		if err := r.Validate(); err != nil {
+			return errors.Append(err, "Invalid custom role %s", r.Name)
+		}
+	}
+
+	for _, u := range data.Users {
+		u.ProjectName = prj.Name
+		if err := u.Validate(); err != nil {
+			return errors.Append(err, "Invalid user %s", u.Name)
+		}
+
+		for _, r := range u.SystemRoles {
+			res, typ, ok := role.GetInst().Parse(r)
+			if !ok {
+				return errors.Append(model.ErrUserValidateFailed, "Invalid system role %s of user %s", r, u.Name)
+			}
+			if *res == role.ResCluster && prj.Name != "master" {
+				return errors.Append(model.ErrUserValidateFailed, "Resource cluster can add to master project user")
+			}
+			if *typ == role.TypeWrite && !role.Authorize(u.SystemRoles, *res, role.TypeRead) {
+				return errors.Append(model.ErrUserValidateFailed, "User %s does not have read permission", u.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasPortalClient(clients []*model.ClientInfo) bool {
+	for _, cli := range clients {
+		if cli.ID == "portal" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/memory"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+func newExportTestManager() *Manager {
+	return newMemoryManager(memory.NewStore())
+}
+
+func newExportTestData() *model.ProjectData {
+	now := time.Now()
+	return &model.ProjectData{
+		Project: &model.ProjectInfo{
+			Name:      "test-project",
+			CreatedAt: now,
+			TokenConfig: &model.TokenConfig{
+				AccessTokenLifeSpan:  1,
+				RefreshTokenLifeSpan: 1,
+				SigningAlgorithm:     "RS256",
+			},
+		},
+		CustomRoles: []*model.CustomRole{
+			{ID: "cd2e4ea3-5c2e-4b2c-9a0b-6c0b0e0d0a01", Name: "test-role", CreatedAt: now},
+		},
+		Users: []*model.UserInfo{
+			{
+				ID:           "b0e5b2a4-0d4e-4d3a-8f2c-1c1e1b1a1f01",
+				Name:         "test-user",
+				CreatedAt:    now,
+				PasswordHash: "hash",
+				CustomRoles:  []string{"cd2e4ea3-5c2e-4b2c-9a0b-6c0b0e0d0a01"},
+			},
+		},
+	}
+}
+
+func TestProjectImport(t *testing.T) {
+	mgr := newExportTestManager()
+
+	res, err := mgr.ProjectImport(newExportTestData(), model.ImportStrategyFail)
+	if err != nil {
+		t.Fatalf("Failed to import project: %v", err)
+	}
+	// project, portal client, role and user
+	if len(res.Created) != 4 {
+		t.Errorf("Expect 4 created entries, but got %v", res.Created)
+	}
+
+	data, err := mgr.ProjectExport("test-project")
+	if err != nil {
+		t.Fatalf("Failed to export project: %v", err)
+	}
+	if data.Project.TokenConfig.ActiveSignKey() == nil {
+		t.Errorf("Sign key should be generated")
+	}
+	if len(data.Users) != 1 || len(data.Users[0].CustomRoles) != 1 || data.Users[0].PasswordHash != "hash" {
+		t.Errorf("User is not imported correctly: %v", data.Users)
+	}
+
+	// Test conflict strategies
+	if _, err := mgr.ProjectImport(newExportTestData(), model.ImportStrategyFail); !errors.Contains(err, model.ErrImportConflict) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrImportConflict, err)
+	}
+	res, err = mgr.ProjectImport(newExportTestData(), model.ImportStrategySkip)
+	if err != nil || len(res.Skipped) != 3 {
+		t.Errorf("Expect 3 skipped entries, but got %v, %v", res, err)
+	}
+
+	in := newExportTestData()
+	in.Users[0].PasswordHash = "new-hash"
+	if _, err := mgr.ProjectImport(in, model.ImportStrategyOverwrite); err != nil {
+		t.Fatalf("Failed to overwrite project: %v", err)
+	}
+	usr, _ := mgr.user.GetList("test-project", &model.UserFilter{Name: "test-user"})
+	if len(usr) != 1 || usr[0].PasswordHash != "new-hash" {
+		t.Errorf("User should be overwritten, but got %v", usr)
+	}
+	prj, _ := mgr.project.GetList(&model.ProjectFilter{Name: "test-project"})
+	if k := prj[0].TokenConfig.ActiveSignKey(); k == nil || k.KeyID != data.Project.TokenConfig.ActiveSignKey().KeyID {
+		t.Errorf("Existing sign key should be kept")
+	}
+
+	// Test unknown custom role
+	in = newExportTestData()
+	in.Project.Name = "other-project"
+	in.CustomRoles = nil
+	if _, err := mgr.ProjectImport(in, model.ImportStrategyFail); !errors.Contains(err, model.ErrImportValidateFailed) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrImportValidateFailed, err)
+	}
+}
@@ -26,5 +26,9 @@ func RunGC() {
 		if err := GetInst().DeleteExpiredSessions(); err != nil {
 			errors.Print(errors.Append(err, "Failed to delete expired sessions"))
 		}
+		logger.Debug("Rotate project sign keys")
+		if err := GetInst().ProjectRotateSignKeys(); err != nil {
+			errors.Print(errors.Append(err, "Failed to rotate project sign keys"))
+		}
 	}
 }
@@ -1,102 +1,124 @@
 package memory
 
 import (
+	"sort"
+
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 )
 
 // ClientInfoHandler implement db.ClientInfoHandler
 type ClientInfoHandler struct {
-	clientList []*model.ClientInfo
+	store *Store
+	// clientList[projectName][clientID] = ClientInfo
+	clientList map[string]map[string]*model.ClientInfo
 }
 
 // NewClientHandler ...
-func NewClientHandler() *ClientInfoHandler {
-	res := &ClientInfoHandler{}
+func NewClientHandler(store *Store) *ClientInfoHandler {
+	res := &ClientInfoHandler{
+		store:      store,
+		clientList: make(map[string]map[string]*model.ClientInfo),
+	}
+	store.register(res)
 	return res
 }
 
 // Add ...
 func (h *ClientInfoHandler) Add(projectName string, ent *model.ClientInfo) *errors.Error {
-	h.clientList = append(h.clientList, ent)
+	h.store.write(func() {
+		h.put(projectName, cloneClient(ent))
+	})
 	return nil
 }
 
 // Delete ...
 func (h *ClientInfoHandler) Delete(projectName, clientID string) *errors.Error {
-	newList := []*model.ClientInfo{}
-	found := false
-	for _, c := range h.clientList {
-		if c.ProjectName == projectName && c.ID == clientID {
-			found = true
-		} else {
-			newList = append(newList, c)
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.clientList[projectName][clientID]; !exists {
+			err = errors.New("Internal Error", "No such client %s", clientID)
+			return
 		}
-	}
-
-	if found {
-		h.clientList = newList
-		return nil
-	}
-	return errors.New("Internal Error", "No such client %s", clientID)
+		h.del(projectName, clientID)
+	})
+	return err
 }
 
 // GetList ...
 func (h *ClientInfoHandler) GetList(projectName string, filter *model.ClientFilter) ([]*model.ClientInfo, *errors.Error) {
 	res := []*model.ClientInfo{}
 
-	for _, client := range h.clientList {
-		if client.ProjectName == projectName {
-			res = append(res, client)
+	h.store.read(func() {
+		clients := h.clientList[projectName]
+		if filter != nil && filter.ID != "" {
+			if cli, exists := clients[filter.ID]; exists {
+				res = append(res, cloneClient(cli))
+			}
+			return
 		}
-	}
 
-	if filter != nil {
-		res = matchFilterClientList(res, projectName, filter)
-	}
+		for _, cli := range clients {
+			res = append(res, cloneClient(cli))
+		}
+	})
 
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
 	return res, nil
 }
 
 // Update ...
 func (h *ClientInfoHandler) Update(projectName string, ent *model.ClientInfo) *errors.Error {
-	for i, c := range h.clientList {
-		if c.ProjectName == projectName && c.ID == ent.ID {
-			h.clientList[i] = ent
-			return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.clientList[projectName][ent.ID]; !exists {
+			err = errors.New("Internal Error", "No such client %s", ent.ID)
+			return
 		}
-	}
-	return errors.New("Internal Error", "No such client %s", ent.ID)
+		h.put(projectName, cloneClient(ent))
+	})
+	return err
 }
 
 // DeleteAll ...
 func (h *ClientInfoHandler) DeleteAll(projectName string) *errors.Error {
-	newList := []*model.ClientInfo{}
-	for _, c := range h.clientList {
-		if c.ProjectName != projectName {
-			newList = append(newList, c)
+	h.store.write(func() {
+		for id := range h.clientList[projectName] {
+			h.del(projectName, id)
 		}
-	}
-	h.clientList = newList
+	})
 	return nil
 }
 
-// matchFilterClientList returns a list which matches the filter rules
-func matchFilterClientList(data []*model.ClientInfo, projectName string, filter *model.ClientFilter) []*model.ClientInfo {
-	if filter == nil {
-		return data
+// put must be called in store.write
+func (h *ClientInfoHandler) put(projectName string, ent *model.ClientInfo) {
+	if _, exists := h.clientList[projectName]; !exists {
+		h.clientList[projectName] = make(map[string]*model.ClientInfo)
 	}
-	res := []*model.ClientInfo{}
+	old := h.clientList[projectName][ent.ID]
+	h.clientList[projectName][ent.ID] = ent
 
-	for _, cli := range data {
-		if projectName == cli.ProjectName {
-			if filter.ID != "" && cli.ID != filter.ID {
-				// missmatch id
-				continue
-			}
+	id := ent.ID
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.clientList[projectName], id)
+		} else {
+			h.clientList[projectName][id] = old
 		}
-		res = append(res, cli)
+	})
+}
+
+// del must be called in store.write
+func (h *ClientInfoHandler) del(projectName string, clientID string) {
+	old, exists := h.clientList[projectName][clientID]
+	if !exists {
+		return
 	}
+	delete(h.clientList[projectName], clientID)
 
-	return res
+	h.store.onRollback(func() {
+		h.clientList[projectName][clientID] = old
+	})
 }
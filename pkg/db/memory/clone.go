@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+// The handlers store and return copies of the entries,
+// so that the caller can not change the data without lock
+
+func copyStrings(data []string) []string {
+	if data == nil {
+		return nil
+	}
+	return append([]string{}, data...)
+}
+
+func copyStringMap(data map[string]string) map[string]string {
+	if data == nil {
+		return nil
+	}
+	res := make(map[string]string, len(data))
+	for k, v := range data {
+		res[k] = v
+	}
+	return res
+}
+
+func copyBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	return append([]byte{}, data...)
+}
+
+func cloneProject(ent *model.ProjectInfo) *model.ProjectInfo {
+	res := *ent
+	if ent.TokenConfig != nil {
+		tc := *ent.TokenConfig
+		tc.SignKeys = nil
+		for _, k := range ent.TokenConfig.SignKeys {
+			key := *k
+			key.PublicKey = copyBytes(k.PublicKey)
+			key.PrivateKey = copyBytes(k.PrivateKey)
+			tc.SignKeys = append(tc.SignKeys, &key)
+		}
+		res.TokenConfig = &tc
+	}
+	if ent.AllowGrantTypes != nil {
+		res.AllowGrantTypes = append([]model.GrantType{}, ent.AllowGrantTypes...)
+	}
+	res.PasswordPolicy.BlackList = copyStrings(ent.PasswordPolicy.BlackList)
+	if ent.TrustedIssuers != nil {
+		res.TrustedIssuers = []model.TrustedIssuer{}
+		for _, t := range ent.TrustedIssuers {
+			t.SubjectMappings = append([]model.SubjectMapping{}, t.SubjectMappings...)
+			res.TrustedIssuers = append(res.TrustedIssuers, t)
+		}
+	}
+	return &res
+}
+
+func cloneUser(ent *model.UserInfo) *model.UserInfo {
+	res := *ent
+	res.SystemRoles = copyStrings(ent.SystemRoles)
+	res.CustomRoles = copyStrings(ent.CustomRoles)
+	if ent.LockState.VerifyFailedTimes != nil {
+		res.LockState.VerifyFailedTimes = append(res.LockState.VerifyFailedTimes[:0:0], ent.LockState.VerifyFailedTimes...)
+	}
+	res.Attributes = copyStringMap(ent.Attributes)
+	return &res
+}
+
+func cloneClient(ent *model.ClientInfo) *model.ClientInfo {
+	res := *ent
+	res.AllowedCallbackURLs = copyStrings(ent.AllowedCallbackURLs)
+	res.RequestURIs = copyStrings(ent.RequestURIs)
+	res.PostLogoutRedirectURIs = copyStrings(ent.PostLogoutRedirectURIs)
+	res.TokenExchangeAudiences = copyStrings(ent.TokenExchangeAudiences)
+	return &res
+}
+
+func cloneCustomRole(ent *model.CustomRole) *model.CustomRole {
+	res := *ent
+	return &res
+}
+
+func cloneSession(ent *model.Session) *model.Session {
+	res := *ent
+	return &res
+}
+
+func cloneLoginSession(ent *model.LoginSession) *model.LoginSession {
+	res := *ent
+	res.ResponseType = copyStrings(ent.ResponseType)
+	res.Prompt = copyStrings(ent.Prompt)
+	return &res
+}
+
+func cloneDevice(ent *model.Device) *model.Device {
+	res := *ent
+	return &res
+}
+
+func clonePushedAuthRequest(ent *model.PushedAuthRequest) *model.PushedAuthRequest {
+	res := *ent
+	if ent.Parameters != nil {
+		res.Parameters = make(map[string][]string)
+		for k, v := range ent.Parameters {
+			res.Parameters[k] = copyStrings(v)
+		}
+	}
+	return &res
+}
+
+func cloneRevokedToken(ent *model.RevokedToken) *model.RevokedToken {
+	res := *ent
+	return &res
+}
+
+func cloneUsedJTI(ent *model.UsedJTI) *model.UsedJTI {
+	res := *ent
+	return &res
+}
@@ -1,89 +1,146 @@
 package memory
 
 import (
+	"sort"
+
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 )
 
 // CustomRoleHandler implement db.CustomRoleHandler
 type CustomRoleHandler struct {
-	// roleList[roleID] = CustomRole
-	roleList map[string]*model.CustomRole
+	store *Store
+	// roleList[projectName][roleID] = CustomRole
+	roleList map[string]map[string]*model.CustomRole
 }
 
 // NewCustomRoleHandler ...
-func NewCustomRoleHandler() *CustomRoleHandler {
+func NewCustomRoleHandler(store *Store) *CustomRoleHandler {
 	res := &CustomRoleHandler{
-		roleList: make(map[string]*model.CustomRole),
+		store:    store,
+		roleList: make(map[string]map[string]*model.CustomRole),
 	}
+	store.register(res)
 	return res
 }
 
 // Add ...
 func (h *CustomRoleHandler) Add(projectName string, ent *model.CustomRole) *errors.Error {
-	h.roleList[ent.ID] = ent
+	h.store.write(func() {
+		h.put(projectName, cloneCustomRole(ent))
+	})
 	return nil
 }
 
 // Delete ...
 func (h *CustomRoleHandler) Delete(projectName string, roleID string) *errors.Error {
-	if _, exists := h.roleList[roleID]; exists {
-		if h.roleList[roleID].ProjectName == projectName {
-			delete(h.roleList, roleID)
-			return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.roleList[projectName][roleID]; !exists {
+			err = errors.New("Internal Error", "No such custom role %s", roleID)
+			return
 		}
-	}
-	return errors.New("Internal Error", "No such custom role %s", roleID)
+		h.del(projectName, roleID)
+	})
+	return err
 }
 
 // GetList ...
 func (h *CustomRoleHandler) GetList(projectName string, filter *model.CustomRoleFilter) ([]*model.CustomRole, *errors.Error) {
 	res := []*model.CustomRole{}
 
-	for _, role := range h.roleList {
-		if role.ProjectName == projectName {
-			res = append(res, role)
+	h.store.read(func() {
+		data := []*model.CustomRole{}
+		if filter != nil && filter.ID != "" {
+			if role, exists := h.roleList[projectName][filter.ID]; exists {
+				data = append(data, role)
+			}
+		} else {
+			for _, role := range h.roleList[projectName] {
+				data = append(data, role)
+			}
 		}
-	}
 
-	if filter != nil {
-		res = matchFilterRoleList(res, projectName, filter)
-	}
+		for _, role := range matchFilterRoleList(data, projectName, filter) {
+			res = append(res, cloneCustomRole(role))
+		}
+	})
 
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
 	return res, nil
 }
 
 // Get ...
 func (h *CustomRoleHandler) Get(projectName string, roleID string) (*model.CustomRole, *errors.Error) {
-	res, exists := h.roleList[roleID]
-	if !exists || res.ProjectName != projectName {
+	var res *model.CustomRole
+	h.store.read(func() {
+		if role, exists := h.roleList[projectName][roleID]; exists {
+			res = cloneCustomRole(role)
+		}
+	})
+
+	if res == nil {
 		return nil, errors.New("Internal Error", "No such custom role %s", roleID)
 	}
-
 	return res, nil
 }
 
 // Update ...
 func (h *CustomRoleHandler) Update(projectName string, ent *model.CustomRole) *errors.Error {
-	if res, exists := h.roleList[ent.ID]; !exists || res.ProjectName != projectName {
-		return errors.New("Internal Error", "No such custom role %s", ent.ID)
-	}
-
-	h.roleList[ent.ID] = ent
-
-	return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.roleList[projectName][ent.ID]; !exists {
+			err = errors.New("Internal Error", "No such custom role %s", ent.ID)
+			return
+		}
+		h.put(projectName, cloneCustomRole(ent))
+	})
+	return err
 }
 
 // DeleteAll ...
 func (h *CustomRoleHandler) DeleteAll(projectName string) *errors.Error {
-	for _, role := range h.roleList {
-		if role.ProjectName == projectName {
-			delete(h.roleList, role.ID)
+	h.store.write(func() {
+		for id := range h.roleList[projectName] {
+			h.del(projectName, id)
 		}
-	}
+	})
 	return nil
 }
 
+// put must be called in store.write
+func (h *CustomRoleHandler) put(projectName string, ent *model.CustomRole) {
+	if _, exists := h.roleList[projectName]; !exists {
+		h.roleList[projectName] = make(map[string]*model.CustomRole)
+	}
+	old := h.roleList[projectName][ent.ID]
+	h.roleList[projectName][ent.ID] = ent
+
+	id := ent.ID
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.roleList[projectName], id)
+		} else {
+			h.roleList[projectName][id] = old
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *CustomRoleHandler) del(projectName string, roleID string) {
+	old, exists := h.roleList[projectName][roleID]
+	if !exists {
+		return
+	}
+	delete(h.roleList[projectName], roleID)
+
+	h.store.onRollback(func() {
+		h.roleList[projectName][roleID] = old
+	})
+}
+
 // matchFilterRoleList returns a list which matches the filter rules
 func matchFilterRoleList(data []*model.CustomRole, projectName string, filter *model.CustomRoleFilter) []*model.CustomRole {
 	if filter == nil {
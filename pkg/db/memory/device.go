@@ -9,44 +9,51 @@ import (
 
 // DeviceHandler implement db.DeviceHandler
 type DeviceHandler struct {
-	devices []*model.Device
+	store *Store
+	// devices[projectName][deviceCode] = Device
+	devices map[string]map[string]*model.Device
 }
 
 // NewDeviceHandler ...
-func NewDeviceHandler() *DeviceHandler {
-	return &DeviceHandler{}
+func NewDeviceHandler(store *Store) *DeviceHandler {
+	res := &DeviceHandler{
+		store:   store,
+		devices: make(map[string]map[string]*model.Device),
+	}
+	store.register(res)
+	return res
 }
 
 // Add ...
 func (h *DeviceHandler) Add(projectName string, ent *model.Device) *errors.Error {
-	h.devices = append(h.devices, ent)
+	h.store.write(func() {
+		h.put(projectName, cloneDevice(ent))
+	})
 	return nil
 }
 
 // DeleteAll ...
 func (h *DeviceHandler) DeleteAll(projectName string) *errors.Error {
-	newList := []*model.Device{}
-	for _, s := range h.devices {
-		if s.ProjectName != projectName {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for code := range h.devices[projectName] {
+			h.del(projectName, code)
 		}
-	}
-
-	h.devices = newList
+	})
 	return nil
 }
 
 // Cleanup ...
 func (h *DeviceHandler) Cleanup(now time.Time) *errors.Error {
-	newList := []*model.Device{}
-	for _, s := range h.devices {
-		expire := s.CreatedAt.Add(time.Second * time.Duration(s.ExpiresIn))
-		if now.Before(expire) {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for projectName, devices := range h.devices {
+			for code, d := range devices {
+				expire := d.CreatedAt.Add(time.Second * time.Duration(d.ExpiresIn))
+				if !now.Before(expire) {
+					h.del(projectName, code)
+				}
+			}
 		}
-	}
-
-	h.devices = newList
+	})
 	return nil
 }
 
@@ -54,36 +61,68 @@ func (h *DeviceHandler) Cleanup(now time.Time) *errors.Error {
 func (h *DeviceHandler) GetList(projectName string, filter *model.DeviceFilter) ([]*model.Device, *errors.Error) {
 	res := []*model.Device{}
 
-	for _, role := range h.devices {
-		if role.ProjectName == projectName {
-			res = append(res, role)
+	h.store.read(func() {
+		data := []*model.Device{}
+		if filter != nil && filter.DeviceCode != "" {
+			if d, exists := h.devices[projectName][filter.DeviceCode]; exists {
+				data = append(data, d)
+			}
+		} else {
+			for _, d := range h.devices[projectName] {
+				data = append(data, d)
+			}
 		}
-	}
 
-	if filter != nil {
-		res = matchFilterDeviceList(res, projectName, filter)
-	}
+		for _, d := range matchFilterDeviceList(data, projectName, filter) {
+			res = append(res, cloneDevice(d))
+		}
+	})
 
 	return res, nil
 }
 
 // Delete ...
 func (h *DeviceHandler) Delete(projectName string, deviceCode string) *errors.Error {
-	newList := []*model.Device{}
-	found := false
-	for _, d := range h.devices {
-		if d.ProjectName == projectName && d.DeviceCode == deviceCode {
-			found = true
-		} else {
-			newList = append(newList, d)
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.devices[projectName][deviceCode]; !exists {
+			err = errors.New("Internal Error", "No such device %s", deviceCode)
+			return
 		}
+		h.del(projectName, deviceCode)
+	})
+	return err
+}
+
+// put must be called in store.write
+func (h *DeviceHandler) put(projectName string, ent *model.Device) {
+	if _, exists := h.devices[projectName]; !exists {
+		h.devices[projectName] = make(map[string]*model.Device)
 	}
+	old := h.devices[projectName][ent.DeviceCode]
+	h.devices[projectName][ent.DeviceCode] = ent
 
-	if found {
-		h.devices = newList
-		return nil
+	code := ent.DeviceCode
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.devices[projectName], code)
+		} else {
+			h.devices[projectName][code] = old
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *DeviceHandler) del(projectName string, deviceCode string) {
+	old, exists := h.devices[projectName][deviceCode]
+	if !exists {
+		return
 	}
-	return errors.New("Internal Error", "No such device %s", deviceCode)
+	delete(h.devices[projectName], deviceCode)
+
+	h.store.onRollback(func() {
+		h.devices[projectName][deviceCode] = old
+	})
 }
 
 // matchFilterDeviceList returns a list which matches the filter rules
@@ -9,110 +9,188 @@ import (
 
 // LoginSessionHandler implement db.LoginSessionHandler
 type LoginSessionHandler struct {
-	sessionList []*model.LoginSession
+	store *Store
+	// sessionList[projectName][sessionID] = LoginSession
+	sessionList map[string]map[string]*model.LoginSession
+	// codeIndex[projectName][code] = sessionID
+	codeIndex map[string]map[string]string
 }
 
 // NewLoginSessionHandler ...
-func NewLoginSessionHandler() *LoginSessionHandler {
-	return &LoginSessionHandler{}
+func NewLoginSessionHandler(store *Store) *LoginSessionHandler {
+	res := &LoginSessionHandler{
+		store:       store,
+		sessionList: make(map[string]map[string]*model.LoginSession),
+		codeIndex:   make(map[string]map[string]string),
+	}
+	store.register(res)
+	return res
 }
 
 // Add ...
 func (h *LoginSessionHandler) Add(projectName string, ent *model.LoginSession) *errors.Error {
-	h.sessionList = append(h.sessionList, ent)
+	h.store.write(func() {
+		h.put(projectName, cloneLoginSession(ent))
+	})
 	return nil
 }
 
 // Update ...
 func (h *LoginSessionHandler) Update(projectName string, ent *model.LoginSession) *errors.Error {
-	for i, s := range h.sessionList {
-		if s.ProjectName == projectName && s.SessionID == ent.SessionID {
-			h.sessionList[i] = ent
-			return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.sessionList[projectName][ent.SessionID]; !exists {
+			err = model.ErrNoSuchLoginSession
+			return
 		}
-	}
-	return model.ErrNoSuchLoginSession
+		h.put(projectName, cloneLoginSession(ent))
+	})
+	return err
 }
 
-// Delete ...
+// Delete deletes the login sessions which match any of the filter rules
 func (h *LoginSessionHandler) Delete(projectName string, filter *model.LoginSessionFilter) *errors.Error {
-	newList := missMatchFilterLoginSessionList(h.sessionList, projectName, filter)
+	if filter == nil {
+		return nil
+	}
 
-	h.sessionList = newList
+	h.store.write(func() {
+		if filter.UserID == "" && filter.ClientID == "" {
+			// only session id is specified
+			h.del(projectName, filter.SessionID)
+			return
+		}
+
+		for id, s := range h.sessionList[projectName] {
+			if matchAnyLoginSessionFilter(s, filter) {
+				h.del(projectName, id)
+			}
+		}
+	})
 	return nil
 }
 
 // GetByCode ...
 func (h *LoginSessionHandler) GetByCode(projectName string, code string) (*model.LoginSession, *errors.Error) {
-	for _, s := range h.sessionList {
-		if s.ProjectName == projectName && s.Code == code {
-			return s, nil
+	var res *model.LoginSession
+	h.store.read(func() {
+		if id, exists := h.codeIndex[projectName][code]; exists {
+			res = cloneLoginSession(h.sessionList[projectName][id])
 		}
-	}
+	})
 
-	return nil, model.ErrNoSuchLoginSession
+	if res == nil {
+		return nil, model.ErrNoSuchLoginSession
+	}
+	return res, nil
 }
 
 // Get ...
 func (h *LoginSessionHandler) Get(projectName string, id string) (*model.LoginSession, *errors.Error) {
-	for _, s := range h.sessionList {
-		if s.ProjectName == projectName && s.SessionID == id {
-			return s, nil
+	var res *model.LoginSession
+	h.store.read(func() {
+		if s, exists := h.sessionList[projectName][id]; exists {
+			res = cloneLoginSession(s)
 		}
-	}
+	})
 
-	return nil, model.ErrNoSuchLoginSession
+	if res == nil {
+		return nil, model.ErrNoSuchLoginSession
+	}
+	return res, nil
 }
 
 // DeleteAll ...
 func (h *LoginSessionHandler) DeleteAll(projectName string) *errors.Error {
-	newList := []*model.LoginSession{}
-	for _, s := range h.sessionList {
-		if s.ProjectName != projectName {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for id := range h.sessionList[projectName] {
+			h.del(projectName, id)
 		}
-	}
-
-	h.sessionList = newList
+	})
 	return nil
 }
 
 // Cleanup ...
 func (h *LoginSessionHandler) Cleanup(now time.Time) *errors.Error {
-	newList := []*model.LoginSession{}
-	for _, s := range h.sessionList {
-		if now.Before(s.ExpiresDate) {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for projectName, sessions := range h.sessionList {
+			for id, s := range sessions {
+				if !now.Before(s.ExpiresDate) {
+					h.del(projectName, id)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// put must be called in store.write
+func (h *LoginSessionHandler) put(projectName string, ent *model.LoginSession) {
+	old := h.sessionList[projectName][ent.SessionID]
+	h.set(projectName, ent)
+
+	id := ent.SessionID
+	h.store.onRollback(func() {
+		if old == nil {
+			h.unset(projectName, id)
+		} else {
+			h.set(projectName, old)
 		}
+	})
+}
+
+// del must be called in store.write
+func (h *LoginSessionHandler) del(projectName string, sessionID string) {
+	old, exists := h.sessionList[projectName][sessionID]
+	if !exists {
+		return
 	}
+	h.unset(projectName, sessionID)
 
-	h.sessionList = newList
-	return nil
+	h.store.onRollback(func() {
+		h.set(projectName, old)
+	})
 }
 
-func missMatchFilterLoginSessionList(data []*model.LoginSession, projectName string, filter *model.LoginSessionFilter) []*model.LoginSession {
-	if filter == nil {
-		return data
+func (h *LoginSessionHandler) set(projectName string, ent *model.LoginSession) {
+	h.unset(projectName, ent.SessionID)
+
+	if _, exists := h.sessionList[projectName]; !exists {
+		h.sessionList[projectName] = make(map[string]*model.LoginSession)
+		h.codeIndex[projectName] = make(map[string]string)
 	}
-	res := []*model.LoginSession{}
+	h.sessionList[projectName][ent.SessionID] = ent
+	// code is set after the user is authenticated
+	if ent.Code != "" {
+		h.codeIndex[projectName][ent.Code] = ent.SessionID
+	}
+}
 
-	for _, s := range data {
-		if projectName == s.ProjectName {
-			if filter.SessionID != "" && s.SessionID == filter.SessionID {
-				// matched to session id
-				continue
-			}
-			if filter.UserID != "" && s.UserID == filter.UserID {
-				// matched to user id
-				continue
-			}
-			if filter.ClientID != "" && s.ClientID == filter.ClientID {
-				// matched to client id
-				continue
-			}
-		}
-		res = append(res, s)
+func (h *LoginSessionHandler) unset(projectName string, sessionID string) {
+	old, exists := h.sessionList[projectName][sessionID]
+	if !exists {
+		return
 	}
 
-	return res
+	if old.Code != "" && h.codeIndex[projectName][old.Code] == sessionID {
+		delete(h.codeIndex[projectName], old.Code)
+	}
+	delete(h.sessionList[projectName], sessionID)
+	if len(h.sessionList[projectName]) == 0 {
+		delete(h.sessionList, projectName)
+		delete(h.codeIndex, projectName)
+	}
+}
+
+func matchAnyLoginSessionFilter(s *model.LoginSession, filter *model.LoginSessionFilter) bool {
+	if filter.SessionID != "" && s.SessionID == filter.SessionID {
+		return true
+	}
+	if filter.UserID != "" && s.UserID == filter.UserID {
+		return true
+	}
+	if filter.ClientID != "" && s.ClientID == filter.ClientID {
+		return true
+	}
+	return false
 }
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// MigrationHandler implement db.MigrationHandler
+type MigrationHandler struct {
+	store *Store
+	// data is shared with the handler used in the transaction
+	data *migrationData
+}
+
+type migrationData struct {
+	version int
+}
+
+// NewMigrationHandler ...
+func NewMigrationHandler(store *Store) *MigrationHandler {
+	res := &MigrationHandler{
+		store: store,
+		data:  &migrationData{},
+	}
+	store.register(res)
+	return res
+}
+
+// Steps ...
+func (h *MigrationHandler) Steps() []*model.MigrationStep {
+	return []*model.MigrationStep{
+		{
+			Version:     1,
+			Description: "initial schema",
+			Up:          func() *errors.Error { return nil },
+			Down:        func() *errors.Error { return nil },
+		},
+	}
+}
+
+// GetVersion ...
+func (h *MigrationHandler) GetVersion() (int, *errors.Error) {
+	var res int
+	h.store.read(func() {
+		res = h.data.version
+	})
+	return res, nil
+}
+
+// SetVersion ...
+func (h *MigrationHandler) SetVersion(version int) *errors.Error {
+	h.store.write(func() {
+		h.data.version = version
+	})
+	return nil
+}
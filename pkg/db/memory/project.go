@@ -7,62 +7,104 @@ import (
 
 // ProjectInfoHandler implement db.ProjectInfoHandler
 type ProjectInfoHandler struct {
+	store *Store
+	// data is shared with the handler used in the transaction
+	data *projectData
+}
+
+// projectData keeps the projects in the order of creation
+type projectData struct {
 	projectList []*model.ProjectInfo
 }
 
 // NewProjectHandler ...
-func NewProjectHandler() *ProjectInfoHandler {
-	return &ProjectInfoHandler{}
+func NewProjectHandler(store *Store) *ProjectInfoHandler {
+	res := &ProjectInfoHandler{
+		store: store,
+		data:  &projectData{},
+	}
+	store.register(res)
+	return res
 }
 
 // Add ...
 func (h *ProjectInfoHandler) Add(ent *model.ProjectInfo) *errors.Error {
-	h.projectList = append(h.projectList, ent)
+	name := ent.Name
+	h.store.write(func() {
+		h.data.projectList = append(h.data.projectList, cloneProject(ent))
+		h.store.onRollback(func() {
+			h.remove(name)
+		})
+	})
 	return nil
 }
 
 // Delete ...
 func (h *ProjectInfoHandler) Delete(name string) *errors.Error {
-	newList := []*model.ProjectInfo{}
-	found := false
-	for _, p := range h.projectList {
-		if p.Name == name {
-			found = true
-		} else {
-			newList = append(newList, p)
+	var err *errors.Error
+	h.store.write(func() {
+		i := h.index(name)
+		if i < 0 {
+			err = errors.New("Internal Error", "No such project %s", name)
+			return
 		}
-	}
 
-	if found {
-		h.projectList = newList
-		return nil
-	}
-	return errors.New("Internal Error", "No such project %s", name)
+		old := h.data.projectList[i]
+		h.remove(name)
+		h.store.onRollback(func() {
+			h.insert(i, old)
+		})
+	})
+	return err
 }
 
 // GetList ...
 func (h *ProjectInfoHandler) GetList(filter *model.ProjectFilter) ([]*model.ProjectInfo, *errors.Error) {
 	res := []*model.ProjectInfo{}
-	for _, prj := range h.projectList {
-		res = append(res, prj)
-	}
-
-	if filter != nil {
-		res = matchFilterProjectList(res, filter)
-	}
-
+	h.store.read(func() {
+		for _, prj := range matchFilterProjectList(h.data.projectList, filter) {
+			res = append(res, cloneProject(prj))
+		}
+	})
 	return res, nil
 }
 
 // Update ...
 func (h *ProjectInfoHandler) Update(ent *model.ProjectInfo) *errors.Error {
-	for i, p := range h.projectList {
-		if p.Name == ent.Name {
-			h.projectList[i] = ent
-			return nil
+	var err *errors.Error
+	h.store.write(func() {
+		i := h.index(ent.Name)
+		if i < 0 {
+			err = errors.New("Internal Error", "No such project %s", ent.Name)
+			return
+		}
+
+		old := h.data.projectList[i]
+		h.data.projectList[i] = cloneProject(ent)
+		h.store.onRollback(func() {
+			h.data.projectList[i] = old
+		})
+	})
+	return err
+}
+
+func (h *ProjectInfoHandler) index(name string) int {
+	for i, p := range h.data.projectList {
+		if p.Name == name {
+			return i
 		}
 	}
-	return errors.New("Internal Error", "No such project %s", ent.Name)
+	return -1
+}
+
+func (h *ProjectInfoHandler) remove(name string) {
+	if i := h.index(name); i >= 0 {
+		h.data.projectList = append(h.data.projectList[:i:i], h.data.projectList[i+1:]...)
+	}
+}
+
+func (h *ProjectInfoHandler) insert(i int, ent *model.ProjectInfo) {
+	h.data.projectList = append(h.data.projectList[:i:i], append([]*model.ProjectInfo{ent}, h.data.projectList[i:]...)...)
 }
 
 func matchFilterProjectList(data []*model.ProjectInfo, filter *model.ProjectFilter) []*model.ProjectInfo {
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// PushedAuthRequestHandler implement db.PushedAuthRequestHandler
+type PushedAuthRequestHandler struct {
+	store *Store
+	// requests[projectName][requestURI] = PushedAuthRequest
+	requests map[string]map[string]*model.PushedAuthRequest
+}
+
+// NewPushedAuthRequestHandler ...
+func NewPushedAuthRequestHandler(store *Store) *PushedAuthRequestHandler {
+	res := &PushedAuthRequestHandler{
+		store:    store,
+		requests: make(map[string]map[string]*model.PushedAuthRequest),
+	}
+	store.register(res)
+	return res
+}
+
+// Add ...
+func (h *PushedAuthRequestHandler) Add(projectName string, ent *model.PushedAuthRequest) *errors.Error {
+	h.store.write(func() {
+		h.put(projectName, clonePushedAuthRequest(ent))
+	})
+	return nil
+}
+
+// DeleteAll ...
+func (h *PushedAuthRequestHandler) DeleteAll(projectName string) *errors.Error {
+	h.store.write(func() {
+		for uri := range h.requests[projectName] {
+			h.del(projectName, uri)
+		}
+	})
+	return nil
+}
+
+// Cleanup ...
+func (h *PushedAuthRequestHandler) Cleanup(now time.Time) *errors.Error {
+	h.store.write(func() {
+		for projectName, requests := range h.requests {
+			for uri, r := range requests {
+				expire := r.CreatedAt.Add(time.Second * time.Duration(r.ExpiresIn))
+				if !now.Before(expire) {
+					h.del(projectName, uri)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// GetList ...
+func (h *PushedAuthRequestHandler) GetList(projectName string, filter *model.PushedAuthRequestFilter) ([]*model.PushedAuthRequest, *errors.Error) {
+	res := []*model.PushedAuthRequest{}
+
+	h.store.read(func() {
+		if filter != nil && filter.RequestURI != "" {
+			if r, exists := h.requests[projectName][filter.RequestURI]; exists {
+				res = append(res, clonePushedAuthRequest(r))
+			}
+			return
+		}
+		for _, r := range h.requests[projectName] {
+			res = append(res, clonePushedAuthRequest(r))
+		}
+	})
+
+	return res, nil
+}
+
+// Delete ...
+func (h *PushedAuthRequestHandler) Delete(projectName string, requestURI string) *errors.Error {
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.requests[projectName][requestURI]; !exists {
+			err = errors.New("Internal Error", "No such pushed authorization request %s", requestURI)
+			return
+		}
+		h.del(projectName, requestURI)
+	})
+	return err
+}
+
+// put must be called in store.write
+func (h *PushedAuthRequestHandler) put(projectName string, ent *model.PushedAuthRequest) {
+	if _, exists := h.requests[projectName]; !exists {
+		h.requests[projectName] = make(map[string]*model.PushedAuthRequest)
+	}
+	old := h.requests[projectName][ent.RequestURI]
+	h.requests[projectName][ent.RequestURI] = ent
+
+	uri := ent.RequestURI
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.requests[projectName], uri)
+		} else {
+			h.requests[projectName][uri] = old
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *PushedAuthRequestHandler) del(projectName string, requestURI string) {
+	old, exists := h.requests[projectName][requestURI]
+	if !exists {
+		return
+	}
+	delete(h.requests[projectName], requestURI)
+
+	h.store.onRollback(func() {
+		h.requests[projectName][requestURI] = old
+	})
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// RevokedTokenHandler implement db.RevokedTokenHandler
+type RevokedTokenHandler struct {
+	store *Store
+	// tokens[projectName][tokenID] = RevokedToken
+	tokens map[string]map[string]*model.RevokedToken
+}
+
+// NewRevokedTokenHandler ...
+func NewRevokedTokenHandler(store *Store) *RevokedTokenHandler {
+	res := &RevokedTokenHandler{
+		store:  store,
+		tokens: make(map[string]map[string]*model.RevokedToken),
+	}
+	store.register(res)
+	return res
+}
+
+// Add ...
+func (h *RevokedTokenHandler) Add(projectName string, ent *model.RevokedToken) *errors.Error {
+	h.store.write(func() {
+		h.put(projectName, cloneRevokedToken(ent))
+	})
+	return nil
+}
+
+// DeleteAll ...
+func (h *RevokedTokenHandler) DeleteAll(projectName string) *errors.Error {
+	h.store.write(func() {
+		for id := range h.tokens[projectName] {
+			h.del(projectName, id)
+		}
+	})
+	return nil
+}
+
+// GetList ...
+func (h *RevokedTokenHandler) GetList(projectName string, filter *model.RevokedTokenFilter) ([]*model.RevokedToken, *errors.Error) {
+	res := []*model.RevokedToken{}
+
+	h.store.read(func() {
+		if filter != nil && filter.TokenID != "" {
+			if t, exists := h.tokens[projectName][filter.TokenID]; exists {
+				res = append(res, cloneRevokedToken(t))
+			}
+			return
+		}
+
+		for _, t := range h.tokens[projectName] {
+			res = append(res, cloneRevokedToken(t))
+		}
+	})
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *RevokedTokenHandler) Cleanup(now time.Time) *errors.Error {
+	h.store.write(func() {
+		for projectName, tokens := range h.tokens {
+			for id, t := range tokens {
+				if !now.Before(t.ExpiresAt) {
+					h.del(projectName, id)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// put must be called in store.write
+func (h *RevokedTokenHandler) put(projectName string, ent *model.RevokedToken) {
+	if _, exists := h.tokens[projectName]; !exists {
+		h.tokens[projectName] = make(map[string]*model.RevokedToken)
+	}
+	old := h.tokens[projectName][ent.TokenID]
+	h.tokens[projectName][ent.TokenID] = ent
+
+	id := ent.TokenID
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.tokens[projectName], id)
+		} else {
+			h.tokens[projectName][id] = old
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *RevokedTokenHandler) del(projectName string, tokenID string) {
+	old, exists := h.tokens[projectName][tokenID]
+	if !exists {
+		return
+	}
+	delete(h.tokens[projectName], tokenID)
+
+	h.store.onRollback(func() {
+		h.tokens[projectName][tokenID] = old
+	})
+}
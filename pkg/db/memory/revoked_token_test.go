@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+func TestRevokedTokenCleanup(t *testing.T) {
+	const project = "master"
+	now := time.Now()
+
+	h := NewRevokedTokenHandler(NewStore())
+	expired := uuid.New().String()
+	valid := uuid.New().String()
+	h.Add(project, &model.RevokedToken{TokenID: expired, ProjectName: project, ExpiresAt: now.Add(-time.Minute)})
+	h.Add(project, &model.RevokedToken{TokenID: valid, ProjectName: project, ExpiresAt: now.Add(time.Minute)})
+
+	if err := h.Cleanup(now); err != nil {
+		t.Fatalf("Failed to cleanup revoked tokens: %v", err)
+	}
+
+	res, _ := h.GetList(project, nil)
+	if len(res) != 1 || res[0].TokenID != valid {
+		t.Errorf("Only the token which is not expired should remain, but got %v", res)
+	}
+}
@@ -9,39 +9,55 @@ import (
 
 // SessionHandler implement db.SessionHandler
 type SessionHandler struct {
-	// sessionList[sessionID] = Session
-	sessionList []*model.Session
+	store *Store
+	// sessionList[projectName][sessionID] = Session
+	sessionList map[string]map[string]*model.Session
+	// userIndex[projectName][userID] = set of sessionID
+	userIndex map[string]map[string]map[string]struct{}
 }
 
 // NewSessionHandler ...
-func NewSessionHandler() *SessionHandler {
-	res := &SessionHandler{}
+func NewSessionHandler(store *Store) *SessionHandler {
+	res := &SessionHandler{
+		store:       store,
+		sessionList: make(map[string]map[string]*model.Session),
+		userIndex:   make(map[string]map[string]map[string]struct{}),
+	}
+	store.register(res)
 	return res
 }
 
 // Add ...
 func (h *SessionHandler) Add(projectName string, ent *model.Session) *errors.Error {
-	h.sessionList = append(h.sessionList, ent)
+	h.store.write(func() {
+		h.put(projectName, cloneSession(ent))
+	})
 	return nil
 }
 
-// Delete ...
+// Delete deletes the sessions which match any of the filter rules
 func (h *SessionHandler) Delete(projectName string, filter *model.SessionFilter) *errors.Error {
-	newList := missMatchFilterSessionList(h.sessionList, projectName, filter)
+	if filter == nil {
+		return nil
+	}
 
-	h.sessionList = newList
+	h.store.write(func() {
+		for _, s := range h.candidates(projectName, filter) {
+			if matchAnySessionFilter(s, filter) {
+				h.del(projectName, s.SessionID)
+			}
+		}
+	})
 	return nil
 }
 
 // DeleteAll ...
 func (h *SessionHandler) DeleteAll(projectName string) *errors.Error {
-	newList := []*model.Session{}
-	for _, s := range h.sessionList {
-		if s.ProjectName != projectName {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for id := range h.sessionList[projectName] {
+			h.del(projectName, id)
 		}
-	}
-	h.sessionList = newList
+	})
 	return nil
 }
 
@@ -49,31 +65,129 @@ func (h *SessionHandler) DeleteAll(projectName string) *errors.Error {
 func (h *SessionHandler) GetList(projectName string, filter *model.SessionFilter) ([]*model.Session, *errors.Error) {
 	res := []*model.Session{}
 
-	for _, s := range h.sessionList {
-		if s.ProjectName == projectName {
-			res = append(res, s)
+	h.store.read(func() {
+		data := []*model.Session{}
+		if filter != nil && filter.SessionID != "" {
+			if s, exists := h.sessionList[projectName][filter.SessionID]; exists {
+				data = append(data, s)
+			}
+		} else if filter != nil && filter.UserID != "" {
+			for id := range h.userIndex[projectName][filter.UserID] {
+				data = append(data, h.sessionList[projectName][id])
+			}
+		} else {
+			for _, s := range h.sessionList[projectName] {
+				data = append(data, s)
+			}
 		}
-	}
 
-	if filter != nil {
-		res = matchFilterSessionList(res, projectName, filter)
-	}
+		for _, s := range matchFilterSessionList(data, projectName, filter) {
+			res = append(res, cloneSession(s))
+		}
+	})
 
 	return res, nil
 }
 
 // Cleanup ...
 func (h *SessionHandler) Cleanup(now time.Time) *errors.Error {
-	newList := []*model.Session{}
-	for _, s := range h.sessionList {
-		expire := s.CreatedAt.Add(time.Second * time.Duration(s.ExpiresIn))
-		if now.Before(expire) {
-			newList = append(newList, s)
+	h.store.write(func() {
+		for projectName, sessions := range h.sessionList {
+			for id, s := range sessions {
+				expire := s.CreatedAt.Add(time.Second * time.Duration(s.ExpiresIn))
+				if !now.Before(expire) {
+					h.del(projectName, id)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// candidates returns the sessions which may match any of the filter rules by the indexes
+// it must be called in store.read or store.write
+func (h *SessionHandler) candidates(projectName string, filter *model.SessionFilter) []*model.Session {
+	res := []*model.Session{}
+	if filter.FamilyID != "" {
+		// family id is not indexed, so check all sessions in the project
+		for _, s := range h.sessionList[projectName] {
+			res = append(res, s)
 		}
+		return res
 	}
 
-	h.sessionList = newList
-	return nil
+	if filter.SessionID != "" {
+		if s, exists := h.sessionList[projectName][filter.SessionID]; exists {
+			res = append(res, s)
+		}
+	}
+	if filter.UserID != "" {
+		for id := range h.userIndex[projectName][filter.UserID] {
+			if id != filter.SessionID {
+				res = append(res, h.sessionList[projectName][id])
+			}
+		}
+	}
+	return res
+}
+
+// put must be called in store.write
+func (h *SessionHandler) put(projectName string, ent *model.Session) {
+	old := h.sessionList[projectName][ent.SessionID]
+	h.set(projectName, ent)
+
+	id := ent.SessionID
+	h.store.onRollback(func() {
+		if old == nil {
+			h.unset(projectName, id)
+		} else {
+			h.set(projectName, old)
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *SessionHandler) del(projectName string, sessionID string) {
+	old, exists := h.sessionList[projectName][sessionID]
+	if !exists {
+		return
+	}
+	h.unset(projectName, sessionID)
+
+	h.store.onRollback(func() {
+		h.set(projectName, old)
+	})
+}
+
+func (h *SessionHandler) set(projectName string, ent *model.Session) {
+	h.unset(projectName, ent.SessionID)
+
+	if _, exists := h.sessionList[projectName]; !exists {
+		h.sessionList[projectName] = make(map[string]*model.Session)
+		h.userIndex[projectName] = make(map[string]map[string]struct{})
+	}
+	h.sessionList[projectName][ent.SessionID] = ent
+	if _, exists := h.userIndex[projectName][ent.UserID]; !exists {
+		h.userIndex[projectName][ent.UserID] = make(map[string]struct{})
+	}
+	h.userIndex[projectName][ent.UserID][ent.SessionID] = struct{}{}
+}
+
+func (h *SessionHandler) unset(projectName string, sessionID string) {
+	old, exists := h.sessionList[projectName][sessionID]
+	if !exists {
+		return
+	}
+
+	delete(h.userIndex[projectName][old.UserID], sessionID)
+	if len(h.userIndex[projectName][old.UserID]) == 0 {
+		delete(h.userIndex[projectName], old.UserID)
+	}
+	delete(h.sessionList[projectName], sessionID)
+	if len(h.sessionList[projectName]) == 0 {
+		delete(h.sessionList, projectName)
+		delete(h.userIndex, projectName)
+	}
 }
 
 func matchFilterSessionList(data []*model.Session, projectName string, filter *model.SessionFilter) []*model.Session {
@@ -92,6 +206,10 @@ func matchFilterSessionList(data []*model.Session, projectName string, filter *m
 				// missmatch user id
 				continue
 			}
+			if filter.FamilyID != "" && s.FamilyID != filter.FamilyID {
+				// missmatch family id
+				continue
+			}
 		}
 		res = append(res, s)
 	}
@@ -99,25 +217,15 @@ func matchFilterSessionList(data []*model.Session, projectName string, filter *m
 	return res
 }
 
-func missMatchFilterSessionList(data []*model.Session, projectName string, filter *model.SessionFilter) []*model.Session {
-	if filter == nil {
-		return data
+func matchAnySessionFilter(s *model.Session, filter *model.SessionFilter) bool {
+	if filter.SessionID != "" && s.SessionID == filter.SessionID {
+		return true
 	}
-	res := []*model.Session{}
-
-	for _, s := range data {
-		if projectName == s.ProjectName {
-			if filter.SessionID != "" && s.SessionID == filter.SessionID {
-				// matched to session id
-				continue
-			}
-			if filter.UserID != "" && s.UserID == filter.UserID {
-				// matched to user id
-				continue
-			}
-		}
-		res = append(res, s)
+	if filter.UserID != "" && s.UserID == filter.UserID {
+		return true
 	}
-
-	return res
+	if filter.FamilyID != "" && s.FamilyID == filter.FamilyID {
+		return true
+	}
+	return false
 }
@@ -0,0 +1,340 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
+)
+
+var (
+	// snapshotPrefix is a marker of the plain snapshot file
+	snapshotPrefix = []byte("hekate-snapshot-v1:")
+	// encryptedSnapshotPrefix is a marker of the snapshot file encrypted by the key
+	encryptedSnapshotPrefix = []byte("hekate-snapshot-enc-v1:")
+)
+
+// snapshotHandler is a handler whose data is saved in the snapshot
+// dump and restore must be called in store.read and store.write
+type snapshotHandler interface {
+	dump(data *snapshotData)
+	restore(data *snapshotData)
+}
+
+// snapshotData is a format of all data in the snapshot file
+type snapshotData struct {
+	SchemaVersion int                              `json:"schema_version"`
+	Projects      []*model.ProjectInfo             `json:"projects"`
+	Users         map[string][]*model.UserInfo     `json:"users"`
+	Clients       map[string][]*model.ClientInfo   `json:"clients"`
+	CustomRoles   map[string][]*model.CustomRole   `json:"custom_roles"`
+	Sessions      map[string][]*model.Session      `json:"sessions"`
+	LoginSessions map[string][]*model.LoginSession `json:"login_sessions"`
+	Devices       map[string][]*model.Device       `json:"devices"`
+	RevokedTokens map[string][]*model.RevokedToken `json:"revoked_tokens"`
+
+	PushedAuthRequests map[string][]*model.PushedAuthRequest `json:"pushed_auth_requests"`
+	UsedJTIs           map[string][]*model.UsedJTI           `json:"used_jtis"`
+}
+
+// Snapshot saves all data in the store to the local file, and restores them
+type Snapshot struct {
+	store    *Store
+	fileName string
+	// key encrypts the snapshot by AES-256-GCM, the snapshot is not encrypted if nil
+	key []byte
+	mu  sync.Mutex
+}
+
+// NewSnapshot ...
+func NewSnapshot(store *Store, fileName string, key []byte) *Snapshot {
+	return &Snapshot{
+		store:    store,
+		fileName: fileName,
+		key:      key,
+	}
+}
+
+// Save writes all data to the snapshot file atomically
+// it waits for the running transaction to save the consistent data
+func (s *Snapshot) Save() *errors.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := &snapshotData{}
+	s.store.read(func() {
+		for _, h := range s.store.handlers {
+			h.dump(data)
+		}
+	})
+
+	// the stored entries are never changed in place, so they can be marshaled without lock
+	b, err := json.Marshal(data)
+	if err != nil {
+		return errors.New("Snapshot failed", "Failed to marshal snapshot: %v", err)
+	}
+
+	prefix := snapshotPrefix
+	if s.key != nil {
+		var e *errors.Error
+		if b, e = secret.EncryptByKey(s.key, b); e != nil {
+			return errors.Append(e, "Failed to encrypt snapshot")
+		}
+		prefix = encryptedSnapshotPrefix
+	}
+
+	if err := writeFileAtomic(s.fileName, append(append([]byte{}, prefix...), b...)); err != nil {
+		return errors.Append(err, "Failed to write snapshot file")
+	}
+	logger.Debug("Successfully save snapshot to %s", s.fileName)
+	return nil
+}
+
+// Restore reads the snapshot file and adds all data to the store
+// it does nothing if the snapshot file does not exist
+func (s *Snapshot) Restore() *errors.Error {
+	b, err := ioutil.ReadFile(s.fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Info("Snapshot file %s does not exist, so start with empty data", s.fileName)
+			return nil
+		}
+		return errors.New("Snapshot restore failed", "Failed to read snapshot file: %v", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(b, encryptedSnapshotPrefix):
+		if s.key == nil {
+			return errors.New("Snapshot restore failed", "Snapshot is encrypted, but the key is not set")
+		}
+		var e *errors.Error
+		if b, e = secret.DecryptByKey(s.key, b[len(encryptedSnapshotPrefix):]); e != nil {
+			return errors.Append(e, "Failed to decrypt snapshot")
+		}
+	case bytes.HasPrefix(b, snapshotPrefix):
+		// the snapshot saved before enabling the encryption is encrypted in the next save
+		b = b[len(snapshotPrefix):]
+	default:
+		return errors.New("Snapshot restore failed", "Unknown snapshot format")
+	}
+
+	data := &snapshotData{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return errors.New("Snapshot restore failed", "Failed to unmarshal snapshot: %v", err)
+	}
+
+	s.store.write(func() {
+		for _, h := range s.store.handlers {
+			h.restore(data)
+		}
+	})
+	logger.Info("Successfully restore %d projects from snapshot %s", len(data.Projects), s.fileName)
+	return nil
+}
+
+// writeFileAtomic writes data to the temporary file and renames it,
+// so that the old file is kept if the process is stopped while writing
+func writeFileAtomic(fileName string, data []byte) *errors.Error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return errors.New("Write file failed", "Failed to create temporary file: %v", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.New("Write file failed", "Failed to write temporary file: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.New("Write file failed", "Failed to sync temporary file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.New("Write file failed", "Failed to close temporary file: %v", err)
+	}
+	if err := os.Rename(tmpName, fileName); err != nil {
+		return errors.New("Write file failed", "Failed to rename temporary file: %v", err)
+	}
+	return nil
+}
+
+func (h *ProjectInfoHandler) dump(data *snapshotData) {
+	data.Projects = append([]*model.ProjectInfo{}, h.data.projectList...)
+}
+
+func (h *ProjectInfoHandler) restore(data *snapshotData) {
+	for _, prj := range data.Projects {
+		if h.index(prj.Name) < 0 {
+			h.data.projectList = append(h.data.projectList, prj)
+		}
+	}
+}
+
+func (h *UserInfoHandler) dump(data *snapshotData) {
+	data.Users = make(map[string][]*model.UserInfo)
+	for prj, users := range h.userList {
+		for _, u := range users {
+			data.Users[prj] = append(data.Users[prj], u)
+		}
+	}
+}
+
+func (h *UserInfoHandler) restore(data *snapshotData) {
+	for prj, users := range data.Users {
+		for _, u := range users {
+			h.put(prj, u)
+		}
+	}
+}
+
+func (h *ClientInfoHandler) dump(data *snapshotData) {
+	data.Clients = make(map[string][]*model.ClientInfo)
+	for prj, clients := range h.clientList {
+		for _, c := range clients {
+			data.Clients[prj] = append(data.Clients[prj], c)
+		}
+	}
+}
+
+func (h *ClientInfoHandler) restore(data *snapshotData) {
+	for prj, clients := range data.Clients {
+		for _, c := range clients {
+			h.put(prj, c)
+		}
+	}
+}
+
+func (h *CustomRoleHandler) dump(data *snapshotData) {
+	data.CustomRoles = make(map[string][]*model.CustomRole)
+	for prj, roles := range h.roleList {
+		for _, r := range roles {
+			data.CustomRoles[prj] = append(data.CustomRoles[prj], r)
+		}
+	}
+}
+
+func (h *CustomRoleHandler) restore(data *snapshotData) {
+	for prj, roles := range data.CustomRoles {
+		for _, r := range roles {
+			h.put(prj, r)
+		}
+	}
+}
+
+func (h *SessionHandler) dump(data *snapshotData) {
+	data.Sessions = make(map[string][]*model.Session)
+	for prj, sessions := range h.sessionList {
+		for _, s := range sessions {
+			data.Sessions[prj] = append(data.Sessions[prj], s)
+		}
+	}
+}
+
+func (h *SessionHandler) restore(data *snapshotData) {
+	for prj, sessions := range data.Sessions {
+		for _, s := range sessions {
+			h.put(prj, s)
+		}
+	}
+}
+
+func (h *LoginSessionHandler) dump(data *snapshotData) {
+	data.LoginSessions = make(map[string][]*model.LoginSession)
+	for prj, sessions := range h.sessionList {
+		for _, s := range sessions {
+			data.LoginSessions[prj] = append(data.LoginSessions[prj], s)
+		}
+	}
+}
+
+func (h *LoginSessionHandler) restore(data *snapshotData) {
+	for prj, sessions := range data.LoginSessions {
+		for _, s := range sessions {
+			h.put(prj, s)
+		}
+	}
+}
+
+func (h *DeviceHandler) dump(data *snapshotData) {
+	data.Devices = make(map[string][]*model.Device)
+	for prj, devices := range h.devices {
+		for _, d := range devices {
+			data.Devices[prj] = append(data.Devices[prj], d)
+		}
+	}
+}
+
+func (h *DeviceHandler) restore(data *snapshotData) {
+	for prj, devices := range data.Devices {
+		for _, d := range devices {
+			h.put(prj, d)
+		}
+	}
+}
+
+func (h *RevokedTokenHandler) dump(data *snapshotData) {
+	data.RevokedTokens = make(map[string][]*model.RevokedToken)
+	for prj, tokens := range h.tokens {
+		for _, t := range tokens {
+			data.RevokedTokens[prj] = append(data.RevokedTokens[prj], t)
+		}
+	}
+}
+
+func (h *RevokedTokenHandler) restore(data *snapshotData) {
+	for prj, tokens := range data.RevokedTokens {
+		for _, t := range tokens {
+			h.put(prj, t)
+		}
+	}
+}
+
+func (h *PushedAuthRequestHandler) dump(data *snapshotData) {
+	data.PushedAuthRequests = make(map[string][]*model.PushedAuthRequest)
+	for prj, requests := range h.requests {
+		for _, r := range requests {
+			data.PushedAuthRequests[prj] = append(data.PushedAuthRequests[prj], r)
+		}
+	}
+}
+
+func (h *PushedAuthRequestHandler) restore(data *snapshotData) {
+	for prj, requests := range data.PushedAuthRequests {
+		for _, r := range requests {
+			h.put(prj, r)
+		}
+	}
+}
+
+func (h *UsedJTIHandler) dump(data *snapshotData) {
+	data.UsedJTIs = make(map[string][]*model.UsedJTI)
+	for prj, jtis := range h.jtis {
+		for _, j := range jtis {
+			data.UsedJTIs[prj] = append(data.UsedJTIs[prj], j)
+		}
+	}
+}
+
+func (h *UsedJTIHandler) restore(data *snapshotData) {
+	for prj, jtis := range data.UsedJTIs {
+		for _, j := range jtis {
+			h.put(prj, j)
+		}
+	}
+}
+
+func (h *MigrationHandler) dump(data *snapshotData) {
+	data.SchemaVersion = h.data.version
+}
+
+func (h *MigrationHandler) restore(data *snapshotData) {
+	h.data.version = data.SchemaVersion
+}
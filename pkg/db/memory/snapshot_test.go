@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+type testHandlers struct {
+	project   *ProjectInfoHandler
+	user      *UserInfoHandler
+	client    *ClientInfoHandler
+	session   *SessionHandler
+	migration *MigrationHandler
+}
+
+func newTestHandlers(store *Store) *testHandlers {
+	return &testHandlers{
+		project:   NewProjectHandler(store),
+		user:      NewUserHandler(store),
+		client:    NewClientHandler(store),
+		session:   NewSessionHandler(store),
+		migration: NewMigrationHandler(store),
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	const project = "master"
+
+	dir, err := ioutil.TempDir("", "hekate-snapshot")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := bytes.Repeat([]byte{1}, 32)
+	tt := []struct {
+		Name       string
+		SaveKey    []byte
+		RestoreKey []byte
+		ExpectErr  bool
+	}{
+		{"plain snapshot", nil, nil, false},
+		{"encrypted snapshot", key, key, false},
+		{"enable encryption after save", nil, key, false},
+		{"encrypted snapshot without key", key, nil, true},
+		{"encrypted snapshot with wrong key", key, bytes.Repeat([]byte{2}, 32), true},
+	}
+
+	for i, tc := range tt {
+		fileName := filepath.Join(dir, fmt.Sprintf("snapshot%d", i))
+
+		store := NewStore()
+		h := newTestHandlers(store)
+		h.project.Add(&model.ProjectInfo{Name: project, TokenConfig: &model.TokenConfig{
+			SignKeys: []*model.SignKey{{KeyID: "key1", PrivateKey: []byte("private")}},
+		}})
+		h.user.Add(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "admin", SystemRoles: []string{"read-cluster"}})
+		h.client.Add(project, &model.ClientInfo{ID: "client1", ProjectName: project})
+		h.session.Add(project, &model.Session{SessionID: "s1", ProjectName: project, UserID: "user1"})
+		h.migration.SetVersion(1)
+
+		if err := NewSnapshot(store, fileName, tc.SaveKey).Save(); err != nil {
+			t.Fatalf("Test %s: Failed to save snapshot: %v", tc.Name, err)
+		}
+
+		newStore := NewStore()
+		res := newTestHandlers(newStore)
+		err := NewSnapshot(newStore, fileName, tc.RestoreKey).Restore()
+		if tc.ExpectErr {
+			if err == nil {
+				t.Errorf("Test %s: Restore should return error, but got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %s: Failed to restore snapshot: %v", tc.Name, err)
+		}
+
+		prjs, _ := res.project.GetList(nil)
+		if len(prjs) != 1 || string(prjs[0].TokenConfig.SignKeys[0].PrivateKey) != "private" {
+			t.Errorf("Test %s: Project is not restored: %v", tc.Name, prjs)
+		}
+		users, _ := res.user.GetList(project, &model.UserFilter{Name: "admin"})
+		if len(users) != 1 || len(users[0].SystemRoles) != 1 {
+			t.Errorf("Test %s: User is not restored: %v", tc.Name, users)
+		}
+		if clients, _ := res.client.GetList(project, nil); len(clients) != 1 {
+			t.Errorf("Test %s: Client is not restored: %v", tc.Name, clients)
+		}
+		sessions, _ := res.session.GetList(project, &model.SessionFilter{UserID: "user1"})
+		if len(sessions) != 1 {
+			t.Errorf("Test %s: Session is not restored: %v", tc.Name, sessions)
+		}
+		if v, _ := res.migration.GetVersion(); v != 1 {
+			t.Errorf("Test %s: Schema version is not restored, expect 1, but got %d", tc.Name, v)
+		}
+	}
+}
+
+func TestSnapshotRestoreNoFile(t *testing.T) {
+	store := NewStore()
+	h := newTestHandlers(store)
+	if err := NewSnapshot(store, filepath.Join(os.TempDir(), "hekate-no-such-snapshot"), nil).Restore(); err != nil {
+		t.Errorf("Restore should ignore the missing file, but got %v", err)
+	}
+	if prjs, _ := h.project.GetList(nil); len(prjs) != 0 {
+		t.Errorf("Store should be empty, but got %v", prjs)
+	}
+}
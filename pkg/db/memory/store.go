@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"sync"
+)
+
+// Store is a shared state of all memory handlers
+// each handler locks mu while it accesses its data, and registers the function to revert the change
+// by onRollback so that TransactionManager can cancel the changes in the failed transaction
+// transactions are serialized by txMu, and reads outside a transaction wait for the running transaction
+// not to see the changes which may be reverted, so db.Manager must change the data only in a transaction
+// and read the data in the transaction by the handlers returned by InTx
+type Store struct {
+	*storeState
+
+	// inTxView is true in the view of the store used by the handlers in the transaction
+	inTxView bool
+}
+
+// storeState is shared by the store and its view
+type storeState struct {
+	mu   sync.RWMutex
+	txMu sync.RWMutex
+
+	// handlers are saved to and restored from the snapshot
+	handlers []snapshotHandler
+
+	// the following values are protected by mu
+	inTx bool
+	undo []func()
+}
+
+// NewStore ...
+func NewStore() *Store {
+	return &Store{storeState: &storeState{}}
+}
+
+// txView returns the view of the store whose reads are not blocked by the running transaction
+func (s *Store) txView() *Store {
+	return &Store{storeState: s.storeState, inTxView: true}
+}
+
+// register adds the handler to the target of the snapshot
+func (s *Store) register(h snapshotHandler) {
+	s.handlers = append(s.handlers, h)
+}
+
+func (s *Store) read(f func()) {
+	if !s.inTxView {
+		s.txMu.RLock()
+		defer s.txMu.RUnlock()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f()
+}
+
+func (s *Store) write(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+}
+
+// onRollback registers f which reverts the change in the transaction
+// it must be called in write
+func (s *Store) onRollback(f func()) {
+	if s.inTx {
+		s.undo = append(s.undo, f)
+	}
+}
+
+func (s *Store) begin() {
+	s.txMu.Lock()
+	s.write(func() {
+		s.inTx = true
+		s.undo = nil
+	})
+}
+
+func (s *Store) end(commit bool) {
+	s.write(func() {
+		if !commit {
+			// revert the changes in reverse order
+			for i := len(s.undo) - 1; i >= 0; i-- {
+				s.undo[i]()
+			}
+		}
+		s.inTx = false
+		s.undo = nil
+	})
+	s.txMu.Unlock()
+}
@@ -1,25 +1,114 @@
 package memory
 
 import (
-	"sync"
-
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 )
 
 // TransactionManager ...
 type TransactionManager struct {
-	mu sync.Mutex
+	store *Store
 }
 
 // NewTransactionManager ...
-func NewTransactionManager() *TransactionManager {
-	return &TransactionManager{}
+func NewTransactionManager(store *Store) *TransactionManager {
+	return &TransactionManager{
+		store: store,
+	}
+}
+
+// Transaction runs txFunc exclusively with other transactions
+// all changes in txFunc are reverted if it returns an error or panics
+// the handlers of memory database join the transaction by themselves, so tx is always nil
+func (m *TransactionManager) Transaction(txFunc func(tx model.Tx) *errors.Error) (err *errors.Error) {
+	m.store.begin()
+	committed := false
+	defer func() {
+		m.store.end(committed)
+	}()
+
+	err = txFunc(nil)
+	committed = err == nil
+	return err
+}
+
+// The handlers returned by InTx share the data with the original handler,
+// and their reads are not blocked by the running transaction
+// they must be used only in the transaction
+
+// InTx returns the handler which is used in the transaction
+func (h *ProjectInfoHandler) InTx() *ProjectInfoHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *UserInfoHandler) InTx() *UserInfoHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *SessionHandler) InTx() *SessionHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *ClientInfoHandler) InTx() *ClientInfoHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *CustomRoleHandler) InTx() *CustomRoleHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *LoginSessionHandler) InTx() *LoginSessionHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *DeviceHandler) InTx() *DeviceHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *RevokedTokenHandler) InTx() *RevokedTokenHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
 }
 
-// Transaction ...
-func (m *TransactionManager) Transaction(txFunc func() *errors.Error) *errors.Error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// InTx returns the handler which is used in the transaction
+func (h *MigrationHandler) InTx() *MigrationHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
+
+// InTx returns the handler which is used in the transaction
+func (h *PushedAuthRequestHandler) InTx() *PushedAuthRequestHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
+}
 
-	return txFunc()
+// InTx returns the handler which is used in the transaction
+func (h *UsedJTIHandler) InTx() *UsedJTIHandler {
+	res := *h
+	res.store = h.store.txView()
+	return &res
 }
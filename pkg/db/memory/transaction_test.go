@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	const project = "master"
+
+	store := NewStore()
+	users := NewUserHandler(store)
+	sessions := NewSessionHandler(store)
+	tx := NewTransactionManager(store)
+
+	users.Add(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "admin"})
+	sessions.Add(project, &model.Session{SessionID: "s1", ProjectName: project, UserID: "user1"})
+
+	err := tx.Transaction(func(model.Tx) *errors.Error {
+		users.Update(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "renamed"})
+		users.AddRole(project, "user1", model.RoleSystem, "read-cluster")
+		users.Add(project, &model.UserInfo{ID: "user2", ProjectName: project, Name: "admin2"})
+		sessions.Delete(project, &model.SessionFilter{UserID: "user1"})
+		return errors.New("", "test error")
+	})
+	if err == nil {
+		t.Fatalf("Transaction should return the error of txFunc")
+	}
+
+	res, _ := users.GetList(project, &model.UserFilter{Name: "admin"})
+	if len(res) != 1 || len(res[0].SystemRoles) != 0 {
+		t.Errorf("User is not rolled back: %v", res)
+	}
+	if res, _ := users.GetList(project, &model.UserFilter{Name: "renamed"}); len(res) != 0 {
+		t.Errorf("Name index is not rolled back: %v", res)
+	}
+	if res, _ := users.GetList(project, nil); len(res) != 1 {
+		t.Errorf("Added user is not rolled back: %v", res)
+	}
+	if res, _ := sessions.GetList(project, &model.SessionFilter{UserID: "user1"}); len(res) != 1 {
+		t.Errorf("Deleted session is not rolled back: %v", res)
+	}
+
+	err = tx.Transaction(func(model.Tx) *errors.Error {
+		return users.Update(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "renamed"})
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+	if res, _ := users.GetList(project, &model.UserFilter{Name: "renamed"}); len(res) != 1 {
+		t.Errorf("Committed change is not found: %v", res)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	const project = "master"
+
+	store := NewStore()
+	users := NewUserHandler(store)
+	tx := NewTransactionManager(store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("user%d", i)
+			tx.Transaction(func(model.Tx) *errors.Error {
+				return users.Add(project, &model.UserInfo{ID: id, ProjectName: project, Name: id})
+			})
+			for j := 0; j < 10; j++ {
+				res, _ := users.GetList(project, &model.UserFilter{ID: id})
+				if len(res) != 1 {
+					t.Errorf("Failed to get user %s", id)
+					return
+				}
+				// changing the result must not affect the stored data
+				res[0].SystemRoles = append(res[0].SystemRoles, "read-cluster")
+				users.AddRole(project, id, model.RoleCustom, fmt.Sprintf("role%d", j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	res, _ := users.GetList(project, nil)
+	if len(res) != 10 {
+		t.Errorf("Expect 10 users, but got %d", len(res))
+	}
+	for _, u := range res {
+		if len(u.SystemRoles) != 0 || len(u.CustomRoles) != 10 {
+			t.Errorf("User %s has wrong roles: %v, %v", u.ID, u.SystemRoles, u.CustomRoles)
+		}
+	}
+}
+
+func TestReadIsolation(t *testing.T) {
+	const project = "master"
+
+	store := NewStore()
+	users := NewUserHandler(store)
+	tx := NewTransactionManager(store)
+
+	users.Add(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "admin"})
+
+	written := make(chan struct{})
+	go tx.Transaction(func(model.Tx) *errors.Error {
+		users.Update(project, &model.UserInfo{ID: "user1", ProjectName: project, Name: "renamed"})
+		// the read in the transaction sees the change
+		if res, _ := users.InTx().GetList(project, &model.UserFilter{Name: "renamed"}); len(res) != 1 {
+			t.Errorf("Change in the transaction is not found: %v", res)
+		}
+		close(written)
+		// give the time to read the uncommitted change outside the transaction
+		time.Sleep(100 * time.Millisecond)
+		return errors.New("", "test error")
+	})
+
+	<-written
+	if res, _ := users.GetList(project, &model.UserFilter{Name: "renamed"}); len(res) != 0 {
+		t.Errorf("Read outside the transaction sees the uncommitted change: %v", res)
+	}
+}
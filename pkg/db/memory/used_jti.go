@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// UsedJTIHandler implement db.UsedJTIHandler
+type UsedJTIHandler struct {
+	store *Store
+	// jtis[projectName][issuer + " " + jti] = UsedJTI
+	jtis map[string]map[string]*model.UsedJTI
+}
+
+// NewUsedJTIHandler ...
+func NewUsedJTIHandler(store *Store) *UsedJTIHandler {
+	res := &UsedJTIHandler{
+		store: store,
+		jtis:  make(map[string]map[string]*model.UsedJTI),
+	}
+	store.register(res)
+	return res
+}
+
+// Add ...
+func (h *UsedJTIHandler) Add(projectName string, ent *model.UsedJTI) *errors.Error {
+	h.store.write(func() {
+		h.put(projectName, cloneUsedJTI(ent))
+	})
+	return nil
+}
+
+// DeleteAll ...
+func (h *UsedJTIHandler) DeleteAll(projectName string) *errors.Error {
+	h.store.write(func() {
+		for key := range h.jtis[projectName] {
+			h.del(projectName, key)
+		}
+	})
+	return nil
+}
+
+// GetList ...
+func (h *UsedJTIHandler) GetList(projectName string, filter *model.UsedJTIFilter) ([]*model.UsedJTI, *errors.Error) {
+	res := []*model.UsedJTI{}
+
+	h.store.read(func() {
+		for _, j := range h.jtis[projectName] {
+			if filter != nil {
+				if filter.JTI != "" && filter.JTI != j.JTI {
+					continue
+				}
+				if filter.Issuer != "" && filter.Issuer != j.Issuer {
+					continue
+				}
+			}
+			res = append(res, cloneUsedJTI(j))
+		}
+	})
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *UsedJTIHandler) Cleanup(now time.Time) *errors.Error {
+	h.store.write(func() {
+		for projectName, jtis := range h.jtis {
+			for key, j := range jtis {
+				if !now.Before(j.ExpiresAt) {
+					h.del(projectName, key)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func usedJTIKey(ent *model.UsedJTI) string {
+	return ent.Issuer + " " + ent.JTI
+}
+
+// put must be called in store.write
+func (h *UsedJTIHandler) put(projectName string, ent *model.UsedJTI) {
+	if _, exists := h.jtis[projectName]; !exists {
+		h.jtis[projectName] = make(map[string]*model.UsedJTI)
+	}
+	key := usedJTIKey(ent)
+	old := h.jtis[projectName][key]
+	h.jtis[projectName][key] = ent
+
+	h.store.onRollback(func() {
+		if old == nil {
+			delete(h.jtis[projectName], key)
+		} else {
+			h.jtis[projectName][key] = old
+		}
+	})
+}
+
+// del must be called in store.write
+func (h *UsedJTIHandler) del(projectName string, key string) {
+	old, exists := h.jtis[projectName][key]
+	if !exists {
+		return
+	}
+	delete(h.jtis[projectName], key)
+
+	h.store.onRollback(func() {
+		h.jtis[projectName][key] = old
+	})
+}
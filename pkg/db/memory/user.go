@@ -7,162 +7,239 @@ import (
 
 // UserInfoHandler implement db.UserInfoHandler
 type UserInfoHandler struct {
-	// userList[userID] = UserInfo
-	userList map[string]*model.UserInfo
+	store *Store
+	// userList[projectName][userID] = UserInfo
+	userList map[string]map[string]*model.UserInfo
+	// nameIndex[projectName][userName] = userID
+	nameIndex map[string]map[string]string
 }
 
 // NewUserHandler ...
-func NewUserHandler() *UserInfoHandler {
+func NewUserHandler(store *Store) *UserInfoHandler {
 	res := &UserInfoHandler{
-		userList: make(map[string]*model.UserInfo),
+		store:     store,
+		userList:  make(map[string]map[string]*model.UserInfo),
+		nameIndex: make(map[string]map[string]string),
 	}
+	store.register(res)
 	return res
 }
 
 // Add ...
 func (h *UserInfoHandler) Add(projectName string, ent *model.UserInfo) *errors.Error {
-	h.userList[ent.ID] = ent
+	h.store.write(func() {
+		h.put(projectName, cloneUser(ent))
+	})
 	return nil
 }
 
 // Delete ...
 func (h *UserInfoHandler) Delete(projectName string, userID string) *errors.Error {
-	if res, exists := h.userList[userID]; exists {
-		if res.ProjectName == projectName {
-			delete(h.userList, userID)
-			return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.userList[projectName][userID]; !exists {
+			err = model.ErrNoSuchUser
+			return
 		}
-	}
-	return model.ErrNoSuchUser
+		h.del(projectName, userID)
+	})
+	return err
 }
 
 // GetList ...
 func (h *UserInfoHandler) GetList(projectName string, filter *model.UserFilter) ([]*model.UserInfo, *errors.Error) {
 	res := []*model.UserInfo{}
 
-	for _, user := range h.userList {
-		if user.ProjectName == projectName {
-			res = append(res, user)
+	h.store.read(func() {
+		users := h.userList[projectName]
+		data := []*model.UserInfo{}
+		if filter != nil && filter.ID != "" {
+			if user, exists := users[filter.ID]; exists {
+				data = append(data, user)
+			}
+		} else if filter != nil && filter.Name != "" {
+			if user, exists := users[h.nameIndex[projectName][filter.Name]]; exists {
+				data = append(data, user)
+			}
+		} else {
+			for _, user := range users {
+				data = append(data, user)
+			}
 		}
-	}
 
-	if filter != nil {
-		res = matchFilterUserList(res, projectName, filter)
-	}
+		for _, user := range matchFilterUserList(data, projectName, filter) {
+			res = append(res, cloneUser(user))
+		}
+	})
 
 	return res, nil
 }
 
 // Update ...
 func (h *UserInfoHandler) Update(projectName string, ent *model.UserInfo) *errors.Error {
-	if res, exists := h.userList[ent.ID]; !exists || res.ProjectName != projectName {
-		return model.ErrNoSuchUser
-	}
-
-	h.userList[ent.ID] = ent
-
-	return nil
+	var err *errors.Error
+	h.store.write(func() {
+		if _, exists := h.userList[projectName][ent.ID]; !exists {
+			err = model.ErrNoSuchUser
+			return
+		}
+		h.put(projectName, cloneUser(ent))
+	})
+	return err
 }
 
 // DeleteAll ...
 func (h *UserInfoHandler) DeleteAll(projectName string) *errors.Error {
-	for _, user := range h.userList {
-		if user.ProjectName == projectName {
-			delete(h.userList, user.ID)
+	h.store.write(func() {
+		for id := range h.userList[projectName] {
+			h.del(projectName, id)
 		}
-	}
+	})
 	return nil
 }
 
 // AddRole ...
 func (h *UserInfoHandler) AddRole(projectName string, userID string, roleType model.RoleType, roleID string) *errors.Error {
-	if res, exists := h.userList[userID]; !exists || res.ProjectName != projectName {
-		return model.ErrNoSuchUser
-	}
-
-	roles := h.userList[userID].SystemRoles
-	if roleType == model.RoleCustom {
-		roles = h.userList[userID].CustomRoles
-	}
+	var err *errors.Error
+	h.store.write(func() {
+		user, exists := h.userList[projectName][userID]
+		if !exists {
+			err = model.ErrNoSuchUser
+			return
+		}
 
-	for _, r := range roles {
-		if r == roleID {
-			return model.ErrRoleAlreadyAppended
+		roles := user.SystemRoles
+		if roleType == model.RoleCustom {
+			roles = user.CustomRoles
 		}
-	}
 
-	roles = append(roles, roleID)
-	if roleType == model.RoleCustom {
-		h.userList[userID].CustomRoles = roles
-	} else if roleType == model.RoleSystem {
-		h.userList[userID].SystemRoles = roles
-	}
+		for _, r := range roles {
+			if r == roleID {
+				err = model.ErrRoleAlreadyAppended
+				return
+			}
+		}
 
-	return nil
+		// stored entry is not changed in place because it may be restored by rollback
+		newUser := cloneUser(user)
+		if roleType == model.RoleCustom {
+			newUser.CustomRoles = append(newUser.CustomRoles, roleID)
+		} else if roleType == model.RoleSystem {
+			newUser.SystemRoles = append(newUser.SystemRoles, roleID)
+		}
+		h.put(projectName, newUser)
+	})
+	return err
 }
 
 // DeleteRole ....
 func (h *UserInfoHandler) DeleteRole(projectName string, userID string, roleID string) *errors.Error {
-	if res, exists := h.userList[userID]; !exists || res.ProjectName != projectName {
-		return model.ErrNoSuchUser
-	}
+	var err *errors.Error
+	h.store.write(func() {
+		user, exists := h.userList[projectName][userID]
+		if !exists {
+			err = model.ErrNoSuchUser
+			return
+		}
 
-	deleted := false
-	roles := []string{}
-	for _, r := range h.userList[userID].SystemRoles {
-		if r == roleID {
-			deleted = true
+		newUser := cloneUser(user)
+		if roles, deleted := removeString(user.SystemRoles, roleID); deleted {
+			newUser.SystemRoles = roles
+		} else if roles, deleted := removeString(user.CustomRoles, roleID); deleted {
+			newUser.CustomRoles = roles
 		} else {
-			roles = append(roles, r)
+			err = model.ErrNoSuchRoleInUser
+			return
 		}
-	}
+		h.put(projectName, newUser)
+	})
+	return err
+}
 
-	if deleted {
-		h.userList[userID].SystemRoles = roles
-		return nil
-	}
+// DeleteAllCustomRole ...
+func (h *UserInfoHandler) DeleteAllCustomRole(projectName string, roleID string) *errors.Error {
+	h.store.write(func() {
+		for _, user := range h.userList[projectName] {
+			if roles, deleted := removeString(user.CustomRoles, roleID); deleted {
+				newUser := cloneUser(user)
+				newUser.CustomRoles = roles
+				h.put(projectName, newUser)
+			}
+		}
+	})
+	return nil
+}
 
-	deleted = false
-	roles = []string{}
-	for _, r := range h.userList[userID].CustomRoles {
-		if r == roleID {
-			deleted = true
+// put stores the user and updates the indexes
+// it must be called in store.write
+func (h *UserInfoHandler) put(projectName string, ent *model.UserInfo) {
+	old := h.userList[projectName][ent.ID]
+	h.set(projectName, ent)
+
+	id := ent.ID
+	h.store.onRollback(func() {
+		if old == nil {
+			h.unset(projectName, id)
 		} else {
-			roles = append(roles, r)
+			h.set(projectName, old)
 		}
-	}
+	})
+}
 
-	if deleted {
-		h.userList[userID].CustomRoles = roles
-		return nil
+// del removes the user and updates the indexes
+// it must be called in store.write
+func (h *UserInfoHandler) del(projectName string, userID string) {
+	old, exists := h.userList[projectName][userID]
+	if !exists {
+		return
 	}
+	h.unset(projectName, userID)
 
-	return model.ErrNoSuchRoleInUser
+	h.store.onRollback(func() {
+		h.set(projectName, old)
+	})
 }
 
-// DeleteAllCustomRole ...
-func (h *UserInfoHandler) DeleteAllCustomRole(projectName string, roleID string) *errors.Error {
-	for id, user := range h.userList {
-		if user.ProjectName != projectName {
-			continue
-		}
+func (h *UserInfoHandler) set(projectName string, ent *model.UserInfo) {
+	if _, exists := h.userList[projectName]; !exists {
+		h.userList[projectName] = make(map[string]*model.UserInfo)
+		h.nameIndex[projectName] = make(map[string]string)
+	}
 
-		deleted := false
-		roles := []string{}
+	if old, exists := h.userList[projectName][ent.ID]; exists && h.nameIndex[projectName][old.Name] == ent.ID {
+		delete(h.nameIndex[projectName], old.Name)
+	}
+	h.userList[projectName][ent.ID] = ent
+	h.nameIndex[projectName][ent.Name] = ent.ID
+}
 
-		for _, r := range user.CustomRoles {
-			if roleID == r {
-				deleted = true
-			} else {
-				roles = append(roles, r)
-			}
-		}
+func (h *UserInfoHandler) unset(projectName string, userID string) {
+	old, exists := h.userList[projectName][userID]
+	if !exists {
+		return
+	}
+
+	if h.nameIndex[projectName][old.Name] == userID {
+		delete(h.nameIndex[projectName], old.Name)
+	}
+	delete(h.userList[projectName], userID)
+	if len(h.userList[projectName]) == 0 {
+		delete(h.userList, projectName)
+		delete(h.nameIndex, projectName)
+	}
+}
 
-		if deleted {
-			h.userList[id].CustomRoles = roles
+func removeString(data []string, target string) ([]string, bool) {
+	deleted := false
+	res := []string{}
+	for _, v := range data {
+		if v == target {
+			deleted = true
+		} else {
+			res = append(res, v)
 		}
 	}
-	return nil
+	return res, deleted
 }
 
 func matchFilterUserList(data []*model.UserInfo, projectName string, filter *model.UserFilter) []*model.UserInfo {
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+// MigrationStatus is a current state of the schema migration
+type MigrationStatus struct {
+	CurrentVersion int
+	LatestVersion  int
+	Steps          []*model.MigrationStep
+}
+
+// MigrationStatus ...
+func (m *Manager) MigrationStatus() (*MigrationStatus, *errors.Error) {
+	current, err := m.migration.GetVersion()
+	if err != nil {
+		return nil, errors.Append(err, "Failed to get schema version")
+	}
+
+	steps := m.migration.Steps()
+	return &MigrationStatus{
+		CurrentVersion: current,
+		LatestVersion:  latestVersion(steps),
+		Steps:          steps,
+	}, nil
+}
+
+// CheckSchemaVersion returns ErrSchemaVersionTooNew if the database was migrated by the newer server
+func (m *Manager) CheckSchemaVersion() *errors.Error {
+	st, err := m.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if st.CurrentVersion > st.LatestVersion {
+		return errors.Append(model.ErrSchemaVersionTooNew, "Schema version is %d, but the server supports up to %d", st.CurrentVersion, st.LatestVersion)
+	}
+	return nil
+}
+
+// MigrateUp applies the migration steps up to the target version
+// target 0 means the latest version
+func (m *Manager) MigrateUp(target int) *errors.Error {
+	st, err := m.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if target == 0 {
+		target = st.LatestVersion
+	}
+	if target > st.LatestVersion {
+		return errors.Append(model.ErrNoSuchSchemaVersion, "Target version %d is not defined", target)
+	}
+	if st.CurrentVersion > st.LatestVersion {
+		return errors.Append(model.ErrSchemaVersionTooNew, "Schema version is %d, but the server supports up to %d", st.CurrentVersion, st.LatestVersion)
+	}
+
+	for i, step := range st.Steps {
+		if step.Version <= st.CurrentVersion || step.Version > target {
+			continue
+		}
+
+		logger.Info("Apply migration %d: %s", step.Version, step.Description)
+		err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+			h := m.inTx(tx)
+			// the step must be executed in the transaction
+			if err := h.migration.Steps()[i].Up(); err != nil {
+				return err
+			}
+			return h.migration.SetVersion(step.Version)
+		})
+		if err != nil {
+			return errors.Append(err, "Failed to apply migration %d", step.Version)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts the migration steps until the schema version becomes the target
+// target 0 means reverting all steps
+func (m *Manager) MigrateDown(target int) *errors.Error {
+	st, err := m.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if target < 0 || target > st.LatestVersion {
+		return errors.Append(model.ErrNoSuchSchemaVersion, "Target version %d is not defined", target)
+	}
+	if st.CurrentVersion > st.LatestVersion {
+		return errors.Append(model.ErrSchemaVersionTooNew, "Schema version is %d, but the server supports up to %d", st.CurrentVersion, st.LatestVersion)
+	}
+
+	for i := len(st.Steps) - 1; i >= 0; i-- {
+		step := st.Steps[i]
+		if step.Version > st.CurrentVersion || step.Version <= target {
+			continue
+		}
+
+		// the version before this step
+		prev := 0
+		if i > 0 {
+			prev = st.Steps[i-1].Version
+		}
+
+		logger.Info("Revert migration %d: %s", step.Version, step.Description)
+		err := m.transaction.Transaction(func(tx model.Tx) *errors.Error {
+			h := m.inTx(tx)
+			if err := h.migration.Steps()[i].Down(); err != nil {
+				return err
+			}
+			return h.migration.SetVersion(prev)
+		})
+		if err != nil {
+			return errors.Append(err, "Failed to revert migration %d", step.Version)
+		}
+	}
+	return nil
+}
+
+func latestVersion(steps []*model.MigrationStep) int {
+	res := 0
+	for _, step := range steps {
+		if step.Version > res {
+			res = step.Version
+		}
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/memory"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+type testMigrationHandler struct {
+	*memory.MigrationHandler
+	applied []int
+}
+
+func (h *testMigrationHandler) Steps() []*model.MigrationStep {
+	res := []*model.MigrationStep{}
+	for _, v := range []int{1, 2, 3} {
+		v := v
+		res = append(res, &model.MigrationStep{
+			Version: v,
+			Up: func() *errors.Error {
+				h.applied = append(h.applied, v)
+				return nil
+			},
+			Down: func() *errors.Error {
+				h.applied = h.applied[:len(h.applied)-1]
+				return nil
+			},
+		})
+	}
+	return res
+}
+
+func TestMigrate(t *testing.T) {
+	store := memory.NewStore()
+	h := &testMigrationHandler{MigrationHandler: memory.NewMigrationHandler(store)}
+	mgr := &Manager{
+		transaction: memory.NewTransactionManager(store),
+		migration:   h,
+	}
+
+	if err := mgr.MigrateUp(2); err != nil {
+		t.Fatalf("Failed to migrate up to 2: %v", err)
+	}
+	if v, _ := h.GetVersion(); v != 2 || len(h.applied) != 2 {
+		t.Errorf("Version should be 2, but got %d, applied %v", v, h.applied)
+	}
+
+	if err := mgr.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate up to latest: %v", err)
+	}
+	if v, _ := h.GetVersion(); v != 3 || len(h.applied) != 3 {
+		t.Errorf("Version should be 3, but got %d, applied %v", v, h.applied)
+	}
+
+	if err := mgr.MigrateDown(1); err != nil {
+		t.Fatalf("Failed to migrate down to 1: %v", err)
+	}
+	if v, _ := h.GetVersion(); v != 1 || len(h.applied) != 1 {
+		t.Errorf("Version should be 1, but got %d, applied %v", v, h.applied)
+	}
+
+	if err := mgr.MigrateUp(4); !errors.Contains(err, model.ErrNoSuchSchemaVersion) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrNoSuchSchemaVersion, err)
+	}
+
+	// the database is migrated by newer server
+	h.SetVersion(4)
+	if err := mgr.CheckSchemaVersion(); !errors.Contains(err, model.ErrSchemaVersionTooNew) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrSchemaVersionTooNew, err)
+	}
+	if err := mgr.MigrateUp(0); !errors.Contains(err, model.ErrSchemaVersionTooNew) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrSchemaVersionTooNew, err)
+	}
+}
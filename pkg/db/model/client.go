@@ -1,10 +1,13 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/sh-miyoshi/hekate/pkg/config"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 // ClientFilter ...
@@ -20,6 +23,39 @@ type ClientInfo struct {
 	AccessType          string
 	CreatedAt           time.Time
 	AllowedCallbackURLs []string
+
+	// JWKS is a JSON Web Key Set document of the client public keys
+	JWKS string
+	// JWKSURI is a URL of the client JWK Set, it is used if JWKS is empty
+	JWKSURI string
+	// RequestURIs are the allowed request_uri values for the authorization request
+	RequestURIs []string
+	// PostLogoutRedirectURIs are the allowed post_logout_redirect_uri values for the RP-initiated logout
+	PostLogoutRedirectURIs []string
+	// BackchannelLogoutURI is a URL to receive the logout token when the session of the user is ended
+	BackchannelLogoutURI string
+	// FrontchannelLogoutURI is a URL which is loaded in the browser of the user when the user logs out
+	FrontchannelLogoutURI string
+	// RequestObjectSigningAlg is an algorithm which must be used for signing the request object
+	// any supported algorithm is accepted if empty
+	RequestObjectSigningAlg string
+	// RequirePushedAuthRequests rejects the authorization request which is not pushed to the PAR endpoint
+	RequirePushedAuthRequests bool
+	// TokenEndpointAuthMethod is a client authentication method which must be used in the token endpoint
+	// client_secret_basic and client_secret_post are accepted if empty
+	TokenEndpointAuthMethod string
+	// TLSClientAuthSubjectDN is a subject distinguished name of the certificate used in tls_client_auth
+	TLSClientAuthSubjectDN string
+	// RequireDPoP rejects the token request which does not have the DPoP proof
+	RequireDPoP bool
+	// TokenExchangeAudiences are the audiences which the client can request in the token exchange
+	// the client can not use the token exchange if empty
+	TokenExchangeAudiences []string
+	// TokenExchangeImpersonation allows the client to exchange the token without the actor token
+	TokenExchangeImpersonation bool
+	// RegistrationAccessTokenHash is a hash of the token to manage the client registered dynamically
+	// it is empty if the client is created by the admin
+	RegistrationAccessTokenHash string
 }
 
 var (
@@ -66,5 +102,70 @@ func (c *ClientInfo) Validate() *errors.Error {
 		}
 	}
 
+	if c.JWKS != "" {
+		var keys jose.JSONWebKeySet
+		if err := json.Unmarshal([]byte(c.JWKS), &keys); err != nil {
+			return errors.Append(ErrClientValidateFailed, "Invalid JWK Set: %v", err)
+		}
+		for _, k := range keys.Keys {
+			if !k.IsPublic() {
+				return errors.Append(ErrClientValidateFailed, "JWK Set must not contain private or symmetric key")
+			}
+		}
+	}
+
+	if c.JWKSURI != "" && !govalidator.IsRequestURL(c.JWKSURI) {
+		return errors.Append(ErrClientValidateFailed, "Invalid JWK Set URL")
+	}
+
+	for _, u := range c.RequestURIs {
+		if !govalidator.IsRequestURL(u) {
+			return errors.Append(ErrClientValidateFailed, "Invalid request URI")
+		}
+	}
+
+	for _, u := range c.PostLogoutRedirectURIs {
+		if !govalidator.IsRequestURL(u) {
+			return errors.Append(ErrClientValidateFailed, "Invalid post logout redirect URI")
+		}
+	}
+
+	if c.BackchannelLogoutURI != "" && !govalidator.IsRequestURL(c.BackchannelLogoutURI) {
+		return errors.Append(ErrClientValidateFailed, "Invalid back-channel logout URI")
+	}
+
+	if c.FrontchannelLogoutURI != "" && !govalidator.IsRequestURL(c.FrontchannelLogoutURI) {
+		return errors.Append(ErrClientValidateFailed, "Invalid front-channel logout URI")
+	}
+
+	if c.RequestObjectSigningAlg != "" && !ValidateTokenSigningAlgorithm(c.RequestObjectSigningAlg) {
+		return errors.Append(ErrClientValidateFailed, "Invalid request object signing algorithm")
+	}
+
+	for _, aud := range c.TokenExchangeAudiences {
+		if aud == "" {
+			return errors.Append(ErrClientValidateFailed, "Token exchange audience must not be empty")
+		}
+	}
+
+	if c.TokenEndpointAuthMethod != "" {
+		if !ValidateTokenEndpointAuthMethod(c.TokenEndpointAuthMethod, c.AccessType) {
+			return errors.Append(ErrClientValidateFailed, "Invalid token endpoint auth method")
+		}
+		switch c.TokenEndpointAuthMethod {
+		case "private_key_jwt", "self_signed_tls_client_auth":
+			if c.JWKS == "" && c.JWKSURI == "" {
+				return errors.Append(ErrClientValidateFailed, "%s requires JWK Set or JWK Set URL", c.TokenEndpointAuthMethod)
+			}
+		case "tls_client_auth":
+			if c.TLSClientAuthSubjectDN == "" {
+				return errors.Append(ErrClientValidateFailed, "tls_client_auth requires subject DN of the certificate")
+			}
+			if config.Get().HTTPSConfig.ClientCAFile == "" {
+				return errors.Append(ErrClientValidateFailed, "tls_client_auth requires client CA file in server config")
+			}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+)
+
+func TestClientValidateTLSClientAuth(t *testing.T) {
+	c := &ClientInfo{
+		ID:                      "mtls-client",
+		ProjectName:             "test",
+		AccessType:              "confidential",
+		Secret:                  "0123456789abcdef",
+		TokenEndpointAuthMethod: "tls_client_auth",
+		TLSClientAuthSubjectDN:  "CN=client",
+	}
+
+	org := config.Get().HTTPSConfig.ClientCAFile
+	defer func() {
+		config.Get().HTTPSConfig.ClientCAFile = org
+	}()
+
+	config.Get().HTTPSConfig.ClientCAFile = ""
+	if err := c.Validate(); err == nil {
+		t.Errorf("tls_client_auth without client CA file expects error, but got nil")
+	}
+
+	config.Get().HTTPSConfig.ClientCAFile = "/etc/hekate/ca.crt"
+	if err := c.Validate(); err != nil {
+		t.Errorf("Failed to validate tls_client_auth client: %v", err)
+	}
+}
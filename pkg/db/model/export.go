@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// ImportStrategy is a way to handle the entry which already exists in the importing project
+type ImportStrategy string
+
+// ProjectData is all resources of the project to move it between environments
+type ProjectData struct {
+	Project     *ProjectInfo
+	Clients     []*ClientInfo
+	CustomRoles []*CustomRole
+	Users       []*UserInfo
+}
+
+// ImportResult is a summary of imported entries
+type ImportResult struct {
+	Created []string
+	Updated []string
+	Skipped []string
+}
+
+const (
+	// ImportStrategySkip keeps the existing entry
+	ImportStrategySkip ImportStrategy = "skip"
+	// ImportStrategyOverwrite replaces the existing entry by the imported one
+	ImportStrategyOverwrite ImportStrategy = "overwrite"
+	// ImportStrategyFail aborts the import if the entry already exists
+	ImportStrategyFail ImportStrategy = "fail"
+)
+
+var (
+	// ErrImportConflict ...
+	ErrImportConflict = errors.New("Import conflict", "Entry already exists in the project")
+	// ErrImportValidateFailed ...
+	ErrImportValidateFailed = errors.New("Import data validation failed", "Import data validation failed")
+)
+
+// GetImportStrategy returns ImportStrategy from string
+func GetImportStrategy(strategy string) (ImportStrategy, *errors.Error) {
+	switch ImportStrategy(strategy) {
+	case ImportStrategySkip, ImportStrategyOverwrite, ImportStrategyFail:
+		return ImportStrategy(strategy), nil
+	}
+	return ImportStrategy(""), errors.Append(ErrImportValidateFailed, "No such import strategy %s", strategy)
+}
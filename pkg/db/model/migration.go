@@ -0,0 +1,28 @@
+package model
+
+import "github.com/sh-miyoshi/hekate/pkg/errors"
+
+// MigrationStep is a step to change the schema of database to the Version
+// Up and Down must be idempotent because the interrupted step is applied again
+type MigrationStep struct {
+	Version     int
+	Description string
+	Up          func() *errors.Error
+	Down        func() *errors.Error
+}
+
+// MigrationHandler ...
+type MigrationHandler interface {
+	// Steps returns all migration steps in ascending order of version
+	Steps() []*MigrationStep
+	// GetVersion returns the schema version stored in database, 0 means that no step is applied
+	GetVersion() (int, *errors.Error)
+	SetVersion(version int) *errors.Error
+}
+
+var (
+	// ErrSchemaVersionTooNew ...
+	ErrSchemaVersionTooNew = errors.New("Unknown schema version", "Schema version of database is newer than supported")
+	// ErrNoSuchSchemaVersion ...
+	ErrNoSuchSchemaVersion = errors.New("No such schema version", "No such schema version")
+)
@@ -1,10 +1,13 @@
 package model
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 // GrantType ...
@@ -13,13 +16,32 @@ type GrantType string
 // CharacterType ...
 type CharacterType string
 
+// PasswordHashAlgorithm ...
+type PasswordHashAlgorithm string
+
+// SignKeyState ...
+type SignKeyState string
+
+// SignKey ...
+type SignKey struct {
+	KeyID            string
+	State            SignKeyState
+	SigningAlgorithm string
+	PublicKey        []byte
+	PrivateKey       []byte
+	CreatedAt        time.Time
+	ActivatedAt      time.Time
+	ExpiresAt        time.Time // retired key can verify tokens until this time
+}
+
 // TokenConfig ...
 type TokenConfig struct {
 	AccessTokenLifeSpan  uint
 	RefreshTokenLifeSpan uint
+	RefreshTokenRotation bool // if true, detect reuse of rotated refresh token and revoke the token family
 	SigningAlgorithm     string
-	SignPublicKey        []byte
-	SignSecretKey        []byte
+	SignKeys             []*SignKey
+	KeyRotationInterval  uint // [sec] rotate active sign key in this interval, 0 means no scheduled rotation
 }
 
 // PasswordPolicy ...
@@ -32,6 +54,16 @@ type PasswordPolicy struct {
 	UseSpecialCharacter bool
 }
 
+// PasswordHashConfig is parameters to hash user passwords
+// zero value of each parameter means the default value
+type PasswordHashConfig struct {
+	Algorithm         PasswordHashAlgorithm
+	Argon2Iterations  uint
+	Argon2Memory      uint // [KiB]
+	Argon2Parallelism uint
+	BcryptCost        uint
+}
+
 // UserLock ...
 type UserLock struct {
 	Enabled          bool
@@ -40,6 +72,28 @@ type UserLock struct {
 	FailureResetTime uint
 }
 
+// SubjectMapping maps the subject of the JWT bearer assertion to the user or the client in the project
+type SubjectMapping struct {
+	// Subject is a sub claim of the assertion, the trailing "*" matches any suffix
+	Subject string
+	// UserName is a name of the user which the token is issued for
+	UserName string
+	// ClientID is an ID of the client which the token is issued for, it is used if UserName is empty
+	ClientID string
+}
+
+// TrustedIssuer is an external issuer of the assertion accepted in the JWT bearer grant defined in RFC 7523
+type TrustedIssuer struct {
+	// Issuer is an iss claim of the assertion
+	Issuer string
+	// JWKS is a JSON Web Key Set document to verify the assertion
+	JWKS string
+	// Audience is an expected aud claim, the issuer and the token endpoint URL of the project are expected if empty
+	Audience string
+	// SubjectMappings are evaluated in order, and the first matched mapping is used
+	SubjectMappings []SubjectMapping
+}
+
 // ProjectInfo ...
 type ProjectInfo struct {
 	Name            string
@@ -49,6 +103,9 @@ type ProjectInfo struct {
 	AllowGrantTypes []GrantType
 	PasswordPolicy  PasswordPolicy
 	UserLock        UserLock
+
+	PasswordHashConfig PasswordHashConfig
+	TrustedIssuers     []TrustedIssuer
 }
 
 // ProjectFilter ...
@@ -56,6 +113,15 @@ type ProjectFilter struct {
 	Name string
 }
 
+const (
+	// SignKeyStateNext is a state of the key which will be used after next rotation
+	SignKeyStateNext SignKeyState = "next"
+	// SignKeyStateActive is a state of the key which is used to sign tokens
+	SignKeyStateActive SignKeyState = "active"
+	// SignKeyStateRetired is a state of the key which is only used to verify tokens
+	SignKeyStateRetired SignKeyState = "retired"
+)
+
 const (
 	// DefaultAccessTokenExpiresInSec is default expires time for access token(5 minutes)
 	DefaultAccessTokenExpiresInSec = 5 * 60
@@ -71,6 +137,27 @@ const (
 
 	// DefaultFailureResetTime is default reset time of login failure(10 minutes)
 	DefaultFailureResetTime = 10 * 60
+
+	// DefaultArgon2Iterations ...
+	DefaultArgon2Iterations = 2
+
+	// DefaultArgon2Memory is default memory size of argon2id(19 MiB)
+	DefaultArgon2Memory = 19 * 1024
+
+	// DefaultArgon2Parallelism ...
+	DefaultArgon2Parallelism = 1
+
+	// MaxArgon2Iterations is a limit of iterations to avoid exhausting CPU by the login requests
+	MaxArgon2Iterations = 10
+
+	// MaxArgon2Memory is a limit of memory size of argon2id(1 GiB) to avoid exhausting memory by the login requests
+	MaxArgon2Memory = 1024 * 1024
+
+	// MaxArgon2Parallelism is a limit of parallelism defined in argon2 package
+	MaxArgon2Parallelism = 255
+
+	// DefaultBcryptCost ...
+	DefaultBcryptCost = 10
 )
 
 var (
@@ -97,6 +184,10 @@ var (
 	GrantTypePassword = GrantType("password")
 	// GrantTypeDevice ...
 	GrantTypeDevice = GrantType("urn:ietf:params:oauth:grant-type:device_code")
+	// GrantTypeTokenExchange ...
+	GrantTypeTokenExchange = GrantType("urn:ietf:params:oauth:grant-type:token-exchange")
+	// GrantTypeJWTBearer ...
+	GrantTypeJWTBearer = GrantType("urn:ietf:params:oauth:grant-type:jwt-bearer")
 
 	// Character Types
 
@@ -110,6 +201,15 @@ var (
 	CharacterTypeEither = CharacterType("either")
 	// AllCharacterTypes ...
 	AllCharacterTypes = []CharacterType{CharacterTypeLower, CharacterTypeUpper, CharacterTypeBoth, CharacterTypeEither}
+
+	// Password Hash Algorithms
+
+	// PasswordHashArgon2id ...
+	PasswordHashArgon2id = PasswordHashAlgorithm("argon2id")
+	// PasswordHashBcrypt ...
+	PasswordHashBcrypt = PasswordHashAlgorithm("bcrypt")
+	// AllPasswordHashAlgorithms ...
+	AllPasswordHashAlgorithms = []PasswordHashAlgorithm{PasswordHashArgon2id, PasswordHashBcrypt}
 )
 
 // ProjectInfoHandler ...
@@ -127,6 +227,86 @@ func (p *PasswordPolicy) validate() *errors.Error {
 	return nil
 }
 
+func (c *PasswordHashConfig) validate() *errors.Error {
+	if c.Algorithm != "" && !slice.Contains(AllPasswordHashAlgorithms, c.Algorithm) {
+		return errors.Append(ErrProjectValidateFailed, "Invalid password hash algorithm")
+	}
+	// bcrypt supports cost in 4 to 31
+	if c.BcryptCost != 0 && (c.BcryptCost < 4 || c.BcryptCost > 31) {
+		return errors.Append(ErrProjectValidateFailed, "Bcrypt cost must be in 4 to 31")
+	}
+	if c.Argon2Iterations > MaxArgon2Iterations {
+		return errors.Append(ErrProjectValidateFailed, "Argon2 iterations must <= %d", MaxArgon2Iterations)
+	}
+	if c.Argon2Memory > MaxArgon2Memory {
+		return errors.Append(ErrProjectValidateFailed, "Argon2 memory must <= %d KiB", MaxArgon2Memory)
+	}
+	if c.Argon2Parallelism > MaxArgon2Parallelism {
+		return errors.Append(ErrProjectValidateFailed, "Argon2 parallelism must <= %d", MaxArgon2Parallelism)
+	}
+	if c.Argon2Memory != 0 && c.Argon2Memory < 8*c.Argon2Parallelism {
+		return errors.Append(ErrProjectValidateFailed, "Argon2 memory must >= 8 * parallelism")
+	}
+	return nil
+}
+
+func (t *TrustedIssuer) validate() *errors.Error {
+	if t.Issuer == "" {
+		return errors.Append(ErrProjectValidateFailed, "Trusted issuer must have issuer")
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(t.JWKS), &keys); err != nil {
+		return errors.Append(ErrProjectValidateFailed, "Invalid JWK Set of issuer %s: %v", t.Issuer, err)
+	}
+	if len(keys.Keys) == 0 {
+		return errors.Append(ErrProjectValidateFailed, "JWK Set of issuer %s has no key", t.Issuer)
+	}
+	for _, k := range keys.Keys {
+		if !k.IsPublic() {
+			return errors.Append(ErrProjectValidateFailed, "JWK Set must not contain private or symmetric key")
+		}
+	}
+
+	for _, m := range t.SubjectMappings {
+		if m.Subject == "" {
+			return errors.Append(ErrProjectValidateFailed, "Subject mapping must have subject")
+		}
+		if (m.UserName == "") == (m.ClientID == "") {
+			return errors.Append(ErrProjectValidateFailed, "Subject mapping must have either user name or client ID")
+		}
+	}
+	return nil
+}
+
+// Match returns true if the subject matches the mapping
+func (m *SubjectMapping) Match(subject string) bool {
+	if strings.HasSuffix(m.Subject, "*") {
+		return strings.HasPrefix(subject, strings.TrimSuffix(m.Subject, "*"))
+	}
+	return subject == m.Subject
+}
+
+// WithDefault returns the config in which unset parameters are filled by default values
+func (c PasswordHashConfig) WithDefault() PasswordHashConfig {
+	if c.Algorithm == "" {
+		c.Algorithm = PasswordHashArgon2id
+	}
+	if c.Argon2Iterations == 0 {
+		c.Argon2Iterations = DefaultArgon2Iterations
+	}
+	if c.Argon2Memory == 0 {
+		c.Argon2Memory = DefaultArgon2Memory
+	}
+	if c.Argon2Parallelism == 0 {
+		c.Argon2Parallelism = DefaultArgon2Parallelism
+	}
+	if c.BcryptCost == 0 {
+		c.BcryptCost = DefaultBcryptCost
+	}
+	return c
+}
+
 // Validate ...
 func (p *ProjectInfo) Validate() *errors.Error {
 	if !ValidateProjectName(p.Name) {
@@ -149,6 +329,21 @@ func (p *ProjectInfo) Validate() *errors.Error {
 		return err
 	}
 
+	if err := p.PasswordHashConfig.validate(); err != nil {
+		return err
+	}
+
+	issuers := []string{}
+	for _, t := range p.TrustedIssuers {
+		if err := t.validate(); err != nil {
+			return err
+		}
+		if slice.Contains(issuers, t.Issuer) {
+			return errors.Append(ErrProjectValidateFailed, "Trusted issuer %s is duplicated", t.Issuer)
+		}
+		issuers = append(issuers, t.Issuer)
+	}
+
 	return nil
 }
 
@@ -165,7 +360,47 @@ func GetGrantType(str string) (GrantType, *errors.Error) {
 		return GrantTypePassword, nil
 	case GrantTypeDevice:
 		return GrantTypeDevice, nil
+	case GrantTypeTokenExchange:
+		return GrantTypeTokenExchange, nil
+	case GrantTypeJWTBearer:
+		return GrantTypeJWTBearer, nil
 	}
 
 	return GrantType(""), errors.New("No such grant type", "No such grant type")
 }
+
+// ActiveSignKey returns the key to sign tokens
+func (c *TokenConfig) ActiveSignKey() *SignKey {
+	for _, k := range c.SignKeys {
+		if k.State == SignKeyStateActive {
+			return k
+		}
+	}
+	return nil
+}
+
+// FindSignKey returns the key which can verify the token signed by the key ID
+func (c *TokenConfig) FindSignKey(keyID string, now time.Time) *SignKey {
+	for _, k := range c.SignKeys {
+		if k.KeyID != keyID {
+			continue
+		}
+		if k.State == SignKeyStateRetired && now.After(k.ExpiresAt) {
+			return nil
+		}
+		return k
+	}
+	return nil
+}
+
+// PublishedSignKeys returns all keys which should be published as JWK set
+func (c *TokenConfig) PublishedSignKeys(now time.Time) []*SignKey {
+	res := []*SignKey{}
+	for _, k := range c.SignKeys {
+		if k.State == SignKeyStateRetired && now.After(k.ExpiresAt) {
+			continue
+		}
+		res = append(res, k)
+	}
+	return res
+}
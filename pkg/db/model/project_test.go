@@ -43,6 +43,7 @@ func TestValidate(t *testing.T) {
 		{"project-ok._", "RS256", 1, 1, true},
 		{"project-ng-str-!", "RS256", 1, 1, false},
 		{"project-ok", "invalid", 1, 1, false},
+		{"project-ok", "HS256", 1, 1, true},
 		{"pr", "RS256", 1, 1, false},
 		{"project-name-too-long0123456789012345678901234567890123456789012", "RS256", 1, 1, false},
 		{"0prject", "RS256", 1, 1, false},
@@ -67,3 +68,65 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestValidatePasswordHashConfig(t *testing.T) {
+	tt := []struct {
+		cfg           PasswordHashConfig
+		expectSuccess bool
+	}{
+		{PasswordHashConfig{}, true},
+		{PasswordHashConfig{Algorithm: "argon2id", Argon2Memory: 8, Argon2Parallelism: 1}, true},
+		{PasswordHashConfig{Algorithm: "bcrypt", BcryptCost: 12}, true},
+		{PasswordHashConfig{Algorithm: "sha512"}, false},
+		{PasswordHashConfig{BcryptCost: 3}, false},
+		{PasswordHashConfig{BcryptCost: 32}, false},
+		{PasswordHashConfig{Argon2Parallelism: 256}, false},
+		{PasswordHashConfig{Argon2Memory: 8, Argon2Parallelism: 2}, false},
+		{PasswordHashConfig{Argon2Iterations: MaxArgon2Iterations}, true},
+		{PasswordHashConfig{Argon2Iterations: MaxArgon2Iterations + 1}, false},
+		{PasswordHashConfig{Argon2Memory: MaxArgon2Memory}, true},
+		{PasswordHashConfig{Argon2Memory: MaxArgon2Memory + 1}, false},
+		{PasswordHashConfig{Argon2Memory: 8 * 1024, Argon2Parallelism: MaxArgon2Parallelism}, true},
+	}
+
+	for _, tc := range tt {
+		err := tc.cfg.validate()
+
+		if tc.expectSuccess && err != nil {
+			t.Errorf("Passowrd hash config validate %v returns wrong status. got %v, want nil", tc.cfg, err)
+		}
+		if !tc.expectSuccess && err == nil {
+			t.Errorf("Passowrd hash config validate %v returns wrong status. got nil, want error", tc.cfg)
+		}
+	}
+}
+
+func TestValidateTrustedIssuer(t *testing.T) {
+	const pubJWKS = `{"keys":[{"kty":"EC","crv":"P-256","x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU","y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0"}]}`
+	const privJWKS = `{"keys":[{"kty":"EC","crv":"P-256","x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU","y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0","d":"jpsQnnGQmL-YBIffH1136cspYG6-0iY7X1fCE9-E9LI"}]}`
+
+	tt := []struct {
+		issuer        TrustedIssuer
+		expectSuccess bool
+	}{
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: pubJWKS}, true},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: pubJWKS, SubjectMappings: []SubjectMapping{{Subject: "system:*", ClientID: "workload"}}}, true},
+		{TrustedIssuer{JWKS: pubJWKS}, false},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: "invalid"}, false},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: `{"keys":[]}`}, false},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: privJWKS}, false},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: pubJWKS, SubjectMappings: []SubjectMapping{{ClientID: "workload"}}}, false},
+		{TrustedIssuer{Issuer: "https://issuer.example.com", JWKS: pubJWKS, SubjectMappings: []SubjectMapping{{Subject: "alice", UserName: "admin", ClientID: "workload"}}}, false},
+	}
+
+	for _, tc := range tt {
+		err := tc.issuer.validate()
+
+		if tc.expectSuccess && err != nil {
+			t.Errorf("Trusted issuer validate %v returns wrong status. got %v, want nil", tc.issuer, err)
+		}
+		if !tc.expectSuccess && err == nil {
+			t.Errorf("Trusted issuer validate %v returns wrong status. got nil, want error", tc.issuer)
+		}
+	}
+}
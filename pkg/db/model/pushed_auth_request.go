@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// PushedAuthRequest is an authorization request pushed by the client defined in RFC 9126
+type PushedAuthRequest struct {
+	RequestURI  string
+	ProjectName string
+	ClientID    string
+	Parameters  map[string][]string
+	ExpiresIn   int64
+	CreatedAt   time.Time
+}
+
+// PushedAuthRequestFilter ...
+type PushedAuthRequestFilter struct {
+	RequestURI string
+}
+
+// PushedAuthRequestHandler ...
+type PushedAuthRequestHandler interface {
+	Add(projectName string, ent *PushedAuthRequest) *errors.Error
+	DeleteAll(projectName string) *errors.Error
+	Cleanup(now time.Time) *errors.Error
+	GetList(projectName string, filter *PushedAuthRequestFilter) ([]*PushedAuthRequest, *errors.Error)
+	Delete(projectName string, requestURI string) *errors.Error
+}
+
+var (
+	// ErrPushedAuthRequestValidateFailed ...
+	ErrPushedAuthRequestValidateFailed = errors.New("Pushed authorization request validation failed", "Pushed authorization request validation failed")
+	// ErrNoSuchPushedAuthRequest ...
+	ErrNoSuchPushedAuthRequest = errors.New("No such pushed authorization request", "No such pushed authorization request")
+)
+
+// Validate ...
+func (r *PushedAuthRequest) Validate() *errors.Error {
+	if r.RequestURI == "" {
+		return errors.Append(ErrPushedAuthRequestValidateFailed, "RequestURI is empty")
+	}
+
+	if !ValidateProjectName(r.ProjectName) {
+		return errors.Append(ErrPushedAuthRequestValidateFailed, "Invalid Project Name format")
+	}
+
+	if !ValidateClientID(r.ClientID) {
+		return errors.Append(ErrPushedAuthRequestValidateFailed, "Invalid Client ID format")
+	}
+
+	if r.ExpiresIn <= 0 {
+		return errors.Append(ErrPushedAuthRequestValidateFailed, "expires time must be positive number, but got %d", r.ExpiresIn)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// RevokedToken ...
+type RevokedToken struct {
+	TokenID     string // jti of the revoked token
+	ProjectName string
+	ExpiresAt   time.Time // the token can be removed from the store after this time
+}
+
+// RevokedTokenFilter ...
+type RevokedTokenFilter struct {
+	TokenID string
+}
+
+// RevokedTokenHandler ...
+type RevokedTokenHandler interface {
+	Add(projectName string, ent *RevokedToken) *errors.Error
+	DeleteAll(projectName string) *errors.Error
+	GetList(projectName string, filter *RevokedTokenFilter) ([]*RevokedToken, *errors.Error)
+	Cleanup(now time.Time) *errors.Error
+}
+
+var (
+	// ErrRevokedTokenValidateFailed ...
+	ErrRevokedTokenValidateFailed = errors.New("Revoked token validation failed", "Revoked token validation failed")
+	// ErrRefreshTokenReused ...
+	ErrRefreshTokenReused = errors.New("Refresh token reused", "Refresh token reused")
+)
+
+// Validate ...
+func (t *RevokedToken) Validate() *errors.Error {
+	if !ValidateTokenID(t.TokenID) {
+		return errors.Append(ErrRevokedTokenValidateFailed, "Invalid token ID format")
+	}
+
+	if !ValidateProjectName(t.ProjectName) {
+		return errors.Append(ErrRevokedTokenValidateFailed, "Invalid project Name format")
+	}
+
+	if t.ExpiresAt.IsZero() {
+		return errors.Append(ErrRevokedTokenValidateFailed, "Expires time is empty")
+	}
+
+	return nil
+}
@@ -16,12 +16,15 @@ type Session struct {
 	ExpiresIn    int64
 	FromIP       string // Used to identify the user using this session
 	LastAuthTime time.Time
+	FamilyID     string // ID of the first session in refresh token rotation chain
+	ClientID     string // ID of the client which the session is issued to
 }
 
 // SessionFilter ...
 type SessionFilter struct {
 	SessionID string
 	UserID    string
+	FamilyID  string
 }
 
 // SessionHandler ...
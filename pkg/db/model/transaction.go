@@ -2,7 +2,11 @@ package model
 
 import "github.com/sh-miyoshi/hekate/pkg/errors"
 
+// Tx is a handle of the running transaction, and the type depends on the database
+type Tx interface{}
+
 // TransactionManager ...
 type TransactionManager interface {
-	Transaction(txFunc func() *errors.Error) *errors.Error
+	// Transaction runs txFunc in a transaction, tx must not be used after txFunc returns
+	Transaction(txFunc func(tx Tx) *errors.Error) *errors.Error
 }
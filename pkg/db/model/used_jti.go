@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// UsedJTI is a jti of the one-time token such as the client assertion
+// it is stored to prevent the replay attack until the token is expired
+type UsedJTI struct {
+	JTI         string
+	Issuer      string // issuer of the token such as the client id of the client assertion
+	ProjectName string
+	ExpiresAt   time.Time // the jti can be removed from the store after this time
+}
+
+// UsedJTIFilter ...
+type UsedJTIFilter struct {
+	JTI    string
+	Issuer string
+}
+
+// UsedJTIHandler ...
+type UsedJTIHandler interface {
+	Add(projectName string, ent *UsedJTI) *errors.Error
+	DeleteAll(projectName string) *errors.Error
+	GetList(projectName string, filter *UsedJTIFilter) ([]*UsedJTI, *errors.Error)
+	Cleanup(now time.Time) *errors.Error
+}
+
+var (
+	// ErrUsedJTIValidateFailed ...
+	ErrUsedJTIValidateFailed = errors.New("Used jti validation failed", "Used jti validation failed")
+
+	// ErrJTIAlreadyUsed ...
+	ErrJTIAlreadyUsed = errors.New("JTI already used", "JTI already used")
+)
+
+// Validate ...
+func (j *UsedJTI) Validate() *errors.Error {
+	if !(0 < len(j.JTI) && len(j.JTI) <= 255) {
+		return errors.Append(ErrUsedJTIValidateFailed, "Invalid jti format")
+	}
+
+	if !(0 < len(j.Issuer) && len(j.Issuer) <= 255) {
+		return errors.Append(ErrUsedJTIValidateFailed, "Invalid issuer format")
+	}
+
+	if !ValidateProjectName(j.ProjectName) {
+		return errors.Append(ErrUsedJTIValidateFailed, "Invalid project Name format")
+	}
+
+	if j.ExpiresAt.IsZero() {
+		return errors.Append(ErrUsedJTIValidateFailed, "Expires time is empty")
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"regexp"
 	"time"
 
+	"github.com/asaskevich/govalidator"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 )
 
@@ -19,6 +21,37 @@ type OTPInfo struct {
 	Enabled    bool
 }
 
+// Address is a postal address of the user defined in OpenID Connect Core 1.0 section 5.1.1
+type Address struct {
+	Formatted     string
+	StreetAddress string
+	Locality      string
+	Region        string
+	PostalCode    string
+	Country       string
+}
+
+// UserProfile is a set of the standard claims of the user defined in OpenID Connect Core 1.0 section 5.1
+type UserProfile struct {
+	FullName            string
+	GivenName           string
+	FamilyName          string
+	MiddleName          string
+	Nickname            string
+	ProfileURL          string
+	Picture             string
+	Website             string
+	Gender              string
+	Birthdate           string
+	Zoneinfo            string
+	Locale              string
+	Email               string
+	EmailVerified       bool
+	PhoneNumber         string
+	PhoneNumberVerified bool
+	Address             Address
+}
+
 // UserInfo ...
 type UserInfo struct {
 	ID           string
@@ -30,6 +63,9 @@ type UserInfo struct {
 	CustomRoles  []string
 	LockState    LockState
 	OTPInfo      OTPInfo
+	Profile      UserProfile
+	// Attributes are the custom claims of the user which are released with the profile scope
+	Attributes map[string]string
 }
 
 // UserFilter ...
@@ -66,6 +102,14 @@ var (
 	RoleSystem = RoleType{"system_management"}
 	// RoleCustom ...
 	RoleCustom = RoleType{"custom_role"}
+
+	// phoneNumberRegExp accepts E.164 format and the number with separators and extension
+	phoneNumberRegExp = regexp.MustCompile(`^\+?[0-9][0-9 \-()]{1,30}(;ext=[0-9]+)?$`)
+)
+
+const (
+	// userAttributeValueMaxLen is a max length of the custom attribute value
+	userAttributeValueMaxLen = 1024
 )
 
 // UserInfoHandler ...
@@ -96,5 +140,45 @@ func (ui *UserInfo) Validate() *errors.Error {
 		return errors.Append(ErrUserValidateFailed, "Invalid user name format")
 	}
 
+	if err := ui.Profile.validate(); err != nil {
+		return err
+	}
+
+	for name, value := range ui.Attributes {
+		if !ValidateUserAttributeName(name) {
+			return errors.Append(ErrUserValidateFailed, "Invalid attribute name %s", name)
+		}
+		if len(value) > userAttributeValueMaxLen {
+			return errors.Append(ErrUserValidateFailed, "Attribute %s is too long", name)
+		}
+	}
+
+	return nil
+}
+
+func (p *UserProfile) validate() *errors.Error {
+	if p.Email != "" && !govalidator.IsEmail(p.Email) {
+		return errors.Append(ErrUserValidateFailed, "Invalid email format")
+	}
+
+	for _, u := range []string{p.ProfileURL, p.Picture, p.Website} {
+		if u != "" && !govalidator.IsRequestURL(u) {
+			return errors.Append(ErrUserValidateFailed, "Invalid URL %s in profile", u)
+		}
+	}
+
+	// birthdate is YYYY-MM-DD format, or YYYY if the year is only shared
+	if p.Birthdate != "" {
+		if _, err := time.Parse("2006-01-02", p.Birthdate); err != nil {
+			if _, err := time.Parse("2006", p.Birthdate); err != nil {
+				return errors.Append(ErrUserValidateFailed, "Invalid birthdate format")
+			}
+		}
+	}
+
+	if p.PhoneNumber != "" && !phoneNumberRegExp.MatchString(p.PhoneNumber) {
+		return errors.Append(ErrUserValidateFailed, "Invalid phone number format")
+	}
+
 	return nil
 }
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestValidateUserProfile(t *testing.T) {
+	tt := []struct {
+		profile       UserProfile
+		expectSuccess bool
+	}{
+		{UserProfile{}, true},
+		{UserProfile{Email: "user@example.com", Birthdate: "2000-01-31", PhoneNumber: "+81 90-1234-5678"}, true},
+		{UserProfile{Birthdate: "1990"}, true},
+		{UserProfile{Picture: "https://example.com/user.png"}, true},
+		{UserProfile{Email: "invalid"}, false},
+		{UserProfile{Birthdate: "2000/01/31"}, false},
+		{UserProfile{PhoneNumber: "phone"}, false},
+		{UserProfile{Website: "not a url"}, false},
+	}
+
+	for _, tc := range tt {
+		err := tc.profile.validate()
+		if tc.expectSuccess && err != nil {
+			t.Errorf("Profile validate %v returns wrong status. got %v, want nil", tc.profile, err)
+		}
+		if !tc.expectSuccess && err == nil {
+			t.Errorf("Profile validate %v returns wrong status. got nil, want error", tc.profile)
+		}
+	}
+}
+
+func TestValidateUserAttributeName(t *testing.T) {
+	tt := []struct {
+		name   string
+		expect bool
+	}{
+		{"department", true},
+		{"urn:example:group", true},
+		{"employee_id", true},
+		{"0attr", false},
+		{"", false},
+		{"has space", false},
+		{"sub", false},
+		{"email", false},
+		{"resource_access", false},
+	}
+
+	for _, tc := range tt {
+		if res := ValidateUserAttributeName(tc.name); res != tc.expect {
+			t.Errorf("ValidateUserAttributeName(%s) returns wrong result. got %v, want %v", tc.name, res, tc.expect)
+		}
+	}
+}
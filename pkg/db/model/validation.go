@@ -16,6 +16,10 @@ func ValidateProjectName(name string) bool {
 func ValidateTokenSigningAlgorithm(signAlg string) bool {
 	validAlgs := []string{
 		"RS256",
+		"PS256",
+		"ES256",
+		"EdDSA",
+		"HS256",
 	}
 
 	for _, alg := range validAlgs {
@@ -63,6 +67,30 @@ func ValidateClientAccessType(typ string) bool {
 	return false
 }
 
+// ValidateTokenEndpointAuthMethod ...
+func ValidateTokenEndpointAuthMethod(method string, accessType string) bool {
+	allowedMethods := []string{
+		"client_secret_basic",
+		"client_secret_post",
+		"client_secret_jwt",
+		"private_key_jwt",
+		"tls_client_auth",
+		"self_signed_tls_client_auth",
+	}
+	if accessType == "public" {
+		// public client does not have credentials
+		allowedMethods = []string{
+			"none",
+		}
+	}
+	for _, m := range allowedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateUserName ...
 func ValidateUserName(name string) bool {
 	if !(3 <= len(name) && len(name) < 64) {
@@ -98,3 +126,32 @@ func ValidateCustomRoleName(name string) bool {
 func ValidateAuthCode(code string) bool {
 	return govalidator.IsUUID(code)
 }
+
+// ValidateTokenID ...
+func ValidateTokenID(id string) bool {
+	return govalidator.IsUUID(id)
+}
+
+// ValidateUserAttributeName returns false if the name is invalid or conflicts with the registered claim
+func ValidateUserAttributeName(name string) bool {
+	attrNameRegExp := regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-\.\_:]{0,63}$`)
+	if !attrNameRegExp.MatchString(name) {
+		return false
+	}
+
+	reserved := []string{
+		// JWT and ID token claims
+		"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "nonce", "auth_time", "azp", "sid", "acr", "amr", "at_hash", "c_hash",
+		// hekate specific claims
+		"project", "format", "resource_access", "scope", "cnf", "act", "family_id", "sessionID",
+		// standard claims
+		"name", "given_name", "family_name", "middle_name", "nickname", "preferred_username", "profile", "picture", "website",
+		"email", "email_verified", "gender", "birthdate", "zoneinfo", "locale", "phone_number", "phone_number_verified", "address", "updated_at",
+	}
+	for _, r := range reserved {
+		if name == r {
+			return false
+		}
+	}
+	return true
+}
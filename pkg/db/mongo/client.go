@@ -57,12 +57,26 @@ func NewClientHandler(dbClient *mongo.Client) (*ClientInfoHandler, *errors.Error
 // Add ...
 func (h *ClientInfoHandler) Add(projectName string, ent *model.ClientInfo) *errors.Error {
 	v := &clientInfo{
-		ID:                  ent.ID,
-		ProjectName:         ent.ProjectName,
-		Secret:              ent.Secret,
-		AccessType:          ent.AccessType,
-		CreatedAt:           ent.CreatedAt,
-		AllowedCallbackURLs: ent.AllowedCallbackURLs,
+		ID:                          ent.ID,
+		ProjectName:                 ent.ProjectName,
+		Secret:                      ent.Secret,
+		AccessType:                  ent.AccessType,
+		CreatedAt:                   ent.CreatedAt,
+		AllowedCallbackURLs:         ent.AllowedCallbackURLs,
+		JWKS:                        ent.JWKS,
+		JWKSURI:                     ent.JWKSURI,
+		RequestURIs:                 ent.RequestURIs,
+		PostLogoutRedirectURIs:      ent.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:        ent.BackchannelLogoutURI,
+		FrontchannelLogoutURI:       ent.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:     ent.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:   ent.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:     ent.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:      ent.TLSClientAuthSubjectDN,
+		RequireDPoP:                 ent.RequireDPoP,
+		TokenExchangeAudiences:      ent.TokenExchangeAudiences,
+		TokenExchangeImpersonation:  ent.TokenExchangeImpersonation,
+		RegistrationAccessTokenHash: ent.RegistrationAccessTokenHash,
 	}
 
 	col := h.dbClient.Database(databaseName).Collection(clientCollectionName)
@@ -126,12 +140,26 @@ func (h *ClientInfoHandler) GetList(projectName string, filter *model.ClientFilt
 	res := []*model.ClientInfo{}
 	for _, client := range clients {
 		res = append(res, &model.ClientInfo{
-			ID:                  client.ID,
-			ProjectName:         client.ProjectName,
-			Secret:              client.Secret,
-			AccessType:          client.AccessType,
-			CreatedAt:           client.CreatedAt,
-			AllowedCallbackURLs: client.AllowedCallbackURLs,
+			ID:                          client.ID,
+			ProjectName:                 client.ProjectName,
+			Secret:                      client.Secret,
+			AccessType:                  client.AccessType,
+			CreatedAt:                   client.CreatedAt,
+			AllowedCallbackURLs:         client.AllowedCallbackURLs,
+			JWKS:                        client.JWKS,
+			JWKSURI:                     client.JWKSURI,
+			RequestURIs:                 client.RequestURIs,
+			PostLogoutRedirectURIs:      client.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:        client.BackchannelLogoutURI,
+			FrontchannelLogoutURI:       client.FrontchannelLogoutURI,
+			RequestObjectSigningAlg:     client.RequestObjectSigningAlg,
+			RequirePushedAuthRequests:   client.RequirePushedAuthRequests,
+			TokenEndpointAuthMethod:     client.TokenEndpointAuthMethod,
+			TLSClientAuthSubjectDN:      client.TLSClientAuthSubjectDN,
+			RequireDPoP:                 client.RequireDPoP,
+			TokenExchangeAudiences:      client.TokenExchangeAudiences,
+			TokenExchangeImpersonation:  client.TokenExchangeImpersonation,
+			RegistrationAccessTokenHash: client.RegistrationAccessTokenHash,
 		})
 	}
 
@@ -147,12 +175,26 @@ func (h *ClientInfoHandler) Update(projectName string, ent *model.ClientInfo) *e
 	}
 
 	v := &clientInfo{
-		ID:                  ent.ID,
-		ProjectName:         ent.ProjectName,
-		Secret:              ent.Secret,
-		AccessType:          ent.AccessType,
-		CreatedAt:           ent.CreatedAt,
-		AllowedCallbackURLs: ent.AllowedCallbackURLs,
+		ID:                          ent.ID,
+		ProjectName:                 ent.ProjectName,
+		Secret:                      ent.Secret,
+		AccessType:                  ent.AccessType,
+		CreatedAt:                   ent.CreatedAt,
+		AllowedCallbackURLs:         ent.AllowedCallbackURLs,
+		JWKS:                        ent.JWKS,
+		JWKSURI:                     ent.JWKSURI,
+		RequestURIs:                 ent.RequestURIs,
+		PostLogoutRedirectURIs:      ent.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:        ent.BackchannelLogoutURI,
+		FrontchannelLogoutURI:       ent.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:     ent.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:   ent.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:     ent.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:      ent.TLSClientAuthSubjectDN,
+		RequireDPoP:                 ent.RequireDPoP,
+		TokenExchangeAudiences:      ent.TokenExchangeAudiences,
+		TokenExchangeImpersonation:  ent.TokenExchangeImpersonation,
+		RegistrationAccessTokenHash: ent.RegistrationAccessTokenHash,
 	}
 
 	updates := bson.D{
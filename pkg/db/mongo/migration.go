@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	schemaVersionID = "schema"
+)
+
+type schemaVersion struct {
+	ID      string `bson:"_id"`
+	Version int    `bson:"version"`
+}
+
+// MigrationHandler implement db.MigrationHandler
+type MigrationHandler struct {
+	dbClient *mongo.Client
+}
+
+// NewMigrationHandler ...
+func NewMigrationHandler(dbClient *mongo.Client) *MigrationHandler {
+	return &MigrationHandler{
+		dbClient: dbClient,
+	}
+}
+
+// Steps ...
+func (h *MigrationHandler) Steps() []*model.MigrationStep {
+	return []*model.MigrationStep{
+		{
+			// indexes are created by each handler, so nothing to do
+			Version:     1,
+			Description: "initial schema",
+			Up:          func() *errors.Error { return nil },
+			Down:        func() *errors.Error { return nil },
+		},
+		{
+			Version:     2,
+			Description: "convert single sign key of project to key ring",
+			Up:          h.signKeyRingUp,
+			Down:        h.signKeyRingDown,
+		},
+	}
+}
+
+// GetVersion ...
+func (h *MigrationHandler) GetVersion() (int, *errors.Error) {
+	col := h.dbClient.Database(databaseName).Collection(schemaVersionCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	res := &schemaVersion{}
+	if err := col.FindOne(ctx, bson.D{{Key: "_id", Value: schemaVersionID}}).Decode(res); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, errors.New("DB failed", "Failed to get schema version from mongodb: %v", err)
+	}
+	return res.Version, nil
+}
+
+// SetVersion ...
+func (h *MigrationHandler) SetVersion(version int) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(schemaVersionCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: schemaVersionID}}
+	v := &schemaVersion{ID: schemaVersionID, Version: version}
+	if _, err := col.ReplaceOne(ctx, filter, v, options.Replace().SetUpsert(true)); err != nil {
+		return errors.New("DB failed", "Failed to set schema version in mongodb: %v", err)
+	}
+	return nil
+}
+
+// signKeyRingUp moves the old sign key into the key ring as an active key
+func (h *MigrationHandler) signKeyRingUp() *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(projectCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "token_config.sign_secret_key", Value: bson.D{{Key: "$exists", Value: true}}},
+	}
+	cursor, err := col.Find(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to get project list from mongodb: %v", err)
+	}
+	projects := []projectInfo{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return errors.New("DB failed", "Failed to parse project list: %v", err)
+	}
+
+	for _, prj := range projects {
+		// fromSignKeys converts the old key to the active key with the same key ID as reading time
+		keys := fromSignKeys(prj.TokenConfig)
+		updates := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "token_config.sign_keys", Value: toSignKeys(keys)}}},
+			{Key: "$unset", Value: bson.D{
+				{Key: "token_config.sign_public_key", Value: ""},
+				{Key: "token_config.sign_secret_key", Value: ""},
+			}},
+		}
+		if _, err := col.UpdateOne(ctx, bson.D{{Key: "name", Value: prj.Name}}, updates); err != nil {
+			return errors.New("DB failed", "Failed to update sign keys of project %s: %v", prj.Name, err)
+		}
+	}
+	return nil
+}
+
+// signKeyRingDown sets the active key to the old fields to be read by the old server
+func (h *MigrationHandler) signKeyRingDown() *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(projectCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		return errors.New("DB failed", "Failed to get project list from mongodb: %v", err)
+	}
+	projects := []projectInfo{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return errors.New("DB failed", "Failed to parse project list: %v", err)
+	}
+
+	for _, prj := range projects {
+		cfg := &model.TokenConfig{SignKeys: fromSignKeys(prj.TokenConfig)}
+		key := cfg.ActiveSignKey()
+		if key == nil {
+			continue
+		}
+		updates := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "token_config.sign_public_key", Value: key.PublicKey},
+				{Key: "token_config.sign_secret_key", Value: key.PrivateKey},
+			}},
+		}
+		if _, err := col.UpdateOne(ctx, bson.D{{Key: "name", Value: prj.Name}}, updates); err != nil {
+			return errors.New("DB failed", "Failed to update sign keys of project %s: %v", prj.Name, err)
+		}
+	}
+	return nil
+}
@@ -5,11 +5,27 @@ import (
 )
 
 type tokenConfig struct {
-	AccessTokenLifeSpan  uint   `bson:"access_token_life_span"`
-	RefreshTokenLifeSpan uint   `bson:"refresh_token_life_span"`
-	SigningAlgorithm     string `bson:"signing_algorithm"`
-	SignPublicKey        []byte `bson:"sign_public_key"`
-	SignSecretKey        []byte `bson:"sign_secret_key"`
+	AccessTokenLifeSpan  uint      `bson:"access_token_life_span"`
+	RefreshTokenLifeSpan uint      `bson:"refresh_token_life_span"`
+	RefreshTokenRotation bool      `bson:"refresh_token_rotation"`
+	SigningAlgorithm     string    `bson:"signing_algorithm"`
+	SignKeys             []signKey `bson:"sign_keys"`
+	KeyRotationInterval  uint      `bson:"key_rotation_interval"`
+
+	// Deprecated: sign keys before key rotation support, only used to read old data
+	SignPublicKey []byte `bson:"sign_public_key,omitempty"`
+	SignSecretKey []byte `bson:"sign_secret_key,omitempty"`
+}
+
+type signKey struct {
+	KeyID            string    `bson:"key_id"`
+	State            string    `bson:"state"`
+	SigningAlgorithm string    `bson:"signing_algorithm"`
+	PublicKey        []byte    `bson:"public_key"`
+	PrivateKey       []byte    `bson:"private_key"`
+	CreatedAt        time.Time `bson:"created_at"`
+	ActivatedAt      time.Time `bson:"activated_at"`
+	ExpiresAt        time.Time `bson:"expires_at"`
 }
 
 type passwordPolicy struct {
@@ -28,14 +44,37 @@ type userLock struct {
 	FailureResetTime uint `bson:"failure_reset_time"`
 }
 
+type passwordHashConfig struct {
+	Algorithm         string `bson:"algorithm"`
+	Argon2Iterations  uint   `bson:"argon2_iterations"`
+	Argon2Memory      uint   `bson:"argon2_memory"`
+	Argon2Parallelism uint   `bson:"argon2_parallelism"`
+	BcryptCost        uint   `bson:"bcrypt_cost"`
+}
+
+type subjectMapping struct {
+	Subject  string `bson:"subject"`
+	UserName string `bson:"user_name"`
+	ClientID string `bson:"client_id"`
+}
+
+type trustedIssuer struct {
+	Issuer          string           `bson:"issuer"`
+	JWKS            string           `bson:"jwks"`
+	Audience        string           `bson:"audience"`
+	SubjectMappings []subjectMapping `bson:"subject_mappings"`
+}
+
 type projectInfo struct {
-	Name            string         `bson:"name"`
-	CreatedAt       time.Time      `bson:"create_at"`
-	TokenConfig     *tokenConfig   `bson:"token_config"`
-	PermitDelete    bool           `bson:"permit_delete"`
-	AllowGrantTypes []string       `bson:"allow_grant_types"`
-	PasswordPolicy  passwordPolicy `bson:"password_policy"`
-	UserLock        userLock       `bson:"user_lock"`
+	Name               string             `bson:"name"`
+	CreatedAt          time.Time          `bson:"create_at"`
+	TokenConfig        *tokenConfig       `bson:"token_config"`
+	PermitDelete       bool               `bson:"permit_delete"`
+	AllowGrantTypes    []string           `bson:"allow_grant_types"`
+	PasswordPolicy     passwordPolicy     `bson:"password_policy"`
+	UserLock           userLock           `bson:"user_lock"`
+	PasswordHashConfig passwordHashConfig `bson:"password_hash_config"`
+	TrustedIssuers     []trustedIssuer    `bson:"trusted_issuers"`
 }
 
 type session struct {
@@ -46,6 +85,8 @@ type session struct {
 	ExpiresIn    int64     `bson:"expires_in"`
 	FromIP       string    `bson:"from_ip"`
 	LastAuthTime time.Time `bson:"last_auth_time"`
+	FamilyID     string    `bson:"family_id"`
+	ClientID     string    `bson:"client_id"`
 }
 
 type loginSession struct {
@@ -78,24 +119,69 @@ type otpInfo struct {
 }
 
 type userInfo struct {
-	ID           string    `bson:"id"`
-	ProjectName  string    `bson:"project_name"`
-	Name         string    `bson:"name"`
-	CreatedAt    time.Time `bson:"created_at"`
-	PasswordHash string    `bson:"password_hash"`
-	SystemRoles  []string  `bson:"system_roles"`
-	CustomRoles  []string  `bson:"custom_roles"`
-	LockState    lockState `bson:"lock_state"`
-	OTPInfo      otpInfo   `bson:"otp_info"`
+	ID           string            `bson:"id"`
+	ProjectName  string            `bson:"project_name"`
+	Name         string            `bson:"name"`
+	CreatedAt    time.Time         `bson:"created_at"`
+	PasswordHash string            `bson:"password_hash"`
+	SystemRoles  []string          `bson:"system_roles"`
+	CustomRoles  []string          `bson:"custom_roles"`
+	LockState    lockState         `bson:"lock_state"`
+	OTPInfo      otpInfo           `bson:"otp_info"`
+	Profile      userProfile       `bson:"profile"`
+	Attributes   map[string]string `bson:"attributes"`
+}
+
+type address struct {
+	Formatted     string `bson:"formatted"`
+	StreetAddress string `bson:"street_address"`
+	Locality      string `bson:"locality"`
+	Region        string `bson:"region"`
+	PostalCode    string `bson:"postal_code"`
+	Country       string `bson:"country"`
+}
+
+type userProfile struct {
+	FullName            string  `bson:"name"`
+	GivenName           string  `bson:"given_name"`
+	FamilyName          string  `bson:"family_name"`
+	MiddleName          string  `bson:"middle_name"`
+	Nickname            string  `bson:"nickname"`
+	ProfileURL          string  `bson:"profile"`
+	Picture             string  `bson:"picture"`
+	Website             string  `bson:"website"`
+	Gender              string  `bson:"gender"`
+	Birthdate           string  `bson:"birthdate"`
+	Zoneinfo            string  `bson:"zoneinfo"`
+	Locale              string  `bson:"locale"`
+	Email               string  `bson:"email"`
+	EmailVerified       bool    `bson:"email_verified"`
+	PhoneNumber         string  `bson:"phone_number"`
+	PhoneNumberVerified bool    `bson:"phone_number_verified"`
+	Address             address `bson:"address"`
 }
 
 type clientInfo struct {
-	ID                  string    `bson:"id"`
-	ProjectName         string    `bson:"project_name"`
-	Secret              string    `bson:"secret"`
-	AccessType          string    `bson:"access_type"`
-	CreatedAt           time.Time `bson:"created_at"`
-	AllowedCallbackURLs []string  `bson:"allowed_callback_urls"`
+	ID                          string    `bson:"id"`
+	ProjectName                 string    `bson:"project_name"`
+	Secret                      string    `bson:"secret"`
+	AccessType                  string    `bson:"access_type"`
+	CreatedAt                   time.Time `bson:"created_at"`
+	AllowedCallbackURLs         []string  `bson:"allowed_callback_urls"`
+	JWKS                        string    `bson:"jwks"`
+	JWKSURI                     string    `bson:"jwks_uri"`
+	RequestURIs                 []string  `bson:"request_uris"`
+	PostLogoutRedirectURIs      []string  `bson:"post_logout_redirect_uris"`
+	BackchannelLogoutURI        string    `bson:"backchannel_logout_uri"`
+	FrontchannelLogoutURI       string    `bson:"frontchannel_logout_uri"`
+	RequestObjectSigningAlg     string    `bson:"request_object_signing_alg"`
+	RequirePushedAuthRequests   bool      `bson:"require_pushed_auth_requests"`
+	TokenEndpointAuthMethod     string    `bson:"token_endpoint_auth_method"`
+	TLSClientAuthSubjectDN      string    `bson:"tls_client_auth_subject_dn"`
+	RequireDPoP                 bool      `bson:"require_dpop"`
+	TokenExchangeAudiences      []string  `bson:"token_exchange_audiences"`
+	TokenExchangeImpersonation  bool      `bson:"token_exchange_impersonation"`
+	RegistrationAccessTokenHash string    `bson:"registration_access_token_hash"`
 }
 
 type customRole struct {
@@ -119,3 +205,26 @@ type device struct {
 	CreatedAt      time.Time `bson:"created_at"`
 	LoginSessionID string    `bson:"login_session_id"`
 }
+
+type pushedAuthRequest struct {
+	RequestURI  string              `bson:"request_uri"`
+	ProjectName string              `bson:"project_name"`
+	ClientID    string              `bson:"client_id"`
+	Parameters  map[string][]string `bson:"parameters"`
+	ExpiresIn   int64               `bson:"expires_in"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+type revokedToken struct {
+	TokenID     string    `bson:"token_id"`
+	ProjectName string    `bson:"project_name"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+type usedJTI struct {
+	JTI         string    `bson:"jti"`
+	Issuer      string    `bson:"issuer"`
+	ProjectName string    `bson:"project_name"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
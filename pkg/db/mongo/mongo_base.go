@@ -10,14 +10,18 @@ import (
 )
 
 const (
-	projectCollectionName         = "project"
-	userCollectionName            = "user"
-	clientCollectionName          = "client"
-	sessionCollectionName         = "session"
-	roleCollectionName            = "customrole"
-	authcodeSessionCollectionName = "authcodesession"
-	roleInUserCollectionName      = "customroleinuser"
-	deviceCollectionName          = "device"
+	projectCollectionName           = "project"
+	userCollectionName              = "user"
+	clientCollectionName            = "client"
+	sessionCollectionName           = "session"
+	roleCollectionName              = "customrole"
+	authcodeSessionCollectionName   = "authcodesession"
+	roleInUserCollectionName        = "customroleinuser"
+	deviceCollectionName            = "device"
+	revokedTokenCollectionName      = "revokedtoken"
+	pushedAuthRequestCollectionName = "pushedauthrequest"
+	usedJTICollectionName           = "usedjti"
+	schemaVersionCollectionName     = "schemaversion"
 
 	timeoutSecond = 5
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
@@ -64,9 +65,10 @@ func (h *ProjectInfoHandler) Add(ent *model.ProjectInfo) *errors.Error {
 		TokenConfig: &tokenConfig{
 			AccessTokenLifeSpan:  ent.TokenConfig.AccessTokenLifeSpan,
 			RefreshTokenLifeSpan: ent.TokenConfig.RefreshTokenLifeSpan,
+			RefreshTokenRotation: ent.TokenConfig.RefreshTokenRotation,
 			SigningAlgorithm:     ent.TokenConfig.SigningAlgorithm,
-			SignPublicKey:        ent.TokenConfig.SignPublicKey,
-			SignSecretKey:        ent.TokenConfig.SignSecretKey,
+			SignKeys:             toSignKeys(ent.TokenConfig.SignKeys),
+			KeyRotationInterval:  ent.TokenConfig.KeyRotationInterval,
 		},
 		PasswordPolicy: passwordPolicy{
 			MinimumLength:       ent.PasswordPolicy.MinimumLength,
@@ -82,6 +84,14 @@ func (h *ProjectInfoHandler) Add(ent *model.ProjectInfo) *errors.Error {
 			LockDuration:     ent.UserLock.LockDuration,
 			FailureResetTime: ent.UserLock.FailureResetTime,
 		},
+		PasswordHashConfig: passwordHashConfig{
+			Algorithm:         string(ent.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  ent.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      ent.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: ent.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        ent.PasswordHashConfig.BcryptCost,
+		},
+		TrustedIssuers: toTrustedIssuers(ent.TrustedIssuers),
 	}
 	for _, t := range ent.AllowGrantTypes {
 		v.AllowGrantTypes = append(v.AllowGrantTypes, string(t))
@@ -149,9 +159,10 @@ func (h *ProjectInfoHandler) GetList(filter *model.ProjectFilter) ([]*model.Proj
 			TokenConfig: &model.TokenConfig{
 				AccessTokenLifeSpan:  prj.TokenConfig.AccessTokenLifeSpan,
 				RefreshTokenLifeSpan: prj.TokenConfig.RefreshTokenLifeSpan,
+				RefreshTokenRotation: prj.TokenConfig.RefreshTokenRotation,
 				SigningAlgorithm:     prj.TokenConfig.SigningAlgorithm,
-				SignPublicKey:        prj.TokenConfig.SignPublicKey,
-				SignSecretKey:        prj.TokenConfig.SignSecretKey,
+				SignKeys:             fromSignKeys(prj.TokenConfig),
+				KeyRotationInterval:  prj.TokenConfig.KeyRotationInterval,
 			},
 			PasswordPolicy: model.PasswordPolicy{
 				MinimumLength:       prj.PasswordPolicy.MinimumLength,
@@ -167,6 +178,14 @@ func (h *ProjectInfoHandler) GetList(filter *model.ProjectFilter) ([]*model.Proj
 				LockDuration:     prj.UserLock.LockDuration,
 				FailureResetTime: prj.UserLock.FailureResetTime,
 			},
+			PasswordHashConfig: model.PasswordHashConfig{
+				Algorithm:         model.PasswordHashAlgorithm(prj.PasswordHashConfig.Algorithm),
+				Argon2Iterations:  prj.PasswordHashConfig.Argon2Iterations,
+				Argon2Memory:      prj.PasswordHashConfig.Argon2Memory,
+				Argon2Parallelism: prj.PasswordHashConfig.Argon2Parallelism,
+				BcryptCost:        prj.PasswordHashConfig.BcryptCost,
+			},
+			TrustedIssuers: fromTrustedIssuers(prj.TrustedIssuers),
 		}
 		for _, t := range prj.AllowGrantTypes {
 			info.AllowGrantTypes = append(info.AllowGrantTypes, model.GrantType(t))
@@ -191,9 +210,10 @@ func (h *ProjectInfoHandler) Update(ent *model.ProjectInfo) *errors.Error {
 		TokenConfig: &tokenConfig{
 			AccessTokenLifeSpan:  ent.TokenConfig.AccessTokenLifeSpan,
 			RefreshTokenLifeSpan: ent.TokenConfig.RefreshTokenLifeSpan,
+			RefreshTokenRotation: ent.TokenConfig.RefreshTokenRotation,
 			SigningAlgorithm:     ent.TokenConfig.SigningAlgorithm,
-			SignPublicKey:        ent.TokenConfig.SignPublicKey,
-			SignSecretKey:        ent.TokenConfig.SignSecretKey,
+			SignKeys:             toSignKeys(ent.TokenConfig.SignKeys),
+			KeyRotationInterval:  ent.TokenConfig.KeyRotationInterval,
 		},
 		PasswordPolicy: passwordPolicy{
 			MinimumLength:       ent.PasswordPolicy.MinimumLength,
@@ -209,6 +229,14 @@ func (h *ProjectInfoHandler) Update(ent *model.ProjectInfo) *errors.Error {
 			LockDuration:     ent.UserLock.LockDuration,
 			FailureResetTime: ent.UserLock.FailureResetTime,
 		},
+		PasswordHashConfig: passwordHashConfig{
+			Algorithm:         string(ent.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  ent.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      ent.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: ent.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        ent.PasswordHashConfig.BcryptCost,
+		},
+		TrustedIssuers: toTrustedIssuers(ent.TrustedIssuers),
 	}
 	for _, t := range ent.AllowGrantTypes {
 		v.AllowGrantTypes = append(v.AllowGrantTypes, string(t))
@@ -227,3 +255,92 @@ func (h *ProjectInfoHandler) Update(ent *model.ProjectInfo) *errors.Error {
 
 	return nil
 }
+
+func toSignKeys(keys []*model.SignKey) []signKey {
+	res := []signKey{}
+	for _, k := range keys {
+		res = append(res, signKey{
+			KeyID:            k.KeyID,
+			State:            string(k.State),
+			SigningAlgorithm: k.SigningAlgorithm,
+			PublicKey:        k.PublicKey,
+			PrivateKey:       k.PrivateKey,
+			CreatedAt:        k.CreatedAt,
+			ActivatedAt:      k.ActivatedAt,
+			ExpiresAt:        k.ExpiresAt,
+		})
+	}
+	return res
+}
+
+func fromSignKeys(cfg *tokenConfig) []*model.SignKey {
+	res := []*model.SignKey{}
+	if len(cfg.SignKeys) == 0 && len(cfg.SignSecretKey) > 0 {
+		// old data which has only one key, so use it as active key
+		// key ID is generated from public key to return the same value every time
+		res = append(res, &model.SignKey{
+			KeyID:            uuid.NewSHA1(uuid.NameSpaceOID, cfg.SignPublicKey).String(),
+			State:            model.SignKeyStateActive,
+			SigningAlgorithm: cfg.SigningAlgorithm,
+			PublicKey:        cfg.SignPublicKey,
+			PrivateKey:       cfg.SignSecretKey,
+		})
+		return res
+	}
+
+	for _, k := range cfg.SignKeys {
+		res = append(res, &model.SignKey{
+			KeyID:            k.KeyID,
+			State:            model.SignKeyState(k.State),
+			SigningAlgorithm: k.SigningAlgorithm,
+			PublicKey:        k.PublicKey,
+			PrivateKey:       k.PrivateKey,
+			CreatedAt:        k.CreatedAt,
+			ActivatedAt:      k.ActivatedAt,
+			ExpiresAt:        k.ExpiresAt,
+		})
+	}
+	return res
+}
+
+func toTrustedIssuers(issuers []model.TrustedIssuer) []trustedIssuer {
+	res := []trustedIssuer{}
+	for _, t := range issuers {
+		v := trustedIssuer{
+			Issuer:          t.Issuer,
+			JWKS:            t.JWKS,
+			Audience:        t.Audience,
+			SubjectMappings: []subjectMapping{},
+		}
+		for _, m := range t.SubjectMappings {
+			v.SubjectMappings = append(v.SubjectMappings, subjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+func fromTrustedIssuers(issuers []trustedIssuer) []model.TrustedIssuer {
+	res := []model.TrustedIssuer{}
+	for _, t := range issuers {
+		v := model.TrustedIssuer{
+			Issuer:          t.Issuer,
+			JWKS:            t.JWKS,
+			Audience:        t.Audience,
+			SubjectMappings: []model.SubjectMapping{},
+		}
+		for _, m := range t.SubjectMappings {
+			v.SubjectMappings = append(v.SubjectMappings, model.SubjectMapping{
+				Subject:  m.Subject,
+				UserName: m.UserName,
+				ClientID: m.ClientID,
+			})
+		}
+		res = append(res, v)
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PushedAuthRequestHandler implement db.PushedAuthRequestHandler
+type PushedAuthRequestHandler struct {
+	dbClient *mongo.Client
+}
+
+// NewPushedAuthRequestHandler ...
+func NewPushedAuthRequestHandler(dbClient *mongo.Client) (*PushedAuthRequestHandler, *errors.Error) {
+	res := &PushedAuthRequestHandler{
+		dbClient: dbClient,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	// Get index info
+	col := res.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+	iv := col.Indexes()
+	var ires []bson.M
+	cur, err := iv.List(ctx)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+	if err := cur.All(ctx, &ires); err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+
+	if len(ires) == 0 {
+		logger.Info("Create index for pushed authorization request")
+		// Create Index to Project Name and Request URI
+		mod := mongo.IndexModel{
+			Keys: bson.M{
+				"project_name": 1, // index in ascending order
+				"request_uri":  1, // index in ascending order
+			},
+		}
+		if _, err := iv.CreateOne(ctx, mod); err != nil {
+			return nil, errors.New("DB failed", "Failed to create index: %v", err)
+		}
+	}
+
+	return res, nil
+}
+
+// Add ...
+func (h *PushedAuthRequestHandler) Add(projectName string, ent *model.PushedAuthRequest) *errors.Error {
+	v := &pushedAuthRequest{
+		RequestURI:  ent.RequestURI,
+		ProjectName: ent.ProjectName,
+		ClientID:    ent.ClientID,
+		Parameters:  ent.Parameters,
+		ExpiresIn:   ent.ExpiresIn,
+		CreatedAt:   ent.CreatedAt,
+		ExpiresAt:   ent.CreatedAt.Add(time.Second * time.Duration(ent.ExpiresIn)),
+	}
+
+	col := h.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(ctx, v)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert pushed authorization request to mongodb: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteAll ...
+func (h *PushedAuthRequestHandler) DeleteAll(projectName string) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+	filter := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete pushed authorization request from mongodb: %v", err)
+	}
+	return nil
+}
+
+// Cleanup ...
+func (h *PushedAuthRequestHandler) Cleanup(now time.Time) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+	filter := bson.D{
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete expired pushed authorization request from mongodb: %v", err)
+	}
+
+	return nil
+}
+
+// GetList ...
+func (h *PushedAuthRequestHandler) GetList(projectName string, filter *model.PushedAuthRequestFilter) ([]*model.PushedAuthRequest, *errors.Error) {
+	col := h.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+
+	f := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	if filter != nil {
+		if filter.RequestURI != "" {
+			f = append(f, bson.E{Key: "request_uri", Value: filter.RequestURI})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx, f)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get pushed authorization request list from mongodb: %v", err)
+	}
+
+	requests := []pushedAuthRequest{}
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, errors.New("DB failed", "Failed to get pushed authorization request list from mongodb: %v", err)
+	}
+
+	res := []*model.PushedAuthRequest{}
+	for _, ent := range requests {
+		res = append(res, &model.PushedAuthRequest{
+			RequestURI:  ent.RequestURI,
+			ProjectName: ent.ProjectName,
+			ClientID:    ent.ClientID,
+			Parameters:  ent.Parameters,
+			ExpiresIn:   ent.ExpiresIn,
+			CreatedAt:   ent.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// Delete ...
+func (h *PushedAuthRequestHandler) Delete(projectName string, requestURI string) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(pushedAuthRequestCollectionName)
+	filter := bson.D{
+		{Key: "project_name", Value: projectName},
+		{Key: "request_uri", Value: requestURI},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete pushed authorization request from mongodb: %v", err)
+	}
+	return nil
+}
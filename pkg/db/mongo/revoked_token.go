@@ -0,0 +1,150 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RevokedTokenHandler implement db.RevokedTokenHandler
+type RevokedTokenHandler struct {
+	dbClient *mongo.Client
+}
+
+// NewRevokedTokenHandler ...
+func NewRevokedTokenHandler(dbClient *mongo.Client) (*RevokedTokenHandler, *errors.Error) {
+	res := &RevokedTokenHandler{
+		dbClient: dbClient,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	// Get index info
+	col := res.dbClient.Database(databaseName).Collection(revokedTokenCollectionName)
+	iv := col.Indexes()
+	var ires []bson.M
+	cur, err := iv.List(ctx)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+	if err := cur.All(ctx, &ires); err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+
+	if len(ires) == 0 {
+		logger.Info("Create index for revoked token")
+		// Create Index to Project Name and Token ID
+		mod := mongo.IndexModel{
+			Keys: bson.M{
+				"project_name": 1, // index in ascending order
+				"token_id":     1, // index in ascending order
+			},
+		}
+		if _, err := iv.CreateOne(ctx, mod); err != nil {
+			return nil, errors.New("DB failed", "Failed to create index: %v", err)
+		}
+	}
+
+	return res, nil
+}
+
+// Add ...
+func (h *RevokedTokenHandler) Add(projectName string, ent *model.RevokedToken) *errors.Error {
+	v := &revokedToken{
+		TokenID:     ent.TokenID,
+		ProjectName: ent.ProjectName,
+		ExpiresAt:   ent.ExpiresAt,
+	}
+
+	col := h.dbClient.Database(databaseName).Collection(revokedTokenCollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(ctx, v)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert revoked token to mongodb: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteAll ...
+func (h *RevokedTokenHandler) DeleteAll(projectName string) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(revokedTokenCollectionName)
+	filter := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete revoked token from mongodb: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *RevokedTokenHandler) GetList(projectName string, filter *model.RevokedTokenFilter) ([]*model.RevokedToken, *errors.Error) {
+	col := h.dbClient.Database(databaseName).Collection(revokedTokenCollectionName)
+
+	f := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	if filter != nil {
+		if filter.TokenID != "" {
+			f = append(f, bson.E{Key: "token_id", Value: filter.TokenID})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx, f)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get revoked token list from mongodb: %v", err)
+	}
+
+	tokens := []revokedToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, errors.New("DB failed", "Failed to get revoked token list from mongodb: %v", err)
+	}
+
+	res := []*model.RevokedToken{}
+	for _, ent := range tokens {
+		res = append(res, &model.RevokedToken{
+			TokenID:     ent.TokenID,
+			ProjectName: ent.ProjectName,
+			ExpiresAt:   ent.ExpiresAt,
+		})
+	}
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *RevokedTokenHandler) Cleanup(now time.Time) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(revokedTokenCollectionName)
+	filter := bson.D{
+		{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: now}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete expired revoked token from mongodb: %v", err)
+	}
+
+	return nil
+}
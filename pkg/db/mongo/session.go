@@ -64,6 +64,8 @@ func (h *SessionHandler) Add(projectName string, s *model.Session) *errors.Error
 		ExpiresIn:    s.ExpiresIn,
 		FromIP:       s.FromIP,
 		LastAuthTime: s.LastAuthTime,
+		FamilyID:     s.FamilyID,
+		ClientID:     s.ClientID,
 	}
 
 	col := h.dbClient.Database(databaseName).Collection(sessionCollectionName)
@@ -96,6 +98,9 @@ func (h *SessionHandler) Delete(projectName string, filter *model.SessionFilter)
 		if filter.UserID != "" {
 			f = append(f, bson.E{Key: "user_id", Value: filter.UserID})
 		}
+		if filter.FamilyID != "" {
+			f = append(f, bson.E{Key: "family_id", Value: filter.FamilyID})
+		}
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
@@ -139,6 +144,9 @@ func (h *SessionHandler) GetList(projectName string, filter *model.SessionFilter
 		if filter.UserID != "" {
 			f = append(f, bson.E{Key: "user_id", Value: filter.UserID})
 		}
+		if filter.FamilyID != "" {
+			f = append(f, bson.E{Key: "family_id", Value: filter.FamilyID})
+		}
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
@@ -164,6 +172,8 @@ func (h *SessionHandler) GetList(projectName string, filter *model.SessionFilter
 			ExpiresIn:    s.ExpiresIn,
 			FromIP:       s.FromIP,
 			LastAuthTime: s.LastAuthTime,
+			FamilyID:     s.FamilyID,
+			ClientID:     s.ClientID,
 		})
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -22,12 +23,12 @@ func NewTransactionManager(dbClient *mongo.Client) *TransactionManager {
 }
 
 // Transaction ...
-func (m *TransactionManager) Transaction(txFunc func() *errors.Error) *errors.Error {
+func (m *TransactionManager) Transaction(txFunc func(tx model.Tx) *errors.Error) *errors.Error {
 	if err := m.beginTx(); err != nil {
 		return errors.New("DB failed", "Begin transaction failed: %v", err)
 	}
 
-	if err := txFunc(); err != nil {
+	if err := txFunc(m.session); err != nil {
 		m.abortTx()
 		return err
 	}
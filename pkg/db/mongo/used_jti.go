@@ -0,0 +1,156 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UsedJTIHandler implement db.UsedJTIHandler
+type UsedJTIHandler struct {
+	dbClient *mongo.Client
+}
+
+// NewUsedJTIHandler ...
+func NewUsedJTIHandler(dbClient *mongo.Client) (*UsedJTIHandler, *errors.Error) {
+	res := &UsedJTIHandler{
+		dbClient: dbClient,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	// Get index info
+	col := res.dbClient.Database(databaseName).Collection(usedJTICollectionName)
+	iv := col.Indexes()
+	var ires []bson.M
+	cur, err := iv.List(ctx)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+	if err := cur.All(ctx, &ires); err != nil {
+		return nil, errors.New("DB failed", "Failed to get index info: %v", err)
+	}
+
+	if len(ires) == 0 {
+		logger.Info("Create index for used jti")
+		// Create Index to Project Name, Issuer and JTI
+		mod := mongo.IndexModel{
+			Keys: bson.M{
+				"project_name": 1, // index in ascending order
+				"issuer":       1, // index in ascending order
+				"jti":          1, // index in ascending order
+			},
+		}
+		if _, err := iv.CreateOne(ctx, mod); err != nil {
+			return nil, errors.New("DB failed", "Failed to create index: %v", err)
+		}
+	}
+
+	return res, nil
+}
+
+// Add ...
+func (h *UsedJTIHandler) Add(projectName string, ent *model.UsedJTI) *errors.Error {
+	v := &usedJTI{
+		JTI:         ent.JTI,
+		Issuer:      ent.Issuer,
+		ProjectName: ent.ProjectName,
+		ExpiresAt:   ent.ExpiresAt,
+	}
+
+	col := h.dbClient.Database(databaseName).Collection(usedJTICollectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(ctx, v)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert used jti to mongodb: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteAll ...
+func (h *UsedJTIHandler) DeleteAll(projectName string) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(usedJTICollectionName)
+	filter := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete used jti from mongodb: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *UsedJTIHandler) GetList(projectName string, filter *model.UsedJTIFilter) ([]*model.UsedJTI, *errors.Error) {
+	col := h.dbClient.Database(databaseName).Collection(usedJTICollectionName)
+
+	f := bson.D{
+		{Key: "project_name", Value: projectName},
+	}
+
+	if filter != nil {
+		if filter.JTI != "" {
+			f = append(f, bson.E{Key: "jti", Value: filter.JTI})
+		}
+		if filter.Issuer != "" {
+			f = append(f, bson.E{Key: "issuer", Value: filter.Issuer})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx, f)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get used jti list from mongodb: %v", err)
+	}
+
+	jtis := []usedJTI{}
+	if err := cursor.All(ctx, &jtis); err != nil {
+		return nil, errors.New("DB failed", "Failed to get used jti list from mongodb: %v", err)
+	}
+
+	res := []*model.UsedJTI{}
+	for _, ent := range jtis {
+		res = append(res, &model.UsedJTI{
+			JTI:         ent.JTI,
+			Issuer:      ent.Issuer,
+			ProjectName: ent.ProjectName,
+			ExpiresAt:   ent.ExpiresAt,
+		})
+	}
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *UsedJTIHandler) Cleanup(now time.Time) *errors.Error {
+	col := h.dbClient.Database(databaseName).Collection(usedJTICollectionName)
+	filter := bson.D{
+		{Key: "expires_at", Value: bson.D{{Key: "$lt", Value: now}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSecond*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.New("DB failed", "Failed to delete expired used jti from mongodb: %v", err)
+	}
+
+	return nil
+}
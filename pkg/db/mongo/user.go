@@ -73,6 +73,8 @@ func (h *UserInfoHandler) Add(projectName string, ent *model.UserInfo) *errors.E
 			PrivateKey: ent.OTPInfo.PrivateKey,
 			Enabled:    ent.OTPInfo.Enabled,
 		},
+		Profile:    toUserProfile(&ent.Profile),
+		Attributes: ent.Attributes,
 	}
 
 	uroles := []interface{}{}
@@ -180,6 +182,8 @@ func (h *UserInfoHandler) GetList(projectName string, filter *model.UserFilter)
 				PrivateKey: user.OTPInfo.PrivateKey,
 				Enabled:    user.OTPInfo.Enabled,
 			},
+			Profile:    fromUserProfile(&user.Profile),
+			Attributes: user.Attributes,
 		})
 	}
 
@@ -211,6 +215,8 @@ func (h *UserInfoHandler) Update(projectName string, ent *model.UserInfo) *error
 			PrivateKey: ent.OTPInfo.PrivateKey,
 			Enabled:    ent.OTPInfo.Enabled,
 		},
+		Profile:    toUserProfile(&ent.Profile),
+		Attributes: ent.Attributes,
 	}
 
 	updates := bson.D{
@@ -424,3 +430,47 @@ func (h *UserInfoHandler) DeleteAllCustomRole(projectName string, roleID string)
 
 	return nil
 }
+
+func toUserProfile(p *model.UserProfile) userProfile {
+	return userProfile{
+		FullName:            p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             address(p.Address),
+	}
+}
+
+func fromUserProfile(p *userProfile) model.UserProfile {
+	return model.UserProfile{
+		FullName:            p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             model.Address(p.Address),
+	}
+}
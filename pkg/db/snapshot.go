@@ -0,0 +1,44 @@
+package db
+
+import (
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+var (
+	// snapshotFile is a file path of the snapshot of memory database
+	// the snapshot is disabled if empty
+	snapshotFile string
+	// snapshotKey is a key to encrypt the snapshot, the snapshot is not encrypted if nil
+	snapshotKey []byte
+)
+
+// InitSnapshot enables the snapshot of memory database
+// it must be called before InitDBManager to restore the data from the file
+func InitSnapshot(fileName string, key []byte) {
+	snapshotFile = fileName
+	snapshotKey = key
+}
+
+// SaveSnapshot writes all data to the snapshot file
+// it does nothing if the snapshot is not enabled
+func (m *Manager) SaveSnapshot() *errors.Error {
+	if m.snapshot == nil {
+		return nil
+	}
+	return m.snapshot.Save()
+}
+
+// RunSnapshot saves the snapshot at intervals of intervalSec
+func RunSnapshot(intervalSec uint64) {
+	interval := time.Duration(intervalSec) * time.Second
+	for {
+		time.Sleep(interval)
+		logger.Debug("Save snapshot of database")
+		if err := GetInst().SaveSnapshot(); err != nil {
+			errors.Print(errors.Append(err, "Failed to save snapshot"))
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// ClientInfoHandler implement db.ClientInfoHandler
+type ClientInfoHandler struct {
+	client *Client
+}
+
+// NewClientHandler ...
+func NewClientHandler(client *Client) *ClientInfoHandler {
+	return &ClientInfoHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *ClientInfoHandler) Add(projectName string, ent *model.ClientInfo) *errors.Error {
+	q := `INSERT INTO clients (project_name, id, secret, access_type, created_at, allowed_callback_urls)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	if err := h.client.exec(q, h.values(projectName, ent)...); err != nil {
+		return errors.New("DB failed", "Failed to insert client to database: %v", err)
+	}
+	return h.setMetadata(projectName, ent)
+}
+
+// Delete ...
+func (h *ClientInfoHandler) Delete(projectName, clientID string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM clients WHERE project_name = ? AND id = ?`, projectName, clientID); err != nil {
+		return errors.New("DB failed", "Failed to delete client from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM client_metadata WHERE project_name = ? AND client_id = ?`, projectName, clientID); err != nil {
+		return errors.New("DB failed", "Failed to delete client metadata from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *ClientInfoHandler) GetList(projectName string, filter *model.ClientFilter) ([]*model.ClientInfo, *errors.Error) {
+	q := `SELECT c.id, c.secret, c.access_type, c.created_at, c.allowed_callback_urls, m.metadata FROM clients c
+		LEFT JOIN client_metadata m ON m.project_name = c.project_name AND m.client_id = c.id`
+	conds := []interface{}{"c.project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "c.id", filter.ID)
+	}
+	w, args := where(conds...)
+
+	res := []*model.ClientInfo{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt time.Time
+		var urls string
+		var metadata sql.NullString
+		cli := &model.ClientInfo{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&cli.ID, &cli.Secret, &cli.AccessType, &createdAt, &urls, &metadata); err != nil {
+			return err
+		}
+		cli.CreatedAt = createdAt
+		if err := fromJSON(urls, &cli.AllowedCallbackURLs); err != nil {
+			return err
+		}
+		// the client created before adding metadata table does not have the row
+		if metadata.Valid {
+			var m clientMetadata
+			if err := fromJSON(metadata.String, &m); err != nil {
+				return err
+			}
+			cli.JWKS = m.JWKS
+			cli.JWKSURI = m.JWKSURI
+			cli.RequestURIs = m.RequestURIs
+			cli.PostLogoutRedirectURIs = m.PostLogoutRedirectURIs
+			cli.BackchannelLogoutURI = m.BackchannelLogoutURI
+			cli.FrontchannelLogoutURI = m.FrontchannelLogoutURI
+			cli.RequestObjectSigningAlg = m.RequestObjectSigningAlg
+			cli.RequirePushedAuthRequests = m.RequirePushedAuthRequests
+			cli.TokenEndpointAuthMethod = m.TokenEndpointAuthMethod
+			cli.TLSClientAuthSubjectDN = m.TLSClientAuthSubjectDN
+			cli.RequireDPoP = m.RequireDPoP
+			cli.TokenExchangeAudiences = m.TokenExchangeAudiences
+			cli.TokenExchangeImpersonation = m.TokenExchangeImpersonation
+			cli.RegistrationAccessTokenHash = m.RegistrationAccessTokenHash
+		}
+
+		res = append(res, cli)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get client list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Update ...
+func (h *ClientInfoHandler) Update(projectName string, ent *model.ClientInfo) *errors.Error {
+	q := `UPDATE clients SET secret = ?, access_type = ?, created_at = ?, allowed_callback_urls = ?
+		WHERE project_name = ? AND id = ?`
+	values := h.values(projectName, ent)
+	args := append(append([]interface{}{}, values[2:]...), values[0], values[1])
+	if err := h.client.exec(q, args...); err != nil {
+		return errors.New("DB failed", "Failed to update client in database: %v", err)
+	}
+	return h.setMetadata(projectName, ent)
+}
+
+// DeleteAll ...
+func (h *ClientInfoHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM clients WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete client from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM client_metadata WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete client metadata from database: %v", err)
+	}
+	return nil
+}
+
+// setMetadata replaces the metadata of the client
+func (h *ClientInfoHandler) setMetadata(projectName string, ent *model.ClientInfo) *errors.Error {
+	if err := h.client.exec(`DELETE FROM client_metadata WHERE project_name = ? AND client_id = ?`, projectName, ent.ID); err != nil {
+		return errors.New("DB failed", "Failed to delete client metadata from database: %v", err)
+	}
+
+	m := clientMetadata{
+		JWKS:                        ent.JWKS,
+		JWKSURI:                     ent.JWKSURI,
+		RequestURIs:                 ent.RequestURIs,
+		PostLogoutRedirectURIs:      ent.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:        ent.BackchannelLogoutURI,
+		FrontchannelLogoutURI:       ent.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:     ent.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:   ent.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:     ent.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:      ent.TLSClientAuthSubjectDN,
+		RequireDPoP:                 ent.RequireDPoP,
+		TokenExchangeAudiences:      ent.TokenExchangeAudiences,
+		TokenExchangeImpersonation:  ent.TokenExchangeImpersonation,
+		RegistrationAccessTokenHash: ent.RegistrationAccessTokenHash,
+	}
+	q := `INSERT INTO client_metadata (project_name, client_id, metadata) VALUES (?, ?, ?)`
+	if err := h.client.exec(q, projectName, ent.ID, toJSON(&m)); err != nil {
+		return errors.New("DB failed", "Failed to insert client metadata to database: %v", err)
+	}
+	return nil
+}
+
+// values returns the column values in order of project_name, id, secret, access_type, created_at, allowed_callback_urls
+func (h *ClientInfoHandler) values(projectName string, ent *model.ClientInfo) []interface{} {
+	urls := ent.AllowedCallbackURLs
+	if urls == nil {
+		urls = []string{}
+	}
+	return []interface{}{
+		projectName,
+		ent.ID,
+		ent.Secret,
+		ent.AccessType,
+		toDBTime(ent.CreatedAt),
+		toJSON(urls),
+	}
+}
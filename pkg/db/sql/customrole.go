@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// CustomRoleHandler implement db.CustomRoleHandler
+type CustomRoleHandler struct {
+	client *Client
+}
+
+// NewCustomRoleHandler ...
+func NewCustomRoleHandler(client *Client) *CustomRoleHandler {
+	return &CustomRoleHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *CustomRoleHandler) Add(projectName string, ent *model.CustomRole) *errors.Error {
+	q := `INSERT INTO custom_roles (project_name, id, name, created_at) VALUES (?, ?, ?, ?)`
+	if err := h.client.exec(q, projectName, ent.ID, ent.Name, toDBTime(ent.CreatedAt)); err != nil {
+		return errors.New("DB failed", "Failed to insert custom role to database: %v", err)
+	}
+	return nil
+}
+
+// Delete ...
+func (h *CustomRoleHandler) Delete(projectName string, roleID string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM custom_roles WHERE project_name = ? AND id = ?`, projectName, roleID); err != nil {
+		return errors.New("DB failed", "Failed to delete custom role from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *CustomRoleHandler) GetList(projectName string, filter *model.CustomRoleFilter) ([]*model.CustomRole, *errors.Error) {
+	q := `SELECT id, name, created_at FROM custom_roles`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "id", filter.ID, "name", filter.Name)
+	}
+	w, args := where(conds...)
+
+	res := []*model.CustomRole{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt time.Time
+		role := &model.CustomRole{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&role.ID, &role.Name, &createdAt); err != nil {
+			return err
+		}
+		role.CreatedAt = createdAt
+
+		res = append(res, role)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get custom role list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Update ...
+func (h *CustomRoleHandler) Update(projectName string, ent *model.CustomRole) *errors.Error {
+	q := `UPDATE custom_roles SET name = ?, created_at = ? WHERE project_name = ? AND id = ?`
+	if err := h.client.exec(q, ent.Name, toDBTime(ent.CreatedAt), projectName, ent.ID); err != nil {
+		return errors.New("DB failed", "Failed to update custom role in database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *CustomRoleHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM custom_roles WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete custom role from database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// DeviceHandler implement db.DeviceHandler
+type DeviceHandler struct {
+	client *Client
+}
+
+// NewDeviceHandler ...
+func NewDeviceHandler(client *Client) *DeviceHandler {
+	return &DeviceHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *DeviceHandler) Add(projectName string, ent *model.Device) *errors.Error {
+	q := `INSERT INTO devices (project_name, device_code, user_code, expires_in, expires_at, created_at, login_session_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	expiresAt := ent.CreatedAt.Add(time.Second * time.Duration(ent.ExpiresIn))
+	err := h.client.exec(q, projectName, ent.DeviceCode, ent.UserCode, ent.ExpiresIn, expiresAt.Unix(),
+		toDBTime(ent.CreatedAt), ent.LoginSessionID)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert device to database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *DeviceHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM devices WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete device from database: %v", err)
+	}
+	return nil
+}
+
+// Cleanup ...
+func (h *DeviceHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM devices WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired device from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *DeviceHandler) GetList(projectName string, filter *model.DeviceFilter) ([]*model.Device, *errors.Error) {
+	q := `SELECT device_code, user_code, expires_in, created_at, login_session_id FROM devices`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "device_code", filter.DeviceCode, "user_code", filter.UserCode)
+	}
+	w, args := where(conds...)
+
+	res := []*model.Device{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt time.Time
+		d := &model.Device{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&d.DeviceCode, &d.UserCode, &d.ExpiresIn, &createdAt, &d.LoginSessionID); err != nil {
+			return err
+		}
+		d.CreatedAt = createdAt
+
+		res = append(res, d)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get device list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Delete ...
+func (h *DeviceHandler) Delete(projectName string, deviceCode string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM devices WHERE project_name = ? AND device_code = ?`, projectName, deviceCode); err != nil {
+		return errors.New("DB failed", "Failed to delete device from database: %v", err)
+	}
+	return nil
+}
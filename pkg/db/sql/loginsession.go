@@ -0,0 +1,154 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+const loginSessionColumns = `session_id, code, expires_date, scope, response_type, client_id, redirect_uri, nonce,
+	response_mode, prompt, user_id, login_date, code_challenge, code_challenge_method`
+
+// LoginSessionHandler implement db.LoginSessionHandler
+type LoginSessionHandler struct {
+	client *Client
+}
+
+// NewLoginSessionHandler ...
+func NewLoginSessionHandler(client *Client) *LoginSessionHandler {
+	return &LoginSessionHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *LoginSessionHandler) Add(projectName string, ent *model.LoginSession) *errors.Error {
+	q := `INSERT INTO login_sessions (project_name, expires_at, ` + loginSessionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := append([]interface{}{projectName, ent.ExpiresDate.Unix()}, h.values(ent)...)
+	if err := h.client.exec(q, args...); err != nil {
+		return errors.New("DB failed", "Failed to insert login session to database: %v", err)
+	}
+	return nil
+}
+
+// Update ...
+func (h *LoginSessionHandler) Update(projectName string, ent *model.LoginSession) *errors.Error {
+	q := `UPDATE login_sessions SET expires_at = ?, code = ?, expires_date = ?, scope = ?, response_type = ?, client_id = ?,
+		redirect_uri = ?, nonce = ?, response_mode = ?, prompt = ?, user_id = ?, login_date = ?, code_challenge = ?, code_challenge_method = ?
+		WHERE project_name = ? AND session_id = ?`
+	values := h.values(ent)
+	args := append([]interface{}{ent.ExpiresDate.Unix()}, values[1:]...)
+	args = append(args, projectName, ent.SessionID)
+	if err := h.client.exec(q, args...); err != nil {
+		return errors.New("DB failed", "Failed to update login session in database: %v", err)
+	}
+	return nil
+}
+
+// Delete ...
+func (h *LoginSessionHandler) Delete(projectName string, filter *model.LoginSessionFilter) *errors.Error {
+	if filter == nil {
+		return nil
+	}
+
+	w, args := where("project_name", projectName, "session_id", filter.SessionID, "user_id", filter.UserID, "client_id", filter.ClientID)
+	if err := h.client.exec(`DELETE FROM login_sessions`+w, args...); err != nil {
+		return errors.New("DB failed", "Failed to delete login session from database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *LoginSessionHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM login_sessions WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete login session from database: %v", err)
+	}
+	return nil
+}
+
+// GetByCode ...
+func (h *LoginSessionHandler) GetByCode(projectName string, code string) (*model.LoginSession, *errors.Error) {
+	return h.get(projectName, "code", code)
+}
+
+// Get ...
+func (h *LoginSessionHandler) Get(projectName string, sessionID string) (*model.LoginSession, *errors.Error) {
+	return h.get(projectName, "session_id", sessionID)
+}
+
+// Cleanup ...
+func (h *LoginSessionHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM login_sessions WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired login session from database: %v", err)
+	}
+	return nil
+}
+
+func (h *LoginSessionHandler) get(projectName string, key string, value string) (*model.LoginSession, *errors.Error) {
+	q := `SELECT ` + loginSessionColumns + ` FROM login_sessions WHERE project_name = ? AND ` + key + ` = ?`
+
+	var res *model.LoginSession
+	err := h.client.query(func(rows *sql.Rows) error {
+		var expiresDate, loginDate time.Time
+		var responseType, prompt string
+		s := &model.LoginSession{
+			ProjectName: projectName,
+		}
+		err := rows.Scan(&s.SessionID, &s.Code, &expiresDate, &s.Scope, &responseType, &s.ClientID, &s.RedirectURI, &s.Nonce,
+			&s.ResponseMode, &prompt, &s.UserID, &loginDate, &s.CodeChallenge, &s.CodeChallengeMethod)
+		if err != nil {
+			return err
+		}
+		s.ExpiresDate = expiresDate
+		s.LoginDate = loginDate
+		if err := fromJSON(responseType, &s.ResponseType); err != nil {
+			return err
+		}
+		if err := fromJSON(prompt, &s.Prompt); err != nil {
+			return err
+		}
+
+		res = s
+		return nil
+	}, q, projectName, value)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get login session from database: %v", err)
+	}
+	if res == nil {
+		return nil, model.ErrNoSuchLoginSession
+	}
+
+	return res, nil
+}
+
+// values returns the column values in order of loginSessionColumns
+func (h *LoginSessionHandler) values(ent *model.LoginSession) []interface{} {
+	responseType := ent.ResponseType
+	if responseType == nil {
+		responseType = []string{}
+	}
+	prompt := ent.Prompt
+	if prompt == nil {
+		prompt = []string{}
+	}
+
+	return []interface{}{
+		ent.SessionID,
+		ent.Code,
+		toDBTime(ent.ExpiresDate),
+		ent.Scope,
+		toJSON(responseType),
+		ent.ClientID,
+		ent.RedirectURI,
+		ent.Nonce,
+		ent.ResponseMode,
+		toJSON(prompt),
+		ent.UserID,
+		toDBTime(ent.LoginDate),
+		ent.CodeChallenge,
+		ent.CodeChallengeMethod,
+	}
+}
@@ -0,0 +1,332 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// sqlMigration is a migration step written in SQL statements
+// the steps must not be changed after released, please append a new step instead
+// each step is applied only once because it is applied in the transaction with the schema version
+type sqlMigration struct {
+	version     int
+	description string
+	up          []string
+	down        []string
+}
+
+var migrations = []sqlMigration{
+	{
+		version:     1,
+		description: "initial schema",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS projects (
+				name TEXT PRIMARY KEY,
+				created_at TIMESTAMP NOT NULL,
+				permit_delete BOOLEAN NOT NULL,
+				token_config TEXT NOT NULL,
+				password_policy TEXT NOT NULL,
+				allow_grant_types TEXT NOT NULL,
+				user_lock TEXT NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS users (
+				project_name TEXT NOT NULL,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				password_hash TEXT NOT NULL,
+				system_roles TEXT NOT NULL,
+				lock_state TEXT NOT NULL,
+				otp_info TEXT NOT NULL,
+				PRIMARY KEY (project_name, id)
+			)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS users_name_idx ON users (project_name, name)`,
+			`CREATE TABLE IF NOT EXISTS user_custom_roles (
+				project_name TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				role_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, user_id, role_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS user_custom_roles_role_idx ON user_custom_roles (project_name, role_id)`,
+			`CREATE TABLE IF NOT EXISTS clients (
+				project_name TEXT NOT NULL,
+				id TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				access_type TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				allowed_callback_urls TEXT NOT NULL,
+				PRIMARY KEY (project_name, id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS custom_roles (
+				project_name TEXT NOT NULL,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (project_name, id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS sessions (
+				project_name TEXT NOT NULL,
+				session_id TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				expires_in BIGINT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				from_ip TEXT NOT NULL,
+				last_auth_time TIMESTAMP NOT NULL,
+				family_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, session_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (project_name, user_id)`,
+			`CREATE INDEX IF NOT EXISTS sessions_expires_idx ON sessions (expires_at)`,
+			`CREATE TABLE IF NOT EXISTS login_sessions (
+				project_name TEXT NOT NULL,
+				session_id TEXT NOT NULL,
+				code TEXT NOT NULL,
+				expires_date TIMESTAMP NOT NULL,
+				expires_at BIGINT NOT NULL,
+				scope TEXT NOT NULL,
+				response_type TEXT NOT NULL,
+				client_id TEXT NOT NULL,
+				redirect_uri TEXT NOT NULL,
+				nonce TEXT NOT NULL,
+				response_mode TEXT NOT NULL,
+				prompt TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				login_date TIMESTAMP NOT NULL,
+				code_challenge TEXT NOT NULL,
+				code_challenge_method TEXT NOT NULL,
+				PRIMARY KEY (project_name, session_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS login_sessions_code_idx ON login_sessions (project_name, code)`,
+			`CREATE INDEX IF NOT EXISTS login_sessions_expires_idx ON login_sessions (expires_at)`,
+			`CREATE TABLE IF NOT EXISTS devices (
+				project_name TEXT NOT NULL,
+				device_code TEXT NOT NULL,
+				user_code TEXT NOT NULL,
+				expires_in BIGINT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				login_session_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, device_code)
+			)`,
+			`CREATE INDEX IF NOT EXISTS devices_user_code_idx ON devices (project_name, user_code)`,
+			`CREATE TABLE IF NOT EXISTS revoked_tokens (
+				project_name TEXT NOT NULL,
+				token_id TEXT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				PRIMARY KEY (project_name, token_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS revoked_tokens_expires_idx ON revoked_tokens (expires_at)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS revoked_tokens`,
+			`DROP TABLE IF EXISTS devices`,
+			`DROP TABLE IF EXISTS login_sessions`,
+			`DROP TABLE IF EXISTS sessions`,
+			`DROP TABLE IF EXISTS custom_roles`,
+			`DROP TABLE IF EXISTS clients`,
+			`DROP TABLE IF EXISTS user_custom_roles`,
+			`DROP TABLE IF EXISTS users`,
+			`DROP TABLE IF EXISTS projects`,
+		},
+	},
+	{
+		// the metadata is stored in the other table,
+		// because SQLite can not add a column idempotently and can not drop it
+		version:     2,
+		description: "add client metadata for request object",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS client_metadata (
+				project_name TEXT NOT NULL,
+				client_id TEXT NOT NULL,
+				metadata TEXT NOT NULL,
+				PRIMARY KEY (project_name, client_id)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS client_metadata`,
+		},
+	},
+	{
+		version:     3,
+		description: "add pushed authorization requests",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS pushed_auth_requests (
+				project_name TEXT NOT NULL,
+				request_uri TEXT NOT NULL,
+				client_id TEXT NOT NULL,
+				parameters TEXT NOT NULL,
+				expires_in BIGINT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (project_name, request_uri)
+			)`,
+			`CREATE INDEX IF NOT EXISTS pushed_auth_requests_expires_idx ON pushed_auth_requests (expires_at)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS pushed_auth_requests`,
+		},
+	},
+	{
+		version:     4,
+		description: "add used jtis",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS used_jtis (
+				project_name TEXT NOT NULL,
+				issuer TEXT NOT NULL,
+				jti TEXT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				PRIMARY KEY (project_name, issuer, jti)
+			)`,
+			`CREATE INDEX IF NOT EXISTS used_jtis_expires_idx ON used_jtis (expires_at)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS used_jtis`,
+		},
+	},
+	{
+		version:     5,
+		description: "add project metadata for trusted issuers",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS project_metadata (
+				project_name TEXT NOT NULL PRIMARY KEY,
+				metadata TEXT NOT NULL
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS project_metadata`,
+		},
+	},
+	{
+		version:     6,
+		description: "add clients of sessions for logout notification",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS session_clients (
+				project_name TEXT NOT NULL,
+				session_id TEXT NOT NULL,
+				client_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, session_id)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS session_clients`,
+		},
+	},
+	{
+		version:     7,
+		description: "add user metadata for profile claims",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS user_metadata (
+				project_name TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				metadata TEXT NOT NULL,
+				PRIMARY KEY (project_name, user_id)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS user_metadata`,
+		},
+	},
+	{
+		// the table is recreated in down because SQLite can not drop the column
+		version:     8,
+		description: "move clients of sessions to sessions table",
+		up: []string{
+			`ALTER TABLE sessions ADD COLUMN client_id TEXT NOT NULL DEFAULT ''`,
+			`UPDATE sessions SET client_id = COALESCE((
+				SELECT c.client_id FROM session_clients c
+				WHERE c.project_name = sessions.project_name AND c.session_id = sessions.session_id
+			), '')`,
+			`DROP TABLE IF EXISTS session_clients`,
+		},
+		down: []string{
+			`CREATE TABLE IF NOT EXISTS session_clients (
+				project_name TEXT NOT NULL,
+				session_id TEXT NOT NULL,
+				client_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, session_id)
+			)`,
+			`INSERT INTO session_clients SELECT project_name, session_id, client_id FROM sessions WHERE client_id <> ''`,
+			`CREATE TABLE sessions_v7 (
+				project_name TEXT NOT NULL,
+				session_id TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				expires_in BIGINT NOT NULL,
+				expires_at BIGINT NOT NULL,
+				from_ip TEXT NOT NULL,
+				last_auth_time TIMESTAMP NOT NULL,
+				family_id TEXT NOT NULL,
+				PRIMARY KEY (project_name, session_id)
+			)`,
+			`INSERT INTO sessions_v7 SELECT project_name, session_id, user_id, created_at, expires_in, expires_at, from_ip, last_auth_time, family_id FROM sessions`,
+			`DROP TABLE sessions`,
+			`ALTER TABLE sessions_v7 RENAME TO sessions`,
+			`CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (project_name, user_id)`,
+			`CREATE INDEX IF NOT EXISTS sessions_expires_idx ON sessions (expires_at)`,
+		},
+	},
+}
+
+// MigrationHandler implement db.MigrationHandler
+type MigrationHandler struct {
+	client *Client
+}
+
+// NewMigrationHandler ...
+func NewMigrationHandler(client *Client) *MigrationHandler {
+	return &MigrationHandler{
+		client: client,
+	}
+}
+
+// Steps ...
+func (h *MigrationHandler) Steps() []*model.MigrationStep {
+	res := []*model.MigrationStep{}
+	for _, m := range migrations {
+		m := m
+		res = append(res, &model.MigrationStep{
+			Version:     m.version,
+			Description: m.description,
+			Up:          func() *errors.Error { return h.execAll(m.version, m.up) },
+			Down:        func() *errors.Error { return h.execAll(m.version, m.down) },
+		})
+	}
+	return res
+}
+
+// GetVersion ...
+func (h *MigrationHandler) GetVersion() (int, *errors.Error) {
+	var version sql.NullInt64
+	err := h.client.query(func(rows *sql.Rows) error {
+		return rows.Scan(&version)
+	}, `SELECT MAX(version) FROM schema_migrations`)
+	if err != nil {
+		return 0, errors.New("DB failed", "Failed to get schema version: %v", err)
+	}
+	return int(version.Int64), nil
+}
+
+// SetVersion ...
+func (h *MigrationHandler) SetVersion(version int) *errors.Error {
+	if err := h.client.exec(`DELETE FROM schema_migrations WHERE version >= ?`, version); err != nil {
+		return errors.New("DB failed", "Failed to delete schema version: %v", err)
+	}
+	if version > 0 {
+		if err := h.client.exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			return errors.New("DB failed", "Failed to save schema version: %v", err)
+		}
+	}
+	return nil
+}
+
+func (h *MigrationHandler) execAll(version int, stmts []string) *errors.Error {
+	for _, stmt := range stmts {
+		if err := h.client.exec(stmt); err != nil {
+			return errors.New("DB failed", "Failed to apply migration %d: %v", version, err)
+		}
+	}
+	return nil
+}
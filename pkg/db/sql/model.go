@@ -0,0 +1,238 @@
+package sql
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+// The following structs are stored as JSON text in the column
+
+type tokenConfig struct {
+	AccessTokenLifeSpan  uint      `json:"access_token_life_span"`
+	RefreshTokenLifeSpan uint      `json:"refresh_token_life_span"`
+	RefreshTokenRotation bool      `json:"refresh_token_rotation"`
+	SigningAlgorithm     string    `json:"signing_algorithm"`
+	SignKeys             []signKey `json:"sign_keys"`
+	KeyRotationInterval  uint      `json:"key_rotation_interval"`
+}
+
+type signKey struct {
+	KeyID            string    `json:"key_id"`
+	State            string    `json:"state"`
+	SigningAlgorithm string    `json:"signing_algorithm"`
+	PublicKey        []byte    `json:"public_key"`
+	PrivateKey       []byte    `json:"private_key"`
+	CreatedAt        time.Time `json:"created_at"`
+	ActivatedAt      time.Time `json:"activated_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type passwordPolicy struct {
+	MinimumLength       uint     `json:"length"`
+	NotUserName         bool     `json:"not_user_name"`
+	BlackList           []string `json:"black_list"`
+	UseCharacter        string   `json:"use_character"`
+	UseDigit            bool     `json:"use_digit"`
+	UseSpecialCharacter bool     `json:"use_special_character"`
+
+	// HashConfig is stored with the policy, so that old rows without it use the default config
+	HashConfig passwordHashConfig `json:"hash_config"`
+}
+
+type passwordHashConfig struct {
+	Algorithm         string `json:"algorithm"`
+	Argon2Iterations  uint   `json:"argon2_iterations"`
+	Argon2Memory      uint   `json:"argon2_memory"`
+	Argon2Parallelism uint   `json:"argon2_parallelism"`
+	BcryptCost        uint   `json:"bcrypt_cost"`
+}
+
+type clientMetadata struct {
+	JWKS                        string   `json:"jwks"`
+	JWKSURI                     string   `json:"jwks_uri"`
+	RequestURIs                 []string `json:"request_uris"`
+	PostLogoutRedirectURIs      []string `json:"post_logout_redirect_uris"`
+	BackchannelLogoutURI        string   `json:"backchannel_logout_uri"`
+	FrontchannelLogoutURI       string   `json:"frontchannel_logout_uri"`
+	RequestObjectSigningAlg     string   `json:"request_object_signing_alg"`
+	RequirePushedAuthRequests   bool     `json:"require_pushed_auth_requests"`
+	TokenEndpointAuthMethod     string   `json:"token_endpoint_auth_method"`
+	TLSClientAuthSubjectDN      string   `json:"tls_client_auth_subject_dn"`
+	RequireDPoP                 bool     `json:"require_dpop"`
+	TokenExchangeAudiences      []string `json:"token_exchange_audiences"`
+	TokenExchangeImpersonation  bool     `json:"token_exchange_impersonation"`
+	RegistrationAccessTokenHash string   `json:"registration_access_token_hash"`
+}
+
+type subjectMapping struct {
+	Subject  string `json:"subject"`
+	UserName string `json:"user_name"`
+	ClientID string `json:"client_id"`
+}
+
+type trustedIssuer struct {
+	Issuer          string           `json:"issuer"`
+	JWKS            string           `json:"jwks"`
+	Audience        string           `json:"audience"`
+	SubjectMappings []subjectMapping `json:"subject_mappings"`
+}
+
+type projectMetadata struct {
+	TrustedIssuers []trustedIssuer `json:"trusted_issuers"`
+}
+
+type address struct {
+	Formatted     string `json:"formatted"`
+	StreetAddress string `json:"street_address"`
+	Locality      string `json:"locality"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+}
+
+type userProfile struct {
+	FullName            string  `json:"name"`
+	GivenName           string  `json:"given_name"`
+	FamilyName          string  `json:"family_name"`
+	MiddleName          string  `json:"middle_name"`
+	Nickname            string  `json:"nickname"`
+	ProfileURL          string  `json:"profile"`
+	Picture             string  `json:"picture"`
+	Website             string  `json:"website"`
+	Gender              string  `json:"gender"`
+	Birthdate           string  `json:"birthdate"`
+	Zoneinfo            string  `json:"zoneinfo"`
+	Locale              string  `json:"locale"`
+	Email               string  `json:"email"`
+	EmailVerified       bool    `json:"email_verified"`
+	PhoneNumber         string  `json:"phone_number"`
+	PhoneNumberVerified bool    `json:"phone_number_verified"`
+	Address             address `json:"address"`
+}
+
+type userMetadata struct {
+	Profile    userProfile       `json:"profile"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type userLock struct {
+	Enabled          bool `json:"enabled"`
+	MaxLoginFailure  uint `json:"max_login_failure"`
+	LockDuration     uint `json:"lock_duration"`
+	FailureResetTime uint `json:"failure_reset_time"`
+}
+
+type lockState struct {
+	Locked            bool        `json:"locked"`
+	VerifyFailedTimes []time.Time `json:"verify_failed_times"`
+}
+
+type otpInfo struct {
+	ID         string `json:"id"`
+	PrivateKey string `json:"private_key"`
+	Enabled    bool   `json:"enabled"`
+}
+
+func toJSON(v interface{}) string {
+	// all values are the structs defined in this package, so marshal never fails
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func fromJSON(data string, v interface{}) error {
+	return json.Unmarshal([]byte(data), v)
+}
+
+func toTokenConfig(c *model.TokenConfig) *tokenConfig {
+	res := &tokenConfig{
+		AccessTokenLifeSpan:  c.AccessTokenLifeSpan,
+		RefreshTokenLifeSpan: c.RefreshTokenLifeSpan,
+		RefreshTokenRotation: c.RefreshTokenRotation,
+		SigningAlgorithm:     c.SigningAlgorithm,
+		SignKeys:             []signKey{},
+		KeyRotationInterval:  c.KeyRotationInterval,
+	}
+	for _, k := range c.SignKeys {
+		res.SignKeys = append(res.SignKeys, signKey{
+			KeyID:            k.KeyID,
+			State:            string(k.State),
+			SigningAlgorithm: k.SigningAlgorithm,
+			PublicKey:        k.PublicKey,
+			PrivateKey:       k.PrivateKey,
+			CreatedAt:        k.CreatedAt,
+			ActivatedAt:      k.ActivatedAt,
+			ExpiresAt:        k.ExpiresAt,
+		})
+	}
+	return res
+}
+
+func fromTokenConfig(c *tokenConfig) *model.TokenConfig {
+	res := &model.TokenConfig{
+		AccessTokenLifeSpan:  c.AccessTokenLifeSpan,
+		RefreshTokenLifeSpan: c.RefreshTokenLifeSpan,
+		RefreshTokenRotation: c.RefreshTokenRotation,
+		SigningAlgorithm:     c.SigningAlgorithm,
+		SignKeys:             []*model.SignKey{},
+		KeyRotationInterval:  c.KeyRotationInterval,
+	}
+	for _, k := range c.SignKeys {
+		res.SignKeys = append(res.SignKeys, &model.SignKey{
+			KeyID:            k.KeyID,
+			State:            model.SignKeyState(k.State),
+			SigningAlgorithm: k.SigningAlgorithm,
+			PublicKey:        k.PublicKey,
+			PrivateKey:       k.PrivateKey,
+			CreatedAt:        k.CreatedAt,
+			ActivatedAt:      k.ActivatedAt,
+			ExpiresAt:        k.ExpiresAt,
+		})
+	}
+	return res
+}
+
+func toUserProfile(p *model.UserProfile) userProfile {
+	return userProfile{
+		FullName:            p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             address(p.Address),
+	}
+}
+
+func fromUserProfile(p *userProfile) model.UserProfile {
+	return model.UserProfile{
+		FullName:            p.FullName,
+		GivenName:           p.GivenName,
+		FamilyName:          p.FamilyName,
+		MiddleName:          p.MiddleName,
+		Nickname:            p.Nickname,
+		ProfileURL:          p.ProfileURL,
+		Picture:             p.Picture,
+		Website:             p.Website,
+		Gender:              p.Gender,
+		Birthdate:           p.Birthdate,
+		Zoneinfo:            p.Zoneinfo,
+		Locale:              p.Locale,
+		Email:               p.Email,
+		EmailVerified:       p.EmailVerified,
+		PhoneNumber:         p.PhoneNumber,
+		PhoneNumberVerified: p.PhoneNumberVerified,
+		Address:             model.Address(p.Address),
+	}
+}
@@ -0,0 +1,27 @@
+package sql
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// PingHandler implement db.PingHandler
+type PingHandler struct {
+	client *Client
+}
+
+// NewPingHandler ...
+func NewPingHandler(client *Client) *PingHandler {
+	res := &PingHandler{
+		client: client,
+	}
+
+	return res
+}
+
+// Ping ...
+func (p *PingHandler) Ping() *errors.Error {
+	if err := p.client.db.Ping(); err != nil {
+		return errors.New("DB failed", "DB Ping failed: %v", err)
+	}
+	return nil
+}
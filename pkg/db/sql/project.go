@@ -0,0 +1,224 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// ProjectInfoHandler implement db.ProjectInfoHandler
+type ProjectInfoHandler struct {
+	client *Client
+}
+
+// NewProjectHandler ...
+func NewProjectHandler(client *Client) *ProjectInfoHandler {
+	return &ProjectInfoHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *ProjectInfoHandler) Add(ent *model.ProjectInfo) *errors.Error {
+	q := `INSERT INTO projects (name, created_at, permit_delete, token_config, password_policy, allow_grant_types, user_lock)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if err := h.client.exec(q, h.values(ent)...); err != nil {
+		return errors.New("DB failed", "Failed to insert project to database: %v", err)
+	}
+	return h.setMetadata(ent)
+}
+
+// Delete ...
+func (h *ProjectInfoHandler) Delete(name string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM projects WHERE name = ?`, name); err != nil {
+		return errors.New("DB failed", "Failed to delete project from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM project_metadata WHERE project_name = ?`, name); err != nil {
+		return errors.New("DB failed", "Failed to delete project metadata from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *ProjectInfoHandler) GetList(filter *model.ProjectFilter) ([]*model.ProjectInfo, *errors.Error) {
+	q := `SELECT p.name, p.created_at, p.permit_delete, p.token_config, p.password_policy, p.allow_grant_types, p.user_lock, m.metadata FROM projects p
+		LEFT JOIN project_metadata m ON m.project_name = p.name`
+	var w string
+	var args []interface{}
+	if filter != nil {
+		w, args = where("p.name", filter.Name)
+	}
+
+	res := []*model.ProjectInfo{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt time.Time
+		var tc, pp, grantTypes, ul string
+		var metadata sql.NullString
+		info := &model.ProjectInfo{}
+		if err := rows.Scan(&info.Name, &createdAt, &info.PermitDelete, &tc, &pp, &grantTypes, &ul, &metadata); err != nil {
+			return err
+		}
+		info.CreatedAt = createdAt
+
+		var cfg tokenConfig
+		if err := fromJSON(tc, &cfg); err != nil {
+			return err
+		}
+		info.TokenConfig = fromTokenConfig(&cfg)
+
+		var policy passwordPolicy
+		if err := fromJSON(pp, &policy); err != nil {
+			return err
+		}
+		info.PasswordPolicy = model.PasswordPolicy{
+			MinimumLength:       policy.MinimumLength,
+			NotUserName:         policy.NotUserName,
+			BlackList:           policy.BlackList,
+			UseCharacter:        model.CharacterType(policy.UseCharacter),
+			UseDigit:            policy.UseDigit,
+			UseSpecialCharacter: policy.UseSpecialCharacter,
+		}
+		info.PasswordHashConfig = model.PasswordHashConfig{
+			Algorithm:         model.PasswordHashAlgorithm(policy.HashConfig.Algorithm),
+			Argon2Iterations:  policy.HashConfig.Argon2Iterations,
+			Argon2Memory:      policy.HashConfig.Argon2Memory,
+			Argon2Parallelism: policy.HashConfig.Argon2Parallelism,
+			BcryptCost:        policy.HashConfig.BcryptCost,
+		}
+
+		var types []string
+		if err := fromJSON(grantTypes, &types); err != nil {
+			return err
+		}
+		for _, t := range types {
+			info.AllowGrantTypes = append(info.AllowGrantTypes, model.GrantType(t))
+		}
+
+		var lock userLock
+		if err := fromJSON(ul, &lock); err != nil {
+			return err
+		}
+		info.UserLock = model.UserLock{
+			Enabled:          lock.Enabled,
+			MaxLoginFailure:  lock.MaxLoginFailure,
+			LockDuration:     lock.LockDuration,
+			FailureResetTime: lock.FailureResetTime,
+		}
+
+		// the project created before adding metadata table does not have the row
+		if metadata.Valid {
+			var m projectMetadata
+			if err := fromJSON(metadata.String, &m); err != nil {
+				return err
+			}
+			for _, t := range m.TrustedIssuers {
+				issuer := model.TrustedIssuer{
+					Issuer:   t.Issuer,
+					JWKS:     t.JWKS,
+					Audience: t.Audience,
+				}
+				for _, sm := range t.SubjectMappings {
+					issuer.SubjectMappings = append(issuer.SubjectMappings, model.SubjectMapping{
+						Subject:  sm.Subject,
+						UserName: sm.UserName,
+						ClientID: sm.ClientID,
+					})
+				}
+				info.TrustedIssuers = append(info.TrustedIssuers, issuer)
+			}
+		}
+
+		res = append(res, info)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get project list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Update ...
+func (h *ProjectInfoHandler) Update(ent *model.ProjectInfo) *errors.Error {
+	q := `UPDATE projects SET created_at = ?, permit_delete = ?, token_config = ?, password_policy = ?, allow_grant_types = ?, user_lock = ?
+		WHERE name = ?`
+	values := h.values(ent)
+	args := append(values[1:], values[0])
+	if err := h.client.exec(q, args...); err != nil {
+		return errors.New("DB failed", "Failed to update project in database: %v", err)
+	}
+	return h.setMetadata(ent)
+}
+
+// setMetadata replaces the metadata of the project
+func (h *ProjectInfoHandler) setMetadata(ent *model.ProjectInfo) *errors.Error {
+	if err := h.client.exec(`DELETE FROM project_metadata WHERE project_name = ?`, ent.Name); err != nil {
+		return errors.New("DB failed", "Failed to delete project metadata from database: %v", err)
+	}
+
+	m := projectMetadata{
+		TrustedIssuers: []trustedIssuer{},
+	}
+	for _, t := range ent.TrustedIssuers {
+		issuer := trustedIssuer{
+			Issuer:          t.Issuer,
+			JWKS:            t.JWKS,
+			Audience:        t.Audience,
+			SubjectMappings: []subjectMapping{},
+		}
+		for _, sm := range t.SubjectMappings {
+			issuer.SubjectMappings = append(issuer.SubjectMappings, subjectMapping{
+				Subject:  sm.Subject,
+				UserName: sm.UserName,
+				ClientID: sm.ClientID,
+			})
+		}
+		m.TrustedIssuers = append(m.TrustedIssuers, issuer)
+	}
+	q := `INSERT INTO project_metadata (project_name, metadata) VALUES (?, ?)`
+	if err := h.client.exec(q, ent.Name, toJSON(&m)); err != nil {
+		return errors.New("DB failed", "Failed to insert project metadata to database: %v", err)
+	}
+	return nil
+}
+
+// values returns the column values in order of name, created_at, permit_delete, token_config, password_policy, allow_grant_types, user_lock
+func (h *ProjectInfoHandler) values(ent *model.ProjectInfo) []interface{} {
+	policy := passwordPolicy{
+		MinimumLength:       ent.PasswordPolicy.MinimumLength,
+		NotUserName:         ent.PasswordPolicy.NotUserName,
+		BlackList:           ent.PasswordPolicy.BlackList,
+		UseCharacter:        string(ent.PasswordPolicy.UseCharacter),
+		UseDigit:            ent.PasswordPolicy.UseDigit,
+		UseSpecialCharacter: ent.PasswordPolicy.UseSpecialCharacter,
+		HashConfig: passwordHashConfig{
+			Algorithm:         string(ent.PasswordHashConfig.Algorithm),
+			Argon2Iterations:  ent.PasswordHashConfig.Argon2Iterations,
+			Argon2Memory:      ent.PasswordHashConfig.Argon2Memory,
+			Argon2Parallelism: ent.PasswordHashConfig.Argon2Parallelism,
+			BcryptCost:        ent.PasswordHashConfig.BcryptCost,
+		},
+	}
+	lock := userLock{
+		Enabled:          ent.UserLock.Enabled,
+		MaxLoginFailure:  ent.UserLock.MaxLoginFailure,
+		LockDuration:     ent.UserLock.LockDuration,
+		FailureResetTime: ent.UserLock.FailureResetTime,
+	}
+	types := []string{}
+	for _, t := range ent.AllowGrantTypes {
+		types = append(types, string(t))
+	}
+
+	return []interface{}{
+		ent.Name,
+		toDBTime(ent.CreatedAt),
+		ent.PermitDelete,
+		toJSON(toTokenConfig(ent.TokenConfig)),
+		toJSON(&policy),
+		toJSON(types),
+		toJSON(&lock),
+	}
+}
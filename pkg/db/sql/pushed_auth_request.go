@@ -0,0 +1,92 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// PushedAuthRequestHandler implement db.PushedAuthRequestHandler
+type PushedAuthRequestHandler struct {
+	client *Client
+}
+
+// NewPushedAuthRequestHandler ...
+func NewPushedAuthRequestHandler(client *Client) *PushedAuthRequestHandler {
+	return &PushedAuthRequestHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *PushedAuthRequestHandler) Add(projectName string, ent *model.PushedAuthRequest) *errors.Error {
+	q := `INSERT INTO pushed_auth_requests (project_name, request_uri, client_id, parameters, expires_in, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	expiresAt := ent.CreatedAt.Add(time.Second * time.Duration(ent.ExpiresIn))
+	err := h.client.exec(q, projectName, ent.RequestURI, ent.ClientID, toJSON(ent.Parameters), ent.ExpiresIn, expiresAt.Unix(),
+		toDBTime(ent.CreatedAt))
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert pushed authorization request to database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *PushedAuthRequestHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM pushed_auth_requests WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete pushed authorization request from database: %v", err)
+	}
+	return nil
+}
+
+// Cleanup ...
+func (h *PushedAuthRequestHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM pushed_auth_requests WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired pushed authorization request from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *PushedAuthRequestHandler) GetList(projectName string, filter *model.PushedAuthRequestFilter) ([]*model.PushedAuthRequest, *errors.Error) {
+	q := `SELECT request_uri, client_id, parameters, expires_in, created_at FROM pushed_auth_requests`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "request_uri", filter.RequestURI)
+	}
+	w, args := where(conds...)
+
+	res := []*model.PushedAuthRequest{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var params string
+		var createdAt time.Time
+		r := &model.PushedAuthRequest{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&r.RequestURI, &r.ClientID, &params, &r.ExpiresIn, &createdAt); err != nil {
+			return err
+		}
+		r.CreatedAt = createdAt
+		if err := fromJSON(params, &r.Parameters); err != nil {
+			return err
+		}
+
+		res = append(res, r)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get pushed authorization request list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Delete ...
+func (h *PushedAuthRequestHandler) Delete(projectName string, requestURI string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM pushed_auth_requests WHERE project_name = ? AND request_uri = ?`, projectName, requestURI); err != nil {
+		return errors.New("DB failed", "Failed to delete pushed authorization request from database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// RevokedTokenHandler implement db.RevokedTokenHandler
+type RevokedTokenHandler struct {
+	client *Client
+}
+
+// NewRevokedTokenHandler ...
+func NewRevokedTokenHandler(client *Client) *RevokedTokenHandler {
+	return &RevokedTokenHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *RevokedTokenHandler) Add(projectName string, ent *model.RevokedToken) *errors.Error {
+	q := `INSERT INTO revoked_tokens (project_name, token_id, expires_at) VALUES (?, ?, ?)`
+	if err := h.client.exec(q, projectName, ent.TokenID, ent.ExpiresAt.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to insert revoked token to database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *RevokedTokenHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM revoked_tokens WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete revoked token from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *RevokedTokenHandler) GetList(projectName string, filter *model.RevokedTokenFilter) ([]*model.RevokedToken, *errors.Error) {
+	q := `SELECT token_id, expires_at FROM revoked_tokens`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "token_id", filter.TokenID)
+	}
+	w, args := where(conds...)
+
+	res := []*model.RevokedToken{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var expiresAt int64
+		t := &model.RevokedToken{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&t.TokenID, &expiresAt); err != nil {
+			return err
+		}
+		t.ExpiresAt = time.Unix(expiresAt, 0)
+
+		res = append(res, t)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get revoked token list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *RevokedTokenHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM revoked_tokens WHERE expires_at < ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired revoked token from database: %v", err)
+	}
+	return nil
+}
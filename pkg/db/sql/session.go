@@ -0,0 +1,94 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// SessionHandler implement db.SessionHandler
+type SessionHandler struct {
+	client *Client
+}
+
+// NewSessionHandler ...
+func NewSessionHandler(client *Client) *SessionHandler {
+	return &SessionHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *SessionHandler) Add(projectName string, ent *model.Session) *errors.Error {
+	q := `INSERT INTO sessions (project_name, session_id, user_id, created_at, expires_in, expires_at, from_ip, last_auth_time, family_id, client_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	expiresAt := ent.CreatedAt.Add(time.Second * time.Duration(ent.ExpiresIn))
+	err := h.client.exec(q, projectName, ent.SessionID, ent.UserID, toDBTime(ent.CreatedAt), ent.ExpiresIn,
+		expiresAt.Unix(), ent.FromIP, toDBTime(ent.LastAuthTime), ent.FamilyID, ent.ClientID)
+	if err != nil {
+		return errors.New("DB failed", "Failed to insert session to database: %v", err)
+	}
+	return nil
+}
+
+// Delete ...
+func (h *SessionHandler) Delete(projectName string, filter *model.SessionFilter) *errors.Error {
+	if filter == nil {
+		return nil
+	}
+
+	w, args := where("project_name", projectName, "session_id", filter.SessionID, "user_id", filter.UserID, "family_id", filter.FamilyID)
+	if err := h.client.exec(`DELETE FROM sessions`+w, args...); err != nil {
+		return errors.New("DB failed", "Failed to delete session from database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *SessionHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM sessions WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete session from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *SessionHandler) GetList(projectName string, filter *model.SessionFilter) ([]*model.Session, *errors.Error) {
+	q := `SELECT session_id, user_id, created_at, expires_in, from_ip, last_auth_time, family_id, client_id FROM sessions`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "session_id", filter.SessionID, "user_id", filter.UserID, "family_id", filter.FamilyID)
+	}
+	w, args := where(conds...)
+
+	res := []*model.Session{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt, lastAuthTime time.Time
+		s := &model.Session{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&s.SessionID, &s.UserID, &createdAt, &s.ExpiresIn, &s.FromIP, &lastAuthTime, &s.FamilyID, &s.ClientID); err != nil {
+			return err
+		}
+		s.CreatedAt = createdAt
+		s.LastAuthTime = lastAuthTime
+
+		res = append(res, s)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get session list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *SessionHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM sessions WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired session from database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package sql
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	// register database drivers
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+const (
+	// TypePostgres is a database type of PostgreSQL
+	TypePostgres = "postgres"
+	// TypeSQLite is a database type of SQLite
+	TypeSQLite = "sqlite"
+)
+
+// execer is a common interface of sql.DB and sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Client is a connection to SQL database which is shared by all handlers
+type Client struct {
+	db     *sql.DB
+	dbType string
+
+	// conn executes the statements, it is db or the transaction bound by WithTx
+	conn execer
+}
+
+// NewClient opens the database and applies the schema migrations
+func NewClient(dbType string, connStr string) (*Client, *errors.Error) {
+	var driver string
+	switch dbType {
+	case TypePostgres:
+		driver = "postgres"
+	case TypeSQLite:
+		driver = "sqlite3"
+	default:
+		return nil, errors.New("DB failed", "SQL database type %s is not supported", dbType)
+	}
+
+	db, err := sql.Open(driver, connStr)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to open %s: %v", dbType, err)
+	}
+	if dbType == TypeSQLite {
+		// SQLite allows only one writer, and in-memory database is per connection
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.New("DB failed", "Failed to ping to %s: %v", dbType, err)
+	}
+
+	res := &Client{
+		db:     db,
+		dbType: dbType,
+		conn:   db,
+	}
+
+	// the table of schema version is required before migration
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		db.Close()
+		return nil, errors.New("DB failed", "Failed to create migration table: %v", err)
+	}
+	logger.Debug("Successfully connect to %s database", dbType)
+
+	return res, nil
+}
+
+// Close ...
+func (c *Client) Close() {
+	c.db.Close()
+}
+
+// WithTx returns the client which executes all statements in the transaction tx
+// tx is a handle passed by TransactionManager.Transaction
+func (c *Client) WithTx(tx model.Tx) *Client {
+	return &Client{
+		db:     c.db,
+		dbType: c.dbType,
+		conn:   tx.(*sql.Tx),
+	}
+}
+
+func (c *Client) exec(query string, args ...interface{}) error {
+	_, err := c.conn.Exec(c.rebind(query), args...)
+	return err
+}
+
+// query calls scan for each row of the result
+func (c *Client) query(scan func(rows *sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := c.conn.Query(c.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// rebind converts the placeholder "?" to the format of the database
+func (c *Client) rebind(query string) string {
+	if c.dbType != TypePostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// where builds a where clause from the pairs of column and value
+// the column whose value is empty string is ignored
+func where(conds ...interface{}) (string, []interface{}) {
+	clauses := []string{}
+	args := []interface{}{}
+	for i := 0; i+1 < len(conds); i += 2 {
+		if v, ok := conds[i+1].(string); ok && v == "" {
+			continue
+		}
+		clauses = append(clauses, conds[i].(string)+" = ?")
+		args = append(args, conds[i+1])
+	}
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// toDBTime normalizes the time to store the same value in all databases
+func toDBTime(t time.Time) time.Time {
+	return t.UTC()
+}
@@ -0,0 +1,421 @@
+package sql
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+func newTestClient(t *testing.T) *Client {
+	cli, err := NewClient(TypeSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(cli.Close)
+
+	h := NewMigrationHandler(cli)
+	for _, step := range h.Steps() {
+		if err := step.Up(); err != nil {
+			t.Fatalf("Failed to apply migration %d: %v", step.Version, err)
+		}
+	}
+	return cli
+}
+
+func TestMigrationHandler(t *testing.T) {
+	cli := newTestClient(t)
+	h := NewMigrationHandler(cli)
+
+	v, err := h.GetVersion()
+	if err != nil || v != 0 {
+		t.Errorf("Initial version should be 0, but got %d, %v", v, err)
+	}
+
+	// the session and its client are kept by reverting and applying the step which moves the client
+	sh := NewSessionHandler(cli)
+	sh.Add("test-project", &model.Session{SessionID: "session", UserID: "user", CreatedAt: time.Now(), ExpiresIn: 60, ClientID: "client"})
+	steps := h.Steps()
+	for i := len(steps) - 1; i >= 0 && steps[i].Version >= 8; i-- {
+		if err := steps[i].Down(); err != nil {
+			t.Fatalf("Failed to revert migration %d: %v", steps[i].Version, err)
+		}
+	}
+	for _, step := range steps {
+		if step.Version < 8 {
+			continue
+		}
+		if err := step.Up(); err != nil {
+			t.Fatalf("Failed to apply migration %d again: %v", step.Version, err)
+		}
+	}
+	if res, err := sh.GetList("test-project", nil); err != nil || len(res) != 1 || res[0].ClientID != "client" {
+		t.Errorf("Session should be kept with client, but got %v, %v", res, err)
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if err := h.SetVersion(latest); err != nil {
+		t.Fatalf("Failed to set version: %v", err)
+	}
+	if v, _ := h.GetVersion(); v != latest {
+		t.Errorf("Schema version should be %d, but got %d", latest, v)
+	}
+
+	// Revert all steps
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := steps[i].Down(); err != nil {
+			t.Errorf("Failed to revert migration %d: %v", steps[i].Version, err)
+		}
+	}
+	if err := h.SetVersion(0); err != nil {
+		t.Fatalf("Failed to reset version: %v", err)
+	}
+	if v, _ := h.GetVersion(); v != 0 {
+		t.Errorf("Schema version should be 0, but got %d", v)
+	}
+	if _, err := NewProjectHandler(cli).GetList(nil); err == nil {
+		t.Errorf("Project table should be dropped")
+	}
+}
+
+func TestProjectHandler(t *testing.T) {
+	h := NewProjectHandler(newTestClient(t))
+
+	prj := &model.ProjectInfo{
+		Name:      "test-project",
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  10,
+			RefreshTokenLifeSpan: 20,
+			SigningAlgorithm:     "RS256",
+			SignKeys: []*model.SignKey{
+				{KeyID: "kid", State: model.SignKeyStateActive, SigningAlgorithm: "RS256", PrivateKey: []byte("secret")},
+			},
+		},
+		AllowGrantTypes: []model.GrantType{model.GrantTypeAuthorizationCode},
+		PasswordPolicy: model.PasswordPolicy{
+			BlackList: []string{"password"},
+		},
+		PasswordHashConfig: model.PasswordHashConfig{
+			Algorithm:  model.PasswordHashBcrypt,
+			BcryptCost: 12,
+		},
+		TrustedIssuers: []model.TrustedIssuer{
+			{
+				Issuer: "https://issuer.example.com",
+				JWKS:   `{"keys":[]}`,
+				SubjectMappings: []model.SubjectMapping{
+					{Subject: "system:*", ClientID: "workload"},
+				},
+			},
+		},
+	}
+	if err := h.Add(prj); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+
+	prj.TokenConfig.AccessTokenLifeSpan = 30
+	if err := h.Update(prj); err != nil {
+		t.Fatalf("Failed to update project: %v", err)
+	}
+
+	res, err := h.GetList(&model.ProjectFilter{Name: prj.Name})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Failed to get project: %v, %v", res, err)
+	}
+	if res[0].TokenConfig.AccessTokenLifeSpan != 30 {
+		t.Errorf("Access token life span should be updated, but got %d", res[0].TokenConfig.AccessTokenLifeSpan)
+	}
+	if k := res[0].TokenConfig.ActiveSignKey(); k == nil || string(k.PrivateKey) != "secret" {
+		t.Errorf("Sign key is not restored: %v", k)
+	}
+	if len(res[0].AllowGrantTypes) != 1 || res[0].PasswordPolicy.BlackList[0] != "password" {
+		t.Errorf("Project settings are not restored: %v", res[0])
+	}
+	if res[0].PasswordHashConfig != prj.PasswordHashConfig {
+		t.Errorf("Password hash config is not restored: %v", res[0].PasswordHashConfig)
+	}
+	if !reflect.DeepEqual(res[0].TrustedIssuers, prj.TrustedIssuers) {
+		t.Errorf("Trusted issuers are not restored: %v", res[0].TrustedIssuers)
+	}
+}
+
+func TestClientHandler(t *testing.T) {
+	cli := newTestClient(t)
+	h := NewClientHandler(cli)
+
+	const project = "test-project"
+	ent := &model.ClientInfo{
+		ID:                  "client1",
+		ProjectName:         project,
+		Secret:              "secret",
+		AccessType:          "confidential",
+		CreatedAt:           time.Now(),
+		AllowedCallbackURLs: []string{"http://localhost/callback"},
+	}
+	if err := h.Add(project, ent); err != nil {
+		t.Fatalf("Failed to add client: %v", err)
+	}
+
+	// client created before adding the metadata table does not have the metadata row
+	if err := cli.exec(`DELETE FROM client_metadata`); err != nil {
+		t.Fatalf("Failed to delete metadata: %v", err)
+	}
+	res, err := h.GetList(project, &model.ClientFilter{ID: ent.ID})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Failed to get client without metadata: %v, %v", res, err)
+	}
+
+	ent.JWKSURI = "https://client.example.com/jwks"
+	ent.RequestURIs = []string{"https://client.example.com/request.jwt"}
+	ent.RequestObjectSigningAlg = "RS256"
+	if err := h.Update(project, ent); err != nil {
+		t.Fatalf("Failed to update client: %v", err)
+	}
+	res, err = h.GetList(project, nil)
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Failed to get client: %v, %v", res, err)
+	}
+	if res[0].JWKSURI != ent.JWKSURI || len(res[0].RequestURIs) != 1 || res[0].RequestObjectSigningAlg != "RS256" {
+		t.Errorf("Client metadata is not restored: %v", res[0])
+	}
+
+	if err := h.Delete(project, ent.ID); err != nil {
+		t.Fatalf("Failed to delete client: %v", err)
+	}
+	if err := h.Add(project, &model.ClientInfo{ID: ent.ID, ProjectName: project, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to add client again: %v", err)
+	}
+	if res, _ := h.GetList(project, nil); len(res) != 1 || res[0].JWKSURI != "" {
+		t.Errorf("Metadata of deleted client should be removed: %v", res)
+	}
+}
+
+func TestUserHandler(t *testing.T) {
+	h := NewUserHandler(newTestClient(t))
+	prjName := "test-project"
+
+	user := &model.UserInfo{
+		ID:          "user1",
+		ProjectName: prjName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+		SystemRoles: []string{"read-project"},
+		CustomRoles: []string{"role1"},
+		Profile: model.UserProfile{
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Address:       model.Address{Country: "Japan"},
+		},
+		Attributes: map[string]string{"department": "dev"},
+	}
+	if err := h.Add(prjName, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	if err := h.AddRole(prjName, user.ID, model.RoleCustom, "role1"); !errors.Contains(err, model.ErrRoleAlreadyAppended) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrRoleAlreadyAppended, err)
+	}
+	if err := h.AddRole(prjName, user.ID, model.RoleCustom, "role2"); err != nil {
+		t.Errorf("Failed to add custom role: %v", err)
+	}
+	if err := h.DeleteRole(prjName, user.ID, "read-project"); err != nil {
+		t.Errorf("Failed to delete system role: %v", err)
+	}
+	if err := h.DeleteAllCustomRole(prjName, "role1"); err != nil {
+		t.Errorf("Failed to delete custom role from all users: %v", err)
+	}
+	if err := h.DeleteRole(prjName, user.ID, "role1"); !errors.Contains(err, model.ErrNoSuchRoleInUser) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrNoSuchRoleInUser, err)
+	}
+
+	res, err := h.GetList(prjName, &model.UserFilter{Name: "user"})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Failed to get user: %v, %v", res, err)
+	}
+	if len(res[0].SystemRoles) != 0 || len(res[0].CustomRoles) != 1 || res[0].CustomRoles[0] != "role2" {
+		t.Errorf("Unexpected roles: system %v, custom %v", res[0].SystemRoles, res[0].CustomRoles)
+	}
+	if res[0].Profile.Email != "user@example.com" || !res[0].Profile.EmailVerified || res[0].Profile.Address.Country != "Japan" {
+		t.Errorf("Unexpected profile: %v", res[0].Profile)
+	}
+	if res[0].Attributes["department"] != "dev" {
+		t.Errorf("Unexpected attributes: %v", res[0].Attributes)
+	}
+
+	if err := h.AddRole(prjName, "unknown", model.RoleSystem, "read-project"); !errors.Contains(err, model.ErrNoSuchUser) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrNoSuchUser, err)
+	}
+}
+
+func TestSessionCleanup(t *testing.T) {
+	h := NewSessionHandler(newTestClient(t))
+	prjName := "test-project"
+	now := time.Now()
+
+	h.Add(prjName, &model.Session{SessionID: "expired", UserID: "user", CreatedAt: now.Add(-time.Hour), ExpiresIn: 60, ClientID: "client"})
+	h.Add(prjName, &model.Session{SessionID: "valid", UserID: "user", CreatedAt: now, ExpiresIn: 60, FamilyID: "family", ClientID: "client"})
+
+	if err := h.Cleanup(now); err != nil {
+		t.Fatalf("Failed to cleanup sessions: %v", err)
+	}
+	res, _ := h.GetList(prjName, nil)
+	if len(res) != 1 || res[0].SessionID != "valid" {
+		t.Errorf("Only valid session should remain, but got %v", res)
+	}
+	if len(res) == 1 && res[0].ClientID != "client" {
+		t.Errorf("Client of session should be client, but got %s", res[0].ClientID)
+	}
+
+	// the client of the deleted session must not be attached to the new session with the same id
+	h.Add(prjName, &model.Session{SessionID: "expired", UserID: "user", CreatedAt: now, ExpiresIn: 60})
+	res, _ = h.GetList(prjName, &model.SessionFilter{SessionID: "expired"})
+	if len(res) != 1 || res[0].ClientID != "" {
+		t.Errorf("Client of expired session should be deleted, but got %v", res)
+	}
+	h.Delete(prjName, &model.SessionFilter{SessionID: "expired"})
+
+	h.Delete(prjName, &model.SessionFilter{FamilyID: "family"})
+	res, _ = h.GetList(prjName, nil)
+	if len(res) != 0 {
+		t.Errorf("Session in family should be deleted, but got %v", res)
+	}
+}
+
+func TestLoginSessionHandler(t *testing.T) {
+	h := NewLoginSessionHandler(newTestClient(t))
+	prjName := "test-project"
+
+	s := &model.LoginSession{
+		SessionID:    "session",
+		Code:         "code",
+		ExpiresDate:  time.Now().Add(time.Minute),
+		ResponseType: []string{"code"},
+	}
+	if err := h.Add(prjName, s); err != nil {
+		t.Fatalf("Failed to add login session: %v", err)
+	}
+
+	res, err := h.GetByCode(prjName, "code")
+	if err != nil || res.SessionID != "session" || res.ResponseType[0] != "code" {
+		t.Errorf("Failed to get login session by code: %v, %v", res, err)
+	}
+
+	if _, err := h.Get(prjName, "unknown"); !errors.Contains(err, model.ErrNoSuchLoginSession) {
+		t.Errorf("Expect error is %v, but got %v", model.ErrNoSuchLoginSession, err)
+	}
+}
+
+func TestPushedAuthRequestHandler(t *testing.T) {
+	h := NewPushedAuthRequestHandler(newTestClient(t))
+	prjName := "test-project"
+
+	now := time.Now()
+	for i, expiresIn := range []int64{60, 1} {
+		r := &model.PushedAuthRequest{
+			RequestURI:  fmt.Sprintf("urn:ietf:params:oauth:request_uri:%d", i),
+			ProjectName: prjName,
+			ClientID:    "client",
+			Parameters:  map[string][]string{"scope": {"openid"}},
+			ExpiresIn:   expiresIn,
+			CreatedAt:   now,
+		}
+		if err := h.Add(prjName, r); err != nil {
+			t.Fatalf("Failed to add pushed authorization request: %v", err)
+		}
+	}
+
+	res, err := h.GetList(prjName, &model.PushedAuthRequestFilter{RequestURI: "urn:ietf:params:oauth:request_uri:0"})
+	if err != nil || len(res) != 1 || res[0].Parameters["scope"][0] != "openid" {
+		t.Errorf("Failed to get pushed authorization request: %v, %v", res, err)
+	}
+
+	h.Cleanup(now.Add(10 * time.Second))
+	res, _ = h.GetList(prjName, nil)
+	if len(res) != 1 || res[0].RequestURI != "urn:ietf:params:oauth:request_uri:0" {
+		t.Errorf("Only expired request should be deleted, but got %v", res)
+	}
+}
+
+func TestUsedJTIHandler(t *testing.T) {
+	h := NewUsedJTIHandler(newTestClient(t))
+	prjName := "test-project"
+
+	now := time.Now()
+	for i, issuer := range []string{"client1", "client2"} {
+		j := &model.UsedJTI{
+			JTI:         "jti",
+			Issuer:      issuer,
+			ProjectName: prjName,
+			ExpiresAt:   now.Add(time.Duration(i+1) * time.Minute),
+		}
+		if err := h.Add(prjName, j); err != nil {
+			t.Fatalf("Failed to add used jti: %v", err)
+		}
+	}
+
+	res, err := h.GetList(prjName, &model.UsedJTIFilter{JTI: "jti", Issuer: "client1"})
+	if err != nil || len(res) != 1 || res[0].Issuer != "client1" {
+		t.Errorf("Failed to get used jti: %v, %v", res, err)
+	}
+
+	h.Cleanup(now.Add(90 * time.Second))
+	res, _ = h.GetList(prjName, nil)
+	if len(res) != 1 || res[0].Issuer != "client2" {
+		t.Errorf("Only expired jti should be deleted, but got %v", res)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	cli := newTestClient(t)
+	tx := NewTransactionManager(cli)
+	h := NewCustomRoleHandler(cli)
+	prjName := "test-project"
+
+	err := tx.Transaction(func(t model.Tx) *errors.Error {
+		h := NewCustomRoleHandler(cli.WithTx(t))
+		if err := h.Add(prjName, &model.CustomRole{ID: "role1", Name: "role1"}); err != nil {
+			return err
+		}
+		return errors.New("", "abort transaction")
+	})
+	if err == nil {
+		t.Errorf("Transaction should return the error")
+	}
+	res, _ := h.GetList(prjName, nil)
+	if len(res) != 0 {
+		t.Errorf("Aborted transaction should be rolled back, but got %v", res)
+	}
+
+	err = tx.Transaction(func(t model.Tx) *errors.Error {
+		return NewCustomRoleHandler(cli.WithTx(t)).Add(prjName, &model.CustomRole{ID: "role1", Name: "role1"})
+	})
+	if err != nil {
+		t.Errorf("Failed to commit transaction: %v", err)
+	}
+	res, _ = h.GetList(prjName, nil)
+	if len(res) != 1 {
+		t.Errorf("Committed data should exist, but got %v", res)
+	}
+}
+
+func TestRevokedTokenCleanup(t *testing.T) {
+	h := NewRevokedTokenHandler(newTestClient(t))
+	prjName := "test-project"
+	now := time.Now()
+
+	h.Add(prjName, &model.RevokedToken{TokenID: "expired", ProjectName: prjName, ExpiresAt: now.Add(-time.Minute)})
+	h.Add(prjName, &model.RevokedToken{TokenID: "valid", ProjectName: prjName, ExpiresAt: now.Add(time.Minute)})
+
+	if err := h.Cleanup(now); err != nil {
+		t.Fatalf("Failed to cleanup revoked tokens: %v", err)
+	}
+	res, _ := h.GetList(prjName, nil)
+	if len(res) != 1 || res[0].TokenID != "valid" {
+		t.Errorf("Only the token which is not expired should remain, but got %v", res)
+	}
+}
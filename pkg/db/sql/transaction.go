@@ -0,0 +1,46 @@
+package sql
+
+import (
+	"sync"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+// TransactionManager ...
+type TransactionManager struct {
+	client *Client
+	mu     sync.Mutex // serializes the transactions
+}
+
+// NewTransactionManager ...
+func NewTransactionManager(client *Client) *TransactionManager {
+	return &TransactionManager{
+		client: client,
+	}
+}
+
+// Transaction runs txFunc in a transaction
+// the statements are executed in it only by the handlers created with Client.WithTx(tx)
+func (m *TransactionManager) Transaction(txFunc func(tx model.Tx) *errors.Error) *errors.Error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx, err := m.client.db.Begin()
+	if err != nil {
+		return errors.New("DB failed", "Begin transaction failed: %v", err)
+	}
+
+	if err := txFunc(tx); err != nil {
+		if e := tx.Rollback(); e != nil {
+			logger.Error("Failed to abort transaction: %v", e)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.New("DB failed", "Failed commit transaction: %v", err)
+	}
+	return nil
+}
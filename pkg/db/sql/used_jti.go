@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// UsedJTIHandler implement db.UsedJTIHandler
+type UsedJTIHandler struct {
+	client *Client
+}
+
+// NewUsedJTIHandler ...
+func NewUsedJTIHandler(client *Client) *UsedJTIHandler {
+	return &UsedJTIHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *UsedJTIHandler) Add(projectName string, ent *model.UsedJTI) *errors.Error {
+	q := `INSERT INTO used_jtis (project_name, issuer, jti, expires_at) VALUES (?, ?, ?, ?)`
+	if err := h.client.exec(q, projectName, ent.Issuer, ent.JTI, ent.ExpiresAt.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to insert used jti to database: %v", err)
+	}
+	return nil
+}
+
+// DeleteAll ...
+func (h *UsedJTIHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM used_jtis WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete used jti from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *UsedJTIHandler) GetList(projectName string, filter *model.UsedJTIFilter) ([]*model.UsedJTI, *errors.Error) {
+	q := `SELECT issuer, jti, expires_at FROM used_jtis`
+	conds := []interface{}{"project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "issuer", filter.Issuer, "jti", filter.JTI)
+	}
+	w, args := where(conds...)
+
+	res := []*model.UsedJTI{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var expiresAt int64
+		j := &model.UsedJTI{
+			ProjectName: projectName,
+		}
+		if err := rows.Scan(&j.Issuer, &j.JTI, &expiresAt); err != nil {
+			return err
+		}
+		j.ExpiresAt = time.Unix(expiresAt, 0)
+
+		res = append(res, j)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get used jti list from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Cleanup ...
+func (h *UsedJTIHandler) Cleanup(now time.Time) *errors.Error {
+	if err := h.client.exec(`DELETE FROM used_jtis WHERE expires_at < ?`, now.Unix()); err != nil {
+		return errors.New("DB failed", "Failed to delete expired used jti from database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// UserInfoHandler implement db.UserInfoHandler
+type UserInfoHandler struct {
+	client *Client
+}
+
+// NewUserHandler ...
+func NewUserHandler(client *Client) *UserInfoHandler {
+	return &UserInfoHandler{
+		client: client,
+	}
+}
+
+// Add ...
+func (h *UserInfoHandler) Add(projectName string, ent *model.UserInfo) *errors.Error {
+	q := `INSERT INTO users (project_name, id, name, created_at, password_hash, system_roles, lock_state, otp_info)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if err := h.client.exec(q, h.values(projectName, ent)...); err != nil {
+		return errors.New("DB failed", "Failed to insert user to database: %v", err)
+	}
+
+	if err := h.setCustomRoles(projectName, ent.ID, ent.CustomRoles); err != nil {
+		return errors.Append(err, "Failed to set custom roles of user")
+	}
+
+	return h.setMetadata(projectName, ent)
+}
+
+// Delete ...
+func (h *UserInfoHandler) Delete(projectName string, userID string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM users WHERE project_name = ? AND id = ?`, projectName, userID); err != nil {
+		return errors.New("DB failed", "Failed to delete user from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM user_custom_roles WHERE project_name = ? AND user_id = ?`, projectName, userID); err != nil {
+		return errors.New("DB failed", "Failed to delete custom role in user from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM user_metadata WHERE project_name = ? AND user_id = ?`, projectName, userID); err != nil {
+		return errors.New("DB failed", "Failed to delete user metadata from database: %v", err)
+	}
+	return nil
+}
+
+// GetList ...
+func (h *UserInfoHandler) GetList(projectName string, filter *model.UserFilter) ([]*model.UserInfo, *errors.Error) {
+	q := `SELECT u.id, u.name, u.created_at, u.password_hash, u.system_roles, u.lock_state, u.otp_info, m.metadata FROM users u
+		LEFT JOIN user_metadata m ON m.project_name = u.project_name AND m.user_id = u.id`
+	conds := []interface{}{"u.project_name", projectName}
+	if filter != nil {
+		conds = append(conds, "u.id", filter.ID, "u.name", filter.Name)
+	}
+	w, args := where(conds...)
+
+	res := []*model.UserInfo{}
+	err := h.client.query(func(rows *sql.Rows) error {
+		var createdAt time.Time
+		var roles, lock, otp string
+		var metadata sql.NullString
+		user := &model.UserInfo{
+			ProjectName: projectName,
+			CustomRoles: []string{},
+		}
+		if err := rows.Scan(&user.ID, &user.Name, &createdAt, &user.PasswordHash, &roles, &lock, &otp, &metadata); err != nil {
+			return err
+		}
+		user.CreatedAt = createdAt
+
+		if err := fromJSON(roles, &user.SystemRoles); err != nil {
+			return err
+		}
+		var ls lockState
+		if err := fromJSON(lock, &ls); err != nil {
+			return err
+		}
+		user.LockState = model.LockState{
+			Locked:            ls.Locked,
+			VerifyFailedTimes: ls.VerifyFailedTimes,
+		}
+		var oi otpInfo
+		if err := fromJSON(otp, &oi); err != nil {
+			return err
+		}
+		user.OTPInfo = model.OTPInfo{
+			ID:         oi.ID,
+			PrivateKey: oi.PrivateKey,
+			Enabled:    oi.Enabled,
+		}
+		// the user created before adding metadata table does not have the row
+		if metadata.Valid {
+			var m userMetadata
+			if err := fromJSON(metadata.String, &m); err != nil {
+				return err
+			}
+			user.Profile = fromUserProfile(&m.Profile)
+			user.Attributes = m.Attributes
+		}
+
+		res = append(res, user)
+		return nil
+	}, q+w, args...)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get user list from database: %v", err)
+	}
+
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	// Set custom roles
+	index := map[string]*model.UserInfo{}
+	for _, u := range res {
+		index[u.ID] = u
+	}
+	q = `SELECT user_id, role_id FROM user_custom_roles WHERE project_name = ? ORDER BY role_id`
+	err = h.client.query(func(rows *sql.Rows) error {
+		var userID, roleID string
+		if err := rows.Scan(&userID, &roleID); err != nil {
+			return err
+		}
+		if u, ok := index[userID]; ok {
+			u.CustomRoles = append(u.CustomRoles, roleID)
+		}
+		return nil
+	}, q, projectName)
+	if err != nil {
+		return nil, errors.New("DB failed", "Failed to get custom role in user from database: %v", err)
+	}
+
+	return res, nil
+}
+
+// Update ...
+func (h *UserInfoHandler) Update(projectName string, ent *model.UserInfo) *errors.Error {
+	q := `UPDATE users SET name = ?, created_at = ?, password_hash = ?, system_roles = ?, lock_state = ?, otp_info = ?
+		WHERE project_name = ? AND id = ?`
+	values := h.values(projectName, ent)
+	args := append(append([]interface{}{}, values[2:]...), values[0], values[1])
+	if err := h.client.exec(q, args...); err != nil {
+		return errors.New("DB failed", "Failed to update user in database: %v", err)
+	}
+
+	if err := h.setCustomRoles(projectName, ent.ID, ent.CustomRoles); err != nil {
+		return errors.Append(err, "Failed to set custom roles of user")
+	}
+
+	return h.setMetadata(projectName, ent)
+}
+
+// DeleteAll ...
+func (h *UserInfoHandler) DeleteAll(projectName string) *errors.Error {
+	if err := h.client.exec(`DELETE FROM users WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete user from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM user_custom_roles WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete custom role in user from database: %v", err)
+	}
+	if err := h.client.exec(`DELETE FROM user_metadata WHERE project_name = ?`, projectName); err != nil {
+		return errors.New("DB failed", "Failed to delete user metadata from database: %v", err)
+	}
+	return nil
+}
+
+// AddRole ...
+func (h *UserInfoHandler) AddRole(projectName string, userID string, roleType model.RoleType, roleID string) *errors.Error {
+	user, err := h.get(projectName, userID)
+	if err != nil {
+		return err
+	}
+
+	if roleType == model.RoleSystem {
+		for _, role := range user.SystemRoles {
+			if role == roleID {
+				return model.ErrRoleAlreadyAppended
+			}
+		}
+		user.SystemRoles = append(user.SystemRoles, roleID)
+		q := `UPDATE users SET system_roles = ? WHERE project_name = ? AND id = ?`
+		if err := h.client.exec(q, toJSON(user.SystemRoles), projectName, userID); err != nil {
+			return errors.New("DB failed", "Failed to add role to user in database: %v", err)
+		}
+	} else if roleType == model.RoleCustom {
+		for _, role := range user.CustomRoles {
+			if role == roleID {
+				return model.ErrRoleAlreadyAppended
+			}
+		}
+		q := `INSERT INTO user_custom_roles (project_name, user_id, role_id) VALUES (?, ?, ?)`
+		if err := h.client.exec(q, projectName, userID, roleID); err != nil {
+			return errors.New("DB failed", "Failed to insert role info in user to database: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteRole ...
+func (h *UserInfoHandler) DeleteRole(projectName string, userID string, roleID string) *errors.Error {
+	user, err := h.get(projectName, userID)
+	if err != nil {
+		return err
+	}
+
+	roles := []string{}
+	for _, role := range user.SystemRoles {
+		if role != roleID {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) != len(user.SystemRoles) {
+		q := `UPDATE users SET system_roles = ? WHERE project_name = ? AND id = ?`
+		if err := h.client.exec(q, toJSON(roles), projectName, userID); err != nil {
+			return errors.New("DB failed", "Failed to delete role from user in database: %v", err)
+		}
+		return nil
+	}
+
+	for _, role := range user.CustomRoles {
+		if role == roleID {
+			q := `DELETE FROM user_custom_roles WHERE project_name = ? AND user_id = ? AND role_id = ?`
+			if err := h.client.exec(q, projectName, userID, roleID); err != nil {
+				return errors.New("DB failed", "Failed to delete custom role in user from database: %v", err)
+			}
+			return nil
+		}
+	}
+
+	return model.ErrNoSuchRoleInUser
+}
+
+// DeleteAllCustomRole ...
+func (h *UserInfoHandler) DeleteAllCustomRole(projectName string, roleID string) *errors.Error {
+	q := `DELETE FROM user_custom_roles WHERE project_name = ? AND role_id = ?`
+	if err := h.client.exec(q, projectName, roleID); err != nil {
+		return errors.New("DB failed", "Failed to delete custom role in user from database: %v", err)
+	}
+	return nil
+}
+
+func (h *UserInfoHandler) get(projectName string, userID string) (*model.UserInfo, *errors.Error) {
+	users, err := h.GetList(projectName, &model.UserFilter{ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, model.ErrNoSuchUser
+	}
+	return users[0], nil
+}
+
+func (h *UserInfoHandler) setCustomRoles(projectName string, userID string, roles []string) *errors.Error {
+	q := `DELETE FROM user_custom_roles WHERE project_name = ? AND user_id = ?`
+	if err := h.client.exec(q, projectName, userID); err != nil {
+		return errors.New("DB failed", "Failed to delete previous custom role in user from database: %v", err)
+	}
+
+	q = `INSERT INTO user_custom_roles (project_name, user_id, role_id) VALUES (?, ?, ?)`
+	for _, r := range roles {
+		if err := h.client.exec(q, projectName, userID, r); err != nil {
+			return errors.New("DB failed", "Failed to insert role info in user to database: %v", err)
+		}
+	}
+	return nil
+}
+
+// setMetadata replaces the metadata of the user
+func (h *UserInfoHandler) setMetadata(projectName string, ent *model.UserInfo) *errors.Error {
+	if err := h.client.exec(`DELETE FROM user_metadata WHERE project_name = ? AND user_id = ?`, projectName, ent.ID); err != nil {
+		return errors.New("DB failed", "Failed to delete user metadata from database: %v", err)
+	}
+
+	m := userMetadata{
+		Profile:    toUserProfile(&ent.Profile),
+		Attributes: ent.Attributes,
+	}
+	q := `INSERT INTO user_metadata (project_name, user_id, metadata) VALUES (?, ?, ?)`
+	if err := h.client.exec(q, projectName, ent.ID, toJSON(&m)); err != nil {
+		return errors.New("DB failed", "Failed to insert user metadata to database: %v", err)
+	}
+	return nil
+}
+
+// values returns the column values in order of project_name, id, name, created_at, password_hash, system_roles, lock_state, otp_info
+func (h *UserInfoHandler) values(projectName string, ent *model.UserInfo) []interface{} {
+	roles := ent.SystemRoles
+	if roles == nil {
+		roles = []string{}
+	}
+	lock := lockState{
+		Locked:            ent.LockState.Locked,
+		VerifyFailedTimes: ent.LockState.VerifyFailedTimes,
+	}
+	otp := otpInfo{
+		ID:         ent.OTPInfo.ID,
+		PrivateKey: ent.OTPInfo.PrivateKey,
+		Enabled:    ent.OTPInfo.Enabled,
+	}
+
+	return []interface{}{
+		projectName,
+		ent.ID,
+		ent.Name,
+		toDBTime(ent.CreatedAt),
+		ent.PasswordHash,
+		toJSON(roles),
+		toJSON(&lock),
+		toJSON(&otp),
+	}
+}
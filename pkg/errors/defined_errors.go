@@ -147,6 +147,42 @@ var (
 		httpResponseCode: http.StatusBadRequest,
 	}
 
+	//-------------------------------------
+	// RFC 7591
+	//-------------------------------------
+
+	// ErrInvalidRedirectURI ...
+	ErrInvalidRedirectURI = &Error{
+		publicMsg:        "invalid_redirect_uri",
+		httpResponseCode: http.StatusBadRequest,
+	}
+
+	// ErrInvalidClientMetadata ...
+	ErrInvalidClientMetadata = &Error{
+		publicMsg:        "invalid_client_metadata",
+		httpResponseCode: http.StatusBadRequest,
+	}
+
+	//-------------------------------------
+	// RFC 9449
+	//-------------------------------------
+
+	// ErrInvalidDPoPProof ...
+	ErrInvalidDPoPProof = &Error{
+		publicMsg:        "invalid_dpop_proof",
+		httpResponseCode: http.StatusBadRequest,
+	}
+
+	//-------------------------------------
+	// RFC 8693
+	//-------------------------------------
+
+	// ErrInvalidTarget ...
+	ErrInvalidTarget = &Error{
+		publicMsg:        "invalid_target",
+		httpResponseCode: http.StatusBadRequest,
+	}
+
 	//-------------------------------------
 	// RFC 8628
 	//-------------------------------------
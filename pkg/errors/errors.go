@@ -54,6 +54,11 @@ func (e *Error) StatusCode() int {
 	return e.httpResponseCode
 }
 
+// Description ...
+func (e *Error) Description() string {
+	return e.description
+}
+
 // SetDescription ...
 func (e *Error) SetDescription(format string, a ...interface{}) {
 	e.description = fmt.Sprintf(format, a...)
@@ -106,7 +111,11 @@ func Contains(all, err *Error) bool {
 	}
 
 	if all.publicMsg == err.publicMsg {
-		if len(all.privateInfo) == 0 || len(err.privateInfo) == 0 {
+		if len(err.privateInfo) == 0 {
+			// the defined error such as ErrInvalidRequest is identified by the public message
+			return true
+		}
+		if len(all.privateInfo) == 0 {
 			return false
 		}
 		if all.privateInfo[0].msg != err.privateInfo[0].msg {
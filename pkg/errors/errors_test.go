@@ -21,6 +21,15 @@ func TestContains(t *testing.T) {
 		t.Errorf("Unexpect result: Err2 contains Err3")
 	}
 
+	err4 := Append(ErrInvalidRequest, "4")
+	if !Contains(err4, ErrInvalidRequest) {
+		t.Errorf("Unexpect result: Err4 does not contain ErrInvalidRequest")
+	}
+
+	if Contains(err4, ErrInvalidClient) {
+		t.Errorf("Unexpect result: Err4 contains ErrInvalidClient")
+	}
+
 	if Contains(nil, nil) {
 		t.Errorf("Unexpect result: nil contains nil")
 	}
@@ -0,0 +1,254 @@
+package apply
+
+import (
+	"testing"
+
+	clientapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/client"
+	roleapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/customrole"
+	projectapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/project"
+	userapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/user"
+)
+
+type fakeClient struct {
+	projects map[string]*projectapi.ProjectGetResponse
+	clients  map[string][]*clientapi.ClientGetResponse
+	roles    map[string][]*roleapi.CustomRoleGetResponse
+	users    map[string][]*userapi.UserGetResponse
+	called   []string
+}
+
+func (f *fakeClient) ProjectGetList() ([]*projectapi.ProjectGetResponse, error) {
+	res := []*projectapi.ProjectGetResponse{}
+	for _, p := range f.projects {
+		res = append(res, p)
+	}
+	return res, nil
+}
+func (f *fakeClient) ProjectAdd(req *projectapi.ProjectCreateRequest) (*projectapi.ProjectGetResponse, error) {
+	f.called = append(f.called, "ProjectAdd "+req.Name)
+	return nil, nil
+}
+func (f *fakeClient) ProjectUpdate(projectName string, req *projectapi.ProjectPutRequest) error {
+	f.called = append(f.called, "ProjectUpdate "+projectName)
+	return nil
+}
+func (f *fakeClient) ClientGetList(projectName string) ([]*clientapi.ClientGetResponse, error) {
+	return f.clients[projectName], nil
+}
+func (f *fakeClient) ClientAdd(projectName string, req *clientapi.ClientCreateRequest) (*clientapi.ClientGetResponse, error) {
+	f.called = append(f.called, "ClientAdd "+req.ID)
+	return nil, nil
+}
+func (f *fakeClient) ClientUpdate(projectName, clientID string, req *clientapi.ClientPutRequest) error {
+	f.called = append(f.called, "ClientUpdate "+clientID)
+	return nil
+}
+func (f *fakeClient) ClientDelete(projectName string, clientID string) error {
+	f.called = append(f.called, "ClientDelete "+clientID)
+	return nil
+}
+func (f *fakeClient) RoleGetList(projectName string, roleName string) ([]*roleapi.CustomRoleGetResponse, error) {
+	return f.roles[projectName], nil
+}
+func (f *fakeClient) RoleAdd(projectName string, req *roleapi.CustomRoleCreateRequest) (*roleapi.CustomRoleGetResponse, error) {
+	f.called = append(f.called, "RoleAdd "+req.Name)
+	f.roles[projectName] = append(f.roles[projectName], &roleapi.CustomRoleGetResponse{ID: "id-" + req.Name, Name: req.Name})
+	return nil, nil
+}
+func (f *fakeClient) RoleDelete(projectName string, roleName string) error {
+	f.called = append(f.called, "RoleDelete "+roleName)
+	return nil
+}
+func (f *fakeClient) UserGetList(projectName string, userName string) ([]*userapi.UserGetResponse, error) {
+	return f.users[projectName], nil
+}
+func (f *fakeClient) UserAdd(projectName string, req *userapi.UserCreateRequest) (*userapi.UserGetResponse, error) {
+	f.called = append(f.called, "UserAdd "+req.Name)
+	return nil, nil
+}
+func (f *fakeClient) UserUpdate(projectName string, userName string, req *userapi.UserPutRequest) error {
+	f.called = append(f.called, "UserUpdate "+userName)
+	if len(req.CustomRoles) != 1 || req.CustomRoles[0] != "id-viewer" {
+		return errInvalidRoles
+	}
+	return nil
+}
+func (f *fakeClient) UserDelete(projectName string, userName string) error {
+	f.called = append(f.called, "UserDelete "+userName)
+	return nil
+}
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+const errInvalidRoles = testError("custom roles are not converted to ID")
+
+const testManifests = `
+kind: Project
+name: dev
+spec:
+  tokenConfig:
+    accessTokenLifeSpan: 600
+---
+kind: CustomRole
+name: viewer
+project: dev
+---
+kind: Client
+name: app
+project: dev
+spec:
+  accessType: confidential
+  secret: mysecret-value
+  allowedCallbackURLs:
+  - http://localhost/callback
+---
+kind: User
+name: alice
+project: dev
+spec:
+  systemRoles:
+  - read-project
+  customRoles:
+  - viewer
+`
+
+func TestParseManifests(t *testing.T) {
+	manifests, err := ParseManifests([]byte(testManifests))
+	if err != nil {
+		t.Fatalf("Failed to parse manifests: %v", err)
+	}
+	if len(manifests) != 4 {
+		t.Fatalf("Expect 4 manifests, but got %d", len(manifests))
+	}
+	prj := manifests[0].ProjectSpec
+	if prj.TokenConfig.AccessTokenLifeSpan != 600 || prj.TokenConfig.SigningAlgorithm != "RS256" {
+		t.Errorf("Project spec is not parsed with default values: %+v", prj.TokenConfig)
+	}
+	if manifests[3].Key() != "User/dev/alice" {
+		t.Errorf("Unexpected key %s", manifests[3].Key())
+	}
+
+	invalids := []string{
+		"kind: Unknown\nname: a\n",
+		"kind: Client\nname: a\n",
+		"kind: Project\nname: a\nspec:\n  unknownField: 1\n",
+	}
+	for _, in := range invalids {
+		if _, err := ParseManifests([]byte(in)); err == nil {
+			t.Errorf("Expect error for %q, but got nil", in)
+		}
+	}
+}
+
+func TestMakePlan(t *testing.T) {
+	manifests, _ := ParseManifests([]byte(testManifests))
+	f := &fakeClient{
+		projects: map[string]*projectapi.ProjectGetResponse{
+			"dev": {
+				Name: "dev",
+				TokenConfig: projectapi.TokenConfig{
+					AccessTokenLifeSpan:  300,
+					RefreshTokenLifeSpan: 14 * 24 * 60 * 60,
+					SigningAlgorithm:     "RS256",
+				},
+				PasswordPolicy:  projectapi.PasswordPolicy{BlackList: []string{}},
+				AllowGrantTypes: []string{},
+			},
+		},
+		clients: map[string][]*clientapi.ClientGetResponse{
+			"dev": {
+				{ID: "portal", AccessType: "public"},
+				{ID: "app", AccessType: "confidential", Secret: "mysecret-value", AllowedCallbackURLs: []string{"http://localhost/callback"}},
+				{ID: "old", AccessType: "public"},
+			},
+		},
+		roles: map[string][]*roleapi.CustomRoleGetResponse{},
+		users: map[string][]*userapi.UserGetResponse{
+			"dev": {
+				{Name: "alice", SystemRoles: []string{"read-project"}},
+			},
+		},
+	}
+
+	plan, err := MakePlan(f, manifests, false, nil)
+	if err != nil {
+		t.Fatalf("Failed to make plan: %v", err)
+	}
+	// update project, create role, update user
+	if plan.Count(ActionCreate) != 1 || plan.Count(ActionUpdate) != 2 || plan.Count(ActionDelete) != 0 {
+		t.Errorf("Unexpected plan: %+v", plan.Changes)
+	}
+
+	plan, err = MakePlan(f, manifests, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to make plan with prune: %v", err)
+	}
+	// client old is pruned, but portal is not
+	if plan.Count(ActionDelete) != 1 {
+		t.Errorf("Expect 1 deletion, but got %+v", plan.Changes)
+	}
+
+	if err := plan.Apply(f); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	expect := []string{"ProjectUpdate dev", "RoleAdd viewer", "UserUpdate alice", "ClientDelete old"}
+	if len(f.called) != len(expect) {
+		t.Fatalf("Expect calls %v, but got %v", expect, f.called)
+	}
+	for i := range expect {
+		if f.called[i] != expect[i] {
+			t.Errorf("Expect calls %v, but got %v", expect, f.called)
+			break
+		}
+	}
+}
+
+func TestPruneKeepsAdmins(t *testing.T) {
+	manifests, _ := ParseManifests([]byte(testManifests))
+	f := &fakeClient{
+		projects: map[string]*projectapi.ProjectGetResponse{
+			"dev": {
+				Name: "dev",
+				TokenConfig: projectapi.TokenConfig{
+					AccessTokenLifeSpan:  300,
+					RefreshTokenLifeSpan: 14 * 24 * 60 * 60,
+					SigningAlgorithm:     "RS256",
+				},
+				PasswordPolicy:  projectapi.PasswordPolicy{BlackList: []string{}},
+				AllowGrantTypes: []string{},
+			},
+		},
+		clients: map[string][]*clientapi.ClientGetResponse{
+			"dev": {
+				{ID: "portal", AccessType: "public"},
+				{ID: "app", AccessType: "confidential", Secret: "mysecret-value", AllowedCallbackURLs: []string{"http://localhost/callback"}},
+			},
+		},
+		roles: map[string][]*roleapi.CustomRoleGetResponse{},
+		users: map[string][]*userapi.UserGetResponse{
+			"dev": {
+				{ID: "alice-id", Name: "alice", SystemRoles: []string{"read-project"}},
+				{ID: "caller-id", Name: "caller", SystemRoles: []string{}},
+				{ID: "admin-id", Name: "admin", SystemRoles: []string{"write-cluster"}},
+				{ID: "bob-id", Name: "bob", SystemRoles: []string{"read-project"}},
+			},
+		},
+	}
+
+	plan, err := MakePlan(f, manifests, true, &Caller{ProjectName: "dev", UserID: "caller-id"})
+	if err != nil {
+		t.Fatalf("Failed to make plan with prune: %v", err)
+	}
+	deleted := []string{}
+	for _, ch := range plan.Changes {
+		if ch.Action == ActionDelete {
+			deleted = append(deleted, ch.Name)
+		}
+	}
+	// the caller and the cluster admin are kept
+	if len(deleted) != 1 || deleted[0] != "bob" {
+		t.Errorf("Only bob should be pruned, but got %v", deleted)
+	}
+}
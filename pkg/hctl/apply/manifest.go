@@ -0,0 +1,277 @@
+package apply
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// KindProject ...
+	KindProject = "Project"
+	// KindClient ...
+	KindClient = "Client"
+	// KindCustomRole ...
+	KindCustomRole = "CustomRole"
+	// KindUser ...
+	KindUser = "User"
+)
+
+// TokenConfig ...
+type TokenConfig struct {
+	AccessTokenLifeSpan  uint   `yaml:"accessTokenLifeSpan"`
+	RefreshTokenLifeSpan uint   `yaml:"refreshTokenLifeSpan"`
+	RefreshTokenRotation bool   `yaml:"refreshTokenRotation"`
+	SigningAlgorithm     string `yaml:"signingAlgorithm"`
+	KeyRotationInterval  uint   `yaml:"keyRotationInterval"`
+}
+
+// PasswordPolicy ...
+type PasswordPolicy struct {
+	MinimumLength       uint     `yaml:"length"`
+	NotUserName         bool     `yaml:"notUserName"`
+	BlackList           []string `yaml:"blackList"`
+	UseCharacter        string   `yaml:"useCharacter"`
+	UseDigit            bool     `yaml:"useDigit"`
+	UseSpecialCharacter bool     `yaml:"useSpecialCharacter"`
+}
+
+// UserLock ...
+type UserLock struct {
+	Enabled          bool `yaml:"enabled"`
+	MaxLoginFailure  uint `yaml:"maxLoginFailure"`
+	LockDuration     uint `yaml:"lockDuration"`
+	FailureResetTime uint `yaml:"failureResetTime"`
+}
+
+// ProjectSpec ...
+type ProjectSpec struct {
+	TokenConfig     TokenConfig    `yaml:"tokenConfig"`
+	PasswordPolicy  PasswordPolicy `yaml:"passwordPolicy"`
+	AllowGrantTypes []string       `yaml:"allowGrantTypes"`
+	UserLock        UserLock       `yaml:"userLock"`
+}
+
+// ClientSpec ...
+type ClientSpec struct {
+	AccessType          string   `yaml:"accessType"`
+	Secret              string   `yaml:"secret"`
+	SecretEnv           string   `yaml:"secretEnv"` // name of OS env which has the secret
+	AllowedCallbackURLs []string `yaml:"allowedCallbackURLs"`
+}
+
+// UserSpec ...
+type UserSpec struct {
+	// Password and PasswordEnv are used only when the user is created
+	Password    string   `yaml:"password"`
+	PasswordEnv string   `yaml:"passwordEnv"` // name of OS env which has the password
+	SystemRoles []string `yaml:"systemRoles"`
+	CustomRoles []string `yaml:"customRoles"` // list of custom role name
+}
+
+// manifestHeader is a common format of all kinds
+type manifestHeader struct {
+	Kind    string      `yaml:"kind"`
+	Name    string      `yaml:"name"`
+	Project string      `yaml:"project"`
+	Spec    interface{} `yaml:"spec"`
+}
+
+// Manifest is a desired state of a resource
+type Manifest struct {
+	Kind string
+	// Name is a project name, client ID, custom role name or user name
+	Name string
+	// Project is a name of project which the resource belongs to, it is not used in Project kind
+	Project string
+
+	ProjectSpec *ProjectSpec
+	ClientSpec  *ClientSpec
+	UserSpec    *UserSpec
+
+	source string
+}
+
+// Key returns an identifier of the resource
+func (m *Manifest) Key() string {
+	if m.Kind == KindProject {
+		return fmt.Sprintf("%s/%s", m.Kind, m.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", m.Kind, m.Project, m.Name)
+}
+
+// LoadManifests reads all manifests in the file, or in *.yaml and *.yml files under the directory
+func LoadManifests(path string) ([]*Manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = []string{}
+		err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := filepath.Ext(p)
+			if !fi.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	res := []*Manifest{}
+	keys := map[string]string{}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		manifests, err := ParseManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse %s: %v", f, err)
+		}
+		for _, m := range manifests {
+			m.source = f
+			if src, ok := keys[m.Key()]; ok {
+				return nil, fmt.Errorf("%s is defined in both %s and %s", m.Key(), src, f)
+			}
+			keys[m.Key()] = f
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+// ParseManifests parses YAML documents separated by "---"
+func ParseManifests(data []byte) ([]*Manifest, error) {
+	res := []*Manifest{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if doc == nil {
+			// empty document
+			continue
+		}
+
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parseManifest(b)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	var header manifestHeader
+	if err := yaml.UnmarshalStrict(data, &header); err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		Kind:    header.Kind,
+		Name:    header.Name,
+		Project: header.Project,
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("name is required in %s", m.Kind)
+	}
+
+	var err error
+	switch m.Kind {
+	case KindProject:
+		m.ProjectSpec = &ProjectSpec{}
+		err = unmarshalSpec(header.Spec, m.ProjectSpec)
+		m.ProjectSpec.setDefault()
+	case KindClient:
+		m.ClientSpec = &ClientSpec{}
+		err = unmarshalSpec(header.Spec, m.ClientSpec)
+		if m.ClientSpec.SecretEnv != "" {
+			m.ClientSpec.Secret = os.Getenv(m.ClientSpec.SecretEnv)
+		}
+		if m.ClientSpec.AccessType == "" {
+			m.ClientSpec.AccessType = "public"
+		}
+	case KindCustomRole:
+		// custom role has no spec
+	case KindUser:
+		m.UserSpec = &UserSpec{}
+		err = unmarshalSpec(header.Spec, m.UserSpec)
+		if m.UserSpec.PasswordEnv != "" {
+			m.UserSpec.Password = os.Getenv(m.UserSpec.PasswordEnv)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown kind %q in %s", m.Kind, m.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse spec of %s: %v", m.Name, err)
+	}
+
+	if m.Kind != KindProject && m.Project == "" {
+		return nil, fmt.Errorf("project is required in %s %s", m.Kind, m.Name)
+	}
+	return m, nil
+}
+
+func unmarshalSpec(spec interface{}, out interface{}) error {
+	if spec == nil {
+		return nil
+	}
+	b, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(b, out)
+}
+
+// setDefault sets the same default values as "hctl project add"
+func (s *ProjectSpec) setDefault() {
+	if s.TokenConfig.AccessTokenLifeSpan == 0 {
+		s.TokenConfig.AccessTokenLifeSpan = 5 * 60
+	}
+	if s.TokenConfig.RefreshTokenLifeSpan == 0 {
+		s.TokenConfig.RefreshTokenLifeSpan = 14 * 24 * 60 * 60
+	}
+	if s.TokenConfig.SigningAlgorithm == "" {
+		s.TokenConfig.SigningAlgorithm = "RS256"
+	}
+	if s.UserLock.Enabled {
+		if s.UserLock.MaxLoginFailure == 0 {
+			s.UserLock.MaxLoginFailure = 5
+		}
+		if s.UserLock.LockDuration == 0 {
+			s.UserLock.LockDuration = 10 * 60
+		}
+		if s.UserLock.FailureResetTime == 0 {
+			s.UserLock.FailureResetTime = 10 * 60
+		}
+	}
+	if s.AllowGrantTypes == nil {
+		s.AllowGrantTypes = []string{}
+	}
+	if s.PasswordPolicy.BlackList == nil {
+		s.PasswordPolicy.BlackList = []string{}
+	}
+}
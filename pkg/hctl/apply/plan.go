@@ -0,0 +1,580 @@
+package apply
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	clientapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/client"
+	roleapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/customrole"
+	projectapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/project"
+	userapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/user"
+	"github.com/sh-miyoshi/hekate/pkg/role"
+)
+
+// Client is a set of server APIs used to apply the manifests
+// apiclient.Handler implements it
+type Client interface {
+	ProjectGetList() ([]*projectapi.ProjectGetResponse, error)
+	ProjectAdd(req *projectapi.ProjectCreateRequest) (*projectapi.ProjectGetResponse, error)
+	ProjectUpdate(projectName string, req *projectapi.ProjectPutRequest) error
+	ClientGetList(projectName string) ([]*clientapi.ClientGetResponse, error)
+	ClientAdd(projectName string, req *clientapi.ClientCreateRequest) (*clientapi.ClientGetResponse, error)
+	ClientUpdate(projectName, clientID string, req *clientapi.ClientPutRequest) error
+	ClientDelete(projectName string, clientID string) error
+	RoleGetList(projectName string, roleName string) ([]*roleapi.CustomRoleGetResponse, error)
+	RoleAdd(projectName string, req *roleapi.CustomRoleCreateRequest) (*roleapi.CustomRoleGetResponse, error)
+	RoleDelete(projectName string, roleName string) error
+	UserGetList(projectName string, userName string) ([]*userapi.UserGetResponse, error)
+	UserAdd(projectName string, req *userapi.UserCreateRequest) (*userapi.UserGetResponse, error)
+	UserUpdate(projectName string, userName string, req *userapi.UserPutRequest) error
+	UserDelete(projectName string, userName string) error
+}
+
+// Action ...
+type Action string
+
+const (
+	// ActionCreate ...
+	ActionCreate Action = "create"
+	// ActionUpdate ...
+	ActionUpdate Action = "update"
+	// ActionDelete ...
+	ActionDelete Action = "delete"
+
+	// portalClientID is a client created by the server with the project, so it is never pruned
+	portalClientID = "portal"
+)
+
+// Change is a operation to make the resource the desired state
+type Change struct {
+	Action  Action   `json:"action"`
+	Kind    string   `json:"kind"`
+	Project string   `json:"project,omitempty"`
+	Name    string   `json:"name"`
+	Diffs   []string `json:"diffs,omitempty"`
+
+	apply func(c Client) error
+}
+
+// Caller is a user who applies the manifests
+type Caller struct {
+	ProjectName string
+	UserID      string
+}
+
+// Plan is a list of changes in the order of apply
+type Plan struct {
+	Changes []*Change `json:"changes"`
+}
+
+// projectState is a current state of the project in the server
+type projectState struct {
+	exists  bool
+	clients map[string]*clientapi.ClientGetResponse
+	roles   map[string]*roleapi.CustomRoleGetResponse
+	users   map[string]*userapi.UserGetResponse
+}
+
+// MakePlan compares the manifests with the server and returns the changes
+// if prune is true, clients, custom roles and users which are not in the manifests are deleted
+// from the projects in the manifests, but the caller and the users who have cluster roles are kept
+func MakePlan(c Client, manifests []*Manifest, prune bool, caller *Caller) (*Plan, error) {
+	prjs, err := c.ProjectGetList()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get project list: %v", err)
+	}
+	currentPrjs := map[string]*projectapi.ProjectGetResponse{}
+	for _, p := range prjs {
+		currentPrjs[p.Name] = p
+	}
+
+	byKind := map[string][]*Manifest{}
+	states := map[string]*projectState{}
+	projectNames := []string{}
+	for _, m := range manifests {
+		byKind[m.Kind] = append(byKind[m.Kind], m)
+		name := m.Project
+		if m.Kind == KindProject {
+			name = m.Name
+		}
+		if _, ok := states[name]; !ok {
+			states[name] = nil
+			projectNames = append(projectNames, name)
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, m := range byKind[KindProject] {
+		declared[m.Name] = true
+	}
+	for _, name := range projectNames {
+		_, exists := currentPrjs[name]
+		if !exists && !declared[name] {
+			return nil, fmt.Errorf("Project %s is neither in the server nor in the manifests", name)
+		}
+		st, err := getProjectState(c, name, exists)
+		if err != nil {
+			return nil, err
+		}
+		states[name] = st
+	}
+
+	res := &Plan{Changes: []*Change{}}
+
+	for _, m := range byKind[KindProject] {
+		if ch := planProject(m, currentPrjs[m.Name]); ch != nil {
+			res.Changes = append(res.Changes, ch)
+		}
+	}
+
+	for _, m := range byKind[KindCustomRole] {
+		if _, ok := states[m.Project].roles[m.Name]; !ok {
+			res.Changes = append(res.Changes, createRoleChange(m))
+		}
+	}
+
+	for _, m := range byKind[KindClient] {
+		ch, err := planClient(m, states[m.Project].clients[m.Name])
+		if err != nil {
+			return nil, err
+		}
+		if ch != nil {
+			res.Changes = append(res.Changes, ch)
+		}
+	}
+
+	for _, m := range byKind[KindUser] {
+		// custom roles of the user must exist after apply
+		for _, r := range m.UserSpec.CustomRoles {
+			if _, ok := states[m.Project].roles[r]; ok {
+				continue
+			}
+			if !hasManifest(byKind[KindCustomRole], m.Project, r) {
+				return nil, fmt.Errorf("Custom role %s of user %s is neither in the server nor in the manifests", r, m.Name)
+			}
+		}
+
+		ch, err := planUser(m, states[m.Project].users[m.Name])
+		if err != nil {
+			return nil, err
+		}
+		if ch != nil {
+			res.Changes = append(res.Changes, ch)
+		}
+	}
+
+	if prune {
+		for _, name := range projectNames {
+			name := name
+			st := states[name]
+			for _, u := range sortedKeys(st.users) {
+				u := u
+				if !hasManifest(byKind[KindUser], name, u) && !keepUser(name, st.users[u], caller) {
+					res.Changes = append(res.Changes, deleteChange(KindUser, name, u, func(c Client) error {
+						return c.UserDelete(name, u)
+					}))
+				}
+			}
+			for _, id := range sortedKeys(st.clients) {
+				id := id
+				if id != portalClientID && !hasManifest(byKind[KindClient], name, id) {
+					res.Changes = append(res.Changes, deleteChange(KindClient, name, id, func(c Client) error {
+						return c.ClientDelete(name, id)
+					}))
+				}
+			}
+			for _, r := range sortedKeys(st.roles) {
+				r := r
+				if !hasManifest(byKind[KindCustomRole], name, r) {
+					res.Changes = append(res.Changes, deleteChange(KindCustomRole, name, r, func(c Client) error {
+						return c.RoleDelete(name, r)
+					}))
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// keepUser returns true if the user must not be pruned
+// deleting the caller or the cluster admins may lose the access to the server
+func keepUser(projectName string, user *userapi.UserGetResponse, caller *Caller) bool {
+	if caller != nil && caller.ProjectName == projectName && caller.UserID == user.ID {
+		return true
+	}
+	for _, r := range user.SystemRoles {
+		if strings.HasSuffix(r, "-"+role.ResCluster.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply runs the changes in order, and stops at the first error
+func (p *Plan) Apply(c Client) error {
+	for _, ch := range p.Changes {
+		if err := ch.apply(c); err != nil {
+			return fmt.Errorf("Failed to %s %s: %v", ch.Action, ch.Key(), err)
+		}
+	}
+	return nil
+}
+
+// Count returns the number of changes per action
+func (p *Plan) Count(action Action) int {
+	res := 0
+	for _, ch := range p.Changes {
+		if ch.Action == action {
+			res++
+		}
+	}
+	return res
+}
+
+// Key returns an identifier of the resource
+func (ch *Change) Key() string {
+	m := &Manifest{Kind: ch.Kind, Name: ch.Name, Project: ch.Project}
+	return m.Key()
+}
+
+func getProjectState(c Client, projectName string, exists bool) (*projectState, error) {
+	res := &projectState{
+		exists:  exists,
+		clients: map[string]*clientapi.ClientGetResponse{},
+		roles:   map[string]*roleapi.CustomRoleGetResponse{},
+		users:   map[string]*userapi.UserGetResponse{},
+	}
+	if !exists {
+		// the server creates the portal client with the project
+		res.clients[portalClientID] = &clientapi.ClientGetResponse{
+			ID:                  portalClientID,
+			AccessType:          "public",
+			AllowedCallbackURLs: []string{},
+		}
+		return res, nil
+	}
+
+	clients, err := c.ClientGetList(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get client list of %s: %v", projectName, err)
+	}
+	for _, cli := range clients {
+		res.clients[cli.ID] = cli
+	}
+
+	roles, err := c.RoleGetList(projectName, "")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get custom role list of %s: %v", projectName, err)
+	}
+	for _, r := range roles {
+		res.roles[r.Name] = r
+	}
+
+	users, err := c.UserGetList(projectName, "")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get user list of %s: %v", projectName, err)
+	}
+	for _, u := range users {
+		res.users[u.Name] = u
+	}
+
+	return res, nil
+}
+
+func planProject(m *Manifest, current *projectapi.ProjectGetResponse) *Change {
+	spec := m.ProjectSpec
+	tc := projectapi.TokenConfig{
+		AccessTokenLifeSpan:  spec.TokenConfig.AccessTokenLifeSpan,
+		RefreshTokenLifeSpan: spec.TokenConfig.RefreshTokenLifeSpan,
+		RefreshTokenRotation: spec.TokenConfig.RefreshTokenRotation,
+		SigningAlgorithm:     spec.TokenConfig.SigningAlgorithm,
+		KeyRotationInterval:  spec.TokenConfig.KeyRotationInterval,
+	}
+	pp := projectapi.PasswordPolicy{
+		MinimumLength:       spec.PasswordPolicy.MinimumLength,
+		NotUserName:         spec.PasswordPolicy.NotUserName,
+		BlackList:           spec.PasswordPolicy.BlackList,
+		UseCharacter:        spec.PasswordPolicy.UseCharacter,
+		UseDigit:            spec.PasswordPolicy.UseDigit,
+		UseSpecialCharacter: spec.PasswordPolicy.UseSpecialCharacter,
+	}
+	ul := projectapi.UserLock{
+		Enabled:          spec.UserLock.Enabled,
+		MaxLoginFailure:  spec.UserLock.MaxLoginFailure,
+		LockDuration:     spec.UserLock.LockDuration,
+		FailureResetTime: spec.UserLock.FailureResetTime,
+	}
+
+	if current == nil {
+		req := &projectapi.ProjectCreateRequest{
+			Name:            m.Name,
+			TokenConfig:     tc,
+			PasswordPolicy:  pp,
+			AllowGrantTypes: spec.AllowGrantTypes,
+			UserLock:        ul,
+		}
+		return &Change{
+			Action: ActionCreate,
+			Kind:   KindProject,
+			Name:   m.Name,
+			apply: func(c Client) error {
+				_, err := c.ProjectAdd(req)
+				return err
+			},
+		}
+	}
+
+	diffs := []string{}
+	diffs = appendDiff(diffs, "tokenConfig", current.TokenConfig, tc)
+	diffs = appendDiff(diffs, "passwordPolicy.length", current.PasswordPolicy.MinimumLength, pp.MinimumLength)
+	diffs = appendDiff(diffs, "passwordPolicy.notUserName", current.PasswordPolicy.NotUserName, pp.NotUserName)
+	diffs = appendDiff(diffs, "passwordPolicy.blackList", sortedStrings(current.PasswordPolicy.BlackList), sortedStrings(pp.BlackList))
+	diffs = appendDiff(diffs, "passwordPolicy.useCharacter", current.PasswordPolicy.UseCharacter, pp.UseCharacter)
+	diffs = appendDiff(diffs, "passwordPolicy.useDigit", current.PasswordPolicy.UseDigit, pp.UseDigit)
+	diffs = appendDiff(diffs, "passwordPolicy.useSpecialCharacter", current.PasswordPolicy.UseSpecialCharacter, pp.UseSpecialCharacter)
+	diffs = appendDiff(diffs, "allowGrantTypes", sortedStrings(current.AllowGrantTypes), sortedStrings(spec.AllowGrantTypes))
+	diffs = appendDiff(diffs, "userLock", current.UserLock, ul)
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	req := &projectapi.ProjectPutRequest{
+		TokenConfig:     tc,
+		PasswordPolicy:  pp,
+		AllowGrantTypes: spec.AllowGrantTypes,
+		UserLock:        ul,
+	}
+	return &Change{
+		Action: ActionUpdate,
+		Kind:   KindProject,
+		Name:   m.Name,
+		Diffs:  diffs,
+		apply: func(c Client) error {
+			return c.ProjectUpdate(m.Name, req)
+		},
+	}
+}
+
+func createRoleChange(m *Manifest) *Change {
+	return &Change{
+		Action:  ActionCreate,
+		Kind:    KindCustomRole,
+		Project: m.Project,
+		Name:    m.Name,
+		apply: func(c Client) error {
+			_, err := c.RoleAdd(m.Project, &roleapi.CustomRoleCreateRequest{Name: m.Name})
+			return err
+		},
+	}
+}
+
+func planClient(m *Manifest, current *clientapi.ClientGetResponse) (*Change, error) {
+	spec := m.ClientSpec
+	urls := spec.AllowedCallbackURLs
+	if urls == nil {
+		urls = []string{}
+	}
+
+	if current == nil {
+		if spec.AccessType == "confidential" && spec.Secret == "" {
+			return nil, fmt.Errorf("secret is required to create confidential client %s", m.Name)
+		}
+		req := &clientapi.ClientCreateRequest{
+			ID:                  m.Name,
+			Secret:              spec.Secret,
+			AccessType:          spec.AccessType,
+			AllowedCallbackURLs: urls,
+		}
+		return &Change{
+			Action:  ActionCreate,
+			Kind:    KindClient,
+			Project: m.Project,
+			Name:    m.Name,
+			apply: func(c Client) error {
+				_, err := c.ClientAdd(m.Project, req)
+				return err
+			},
+		}, nil
+	}
+
+	// keep the current secret if it is not specified
+	secret := spec.Secret
+	if secret == "" {
+		secret = current.Secret
+	}
+
+	diffs := []string{}
+	diffs = appendDiff(diffs, "accessType", current.AccessType, spec.AccessType)
+	diffs = appendDiff(diffs, "allowedCallbackURLs", sortedStrings(current.AllowedCallbackURLs), sortedStrings(urls))
+	if secret != current.Secret {
+		// do not show the secret value
+		diffs = append(diffs, "secret: (changed)")
+	}
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	// the manifest does not manage the request object, PAR and client authentication settings, so keep the current values
+	req := &clientapi.ClientPutRequest{
+		Secret:                     secret,
+		AccessType:                 spec.AccessType,
+		AllowedCallbackURLs:        urls,
+		JWKS:                       current.JWKS,
+		JWKSURI:                    current.JWKSURI,
+		RequestURIs:                current.RequestURIs,
+		PostLogoutRedirectURIs:     current.PostLogoutRedirectURIs,
+		BackchannelLogoutURI:       current.BackchannelLogoutURI,
+		FrontchannelLogoutURI:      current.FrontchannelLogoutURI,
+		RequestObjectSigningAlg:    current.RequestObjectSigningAlg,
+		RequirePushedAuthRequests:  current.RequirePushedAuthRequests,
+		TokenEndpointAuthMethod:    current.TokenEndpointAuthMethod,
+		TLSClientAuthSubjectDN:     current.TLSClientAuthSubjectDN,
+		RequireDPoP:                current.RequireDPoP,
+		TokenExchangeAudiences:     current.TokenExchangeAudiences,
+		TokenExchangeImpersonation: current.TokenExchangeImpersonation,
+	}
+	return &Change{
+		Action:  ActionUpdate,
+		Kind:    KindClient,
+		Project: m.Project,
+		Name:    m.Name,
+		Diffs:   diffs,
+		apply: func(c Client) error {
+			return c.ClientUpdate(m.Project, m.Name, req)
+		},
+	}, nil
+}
+
+func planUser(m *Manifest, current *userapi.UserGetResponse) (*Change, error) {
+	spec := m.UserSpec
+	systemRoles := spec.SystemRoles
+	if systemRoles == nil {
+		systemRoles = []string{}
+	}
+
+	if current == nil {
+		if spec.Password == "" {
+			return nil, fmt.Errorf("password is required to create user %s", m.Name)
+		}
+		return &Change{
+			Action:  ActionCreate,
+			Kind:    KindUser,
+			Project: m.Project,
+			Name:    m.Name,
+			apply: func(c Client) error {
+				roles, err := customRoleIDs(c, m.Project, spec.CustomRoles)
+				if err != nil {
+					return err
+				}
+				_, err = c.UserAdd(m.Project, &userapi.UserCreateRequest{
+					Name:        m.Name,
+					Password:    spec.Password,
+					SystemRoles: systemRoles,
+					CustomRoles: roles,
+				})
+				return err
+			},
+		}, nil
+	}
+
+	currentRoles := []string{}
+	for _, r := range current.CustomRoles {
+		currentRoles = append(currentRoles, r.Name)
+	}
+
+	diffs := []string{}
+	diffs = appendDiff(diffs, "systemRoles", sortedStrings(current.SystemRoles), sortedStrings(systemRoles))
+	diffs = appendDiff(diffs, "customRoles", sortedStrings(currentRoles), sortedStrings(spec.CustomRoles))
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	return &Change{
+		Action:  ActionUpdate,
+		Kind:    KindUser,
+		Project: m.Project,
+		Name:    m.Name,
+		Diffs:   diffs,
+		apply: func(c Client) error {
+			roles, err := customRoleIDs(c, m.Project, spec.CustomRoles)
+			if err != nil {
+				return err
+			}
+			return c.UserUpdate(m.Project, m.Name, &userapi.UserPutRequest{
+				Name:        m.Name,
+				SystemRoles: systemRoles,
+				CustomRoles: roles,
+			})
+		},
+	}, nil
+}
+
+func deleteChange(kind, projectName, name string, apply func(c Client) error) *Change {
+	return &Change{
+		Action:  ActionDelete,
+		Kind:    kind,
+		Project: projectName,
+		Name:    name,
+		apply:   apply,
+	}
+}
+
+// customRoleIDs converts the role names to the IDs
+// it is called in apply because the roles may be created in the same plan
+func customRoleIDs(c Client, projectName string, names []string) ([]string, error) {
+	res := []string{}
+	if len(names) == 0 {
+		return res, nil
+	}
+
+	roles, err := c.RoleGetList(projectName, "")
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]string{}
+	for _, r := range roles {
+		ids[r.Name] = r.ID
+	}
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("No such custom role %s", name)
+		}
+		res = append(res, id)
+	}
+	return res, nil
+}
+
+func hasManifest(manifests []*Manifest, projectName, name string) bool {
+	for _, m := range manifests {
+		if m.Project == projectName && m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func appendDiff(diffs []string, field string, current, desired interface{}) []string {
+	if reflect.DeepEqual(current, desired) {
+		return diffs
+	}
+	return append(diffs, fmt.Sprintf("%s: %+v -> %+v", field, current, desired))
+}
+
+func sortedStrings(values []string) []string {
+	res := append([]string{}, values...)
+	sort.Strings(res)
+	return res
+}
+
+func sortedKeys(m interface{}) []string {
+	res := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		res = append(res, k.String())
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,69 @@
+package apply
+
+import (
+	"os"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/apply"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/output"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the manifests to the server",
+	Long: `Apply the manifests to the server
+  the manifests describe the desired state of projects, clients, custom roles and users in YAML`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		manifests, err := apply.LoadManifests(file)
+		if err != nil {
+			print.Error("Failed to load manifests: %v", err)
+			os.Exit(1)
+		}
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+		caller := &apply.Caller{}
+		caller.ProjectName, caller.UserID, err = config.GetLoginUser()
+		if err != nil {
+			print.Error("Failed to get login user: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+		plan, err := apply.MakePlan(handler, manifests, prune, caller)
+		if err != nil {
+			print.Fatal("Failed to make plan: %v", err)
+		}
+
+		output.Print(output.NewPlanFormat(plan))
+		if len(plan.Changes) == 0 {
+			return
+		}
+
+		if err := plan.Apply(handler); err != nil {
+			print.Fatal("Failed to apply: %v", err)
+		}
+		print.Print("Successfully applied")
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringP("file", "f", "", "[Required] manifest file or directory which has *.yaml and *.yml manifests")
+	applyCmd.Flags().Bool("prune", false, "delete clients, custom roles and users which are not in the manifests from the projects in the manifests (the login user and the users who have cluster roles are kept)")
+	applyCmd.MarkFlagRequired("file")
+}
+
+// GetCommand ...
+func GetCommand() *cobra.Command {
+	return applyCmd
+}
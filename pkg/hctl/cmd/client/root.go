@@ -10,6 +10,7 @@ func init() {
 	clientCmd.AddCommand(deleteClientCmd)
 	clientCmd.AddCommand(getClientCmd)
 	clientCmd.AddCommand(updateClientCmd)
+	clientCmd.AddCommand(registrationTokenCmd)
 }
 
 var clientCmd = &cobra.Command{
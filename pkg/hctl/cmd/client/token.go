@@ -0,0 +1,45 @@
+package client
+
+import (
+	"os"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	clientapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/client"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
+	"github.com/spf13/cobra"
+)
+
+var registrationTokenCmd = &cobra.Command{
+	Use:   "registration-token",
+	Short: "Create Initial Access Token",
+	Long:  "Create an initial access token for the dynamic client registration",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName, _ := cmd.Flags().GetString("project")
+		expiresIn, _ := cmd.Flags().GetInt64("expires-in")
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+		req := &clientapi.RegistrationTokenCreateRequest{
+			ExpiresIn: expiresIn,
+		}
+		res, err := handler.ClientRegistrationTokenCreate(projectName, req)
+		if err != nil {
+			print.Fatal("Failed to create the registration token in %s: %v", projectName, err)
+		}
+
+		print.Print("%s", res.Token)
+	},
+}
+
+func init() {
+	registrationTokenCmd.Flags().String("project", "", "[Required] name of the project in which the client is registered")
+	registrationTokenCmd.Flags().Int64("expires-in", 0, "lifetime of the token in seconds (default 1 day)")
+	registrationTokenCmd.MarkFlagRequired("project")
+}
@@ -77,6 +77,21 @@ var updateClientCmd = &cobra.Command{
 			} else {
 				req.AllowedCallbackURLs = prev.AllowedCallbackURLs
 			}
+
+			// request object, PAR and client authentication settings can be changed only by the file
+			req.JWKS = prev.JWKS
+			req.JWKSURI = prev.JWKSURI
+			req.RequestURIs = prev.RequestURIs
+			req.PostLogoutRedirectURIs = prev.PostLogoutRedirectURIs
+			req.BackchannelLogoutURI = prev.BackchannelLogoutURI
+			req.FrontchannelLogoutURI = prev.FrontchannelLogoutURI
+			req.RequestObjectSigningAlg = prev.RequestObjectSigningAlg
+			req.RequirePushedAuthRequests = prev.RequirePushedAuthRequests
+			req.TokenEndpointAuthMethod = prev.TokenEndpointAuthMethod
+			req.TLSClientAuthSubjectDN = prev.TLSClientAuthSubjectDN
+			req.RequireDPoP = prev.RequireDPoP
+			req.TokenExchangeAudiences = prev.TokenExchangeAudiences
+			req.TokenExchangeImpersonation = prev.TokenExchangeImpersonation
 		}
 
 		if err := handler.ClientUpdate(projectName, id, req); err != nil {
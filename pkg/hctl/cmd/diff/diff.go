@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"os"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/apply"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/output"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the changes to apply the manifests",
+	Long:  "Show the changes to apply the manifests without changing the server",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		manifests, err := apply.LoadManifests(file)
+		if err != nil {
+			print.Error("Failed to load manifests: %v", err)
+			os.Exit(1)
+		}
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+		caller := &apply.Caller{}
+		caller.ProjectName, caller.UserID, err = config.GetLoginUser()
+		if err != nil {
+			print.Error("Failed to get login user: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+		plan, err := apply.MakePlan(handler, manifests, prune, caller)
+		if err != nil {
+			print.Fatal("Failed to make plan: %v", err)
+		}
+
+		output.Print(output.NewPlanFormat(plan))
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringP("file", "f", "", "[Required] manifest file or directory which has *.yaml and *.yml manifests")
+	diffCmd.Flags().Bool("prune", false, "show the deletion of clients, custom roles and users which are not in the manifests")
+	diffCmd.MarkFlagRequired("file")
+}
+
+// GetCommand ...
+func GetCommand() *cobra.Command {
+	return diffCmd
+}
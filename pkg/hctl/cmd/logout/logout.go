@@ -19,7 +19,7 @@ var logoutCmd = &cobra.Command{
 			return
 		}
 
-		if err := logout.Logout(cfg.ServerAddr, secret.ProjectName, secret.RefreshToken); err != nil {
+		if err := logout.Logout(cfg.ServerAddr, secret.ProjectName, cfg.ClientID, cfg.ClientSecret, secret.RefreshToken); err != nil {
 			print.Fatal("Logout failed: %v", err)
 		}
 
@@ -55,7 +55,9 @@ var addProjectCmd = &cobra.Command{
 			req.Name = projectName
 			req.TokenConfig.AccessTokenLifeSpan, _ = cmd.Flags().GetUint("accessExpires")
 			req.TokenConfig.RefreshTokenLifeSpan, _ = cmd.Flags().GetUint("refreshExpires")
+			req.TokenConfig.RefreshTokenRotation, _ = cmd.Flags().GetBool("refreshRotation")
 			req.TokenConfig.SigningAlgorithm, _ = cmd.Flags().GetString("signAlg")
+			req.TokenConfig.KeyRotationInterval, _ = cmd.Flags().GetUint("keyRotationInterval")
 			req.AllowGrantTypes, _ = cmd.Flags().GetStringArray("grantTypes")
 
 			pwPols, _ := cmd.Flags().GetStringArray("passwordPolicies")
@@ -89,7 +91,9 @@ func init() {
 	addProjectCmd.Flags().StringP("name", "n", "", "name of new project")
 	addProjectCmd.Flags().Uint("accessExpires", 5*60, "access token life span [sec]")
 	addProjectCmd.Flags().Uint("refreshExpires", 14*24*60*60, "refresh token life span [sec]")
-	addProjectCmd.Flags().String("signAlg", "RS256", "token sigining algorithm, only support RS256")
+	addProjectCmd.Flags().Bool("refreshRotation", false, "rotate refresh token and detect its reuse")
+	addProjectCmd.Flags().String("signAlg", "RS256", "token sigining algorithm, one of RS256, PS256, ES256, EdDSA or HS256")
+	addProjectCmd.Flags().Uint("keyRotationInterval", 0, "interval of sign key rotation [sec], 0 means no scheduled rotation")
 	addProjectCmd.Flags().StringArray("grantTypes", []string{}, "allowed grant type list")
 	addProjectCmd.Flags().StringArray("passwordPolicies", []string{}, "password policy of users, supports \"minLen=<uint>\", \"notUserName=<bool>\", \"useChar=<lower|upper|both|either>\", \"useDigit=<bool>\", \"useSpecialChar=<bool>\", \"blackLists=<string separated by semicolon(;)>\"")
 	addProjectCmd.Flags().Bool("userLockEnabled", false, "enable user lock")
@@ -0,0 +1,71 @@
+package project
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var exportProjectCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all resources of the project",
+	Long:  "Export all resources of the project as a portable document",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName, _ := cmd.Flags().GetString("name")
+		file, _ := cmd.Flags().GetString("file")
+		format, _ := cmd.Flags().GetString("format")
+		includeKeys, _ := cmd.Flags().GetBool("keys")
+
+		if format != "json" && format != "yaml" {
+			print.Error("Unknown document format: %s", format)
+			os.Exit(1)
+		}
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+		doc, err := handler.ProjectExport(projectName, includeKeys)
+		if err != nil {
+			print.Fatal("Failed to export project %s: %v", projectName, err)
+		}
+
+		var out []byte
+		if format == "yaml" {
+			out, err = yaml.Marshal(doc)
+		} else {
+			out, err = json.MarshalIndent(doc, "", "  ")
+		}
+		if err != nil {
+			print.Fatal("Failed to marshal document: %v", err)
+		}
+
+		if file == "" {
+			print.Print("%s", out)
+			return
+		}
+		// the document contains secrets, so only the owner can read it
+		if err := ioutil.WriteFile(file, out, 0600); err != nil {
+			print.Fatal("Failed to write document to %s: %v", file, err)
+		}
+		print.Print("Project %s successfully exported to %s", projectName, file)
+	},
+}
+
+func init() {
+	exportProjectCmd.Flags().StringP("name", "n", "", "[Required] name of export project")
+	exportProjectCmd.Flags().StringP("file", "f", "", "output file name, output to STDOUT if empty")
+	exportProjectCmd.Flags().String("format", "json", "document format, one of json or yaml")
+	exportProjectCmd.Flags().Bool("keys", false, "include sign keys of the project")
+	exportProjectCmd.MarkFlagRequired("name")
+}
@@ -0,0 +1,80 @@
+package project
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	projectapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/project"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var importProjectCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the project from the exported document",
+	Long:  "Import the project from the exported document",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		projectName, _ := cmd.Flags().GetString("name")
+		strategy, _ := cmd.Flags().GetString("strategy")
+
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			print.Error("Failed to read file %s: %v", file, err)
+			os.Exit(1)
+		}
+
+		doc := &projectapi.ProjectExportDocument{}
+		switch filepath.Ext(file) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(bytes, doc)
+		default:
+			err = json.Unmarshal(bytes, doc)
+		}
+		if err != nil {
+			print.Error("Failed to parse input file: %v", err)
+			os.Exit(1)
+		}
+
+		if projectName != "" {
+			// import as other project
+			doc.Project.Name = projectName
+		}
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+		res, err := handler.ProjectImport(doc, strategy)
+		if err != nil {
+			print.Fatal("Failed to import project %s: %v", doc.Project.Name, err)
+		}
+
+		for _, e := range res.Created {
+			print.Print("created: %s", e)
+		}
+		for _, e := range res.Updated {
+			print.Print("updated: %s", e)
+		}
+		for _, e := range res.Skipped {
+			print.Print("skipped: %s", e)
+		}
+		print.Print("Project %s successfully imported", doc.Project.Name)
+	},
+}
+
+func init() {
+	importProjectCmd.Flags().StringP("file", "f", "", "[Required] file name of the exported document, parsed as yaml if the extension is .yaml or .yml")
+	importProjectCmd.Flags().StringP("name", "n", "", "name of project to import, the name in the document is used if empty")
+	importProjectCmd.Flags().String("strategy", "fail", "how to handle the entry which already exists, one of skip, overwrite or fail")
+	importProjectCmd.MarkFlagRequired("file")
+}
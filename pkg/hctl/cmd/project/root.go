@@ -11,6 +11,8 @@ func init() {
 	projectCmd.AddCommand(deleteProjectCmd)
 	projectCmd.AddCommand(getProjectCmd)
 	projectCmd.AddCommand(updateProjectCmd)
+	projectCmd.AddCommand(exportProjectCmd)
+	projectCmd.AddCommand(importProjectCmd)
 	projectCmd.AddCommand(secret.GetCommand())
 }
 
@@ -73,7 +73,9 @@ var updateProjectCmd = &cobra.Command{
 
 			req.TokenConfig.AccessTokenLifeSpan = getData(cmd, "accessExpires", prev.TokenConfig.AccessTokenLifeSpan, "uint").(uint)
 			req.TokenConfig.RefreshTokenLifeSpan = getData(cmd, "refreshExpires", prev.TokenConfig.RefreshTokenLifeSpan, "uint").(uint)
+			req.TokenConfig.RefreshTokenRotation = getData(cmd, "refreshRotation", prev.TokenConfig.RefreshTokenRotation, "bool").(bool)
 			req.TokenConfig.SigningAlgorithm = getData(cmd, "signAlg", prev.TokenConfig.SigningAlgorithm, "string").(string)
+			req.TokenConfig.KeyRotationInterval = getData(cmd, "keyRotationInterval", prev.TokenConfig.KeyRotationInterval, "uint").(uint)
 			req.AllowGrantTypes = getData(cmd, "grantTypes", prev.AllowGrantTypes, "stringarray").([]string)
 			pwPols := getData(cmd, "passwordPolicies", prev.PasswordPolicy, "stringarray").([]string)
 			req.PasswordPolicy, err = util.ParsePolicies(pwPols)
@@ -99,7 +101,9 @@ func init() {
 	updateProjectCmd.Flags().StringP("name", "n", "", "name of update project")
 	updateProjectCmd.Flags().Uint("accessExpires", 5*60, "access token life span [sec]")
 	updateProjectCmd.Flags().Uint("refreshExpires", 14*24*60*60, "refresh token life span [sec]")
-	updateProjectCmd.Flags().String("signAlg", "RS256", "token sigining algorithm, only support RS256")
+	updateProjectCmd.Flags().Bool("refreshRotation", false, "rotate refresh token and detect its reuse")
+	updateProjectCmd.Flags().String("signAlg", "RS256", "token sigining algorithm, one of RS256, PS256, ES256, EdDSA or HS256")
+	updateProjectCmd.Flags().Uint("keyRotationInterval", 0, "interval of sign key rotation [sec], 0 means no scheduled rotation")
 	updateProjectCmd.Flags().StringArray("grantTypes", []string{}, "allowed grant type list")
 	updateProjectCmd.Flags().StringArray("passwordPolicies", []string{}, "password policy of users, supports \"minLen=<uint>\", \"notUserName=<bool>\", \"useChar=<lower|upper|both|either>\", \"useDigit=<bool>\", \"useSpecialChar=<bool>\", \"blackLists=<string separated by semicolon(;)>\"")
 	updateProjectCmd.Flags().Bool("userLockEnabled", false, "enable user lock")
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/apply"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/client"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/config"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/diff"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/login"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/logout"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/cmd/project"
@@ -36,6 +38,8 @@ func init() {
 	rootCmd.AddCommand(client.GetCommand())
 	rootCmd.AddCommand(role.GetCommand())
 	rootCmd.AddCommand(config.GetCommand())
+	rootCmd.AddCommand(apply.GetCommand())
+	rootCmd.AddCommand(diff.GetCommand())
 }
 
 func initOutput() {
@@ -1,6 +1,13 @@
 package update
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	apiclient "github.com/sh-miyoshi/hekate/pkg/apiclient/v1"
+	userapi "github.com/sh-miyoshi/hekate/pkg/apihandler/admin/v1/user"
+	"github.com/sh-miyoshi/hekate/pkg/hctl/config"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
 	"github.com/spf13/cobra"
 )
@@ -10,7 +17,82 @@ var updateUserCmd = &cobra.Command{
 	Short: "Update User",
 	Long:  "Update user",
 	Run: func(cmd *cobra.Command, args []string) {
-		print.Print("TODO: implement this")
+		projectName, _ := cmd.Flags().GetString("project")
+		file, _ := cmd.Flags().GetString("file")
+		userName, _ := cmd.Flags().GetString("name")
+
+		token, err := config.GetAccessToken()
+		if err != nil {
+			print.Error("Token get failed: %v", err)
+			os.Exit(1)
+		}
+
+		c := config.Get()
+		handler := apiclient.NewHandler(c.ServerAddr, token, c.Insecure, c.RequestTimeout)
+
+		req := &userapi.UserPutRequest{}
+		if file != "" {
+			bytes, err := ioutil.ReadFile(file)
+			if err != nil {
+				print.Error("Failed to read file %s: %v", file, err)
+				os.Exit(1)
+			}
+			if err := json.Unmarshal(bytes, req); err != nil {
+				print.Error("Failed to parse input file to json: %v", err)
+				os.Exit(1)
+			}
+		} else {
+			users, err := handler.UserGetList(projectName, userName)
+			if err != nil {
+				print.Error("Failed to get previous user info: %v", err)
+				os.Exit(1)
+			}
+			if len(users) != 1 {
+				print.Error("User %s is not found", userName)
+				os.Exit(1)
+			}
+			prev := users[0]
+
+			req.Name = prev.Name
+			req.SystemRoles = prev.SystemRoles
+			for _, r := range prev.CustomRoles {
+				req.CustomRoles = append(req.CustomRoles, r.ID)
+			}
+
+			profile := prev.Profile
+			setString := func(flag string, v *string) {
+				if cmd.Flag(flag).Changed {
+					*v, _ = cmd.Flags().GetString(flag)
+				}
+			}
+			setBool := func(flag string, v *bool) {
+				if cmd.Flag(flag).Changed {
+					*v, _ = cmd.Flags().GetBool(flag)
+				}
+			}
+			setString("fullName", &profile.Name)
+			setString("givenName", &profile.GivenName)
+			setString("familyName", &profile.FamilyName)
+			setString("nickname", &profile.Nickname)
+			setString("picture", &profile.Picture)
+			setString("locale", &profile.Locale)
+			setString("email", &profile.Email)
+			setBool("emailVerified", &profile.EmailVerified)
+			setString("phoneNumber", &profile.PhoneNumber)
+			setBool("phoneNumberVerified", &profile.PhoneNumberVerified)
+			req.Profile = &profile
+
+			req.Attributes = prev.Attributes
+			if cmd.Flag("attributes").Changed {
+				req.Attributes, _ = cmd.Flags().GetStringToString("attributes")
+			}
+		}
+
+		if err := handler.UserUpdate(projectName, userName, req); err != nil {
+			print.Fatal("Failed to update user %s in %s: %v", userName, projectName, err)
+		}
+
+		print.Print("Successfully updated")
 	},
 }
 
@@ -18,8 +100,22 @@ func init() {
 	updateUserCmd.AddCommand(unlockUserCmd)
 	updateUserCmd.AddCommand(passwordChangeCmd)
 
-	updateUserCmd.Flags().String("project", "", "name of the project to which the user belongs")
+	updateUserCmd.Flags().String("project", "", "[Required] name of the project to which the user belongs")
 	updateUserCmd.Flags().StringP("file", "f", "", "file path for update user info")
+	updateUserCmd.Flags().StringP("name", "n", "", "[Required] name of target user")
+	updateUserCmd.Flags().String("fullName", "", "full name of the user")
+	updateUserCmd.Flags().String("givenName", "", "given name of the user")
+	updateUserCmd.Flags().String("familyName", "", "family name of the user")
+	updateUserCmd.Flags().String("nickname", "", "nickname of the user")
+	updateUserCmd.Flags().String("picture", "", "URL of the profile picture of the user")
+	updateUserCmd.Flags().String("locale", "", "locale of the user such as en-US")
+	updateUserCmd.Flags().String("email", "", "email address of the user")
+	updateUserCmd.Flags().Bool("emailVerified", false, "whether the email address is verified")
+	updateUserCmd.Flags().String("phoneNumber", "", "phone number of the user")
+	updateUserCmd.Flags().Bool("phoneNumberVerified", false, "whether the phone number is verified")
+	updateUserCmd.Flags().StringToString("attributes", nil, "custom attributes of the user (replace all attributes)")
+	updateUserCmd.MarkFlagRequired("project")
+	updateUserCmd.MarkFlagRequired("name")
 }
 
 // GetCommand ...
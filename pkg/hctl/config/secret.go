@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"time"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	oidcapi "github.com/sh-miyoshi/hekate/pkg/apihandler/auth/v1/oidc"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/login"
 	"github.com/sh-miyoshi/hekate/pkg/hctl/print"
@@ -87,6 +88,23 @@ func GetAccessToken() (string, error) {
 	return s.AccessToken, nil
 }
 
+// GetLoginUser returns the project name and the user id of the logged in user
+// the access token is not verified, so use it only to show or protect the user
+func GetLoginUser() (string, string, error) {
+	token, err := GetAccessToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := jwt.StandardClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, &claims); err != nil {
+		return "", "", fmt.Errorf("Failed to parse access token: %v", err)
+	}
+
+	s, _ := GetSecret()
+	return s.ProjectName, claims.Subject, nil
+}
+
 // RemoveSecretFile ...
 func RemoveSecretFile() error {
 	secretFile := filepath.Join(configDir, "secret")
@@ -8,12 +8,16 @@ import (
 )
 
 // Logout ...
-func Logout(serverAddr, projectName, refreshToken string) error {
+func Logout(serverAddr, projectName, clientID, clientSecret, refreshToken string) error {
 	u := fmt.Sprintf("%s/authapi/v1/project/%s/openid-connect/revoke", serverAddr, projectName)
 
 	form := url.Values{}
 	form.Add("token_type_hint", "refresh_token")
 	form.Add("token", refreshToken)
+	form.Add("client_id", clientID)
+	if clientSecret != "" {
+		form.Add("client_secret", clientSecret)
+	}
 	body := strings.NewReader(form.Encode())
 	httpReq, err := http.NewRequest("POST", u, body)
 	if err != nil {
@@ -23,6 +23,16 @@ func NewKeysFormat(keys *keysapi.KeysGetResponse) *KeysFormat {
 func (f *KeysFormat) ToText() (string, error) {
 	res := fmt.Sprintf("Type:       %s\n", f.keys.Type)
 	res += fmt.Sprintf("Public Key: %s\n", f.keys.PublicKey)
+	res += fmt.Sprintf("Keys:\n")
+	for _, key := range f.keys.Keys {
+		res += fmt.Sprintf("  - Key ID:     %s\n", key.KeyID)
+		res += fmt.Sprintf("    State:      %s\n", key.State)
+		res += fmt.Sprintf("    Type:       %s\n", key.Type)
+		res += fmt.Sprintf("    Created At: %s\n", key.CreatedAt)
+		if key.ExpiresAt != "" {
+			res += fmt.Sprintf("    Expires At: %s\n", key.ExpiresAt)
+		}
+	}
 
 	return res, nil
 }
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sh-miyoshi/hekate/pkg/hctl/apply"
+)
+
+// PlanFormat ...
+type PlanFormat struct {
+	plan *apply.Plan
+}
+
+// NewPlanFormat ...
+func NewPlanFormat(plan *apply.Plan) *PlanFormat {
+	return &PlanFormat{
+		plan: plan,
+	}
+}
+
+// ToText ...
+func (f *PlanFormat) ToText() (string, error) {
+	if len(f.plan.Changes) == 0 {
+		return "No changes. The server matches the manifests.\n", nil
+	}
+
+	res := ""
+	for _, ch := range f.plan.Changes {
+		mark := "+"
+		switch ch.Action {
+		case apply.ActionUpdate:
+			mark = "~"
+		case apply.ActionDelete:
+			mark = "-"
+		}
+		res += fmt.Sprintf("%s %s %s\n", mark, ch.Action, ch.Key())
+		for _, d := range ch.Diffs {
+			res += fmt.Sprintf("    %s\n", d)
+		}
+	}
+	res += fmt.Sprintf("\nPlan: %d to create, %d to update, %d to delete\n",
+		f.plan.Count(apply.ActionCreate), f.plan.Count(apply.ActionUpdate), f.plan.Count(apply.ActionDelete))
+	return res, nil
+}
+
+// ToJSON ...
+func (f *PlanFormat) ToJSON() (string, error) {
+	bytes, err := json.Marshal(f.plan)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
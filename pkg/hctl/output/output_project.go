@@ -37,7 +37,9 @@ func (f *ProjectInfoFormat) ToText() (string, error) {
 	res += fmt.Sprintf("Created Time:            %s\n", f.project.CreatedAt)
 	res += fmt.Sprintf("Access Token Life Span:  %d [sec]\n", f.project.TokenConfig.AccessTokenLifeSpan)
 	res += fmt.Sprintf("Refresh Token Life Span: %d [sec]\n", f.project.TokenConfig.RefreshTokenLifeSpan)
+	res += fmt.Sprintf("Refresh Token Rotation:  %v\n", f.project.TokenConfig.RefreshTokenRotation)
 	res += fmt.Sprintf("Token Signing Algorithm: %s\n", f.project.TokenConfig.SigningAlgorithm)
+	res += fmt.Sprintf("Key Rotation Interval:   %d [sec]\n", f.project.TokenConfig.KeyRotationInterval)
 	res += fmt.Sprintf("Allow Grant Types:       %v\n", f.project.AllowGrantTypes)
 	res += fmt.Sprintf("Password Policies:\n")
 	res += fmt.Sprintf("  Minimum Length:        %d\n", f.project.PasswordPolicy.MinimumLength)
@@ -50,6 +52,11 @@ func (f *ProjectInfoFormat) ToText() (string, error) {
 	res += fmt.Sprintf("Max Login Failure:       %d\n", f.project.UserLock.MaxLoginFailure)
 	res += fmt.Sprintf("Lock Duration:           %d [sec]\n", f.project.UserLock.LockDuration)
 	res += fmt.Sprintf("Failure Reset Time:      %d [sec]\n", f.project.UserLock.FailureResetTime)
+	alg := f.project.PasswordHashConfig.Algorithm
+	if alg == "" {
+		alg = "argon2id (default)"
+	}
+	res += fmt.Sprintf("Password Hash Algorithm: %s\n", alg)
 
 	return res, nil
 }
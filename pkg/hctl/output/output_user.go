@@ -38,6 +38,8 @@ func (f *UserInfoFormat) ToText() (string, error) {
 	res += fmt.Sprintf("Created Time: %s\n", f.user.CreatedAt)
 	res += fmt.Sprintf("System Roles: %v\n", f.user.SystemRoles)
 	res += fmt.Sprintf("Custom Roles: %v\n", f.user.CustomRoles)
+	res += fmt.Sprintf("Email:        %s\n", f.user.Profile.Email)
+	res += fmt.Sprintf("Attributes:   %v\n", f.user.Attributes)
 	return res, nil
 }
 
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -22,13 +23,33 @@ func getTokenFromHeader(tokenString string) (string, *errors.Error) {
 	return reqToken, nil
 }
 
-// ValidateAPIToken ...
-func ValidateAPIToken(req *http.Request) (*token.AccessTokenClaims, *errors.Error) {
+// GetBearerToken returns the bearer token in Authorization header
+func GetBearerToken(req *http.Request) (string, *errors.Error) {
+	auth, ok := req.Header["Authorization"]
+	if !ok || len(auth) != 1 {
+		return "", errors.New("Failed to get Authorization header", "Failed to get Authorization header")
+	}
+	return getTokenFromHeader(auth[0])
+}
+
+// getAccessToken returns the access token in Authorization header
+// it also returns true if the token is sent with DPoP authorization scheme
+func getAccessToken(req *http.Request) (string, bool, *errors.Error) {
 	auth, ok := req.Header["Authorization"]
 	if !ok || len(auth) != 1 {
-		return nil, errors.New("Failed to get Authorization header", "Failed to get Authorization header")
+		return "", false, errors.New("Failed to get Authorization header", "Failed to get Authorization header")
+	}
+	if strings.HasPrefix(auth[0], "DPoP ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth[0], "DPoP ")), true, nil
 	}
 	tokenString, err := getTokenFromHeader(auth[0])
+	return tokenString, false, err
+}
+
+// ValidateAPIToken ...
+// the token bound to the DPoP key must be sent with DPoP authorization scheme and the proof
+func ValidateAPIToken(req *http.Request) (*token.AccessTokenClaims, *errors.Error) {
+	tokenString, dpop, err := getAccessToken(req)
 	if err != nil {
 		return nil, errors.Append(err, "Failed to get token from header")
 	}
@@ -37,9 +58,55 @@ func ValidateAPIToken(req *http.Request) (*token.AccessTokenClaims, *errors.Erro
 	if err := token.ValidateAccessToken(claims, tokenString, issuer); err != nil {
 		return nil, errors.Append(err, "Failed to validate token")
 	}
+	if err := validateCertBinding(req, claims); err != nil {
+		return nil, errors.Append(err, "Failed to validate certificate-bound token")
+	}
+	if err := validateDPoPBinding(req, tokenString, dpop, claims); err != nil {
+		return nil, errors.Append(err, "Failed to validate DPoP-bound token")
+	}
 	return claims, nil
 }
 
+// validateCertBinding checks the client certificate of the token bound by RFC 8705
+func validateCertBinding(req *http.Request, claims *token.AccessTokenClaims) *errors.Error {
+	if claims.Confirmation == nil || claims.Confirmation.X5TS256 == "" {
+		return nil
+	}
+	certs := token.GetClientCertificates(req)
+	if len(certs) == 0 {
+		return errors.New("Invalid request", "Client certificate is required for the certificate-bound token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token.CertThumbprint(certs[0])), []byte(claims.Confirmation.X5TS256)) != 1 {
+		return errors.New("Invalid request", "Client certificate does not match the token")
+	}
+	return nil
+}
+
+// validateDPoPBinding checks the DPoP proof of the token bound by RFC 9449
+func validateDPoPBinding(req *http.Request, tokenString string, dpop bool, claims *token.AccessTokenClaims) *errors.Error {
+	if claims.Confirmation == nil || claims.Confirmation.JKT == "" {
+		if dpop {
+			return errors.New("Invalid request", "Token which is not bound to DPoP key is sent with DPoP scheme")
+		}
+		return nil
+	}
+	if !dpop {
+		return errors.New("Invalid request", "DPoP-bound token must be sent with DPoP scheme")
+	}
+
+	jkt, err := token.VerifyDPoPProof(claims.Project, req, tokenString)
+	if err != nil {
+		return errors.Append(err, "Failed to verify DPoP proof")
+	}
+	if jkt == "" {
+		return errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof is required")
+	}
+	if jkt != claims.Confirmation.JKT {
+		return errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof key does not match the token")
+	}
+	return nil
+}
+
 // Authorize ...
 func Authorize(req *http.Request, projectName string, reqTrgRes role.Resource, reqRoleType role.Type) *errors.Error {
 	claims, err := ValidateAPIToken(req)
@@ -1,7 +1,13 @@
 package http
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
 	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 )
 
 func TestGetTokenFromHeader(t *testing.T) {
@@ -38,3 +44,42 @@ func TestGetTokenFromHeader(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateCertBinding(t *testing.T) {
+	config.Get().HTTPSConfig.ClientCertRequest = true
+	defer func() {
+		config.Get().HTTPSConfig.ClientCertRequest = false
+	}()
+
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	other := &x509.Certificate{Raw: []byte("other certificate")}
+	bound := &token.AccessTokenClaims{
+		Confirmation: &token.Confirmation{X5TS256: token.CertThumbprint(cert)},
+	}
+
+	tt := []struct {
+		Name          string
+		Claims        *token.AccessTokenClaims
+		Certs         []*x509.Certificate
+		ExpectSuccess bool
+	}{
+		{"bearer token", &token.AccessTokenClaims{}, nil, true},
+		{"bound token with the certificate", bound, []*x509.Certificate{cert}, true},
+		{"bound token without certificate", bound, nil, false},
+		{"bound token with other certificate", bound, []*x509.Certificate{other}, false},
+	}
+
+	for _, tc := range tt {
+		req, _ := http.NewRequest("GET", "https://localhost/userapi", nil)
+		if tc.Certs != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: tc.Certs}
+		}
+		err := validateCertBinding(req, tc.Claims)
+		if tc.ExpectSuccess && err != nil {
+			t.Errorf("Test %s: Failed to validate certificate binding: %v", tc.Name, err)
+		}
+		if !tc.ExpectSuccess && err == nil {
+			t.Errorf("Test %s: Expect error, but got nil", tc.Name)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package login
 import (
 	"html/template"
 	"net/http"
+	"net/url"
 
 	"github.com/sh-miyoshi/hekate/pkg/config"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 )
 
 // WriteUserLoginPage ...
@@ -135,3 +137,46 @@ func WriteDeviceLoginCompletePage(w http.ResponseWriter) {
 	w.Header().Add("Content-Type", "text/html; charset=UTF-8")
 	tpl.Execute(w, d)
 }
+
+// WriteLogoutPage ...
+// the page contains the one-time token bound to userID which is logged in the browser,
+// and the answer of the page is accepted only with the token
+func WriteLogoutPage(projectName, issuer, userID string, params url.Values, w http.ResponseWriter) {
+	cfg := config.Get()
+
+	tpl, err := template.ParseFiles(cfg.LoginResource.LogoutPage)
+	if err != nil {
+		logger.Error("Failed to parse template: %v", err)
+		e := errors.ErrServerError
+		e.SetDescription("User Logout Page maybe broken")
+		errors.WriteToHTTP(w, e, 0, "")
+		return
+	}
+
+	// the logout request parameters are kept in the url to confirm
+	u := "/authapi/v1/project/" + projectName + "/openid-connect/logout"
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	confirmToken, e := token.GenerateLogoutConfirmToken(token.Request{
+		Issuer:      issuer,
+		ExpiresIn:   int64(cfg.LoginSessionExpiresIn),
+		ProjectName: projectName,
+		UserID:      userID,
+	})
+	if e != nil {
+		errors.Print(errors.Append(e, "Failed to generate logout confirm token"))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, "")
+		return
+	}
+
+	d := map[string]string{
+		"StaticResourcePath": cfg.LoginStaticResourceURL + "/static",
+		"URL":                u,
+		"ConfirmToken":       confirmToken,
+	}
+
+	w.Header().Add("Content-Type", "text/html; charset=UTF-8")
+	tpl.Execute(w, d)
+}
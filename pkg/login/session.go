@@ -21,6 +21,7 @@ func StartLoginSession(projectName string, req *oidc.AuthRequest) (string, *erro
 		ClientID:            req.ClientID,
 		RedirectURI:         req.RedirectURI,
 		Nonce:               req.Nonce,
+		Scope:               req.Scope,
 		ProjectName:         projectName,
 		ResponseMode:        req.ResponseMode,
 		ResponseType:        req.ResponseType,
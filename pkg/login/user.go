@@ -7,7 +7,7 @@ import (
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
-	"github.com/sh-miyoshi/hekate/pkg/util"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
 )
 
 var (
@@ -79,8 +79,16 @@ func UserVerifyByPassword(projectName string, name string, password string) (*mo
 		return nil, ErrUserLocked
 	}
 
-	hash := util.CreateHash(password)
-	if user.PasswordHash != hash {
+	ok, err := secret.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		if errors.Contains(err, secret.ErrInvalidPasswordHash) {
+			// the broken hash such as imported one is not a server error, so treat as the login failure
+			errors.PrintAsInfo(errors.Append(err, "Failed to verify password of user %s", user.ID))
+			return nil, ErrAuthFailed
+		}
+		return nil, errors.Append(err, "Failed to verify password")
+	}
+	if !ok {
 		// update lock state
 		inclementFailedNum(&user.LockState, prj.UserLock)
 		logger.Debug("user lock state: %v", user.LockState)
@@ -90,10 +98,25 @@ func UserVerifyByPassword(projectName string, name string, password string) (*mo
 		return nil, ErrAuthFailed
 	}
 
+	updated := false
+
+	// upgrade legacy or outdated hash while the raw password is available
+	if secret.PasswordNeedsRehash(user.PasswordHash, prj.PasswordHashConfig) {
+		logger.Debug("password hash of user %s is outdated, so rehash it", user.ID)
+		if user.PasswordHash, err = secret.HashPassword(password, prj.PasswordHashConfig); err != nil {
+			return nil, errors.Append(err, "Failed to rehash password")
+		}
+		updated = true
+	}
+
 	// clear lock state
 	if prj.UserLock.Enabled {
 		logger.Debug("successfully user verify, so clear lock state")
 		user.LockState = model.LockState{}
+		updated = true
+	}
+
+	if updated {
 		if err := db.GetInst().UserUpdate(projectName, user); err != nil {
 			return nil, err
 		}
@@ -1,11 +1,13 @@
 package login
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/sh-miyoshi/hekate/pkg/db"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/util"
 )
 
@@ -24,11 +26,10 @@ func TestVerify(t *testing.T) {
 		},
 	})
 	db.GetInst().UserAdd("master", &model.UserInfo{
-		ID:           uuid.New().String(),
-		ProjectName:  "master",
-		Name:         userName,
-		PasswordHash: util.CreateHash(password),
-	})
+		ID:          uuid.New().String(),
+		ProjectName: "master",
+		Name:        userName,
+	}, password)
 
 	tt := []struct {
 		projectName string
@@ -72,3 +73,75 @@ func TestVerify(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyRehashLegacyPassword(t *testing.T) {
+	const userName = "legacy-user"
+	const password = "test-password"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name: "legacy",
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+		PasswordHashConfig: model.PasswordHashConfig{
+			Algorithm:  model.PasswordHashBcrypt,
+			BcryptCost: 4,
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: "legacy",
+		Name:        userName,
+	}
+	db.GetInst().UserAdd("legacy", user, password)
+
+	// overwrite by the hash created by old version
+	user.PasswordHash = util.CreateHash(password)
+	db.GetInst().UserUpdate("legacy", user)
+
+	if _, err := UserVerifyByPassword("legacy", userName, password); err != nil {
+		t.Fatalf("Verify by legacy hash returns wrong response. got %v, want nil", err)
+	}
+
+	res, _ := db.GetInst().UserGet("legacy", user.ID)
+	if !strings.HasPrefix(res.PasswordHash, "$2a$04$") {
+		t.Errorf("Legacy hash was not upgraded. got %s", res.PasswordHash)
+	}
+	if _, err := UserVerifyByPassword("legacy", userName, password); err != nil {
+		t.Errorf("Verify by upgraded hash returns wrong response. got %v, want nil", err)
+	}
+}
+
+func TestVerifyInvalidPasswordHash(t *testing.T) {
+	const userName = "invalid-hash-user"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name: "invalidhash",
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: "invalidhash",
+		Name:        userName,
+	}
+	db.GetInst().UserAdd("invalidhash", user, "test-password")
+
+	// overwrite by the hash with the parameter which makes argon2 panic
+	user.PasswordHash = "$argon2id$v=19$m=1024,t=0,p=1$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhcw"
+	db.GetInst().UserUpdate("invalidhash", user)
+
+	_, err := UserVerifyByPassword("invalidhash", userName, "test-password")
+	if !errors.Contains(err, ErrAuthFailed) {
+		t.Errorf("Verify by invalid hash returns wrong response. got %v, want %v", err, ErrAuthFailed)
+	}
+}
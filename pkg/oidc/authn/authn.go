@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
 	"github.com/sh-miyoshi/hekate/pkg/db"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
@@ -19,11 +20,15 @@ import (
 )
 
 type option struct {
+	clientID        string
+	scope           string
+	familyID        string
 	audiences       []string
 	genRefreshToken bool
 	genIDToken      bool
 	nonce           string
 	endUserAuthTime time.Time
+	actor           *token.Actor
 }
 
 // ReqAuthByPassword ...
@@ -43,6 +48,8 @@ func ReqAuthByPassword(project *model.ProjectInfo, userName string, password str
 	}
 
 	return genTokenRes(usr.ID, project, r, option{
+		clientID:        clientID,
+		scope:           r.Form.Get("scope"),
 		audiences:       audiences,
 		genRefreshToken: true,
 		endUserAuthTime: time.Unix(0, 0),
@@ -101,6 +108,8 @@ func ReqAuthByCode(project *model.ProjectInfo, clientID string, code string, cod
 	}
 
 	return genTokenRes(s.UserID, project, r, option{
+		clientID:        s.ClientID,
+		scope:           s.Scope,
 		audiences:       audiences,
 		genRefreshToken: true,
 		genIDToken:      true,
@@ -129,17 +138,47 @@ func ReqAuthByRefreshToken(project *model.ProjectInfo, clientID string, refreshT
 		return nil, errors.Append(errors.ErrInvalidClient, "refresh token is not for the client")
 	}
 
-	s, err := db.GetInst().SessionGet(project.Name, claims.SessionID)
-	if err != nil {
-		return nil, errors.Append(err, "Failed to get previous token")
+	if claims.Confirmation != nil && claims.Confirmation.JKT != "" && claims.Confirmation.JKT != token.GetDPoPKey(r) {
+		return nil, errors.Append(errors.ErrInvalidDPoPProof, "refresh token is bound to the other DPoP key")
+	}
+
+	// the family id is carried through the refresh to keep the sid of the session
+	familyID := claims.FamilyID
+	if familyID == "" {
+		// the token was issued before the family id was introduced
+		familyID = claims.SessionID
+	}
+
+	var rotated *model.RevokedToken
+	if project.TokenConfig.RefreshTokenRotation {
+		// Remember the rotated token until it expires to detect the reuse
+		rotated = &model.RevokedToken{
+			TokenID:     claims.Id,
+			ProjectName: project.Name,
+			ExpiresAt:   time.Unix(claims.ExpiresAt, 0),
+		}
 	}
 
-	// Delete previous token
-	if err := db.GetInst().SessionDelete(project.Name, claims.SessionID); err != nil {
+	// the reuse check and the revocation of the previous token must be atomic
+	// because the concurrent requests with the same token must not succeed both
+	s, err := db.GetInst().SessionRefresh(project.Name, claims.SessionID, familyID, rotated)
+	if err != nil {
+		if errors.Contains(err, model.ErrRefreshTokenReused) {
+			// The rotated refresh token is presented again, so it may be stolen.
+			// All tokens in the family are already revoked to protect the user.
+			msg := fmt.Sprintf("Refresh token reuse detected, so revoked token family %s", familyID)
+			if err := audit.GetInst().Save(project.Name, time.Now(), "TOKEN", r.Method, r.URL.String(), msg); err != nil {
+				errors.Print(errors.Append(err, "Failed to save audit event"))
+			}
+			return nil, errors.Append(errors.ErrInvalidGrant, msg)
+		}
 		return nil, errors.Append(err, "Failed to revoke previous token")
 	}
 
 	return genTokenRes(claims.Subject, project, r, option{
+		clientID:        clientID,
+		scope:           claims.Scope,
+		familyID:        familyID,
 		audiences:       claims.Audience,
 		genRefreshToken: true,
 		endUserAuthTime: s.LastAuthTime,
@@ -160,6 +199,8 @@ func ReqAuthByClientCredentials(project *model.ProjectInfo, clientID string, r *
 		clientID,
 	}
 	return genTokenRes("", project, r, option{
+		clientID:  clientID,
+		scope:     r.Form.Get("scope"),
 		audiences: audiences,
 	})
 }
@@ -201,6 +242,8 @@ func ReqAuthByDeviceCode(project *model.ProjectInfo, clientID string, deviceCode
 		clientID,
 	}
 	return genTokenRes(s.UserID, project, r, option{
+		clientID:        clientID,
+		scope:           s.Scope,
 		audiences:       audiences,
 		genRefreshToken: true,
 		endUserAuthTime: s.LoginDate,
@@ -214,11 +257,34 @@ func genTokenRes(userID string, project *model.ProjectInfo, r *http.Request, opt
 		ExpiresIn: project.TokenConfig.AccessTokenLifeSpan,
 	}
 
+	sessionID := ""
+	familyID := opt.familyID
+	if opt.genRefreshToken {
+		sessionID = uuid.New().String()
+		if familyID == "" {
+			familyID = sessionID
+		}
+	}
+
 	accessTokenReq := token.Request{
 		Issuer:      token.GetFullIssuer(r),
 		ExpiresIn:   int64(project.TokenConfig.AccessTokenLifeSpan),
 		ProjectName: project.Name,
 		UserID:      userID,
+		ClientID:    opt.clientID,
+		SessionID:   sessionID,
+		Scope:       opt.scope,
+		Actor:       opt.actor,
+	}
+	// bind the access token to the client certificate in the mutual-TLS connection
+	if certs := token.GetClientCertificates(r); len(certs) > 0 {
+		accessTokenReq.CertThumbprint = token.CertThumbprint(certs[0])
+	}
+	// bind the access token to the key of the DPoP proof
+	jkt := token.GetDPoPKey(r)
+	if jkt != "" {
+		res.TokenType = "DPoP"
+		accessTokenReq.DPoPKeyThumbprint = jkt
 	}
 
 	audiences := []string{
@@ -241,9 +307,22 @@ func genTokenRes(userID string, project *model.ProjectInfo, r *http.Request, opt
 			ExpiresIn:   int64(res.RefreshExpiresIn),
 			ProjectName: project.Name,
 			UserID:      userID,
+			ClientID:    opt.clientID,
+			FamilyID:    familyID,
+			Scope:       opt.scope,
+		}
+		if jkt != "" && opt.clientID != "" {
+			// the public client can not be authenticated in the refresh request,
+			// so bind the refresh token to the key of the DPoP proof instead
+			cli, err := db.GetInst().ClientGet(project.Name, opt.clientID)
+			if err != nil {
+				return nil, errors.Append(err, "Failed to get client")
+			}
+			if cli.AccessType == "public" {
+				refreshTokenReq.DPoPKeyThumbprint = jkt
+			}
 		}
 
-		sessionID := uuid.New().String()
 		res.RefreshToken, err = token.GenerateRefreshToken(sessionID, audiences, refreshTokenReq)
 		if err != nil {
 			return nil, errors.Append(err, "Failed to generate refresh token")
@@ -261,6 +340,8 @@ func genTokenRes(userID string, project *model.ProjectInfo, r *http.Request, opt
 			ExpiresIn:    int64(res.RefreshExpiresIn),
 			FromIP:       ip,
 			LastAuthTime: opt.endUserAuthTime,
+			FamilyID:     familyID,
+			ClientID:     opt.clientID,
 		}
 
 		if err := db.GetInst().SessionAdd(project.Name, ent); err != nil {
@@ -275,8 +356,12 @@ func genTokenRes(userID string, project *model.ProjectInfo, r *http.Request, opt
 			ExpiresIn:       int64(project.TokenConfig.AccessTokenLifeSpan),
 			ProjectName:     project.Name,
 			UserID:          userID,
+			ClientID:        opt.clientID,
 			Nonce:           opt.nonce,
 			EndUserAuthTime: opt.endUserAuthTime,
+			Scope:           opt.scope,
+			// the family id is not changed by the refresh, so it is used as sid to identify the session
+			SessionID: familyID,
 		}
 		res.IDToken, err = token.GenerateIDToken(audiences, idTokenReq)
 		if err != nil {
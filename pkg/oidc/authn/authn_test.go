@@ -0,0 +1,165 @@
+package authn
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/audit"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// setupRefreshTest initializes the memory DB with the project, the public client and the user
+func setupRefreshTest(t *testing.T, projectName, clientID string, rotation bool) (*model.ProjectInfo, *model.UserInfo) {
+	t.Helper()
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	audit.Init("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			RefreshTokenRotation: rotation,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	if err := db.GetInst().ClientAdd(projectName, &model.ClientInfo{ID: clientID, ProjectName: projectName, AccessType: "public"}); err != nil {
+		t.Fatalf("Failed to add client: %v", err)
+	}
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	project, err := db.GetInst().ProjectGet(projectName)
+	if err != nil {
+		t.Fatalf("Failed to get project: %v", err)
+	}
+	return project, user
+}
+
+func TestRefreshTokenReuseDetection(t *testing.T) {
+	const projectName = "rotation"
+	const clientID = "rotation-app"
+
+	project, user := setupRefreshTest(t, projectName, clientID, true)
+
+	r := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/rotation/openid-connect/token", nil)
+	opt := option{
+		clientID:        clientID,
+		audiences:       []string{user.ID, clientID},
+		genRefreshToken: true,
+	}
+	first, err := genTokenRes(user.ID, project, r, opt)
+	if err != nil {
+		t.Fatalf("Failed to generate first token: %v", err)
+	}
+	// the other login of the same user is not revoked by the reuse
+	other, err := genTokenRes(user.ID, project, r, opt)
+	if err != nil {
+		t.Fatalf("Failed to generate other token: %v", err)
+	}
+
+	rotated, err := ReqAuthByRefreshToken(project, clientID, first.RefreshToken, r)
+	if err != nil {
+		t.Fatalf("Failed to rotate refresh token: %v", err)
+	}
+
+	// reuse of the rotated token revokes the whole family
+	_, err = ReqAuthByRefreshToken(project, clientID, first.RefreshToken, r)
+	if err == nil || err.Error() != errors.ErrInvalidGrant.Error() {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidGrant, err)
+	}
+	if _, err := ReqAuthByRefreshToken(project, clientID, rotated.RefreshToken, r); err == nil {
+		t.Errorf("Refresh token in the revoked family should be rejected")
+	}
+
+	sessions, _ := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
+	if len(sessions) != 1 {
+		t.Fatalf("Only the session of the other family should remain, but got %d sessions", len(sessions))
+	}
+	if _, err := ReqAuthByRefreshToken(project, clientID, other.RefreshToken, r); err != nil {
+		t.Errorf("Refresh token of the other family should be accepted, but got %v", err)
+	}
+}
+
+func TestRefreshKeepsFamily(t *testing.T) {
+	const projectName = "family"
+	const clientID = "family-app"
+
+	project, user := setupRefreshTest(t, projectName, clientID, false)
+
+	r := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/family/openid-connect/token", nil)
+	first, err := genTokenRes(user.ID, project, r, option{
+		clientID:        clientID,
+		audiences:       []string{user.ID, clientID},
+		genRefreshToken: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate first token: %v", err)
+	}
+	sessions, _ := db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
+	if len(sessions) != 1 {
+		t.Fatalf("One session expects to be created, but got %d sessions", len(sessions))
+	}
+	familyID := sessions[0].FamilyID
+
+	// the refresh without rotation also keeps the family id which is used as sid
+	for i := 0; i < 2; i++ {
+		first, err = ReqAuthByRefreshToken(project, clientID, first.RefreshToken, r)
+		if err != nil {
+			t.Fatalf("Failed to refresh token: %v", err)
+		}
+	}
+	sessions, _ = db.GetInst().SessionGetList(projectName, &model.SessionFilter{UserID: user.ID})
+	if len(sessions) != 1 || sessions[0].FamilyID != familyID || sessions[0].SessionID == familyID {
+		t.Errorf("Refreshed session expects to be in family %s, but got %v", familyID, sessions)
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	const projectName = "concurrent"
+	const clientID = "concurrent-app"
+	project, user := setupRefreshTest(t, projectName, clientID, true)
+
+	r := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/concurrent/openid-connect/token", nil)
+	first, err := genTokenRes(user.ID, project, r, option{
+		clientID:        clientID,
+		audiences:       []string{user.ID, clientID},
+		genRefreshToken: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate first token: %v", err)
+	}
+
+	// only one of the concurrent refresh requests with the same token can succeed
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ReqAuthByRefreshToken(project, clientID, first.RefreshToken, r); err == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if success != 1 {
+		t.Errorf("Only one refresh expects to succeed, but %d succeeded", success)
+	}
+}
@@ -0,0 +1,55 @@
+package authn
+
+import (
+	"net/http"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+// ReqAuthByJWTBearer issues the access token in exchange for the assertion of the trusted issuer defined in RFC 7523
+// the subject of the assertion is mapped to the user or the client by the project setting
+func ReqAuthByJWTBearer(project *model.ProjectInfo, clientID string, r *http.Request) (*oidc.TokenResponse, *errors.Error) {
+	assertion := r.Form.Get("assertion")
+	if assertion == "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Assertion is required")
+	}
+
+	mapping, err := oidc.VerifyJWTBearerAssertion(project, assertion, token.GetFullIssuer(r))
+	if err != nil {
+		return nil, errors.Append(err, "Failed to verify assertion")
+	}
+
+	if mapping.ClientID != "" {
+		// the workload can get the token only as the client mapped to it
+		if mapping.ClientID != clientID {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Assertion is mapped to client %s, but requested by %s", mapping.ClientID, clientID)
+		}
+		return genTokenRes("", project, r, option{
+			clientID:  clientID,
+			scope:     r.Form.Get("scope"),
+			audiences: []string{clientID},
+		})
+	}
+
+	users, err := db.GetInst().UserGetList(project.Name, &model.UserFilter{Name: mapping.UserName})
+	if err != nil {
+		return nil, errors.Append(err, "Failed to get user")
+	}
+	if len(users) == 0 {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Mapped user %s is not found", mapping.UserName)
+	}
+	usr := users[0]
+	if usr.LockState.Locked {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Mapped user %s is locked", mapping.UserName)
+	}
+
+	return genTokenRes(usr.ID, project, r, option{
+		clientID:  clientID,
+		scope:     r.Form.Get("scope"),
+		audiences: []string{usr.ID, clientID},
+	})
+}
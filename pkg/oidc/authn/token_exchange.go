@@ -0,0 +1,155 @@
+package authn
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/oidc"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+	"github.com/stretchr/stew/slice"
+)
+
+const (
+	// TokenTypeAccessToken is a token type identifier of the access token defined in RFC 8693 section 3
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	// TokenTypeJWT is a token type identifier of the JWT, the access token of hekate is also accepted as it
+	TokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// ReqAuthByTokenExchange issues the access token in exchange for the subject token defined in RFC 8693
+// if the actor token is specified, the issued token represents the delegation to the actor,
+// otherwise the client impersonates the subject
+func ReqAuthByTokenExchange(project *model.ProjectInfo, clientID string, r *http.Request) (*oidc.TokenResponse, *errors.Error) {
+	cli, err := db.GetInst().ClientGet(project.Name, clientID)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to get client")
+	}
+	if len(cli.TokenExchangeAudiences) == 0 {
+		return nil, errors.Append(errors.ErrUnauthorizedClient, "Client %s is not allowed to exchange token", clientID)
+	}
+
+	if t := r.Form.Get("requested_token_type"); t != "" && t != TokenTypeAccessToken {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Requested token type %s is not supported", t)
+	}
+
+	subject, err := validateExchangeToken(project.Name, r.Form.Get("subject_token"), r.Form.Get("subject_token_type"), r)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to validate subject token")
+	}
+	if subject.Subject == "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Subject token does not represent the user")
+	}
+	if !slice.Contains(subject.Audience, clientID) {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Subject token is not issued to the client %s", clientID)
+	}
+
+	// prior actors in the subject token are kept to express the delegation chain
+	actor := subject.Actor
+	if r.Form.Get("actor_token") != "" || r.Form.Get("actor_token_type") != "" {
+		act, err := validateExchangeToken(project.Name, r.Form.Get("actor_token"), r.Form.Get("actor_token_type"), r)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to validate actor token")
+		}
+		actor = &token.Actor{
+			Subject:  act.Subject,
+			ClientID: act.ClientID,
+			Actor:    subject.Actor,
+		}
+	} else if !cli.TokenExchangeImpersonation {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Client %s is not allowed to impersonate the user", clientID)
+	}
+
+	targets := append([]string{}, r.Form["audience"]...)
+	targets = append(targets, r.Form["resource"]...)
+	audiences, err := exchangeAudiences(cli, targets)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := exchangeScope(subject.Scope, r.Form.Get("scope"))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := genTokenRes(subject.Subject, project, r, option{
+		clientID:  clientID,
+		scope:     scope,
+		audiences: audiences,
+		actor:     actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.IssuedTokenType = TokenTypeAccessToken
+	return res, nil
+}
+
+// validateExchangeToken validates the subject token or the actor token in the token exchange request
+// only the access token issued by the project without cnf claim can be exchanged
+func validateExchangeToken(projectName, tokenString, tokenType string, r *http.Request) (*token.AccessTokenClaims, *errors.Error) {
+	if tokenString == "" || tokenType == "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Token and token type are required")
+	}
+	if tokenType != TokenTypeAccessToken && tokenType != TokenTypeJWT {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Token type %s is not supported", tokenType)
+	}
+
+	claims := &token.AccessTokenClaims{}
+	if err := token.ValidateAccessToken(claims, tokenString, token.GetExpectIssuer(r)); err != nil {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Invalid token: %v", err)
+	}
+	if claims.Project != projectName {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Token is issued in project %s", claims.Project)
+	}
+	if claims.Confirmation != nil {
+		// the sender-constrained token can not be exchanged because the proof of the bound key is not verified here
+		return nil, errors.Append(errors.ErrInvalidRequest, "Token bound to the key can not be exchanged")
+	}
+	if claims.SessionID != "" {
+		// the token of the logged out session can not be exchanged
+		if _, err := db.GetInst().SessionGet(projectName, claims.SessionID); err != nil {
+			if errors.Contains(err, model.ErrNoSuchSession) || errors.Contains(err, model.ErrSessionValidateFailed) {
+				return nil, errors.Append(errors.ErrInvalidRequest, "Session %s is already revoked", claims.SessionID)
+			}
+			return nil, errors.Append(err, "Failed to get session")
+		}
+	}
+	return claims, nil
+}
+
+// exchangeAudiences returns the audiences of the exchanged token
+// the audiences are narrowed to the targets which are allowed by the client policy
+func exchangeAudiences(cli *model.ClientInfo, targets []string) ([]string, *errors.Error) {
+	if len(targets) == 0 {
+		return []string{cli.ID}, nil
+	}
+
+	res := []string{}
+	for _, t := range targets {
+		if !slice.Contains(cli.TokenExchangeAudiences, t) {
+			return nil, errors.Append(errors.ErrInvalidTarget, "Client %s is not allowed to exchange token for %s", cli.ID, t)
+		}
+		if !slice.Contains(res, t) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+// exchangeScope returns the scope of the exchanged token
+// the requested scope must be a subset of the subject token scope
+func exchangeScope(subjectScope, requestScope string) (string, *errors.Error) {
+	if requestScope == "" {
+		return subjectScope, nil
+	}
+
+	allowed := strings.Fields(subjectScope)
+	for _, s := range strings.Fields(requestScope) {
+		if !slice.Contains(allowed, s) {
+			return "", errors.Append(errors.ErrInvalidScope, "Scope %s is not granted in the subject token", s)
+		}
+	}
+	return requestScope, nil
+}
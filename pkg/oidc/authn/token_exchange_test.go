@@ -0,0 +1,133 @@
+package authn
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+func TestExchangeAudiences(t *testing.T) {
+	cli := &model.ClientInfo{
+		ID:                     "gateway",
+		TokenExchangeAudiences: []string{"backend", "https://api.example.com"},
+	}
+
+	tt := []struct {
+		Name      string
+		Targets   []string
+		Expect    []string
+		ExpectErr bool
+	}{
+		{"no target", nil, []string{"gateway"}, false},
+		{"allowed audience", []string{"backend"}, []string{"backend"}, false},
+		{"allowed resource", []string{"https://api.example.com"}, []string{"https://api.example.com"}, false},
+		{"duplicated targets", []string{"backend", "backend"}, []string{"backend"}, false},
+		{"not allowed audience", []string{"backend", "admin"}, nil, true},
+	}
+
+	for _, tc := range tt {
+		res, err := exchangeAudiences(cli, tc.Targets)
+		if tc.ExpectErr {
+			if err == nil || err.Error() != errors.ErrInvalidTarget.Error() {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidTarget, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to get audiences: %v", tc.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tc.Expect) {
+			t.Errorf("Test %s: Expect audiences %v, but got %v", tc.Name, tc.Expect, res)
+		}
+	}
+}
+
+func TestExchangeScope(t *testing.T) {
+	tt := []struct {
+		Name         string
+		SubjectScope string
+		RequestScope string
+		Expect       string
+		ExpectErr    bool
+	}{
+		{"no request scope", "openid profile", "", "openid profile", false},
+		{"narrowed scope", "openid profile email", "profile", "profile", false},
+		{"same scope", "openid profile", "openid profile", "openid profile", false},
+		{"not granted scope", "openid", "openid email", "", true},
+	}
+
+	for _, tc := range tt {
+		res, err := exchangeScope(tc.SubjectScope, tc.RequestScope)
+		if tc.ExpectErr {
+			if err == nil || err.Error() != errors.ErrInvalidScope.Error() {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidScope, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to get scope: %v", tc.Name, err)
+			continue
+		}
+		if res != tc.Expect {
+			t.Errorf("Test %s: Expect scope %s, but got %s", tc.Name, tc.Expect, res)
+		}
+	}
+}
+
+func TestValidateExchangeToken(t *testing.T) {
+	const projectName = "exchange"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	r := httptest.NewRequest("POST", "http://localhost/authapi/v1/project/exchange/openid-connect/token", nil)
+	req := token.Request{
+		Issuer:      token.GetFullIssuer(r),
+		ExpiresIn:   model.DefaultAccessTokenExpiresInSec,
+		ProjectName: projectName,
+		UserID:      user.ID,
+		ClientID:    "gateway",
+	}
+	tkn, err := token.GenerateAccessToken([]string{"gateway"}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	req.DPoPKeyThumbprint = "thumbprint"
+	boundTkn, err := token.GenerateAccessToken([]string{"gateway"}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate bound access token: %v", err)
+	}
+
+	if _, err := validateExchangeToken(projectName, tkn, TokenTypeAccessToken, r); err != nil {
+		t.Errorf("Failed to validate token: %v", err)
+	}
+	if _, err := validateExchangeToken(projectName, boundTkn, TokenTypeAccessToken, r); err == nil {
+		t.Errorf("Token with cnf claim expects error, but got nil")
+	}
+}
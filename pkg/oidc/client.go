@@ -1,15 +1,25 @@
 package oidc
 
 import (
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+
 	"github.com/sh-miyoshi/hekate/pkg/db"
 	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
 	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 var (
 	// ErrNoRedirectURL ...
 	ErrNoRedirectURL = errors.New("No such redirect url", "No such redirect url")
+
+	// ErrNoClientKeys ...
+	ErrNoClientKeys = errors.New("No client keys", "No client keys")
 )
 
 // CheckRedirectURL ...
@@ -27,9 +37,62 @@ func CheckRedirectURL(projectName, clientID, redirectURL string) *errors.Error {
 	return nil
 }
 
-// ClientAuth authenticates client with id and secret
-func ClientAuth(projectName string, clientID string, clientSecret string) *errors.Error {
-	client, err := db.GetInst().ClientGet(projectName, clientID)
+// ClientCredentials is a set of the client authentication parameters in the request
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+	// Method is the client authentication method used in the request
+	// it is empty if the request has the client assertion, and it is decided by the signing algorithm
+	Method    string
+	Assertion string
+	// Certificates is a certificate chain sent in the mutual-TLS connection
+	Certificates []*x509.Certificate
+}
+
+// GetClientCredentials returns the client authentication parameters in the request
+// r.ParseForm must be called before this function
+func GetClientCredentials(r *http.Request) (*ClientCredentials, *errors.Error) {
+	res := &ClientCredentials{
+		ClientID:     r.Form.Get("client_id"),
+		Certificates: token.GetClientCertificates(r),
+	}
+
+	if typ := r.Form.Get("client_assertion_type"); typ != "" {
+		if typ != ClientAssertionTypeJWT {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Client assertion type %s is not supported", typ)
+		}
+		res.Assertion = r.Form.Get("client_assertion")
+		sub, err := getClientAssertionSubject(res.Assertion)
+		if err != nil {
+			return nil, errors.Append(errors.ErrInvalidClient, "Failed to parse client assertion: %v", err)
+		}
+		if res.ClientID == "" {
+			// client_id is optional in the client assertion
+			res.ClientID = sub
+		} else if res.ClientID != sub {
+			return nil, errors.Append(errors.ErrInvalidClient, "Subject of client assertion %s does not match client_id", sub)
+		}
+	} else if secret := r.Form.Get("client_secret"); secret != "" {
+		res.ClientSecret = secret
+		res.Method = "client_secret_post"
+	} else if i, s, ok := r.BasicAuth(); ok && (res.ClientID == "" || res.ClientID == i) {
+		res.ClientID = i
+		res.ClientSecret = s
+		res.Method = "client_secret_basic"
+	} else {
+		res.Method = "none"
+	}
+
+	if res.ClientID == "" {
+		return nil, errors.Append(errors.ErrInvalidClient, "Failed to get client ID from request")
+	}
+	return res, nil
+}
+
+// ClientAuth authenticates the client with the credentials
+// issuer is used to validate the audience of the client assertion
+func ClientAuth(projectName string, issuer string, cred *ClientCredentials) *errors.Error {
+	client, err := db.GetInst().ClientGet(projectName, cred.ClientID)
 	if err != nil {
 		if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
 			return errors.Append(errors.ErrInvalidClient, err.Error())
@@ -37,11 +100,71 @@ func ClientAuth(projectName string, clientID string, clientSecret string) *error
 		return errors.Append(err, "Failed to get client")
 	}
 
-	if client.AccessType != "public" {
-		if client.Secret != clientSecret {
+	if client.AccessType == "public" {
+		// public client does not have credentials, so the secret is not checked
+		if cred.Assertion != "" {
+			return errors.Append(errors.ErrInvalidClient, "Public client %s can not use client assertion", client.ID)
+		}
+		return nil
+	}
+
+	method := cred.Method
+	if cred.Assertion != "" {
+		method, err = verifyClientAssertion(projectName, client, cred.Assertion, issuer)
+		if err != nil {
+			return errors.Append(err, "Failed to verify client assertion")
+		}
+	} else if cred.Method == "none" && len(cred.Certificates) > 0 {
+		// the client authenticated by the certificate sends only client_id
+		method, err = verifyClientCertificate(client, cred.Certificates)
+		if err != nil {
+			return errors.Append(err, "Failed to verify client certificate")
+		}
+	}
+
+	allowed := []string{"client_secret_basic", "client_secret_post"}
+	if client.TokenEndpointAuthMethod != "" {
+		allowed = []string{client.TokenEndpointAuthMethod}
+	}
+	if !slice.Contains(allowed, method) {
+		return errors.Append(errors.ErrInvalidClient, "Client %s can not use auth method %s", client.ID, method)
+	}
+
+	if method == "client_secret_basic" || method == "client_secret_post" {
+		if subtle.ConstantTimeCompare([]byte(client.Secret), []byte(cred.ClientSecret)) != 1 {
 			return errors.Append(errors.ErrInvalidClient, "client auth failed")
 		}
 	}
 
 	return nil
 }
+
+// GetClientJWKSet returns the public keys registered by the client
+// the keys are fetched from jwks_uri if the client does not register jwks
+func GetClientJWKSet(client *model.ClientInfo) (*jose.JSONWebKeySet, *errors.Error) {
+	data := []byte(client.JWKS)
+	if client.JWKS == "" {
+		if client.JWKSURI == "" {
+			return nil, ErrNoClientKeys
+		}
+
+		if res := jwksCache.get(client); res != nil {
+			return res, nil
+		}
+
+		var err *errors.Error
+		data, err = fetchClientResource(client.JWKSURI)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get JWK Set from %s", client.JWKSURI)
+		}
+	}
+
+	res := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, errors.New("Invalid JWK Set", "Failed to parse JWK Set of client %s: %v", client.ID, err)
+	}
+	if client.JWKS == "" {
+		jwksCache.set(client, res)
+	}
+	return res, nil
+}
@@ -0,0 +1,136 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// ClientAssertionTypeJWT is a client assertion type of the JWT defined in RFC 7523
+	ClientAssertionTypeJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	// ClientAssertionSigningAlgs are the algorithms to sign the client assertion
+	// HS256 is used in client_secret_jwt, and the others are used in private_key_jwt
+	ClientAssertionSigningAlgs = []string{"RS256", "PS256", "ES256", "EdDSA", "HS256"}
+)
+
+type clientAssertionClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt json.Number     `json:"exp"`
+	NotBefore json.Number     `json:"nbf"`
+	JTI       string          `json:"jti"`
+}
+
+// getClientAssertionSubject returns the subject of the client assertion without verification
+func getClientAssertionSubject(assertion string) (string, error) {
+	jws, err := jose.ParseSigned(assertion)
+	if err != nil {
+		return "", err
+	}
+	var claims clientAssertionClaims
+	if err := json.Unmarshal(jws.UnsafePayloadWithoutVerification(), &claims); err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// verifyClientAssertion verifies the client assertion defined in RFC 7523 section 3
+// it returns the client authentication method decided by the signing algorithm
+func verifyClientAssertion(projectName string, client *model.ClientInfo, assertion string, issuer string) (string, *errors.Error) {
+	jws, e := jose.ParseSigned(assertion)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidClient, "Failed to parse client assertion: %v", e)
+	}
+	if len(jws.Signatures) != 1 {
+		return "", errors.Append(errors.ErrInvalidClient, "Client assertion must have one signature")
+	}
+
+	alg := jws.Signatures[0].Header.Algorithm
+	if !slice.Contains(ClientAssertionSigningAlgs, alg) {
+		return "", errors.Append(errors.ErrInvalidClient, "Signing algorithm %s is not supported", alg)
+	}
+	method := "private_key_jwt"
+	if alg == "HS256" {
+		method = "client_secret_jwt"
+	}
+
+	payload, err := verifyClientSignature(client, jws)
+	if err != nil {
+		return "", errors.Append(errors.ErrInvalidClient, "Failed to verify client assertion: %s", err.Error())
+	}
+
+	var claims clientAssertionClaims
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if e := dec.Decode(&claims); e != nil {
+		return "", errors.Append(errors.ErrInvalidClient, "Failed to decode claims: %v", e)
+	}
+
+	if claims.Issuer != client.ID || claims.Subject != client.ID {
+		return "", errors.Append(errors.ErrInvalidClient, "Issuer and subject must be client %s, but got %s and %s", client.ID, claims.Issuer, claims.Subject)
+	}
+
+	// the client can use the issuer or the token endpoint url as the audience
+	var aud []string
+	if e := json.Unmarshal(claims.Audience, &aud); e != nil {
+		var a string
+		if e := json.Unmarshal(claims.Audience, &a); e != nil {
+			return "", errors.Append(errors.ErrInvalidClient, "Invalid audience %s", string(claims.Audience))
+		}
+		aud = []string{a}
+	}
+	if !slice.Contains(aud, issuer) && !slice.Contains(aud, issuer+"/openid-connect/token") {
+		return "", errors.Append(errors.ErrInvalidClient, "Audience %v does not contain %s", aud, issuer)
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == "" {
+		return "", errors.Append(errors.ErrInvalidClient, "Client assertion must have exp")
+	}
+	exp, e := numericDate(claims.ExpiresAt)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidClient, "Invalid exp: %v", e)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return "", errors.Append(errors.ErrInvalidClient, "Client assertion is expired at %v", exp)
+	}
+	if claims.NotBefore != "" {
+		nbf, e := numericDate(claims.NotBefore)
+		if e != nil {
+			return "", errors.Append(errors.ErrInvalidClient, "Invalid nbf: %v", e)
+		}
+		if now.Add(clockSkew).Before(nbf) {
+			return "", errors.Append(errors.ErrInvalidClient, "Client assertion is not valid until %v", nbf)
+		}
+	}
+
+	// the client assertion can be used only once
+	if claims.JTI == "" {
+		return "", errors.Append(errors.ErrInvalidClient, "Client assertion must have jti")
+	}
+	ent := &model.UsedJTI{
+		JTI:         claims.JTI,
+		Issuer:      client.ID,
+		ProjectName: projectName,
+		ExpiresAt:   exp.Add(clockSkew),
+	}
+	if err := db.GetInst().UsedJTIAdd(projectName, ent); err != nil {
+		if errors.Contains(err, model.ErrJTIAlreadyUsed) || errors.Contains(err, model.ErrUsedJTIValidateFailed) {
+			return "", errors.Append(errors.ErrInvalidClient, "Client assertion with jti %s is already used", claims.JTI)
+		}
+		return "", errors.Append(err, "Failed to add used jti")
+	}
+
+	return method, nil
+}
@@ -0,0 +1,150 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestClientAuth(t *testing.T) {
+	const projectName = "master"
+	const issuer = "https://localhost/authapi/v1/project/master"
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &privKey.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"},
+	}})
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	clients := []*model.ClientInfo{
+		{ID: "legacy", AccessType: "confidential", Secret: secret},
+		{ID: "post", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "client_secret_post"},
+		{ID: "secret-jwt", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "client_secret_jwt"},
+		{ID: "private-jwt", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "private_key_jwt", JWKS: string(jwks)},
+		{ID: "public", AccessType: "public"},
+	}
+	for _, c := range clients {
+		c.ProjectName = projectName
+		c.CreatedAt = time.Now()
+		if err := db.GetInst().ClientAdd(projectName, c); err != nil {
+			t.Fatalf("Failed to add client %s: %v", c.ID, err)
+		}
+	}
+
+	assertion := func(alg jose.SignatureAlgorithm, key interface{}, clientID string, aud string, jti string) string {
+		return signRequestObject(t, alg, key, "", map[string]interface{}{
+			"iss": clientID,
+			"sub": clientID,
+			"aud": aud,
+			"exp": time.Now().Add(time.Minute).Unix(),
+			"jti": jti,
+		})
+	}
+	reused := assertion(jose.RS256, privKey, "private-jwt", issuer, uuid.New().String())
+
+	tt := []struct {
+		Name      string
+		Cred      ClientCredentials
+		ExpectErr bool
+	}{
+		{"legacy client with basic", ClientCredentials{ClientID: "legacy", ClientSecret: secret, Method: "client_secret_basic"}, false},
+		{"legacy client with post", ClientCredentials{ClientID: "legacy", ClientSecret: secret, Method: "client_secret_post"}, false},
+		{"wrong secret", ClientCredentials{ClientID: "legacy", ClientSecret: "wrong-secret", Method: "client_secret_basic"}, true},
+		{"legacy client with client assertion", ClientCredentials{ClientID: "legacy", Assertion: assertion(jose.HS256, []byte(secret), "legacy", issuer, uuid.New().String())}, true},
+		{"registered method", ClientCredentials{ClientID: "post", ClientSecret: secret, Method: "client_secret_post"}, false},
+		{"unregistered method", ClientCredentials{ClientID: "post", ClientSecret: secret, Method: "client_secret_basic"}, true},
+		{"client_secret_jwt", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte(secret), "secret-jwt", issuer, uuid.New().String())}, false},
+		{"client_secret_jwt with token endpoint audience", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte(secret), "secret-jwt", issuer+"/openid-connect/token", uuid.New().String())}, false},
+		{"client_secret_jwt with secret", ClientCredentials{ClientID: "secret-jwt", ClientSecret: secret, Method: "client_secret_basic"}, true},
+		{"client_secret_jwt signed by wrong secret", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte("wrong-secret-wrong-secret-wrong!"), "secret-jwt", issuer, uuid.New().String())}, true},
+		{"client_secret_jwt with wrong audience", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte(secret), "secret-jwt", "https://other.example.com", uuid.New().String())}, true},
+		{"client_secret_jwt issued by other client", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte(secret), "legacy", issuer, uuid.New().String())}, true},
+		{"client_secret_jwt without jti", ClientCredentials{ClientID: "secret-jwt", Assertion: assertion(jose.HS256, []byte(secret), "secret-jwt", issuer, "")}, true},
+		{"private_key_jwt", ClientCredentials{ClientID: "private-jwt", Assertion: reused}, false},
+		{"replayed private_key_jwt", ClientCredentials{ClientID: "private-jwt", Assertion: reused}, true},
+		{"private_key_jwt signed by secret", ClientCredentials{ClientID: "private-jwt", Assertion: assertion(jose.HS256, []byte(secret), "private-jwt", issuer, uuid.New().String())}, true},
+		{"public client", ClientCredentials{ClientID: "public", Method: "none"}, false},
+		{"public client with client assertion", ClientCredentials{ClientID: "public", Assertion: reused}, true},
+		{"no such client", ClientCredentials{ClientID: "unknown", Method: "none"}, true},
+	}
+
+	for _, tc := range tt {
+		err := ClientAuth(projectName, issuer, &tc.Cred)
+		if tc.ExpectErr {
+			if !isOAuthError(err, errors.ErrInvalidClient) {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidClient, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to authenticate client: %v", tc.Name, err)
+		}
+	}
+}
+
+func TestGetClientCredentials(t *testing.T) {
+	secretJWT := signRequestObject(t, jose.HS256, []byte("test-secret"), "", map[string]interface{}{"sub": "client1"})
+
+	tt := []struct {
+		Name         string
+		Form         url.Values
+		BasicAuth    []string
+		ExpectID     string
+		ExpectMethod string
+		ExpectErr    bool
+	}{
+		{"basic", url.Values{}, []string{"client1", "secret"}, "client1", "client_secret_basic", false},
+		{"post", url.Values{"client_id": {"client1"}, "client_secret": {"secret"}}, nil, "client1", "client_secret_post", false},
+		{"none", url.Values{"client_id": {"client1"}}, nil, "client1", "none", false},
+		{"client assertion", url.Values{"client_assertion_type": {ClientAssertionTypeJWT}, "client_assertion": {secretJWT}}, nil, "client1", "", false},
+		{"client assertion of other client", url.Values{"client_id": {"client2"}, "client_assertion_type": {ClientAssertionTypeJWT}, "client_assertion": {secretJWT}}, nil, "", "", true},
+		{"unsupported assertion type", url.Values{"client_assertion_type": {"urn:unknown"}, "client_assertion": {secretJWT}}, nil, "", "", true},
+		{"no client", url.Values{}, nil, "", "", true},
+	}
+
+	for _, tc := range tt {
+		r, _ := http.NewRequest("POST", "https://localhost/token", strings.NewReader(tc.Form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tc.BasicAuth != nil {
+			r.SetBasicAuth(tc.BasicAuth[0], tc.BasicAuth[1])
+		}
+		r.ParseForm()
+
+		res, err := GetClientCredentials(r)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Errorf("Test %s: GetClientCredentials should return error, but got %v", tc.Name, res)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to get client credentials: %v", tc.Name, err)
+			continue
+		}
+		if res.ClientID != tc.ExpectID || res.Method != tc.ExpectMethod {
+			t.Errorf("Test %s: Wrong credentials. expect %s/%s, but got %s/%s", tc.Name, tc.ExpectID, tc.ExpectMethod, res.ClientID, res.Method)
+		}
+	}
+}
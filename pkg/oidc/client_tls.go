@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"sync"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+var (
+	clientCAPool     *x509.CertPool
+	clientCAPoolErr  *errors.Error
+	clientCAPoolOnce sync.Once
+)
+
+// getClientCAPool returns the CA certificates to verify the client certificate in tls_client_auth
+func getClientCAPool() (*x509.CertPool, *errors.Error) {
+	clientCAPoolOnce.Do(func() {
+		// the system CA is not trusted because any certificate issued by the public CA is accepted
+		file := config.Get().HTTPSConfig.ClientCAFile
+		if file == "" {
+			clientCAPoolErr = errors.New("Invalid config", "Client CA file is not set")
+			return
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			clientCAPoolErr = errors.New("Invalid config", "Failed to read client CA file: %v", err)
+			return
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			clientCAPoolErr = errors.New("Invalid config", "No certificate in client CA file %s", file)
+			return
+		}
+		clientCAPool = pool
+	})
+	return clientCAPool, clientCAPoolErr
+}
+
+// verifyClientCertificate verifies the certificate chain in the mutual-TLS client authentication defined in RFC 8705
+// it returns the client authentication method registered in the client
+func verifyClientCertificate(client *model.ClientInfo, certs []*x509.Certificate) (string, *errors.Error) {
+	cert := certs[0]
+
+	switch client.TokenEndpointAuthMethod {
+	case "tls_client_auth":
+		roots, err := getClientCAPool()
+		if err != nil {
+			return "", errors.Append(err, "Failed to get client CA")
+		}
+		intermediates := x509.NewCertPool()
+		for _, c := range certs[1:] {
+			intermediates.AddCert(c)
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		if _, e := cert.Verify(opts); e != nil {
+			return "", errors.Append(errors.ErrInvalidClient, "Failed to verify client certificate: %v", e)
+		}
+		if cert.Subject.String() != client.TLSClientAuthSubjectDN {
+			return "", errors.Append(errors.ErrInvalidClient, "Subject DN %s does not match", cert.Subject.String())
+		}
+	case "self_signed_tls_client_auth":
+		// the certificate must be registered in the client JWK Set as x5c
+		jwks, err := GetClientJWKSet(client)
+		if err != nil {
+			return "", errors.Append(errors.ErrInvalidClient, "Failed to get client keys: %s", err.Error())
+		}
+		found := false
+		for _, key := range jwks.Keys {
+			if len(key.Certificates) > 0 && bytes.Equal(key.Certificates[0].Raw, cert.Raw) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", errors.Append(errors.ErrInvalidClient, "Client certificate is not registered in client %s", client.ID)
+		}
+	default:
+		return "", errors.Append(errors.ErrInvalidClient, "Client %s does not use mutual-TLS client authentication", client.ID)
+	}
+
+	return client.TokenEndpointAuthMethod, nil
+}
@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func createTestCert(t *testing.T, subject pkix.Name, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		// self-signed certificate
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent = tmpl
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestClientAuthByCertificate(t *testing.T) {
+	const projectName = "master"
+	const issuer = "https://localhost/authapi/v1/project/master"
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	ca, caKey := createTestCert(t, pkix.Name{CommonName: "Test CA"}, nil, nil)
+	otherCA, otherCAKey := createTestCert(t, pkix.Name{CommonName: "Other CA"}, nil, nil)
+	subject := pkix.Name{CommonName: "client1", Organization: []string{"example"}}
+	pkiCert, _ := createTestCert(t, subject, ca, caKey)
+	untrustedCert, _ := createTestCert(t, subject, otherCA, otherCAKey)
+	selfSignedCert, selfSignedKey := createTestCert(t, subject, nil, nil)
+	otherSelfSignedCert, _ := createTestCert(t, subject, nil, nil)
+
+	// Trust only the test CA
+	dir, _ := ioutil.TempDir("", "hekate-test")
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600)
+	config.Get().HTTPSConfig.ClientCAFile = caFile
+
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &selfSignedKey.PublicKey, KeyID: "key1", Use: "sig", Certificates: []*x509.Certificate{selfSignedCert}},
+	}})
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	clients := []*model.ClientInfo{
+		{ID: "mtls-pki", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "tls_client_auth", TLSClientAuthSubjectDN: subject.String()},
+		{ID: "mtls-other-dn", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "tls_client_auth", TLSClientAuthSubjectDN: "CN=client2"},
+		{ID: "mtls-self-signed", AccessType: "confidential", Secret: secret, TokenEndpointAuthMethod: "self_signed_tls_client_auth", JWKS: string(jwks)},
+		{ID: "mtls-legacy", AccessType: "confidential", Secret: secret},
+	}
+	for _, c := range clients {
+		c.ProjectName = projectName
+		c.CreatedAt = time.Now()
+		if err := db.GetInst().ClientAdd(projectName, c); err != nil {
+			t.Fatalf("Failed to add client %s: %v", c.ID, err)
+		}
+	}
+
+	tt := []struct {
+		Name      string
+		Cred      ClientCredentials
+		ExpectErr bool
+	}{
+		{"tls_client_auth", ClientCredentials{ClientID: "mtls-pki", Method: "none", Certificates: []*x509.Certificate{pkiCert}}, false},
+		{"tls_client_auth with chain", ClientCredentials{ClientID: "mtls-pki", Method: "none", Certificates: []*x509.Certificate{pkiCert, ca}}, false},
+		{"tls_client_auth without certificate", ClientCredentials{ClientID: "mtls-pki", Method: "none"}, true},
+		{"tls_client_auth with secret", ClientCredentials{ClientID: "mtls-pki", ClientSecret: secret, Method: "client_secret_basic", Certificates: []*x509.Certificate{pkiCert}}, true},
+		{"tls_client_auth issued by untrusted CA", ClientCredentials{ClientID: "mtls-pki", Method: "none", Certificates: []*x509.Certificate{untrustedCert}}, true},
+		{"tls_client_auth with self-signed certificate", ClientCredentials{ClientID: "mtls-pki", Method: "none", Certificates: []*x509.Certificate{selfSignedCert}}, true},
+		{"tls_client_auth with wrong subject", ClientCredentials{ClientID: "mtls-other-dn", Method: "none", Certificates: []*x509.Certificate{pkiCert}}, true},
+		{"self_signed_tls_client_auth", ClientCredentials{ClientID: "mtls-self-signed", Method: "none", Certificates: []*x509.Certificate{selfSignedCert}}, false},
+		{"self_signed_tls_client_auth with unregistered certificate", ClientCredentials{ClientID: "mtls-self-signed", Method: "none", Certificates: []*x509.Certificate{otherSelfSignedCert}}, true},
+		{"legacy client with certificate", ClientCredentials{ClientID: "mtls-legacy", Method: "none", Certificates: []*x509.Certificate{pkiCert}}, true},
+		{"legacy client with secret and certificate", ClientCredentials{ClientID: "mtls-legacy", ClientSecret: secret, Method: "client_secret_basic", Certificates: []*x509.Certificate{pkiCert}}, false},
+	}
+
+	for _, tc := range tt {
+		err := ClientAuth(projectName, issuer, &tc.Cred)
+		if tc.ExpectErr {
+			if !isOAuthError(err, errors.ErrInvalidClient) {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidClient, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to authenticate client: %v", tc.Name, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// fetchTimeout is a timeout to get the resource registered by the client
+	fetchTimeout = 5 * time.Second
+	// fetchMaxSize is a max size of the resource registered by the client
+	fetchMaxSize = 64 * 1024
+	// jwksCacheLifeSpan is a life span of the JWK Set fetched from jwks_uri
+	jwksCacheLifeSpan = 5 * time.Minute
+)
+
+var (
+	// externalTransport is used to access the URLs registered by the clients
+	// it connects only to the public addresses to prevent the server side request forgery
+	// the proxy is not used because the address check is bypassed by it
+	externalTransport http.RoundTripper = &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: fetchTimeout,
+			Control: dialPublicAddressOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: fetchTimeout,
+	}
+
+	// privateNetworks are the address blocks which are not reachable from the internet
+	privateNetworks = parseCIDRs(
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"fc00::/7",
+	)
+
+	jwksCache = &clientJWKSCache{
+		entries: make(map[string]*jwksCacheEntry),
+	}
+)
+
+type jwksCacheEntry struct {
+	uri       string
+	keys      *jose.JSONWebKeySet
+	expiresAt time.Time
+}
+
+// clientJWKSCache holds the JWK Sets fetched from jwks_uri of each client
+type clientJWKSCache struct {
+	mu      sync.Mutex
+	entries map[string]*jwksCacheEntry
+}
+
+func (c *clientJWKSCache) get(client *model.ClientInfo) *jose.JSONWebKeySet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := client.ProjectName + "/" + client.ID
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if e.uri != client.JWKSURI || time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return nil
+	}
+	return e.keys
+}
+
+func (c *clientJWKSCache) set(client *model.ClientInfo, keys *jose.JSONWebKeySet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[client.ProjectName+"/"+client.ID] = &jwksCacheEntry{
+		uri:       client.JWKSURI,
+		keys:      keys,
+		expiresAt: time.Now().Add(jwksCacheLifeSpan),
+	}
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	res := []*net.IPNet{}
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
+// isPublicIP returns true if the ip is a global unicast address out of the private networks
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublicAddressOnly is called after the host name is resolved, so it checks the actual address to connect
+func dialPublicAddressOnly(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
+
+// newExternalClient returns the http client to access the URL registered by the client
+// the redirect is not followed because the redirected URL is not registered
+func newExternalClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: externalTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkExternalURL checks that the URL registered by the client uses https
+func checkExternalURL(uri string) *errors.Error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return errors.New("Invalid URL", "Failed to parse %s: %v", uri, err)
+	}
+	if u.Scheme != "https" {
+		return errors.New("Invalid URL", "URL %s does not use https", uri)
+	}
+	return nil
+}
+
+// fetchClientResource gets the resource such as JWK Set or request object from the URL registered by the client
+func fetchClientResource(uri string) ([]byte, *errors.Error) {
+	if err := checkExternalURL(uri); err != nil {
+		return nil, errors.Append(err, "Failed to check URL")
+	}
+
+	res, err := newExternalClient(fetchTimeout).Get(uri)
+	if err != nil {
+		return nil, errors.New("Fetch failed", "Failed to get %s: %v", uri, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("Fetch failed", "Failed to get %s: status %d", uri, res.StatusCode)
+	}
+
+	// read one more byte to detect too large resource
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, fetchMaxSize+1))
+	if err != nil {
+		return nil, errors.New("Fetch failed", "Failed to read %s: %v", uri, err)
+	}
+	if len(body) > fetchMaxSize {
+		return nil, errors.New("Fetch failed", "Resource %s is too large", uri)
+	}
+	return body, nil
+}
@@ -0,0 +1,104 @@
+package oidc
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tt := []struct {
+		ip     string
+		expect bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tc := range tt {
+		if res := isPublicIP(net.ParseIP(tc.ip)); res != tc.expect {
+			t.Errorf("isPublicIP(%s) expects %v, but got %v", tc.ip, tc.expect, res)
+		}
+	}
+}
+
+func TestFetchClientResource(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/jwks", http.StatusFound)
+			return
+		}
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	// the default transport refuses the loopback address
+	if _, err := fetchClientResource(srv.URL + "/jwks"); err == nil {
+		t.Errorf("Fetching from the loopback address expects error, but got nil")
+	}
+
+	orgTransport := externalTransport
+	externalTransport = srv.Client().Transport
+	defer func() {
+		externalTransport = orgTransport
+	}()
+
+	if _, err := fetchClientResource(srv.URL + "/jwks"); err != nil {
+		t.Errorf("Failed to fetch resource: %v", err)
+	}
+	if _, err := fetchClientResource(srv.URL + "/redirect"); err == nil {
+		t.Errorf("Fetching with redirect expects error, but got nil")
+	}
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer httpSrv.Close()
+	if _, err := fetchClientResource(httpSrv.URL + "/jwks"); err == nil {
+		t.Errorf("Fetching by http expects error, but got nil")
+	}
+}
+
+func TestClientJWKSetCache(t *testing.T) {
+	calls := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	orgTransport := externalTransport
+	externalTransport = srv.Client().Transport
+	defer func() {
+		externalTransport = orgTransport
+	}()
+
+	client := &model.ClientInfo{ID: "cache-rp", ProjectName: "cache", JWKSURI: srv.URL + "/jwks"}
+	for i := 0; i < 2; i++ {
+		if _, err := GetClientJWKSet(client); err != nil {
+			t.Fatalf("Failed to get JWK Set: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("JWK Set expects to be fetched once, but fetched %d times", calls)
+	}
+
+	// the changed jwks_uri is fetched again
+	client.JWKSURI = srv.URL + "/jwks2"
+	if _, err := GetClientJWKSet(client); err != nil {
+		t.Fatalf("Failed to get JWK Set: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("JWK Set expects to be fetched again after jwks_uri is changed, but fetched %d times", calls)
+	}
+}
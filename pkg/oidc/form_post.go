@@ -0,0 +1,85 @@
+package oidc
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+// formPostTemplate is a page to post the authorization response to the client
+// defined in https://openid.net/specs/oauth-v2-form-post-response-mode-1_0.html
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <title>Submit This Form</title>
+</head>
+<body onload="javascript:document.forms[0].submit()">
+  <form method="post" action="{{ .Action }}">
+    {{- range $name, $values := .Values }}
+    {{- range $values }}
+    <input type="hidden" name="{{ $name }}" value="{{ . }}"/>
+    {{- end }}
+    {{- end }}
+    <noscript>
+      <p>JavaScript is disabled. Click the button to continue.</p>
+      <button type="submit">Continue</button>
+    </noscript>
+  </form>
+</body>
+</html>
+`))
+
+// WriteLoggedInResponse returns the response created by CreateLoggedInResponse to the client
+// it writes the auto-submitting form if the response mode is form_post, otherwise redirects to the client
+func WriteLoggedInResponse(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Redirect(w, req, req.URL.String(), http.StatusFound)
+		return
+	}
+
+	d := map[string]interface{}{
+		"Action": req.URL.String(),
+		"Values": req.PostForm,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err := formPostTemplate.Execute(w, d); err != nil {
+		errors.Print(errors.New("Internal server error", "Failed to write form post response: %v", err))
+		return
+	}
+	logger.Debug("Return form post response to %s", req.URL.String())
+}
+
+// WriteErrorResponse returns the OAuth error to the client in the response mode of the authorization request
+func WriteErrorResponse(w http.ResponseWriter, err *errors.Error, responseMode, redirectURI, state string) {
+	if responseMode != "form_post" {
+		errors.RedirectWithOAuthError(w, err, "GET", redirectURI, state)
+		return
+	}
+
+	values := url.Values{}
+	values.Set("error", err.Error())
+	if err.Description() != "" {
+		values.Set("error_description", err.Description())
+	}
+	if state != "" {
+		values.Set("state", state)
+	}
+
+	req, e := http.NewRequest("POST", redirectURI, strings.NewReader(values.Encode()))
+	if e != nil {
+		errors.Print(errors.New("Internal server error", "Failed to create error response: %v", e))
+		errors.WriteToHTTP(w, errors.ErrServerError, 0, state)
+		return
+	}
+	req.PostForm = values
+	logger.Debug("Return OAuth error by form post to %s: %v", redirectURI, values)
+	WriteLoggedInResponse(w, req)
+}
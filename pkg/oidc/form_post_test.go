@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+func TestWriteLoggedInResponse(t *testing.T) {
+	// form_post response
+	values := url.Values{}
+	values.Set("code", "test-code")
+	values.Set("state", `"><script>alert(1)</script>`)
+	req, _ := http.NewRequest("POST", "https://client.example.com/callback", strings.NewReader(values.Encode()))
+	req.PostForm = values
+
+	w := httptest.NewRecorder()
+	WriteLoggedInResponse(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Wrong status code for form_post. expect %d, but got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `action="https://client.example.com/callback"`) {
+		t.Errorf("Form action is not the redirect uri: %s", body)
+	}
+	if !strings.Contains(body, `name="code" value="test-code"`) {
+		t.Errorf("Form does not contain the code: %s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("Form value is not escaped: %s", body)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("form_post response should not be cached, but got Cache-Control: %s", w.Header().Get("Cache-Control"))
+	}
+
+	// redirect response
+	req, _ = http.NewRequest("GET", "https://client.example.com/callback?code=test-code", nil)
+	w = httptest.NewRecorder()
+	WriteLoggedInResponse(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Errorf("Wrong status code for query. expect %d, but got %d", http.StatusFound, w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "https://client.example.com/callback?code=test-code" {
+		t.Errorf("Wrong redirect location: %s", loc)
+	}
+}
+
+func TestWriteErrorResponse(t *testing.T) {
+	// form_post response
+	w := httptest.NewRecorder()
+	WriteErrorResponse(w, errors.ErrConsentRequired, "form_post", "https://client.example.com/callback", "xyz")
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Wrong status code for form_post. expect %d, but got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `action="https://client.example.com/callback"`) {
+		t.Errorf("Form action is not the redirect uri: %s", body)
+	}
+	if !strings.Contains(body, `name="error" value="`+errors.ErrConsentRequired.Error()+`"`) || !strings.Contains(body, `name="state" value="xyz"`) {
+		t.Errorf("Form does not contain the error and state: %s", body)
+	}
+
+	// redirect response
+	w = httptest.NewRecorder()
+	WriteErrorResponse(w, errors.ErrLoginRequired, "query", "https://client.example.com/callback", "xyz")
+
+	if w.Code != http.StatusFound {
+		t.Errorf("Wrong status code for query. expect %d, but got %d", http.StatusFound, w.Code)
+	}
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	if loc.Query().Get("error") != errors.ErrLoginRequired.Error() || loc.Query().Get("state") != "xyz" {
+		t.Errorf("Wrong redirect location: %s", loc)
+	}
+}
@@ -1,24 +1,47 @@
 package oidc
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/x509"
 
 	"github.com/dvsekhvalnov/jose2go/base64url"
-	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/util"
 )
 
 // GenerateJWKSet ...
-func GenerateJWKSet(signAlg string, publicKey []byte) (*JWKSet, *errors.Error) {
+func GenerateJWKSet(keys []*model.SignKey) (*JWKSet, *errors.Error) {
+	res := &JWKSet{
+		Keys: []JWKInfo{},
+	}
+
+	for _, key := range keys {
+		if key.SigningAlgorithm == "HS256" {
+			// symmetric key must not be published
+			continue
+		}
+
+		jwk, err := generateJWK(key.KeyID, key.SigningAlgorithm, key.PublicKey)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to generate JWK of key %s", key.KeyID)
+		}
+		res.Keys = append(res.Keys, *jwk)
+	}
+
+	return res, nil
+}
+
+func generateJWK(keyID string, signAlg string, publicKey []byte) (*JWKInfo, *errors.Error) {
 	jwk := JWKInfo{
-		KeyID:        uuid.New().String(),
+		KeyID:        keyID,
 		Algorithm:    signAlg,
 		PublicKeyUse: "sig",
 	}
 
 	switch signAlg {
-	case "RS256":
+	case "RS256", "PS256":
 		jwk.KeyType = "RSA"
 		key, err := x509.ParsePKCS1PublicKey(publicKey)
 		if err != nil {
@@ -27,12 +50,45 @@ func GenerateJWKSet(signAlg string, publicKey []byte) (*JWKSet, *errors.Error) {
 		e := util.Int2bytes(uint64(key.E))
 		jwk.E = base64url.Encode(e)
 		jwk.N = base64url.Encode(key.N.Bytes())
+	case "ES256":
+		jwk.KeyType = "EC"
+		k, err := x509.ParsePKIXPublicKey(publicKey)
+		if err != nil {
+			return nil, errors.New("ECDSA key parse failed", "Failed to parse ECDSA public key: %v", err)
+		}
+		key, ok := k.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New("ECDSA key parse failed", "Public key is not ECDSA key")
+		}
+		// the length of coordinate must be the full size of curve
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.Curve = key.Curve.Params().Name
+		jwk.X = base64url.Encode(padBytes(key.X.Bytes(), size))
+		jwk.Y = base64url.Encode(padBytes(key.Y.Bytes(), size))
+	case "EdDSA":
+		jwk.KeyType = "OKP"
+		k, err := x509.ParsePKIXPublicKey(publicKey)
+		if err != nil {
+			return nil, errors.New("Ed25519 key parse failed", "Failed to parse Ed25519 public key: %v", err)
+		}
+		key, ok := k.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("Ed25519 key parse failed", "Public key is not Ed25519 key")
+		}
+		jwk.Curve = "Ed25519"
+		jwk.X = base64url.Encode(key)
 	default:
 		return nil, errors.New("Invalid request", "Now such signing algorithm")
 	}
 
-	res := &JWKSet{}
-	res.Keys = append(res.Keys, jwk)
+	return &jwk, nil
+}
 
-	return res, nil
+func padBytes(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+	res := make([]byte, size)
+	copy(res[size-len(data):], data)
+	return res
 }
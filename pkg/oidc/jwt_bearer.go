@@ -0,0 +1,151 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// JWTBearerSigningAlgs are the algorithms to sign the JWT bearer assertion
+	// the symmetric algorithm is not allowed because the trusted issuer registers only the public keys
+	JWTBearerSigningAlgs = []string{"RS256", "PS256", "ES256", "EdDSA"}
+)
+
+// VerifyJWTBearerAssertion verifies the assertion of the JWT bearer grant defined in RFC 7523 section 3
+// it returns the subject mapping of the trusted issuer which matches the sub claim
+func VerifyJWTBearerAssertion(project *model.ProjectInfo, assertion string, issuer string) (*model.SubjectMapping, *errors.Error) {
+	jws, e := jose.ParseSigned(assertion)
+	if e != nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Failed to parse assertion: %v", e)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Assertion must have one signature")
+	}
+	alg := jws.Signatures[0].Header.Algorithm
+	if !slice.Contains(JWTBearerSigningAlgs, alg) {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Signing algorithm %s is not supported", alg)
+	}
+
+	// the trusted issuer is decided by the iss claim before verification
+	var unsafe clientAssertionClaims
+	if e := json.Unmarshal(jws.UnsafePayloadWithoutVerification(), &unsafe); e != nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Failed to decode claims: %v", e)
+	}
+	var trusted *model.TrustedIssuer
+	for i := range project.TrustedIssuers {
+		if project.TrustedIssuers[i].Issuer == unsafe.Issuer {
+			trusted = &project.TrustedIssuers[i]
+			break
+		}
+	}
+	if trusted == nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Issuer %s is not trusted", unsafe.Issuer)
+	}
+
+	payload, err := verifyIssuerSignature(trusted, jws)
+	if err != nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Failed to verify assertion: %s", err.Error())
+	}
+
+	var claims clientAssertionClaims
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if e := dec.Decode(&claims); e != nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Failed to decode claims: %v", e)
+	}
+	if claims.Issuer != trusted.Issuer {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Issuer must be %s, but got %s", trusted.Issuer, claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Assertion must have sub")
+	}
+
+	var aud []string
+	if e := json.Unmarshal(claims.Audience, &aud); e != nil {
+		var a string
+		if e := json.Unmarshal(claims.Audience, &a); e != nil {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Invalid audience %s", string(claims.Audience))
+		}
+		aud = []string{a}
+	}
+	if trusted.Audience != "" {
+		if !slice.Contains(aud, trusted.Audience) {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Audience %v does not contain %s", aud, trusted.Audience)
+		}
+	} else if !slice.Contains(aud, issuer) && !slice.Contains(aud, issuer+"/openid-connect/token") {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Audience %v does not contain %s", aud, issuer)
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == "" {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Assertion must have exp")
+	}
+	exp, e := numericDate(claims.ExpiresAt)
+	if e != nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Invalid exp: %v", e)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Assertion is expired at %v", exp)
+	}
+	if claims.NotBefore != "" {
+		nbf, e := numericDate(claims.NotBefore)
+		if e != nil {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Invalid nbf: %v", e)
+		}
+		if now.Add(clockSkew).Before(nbf) {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Assertion is not valid until %v", nbf)
+		}
+	}
+
+	var mapping *model.SubjectMapping
+	for i := range trusted.SubjectMappings {
+		if trusted.SubjectMappings[i].Match(claims.Subject) {
+			mapping = &trusted.SubjectMappings[i]
+			break
+		}
+	}
+	if mapping == nil {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Subject %s of issuer %s is not mapped", claims.Subject, trusted.Issuer)
+	}
+
+	// the assertion can be used only once
+	// the issuer is prefixed not to conflict with the client assertion whose issuer is the client ID
+	if claims.JTI == "" {
+		return nil, errors.Append(errors.ErrInvalidGrant, "Assertion must have jti")
+	}
+	ent := &model.UsedJTI{
+		JTI:         claims.JTI,
+		Issuer:      "jwt-bearer:" + trusted.Issuer,
+		ProjectName: project.Name,
+		ExpiresAt:   exp.Add(clockSkew),
+	}
+	if err := db.GetInst().UsedJTIAdd(project.Name, ent); err != nil {
+		if errors.Contains(err, model.ErrJTIAlreadyUsed) || errors.Contains(err, model.ErrUsedJTIValidateFailed) {
+			return nil, errors.Append(errors.ErrInvalidGrant, "Assertion with jti %s is already used", claims.JTI)
+		}
+		return nil, errors.Append(err, "Failed to add used jti")
+	}
+
+	return mapping, nil
+}
+
+// verifyIssuerSignature verifies the signature of the assertion by the static JWK Set of the trusted issuer
+func verifyIssuerSignature(trusted *model.TrustedIssuer, jws *jose.JSONWebSignature) ([]byte, *errors.Error) {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(trusted.JWKS), &keys); err != nil {
+		return nil, errors.New("Verify failed", "Failed to parse JWK Set of issuer %s: %v", trusted.Issuer, err)
+	}
+
+	payload, err := verifyJWSByKeySet(&keys, jws)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to verify by the keys of issuer %s", trusted.Issuer)
+	}
+	return payload, nil
+}
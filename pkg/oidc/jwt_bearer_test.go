@@ -0,0 +1,97 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestVerifyJWTBearerAssertion(t *testing.T) {
+	const projectName = "jwt-bearer"
+	const issuer = "https://localhost/authapi/v1/project/jwt-bearer"
+	const external = "https://workload.example.com"
+
+	privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &privKey.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"},
+	}})
+
+	project := &model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+		TrustedIssuers: []model.TrustedIssuer{
+			{
+				Issuer: external,
+				JWKS:   string(jwks),
+				SubjectMappings: []model.SubjectMapping{
+					{Subject: "system:serviceaccount:*", ClientID: "workload"},
+					{Subject: "alice", UserName: "admin"},
+				},
+			},
+		},
+	}
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(project)
+
+	assertion := func(key interface{}, iss, sub, aud string, exp time.Time, jti string) string {
+		return signRequestObject(t, jose.RS256, key, "key1", map[string]interface{}{
+			"iss": iss,
+			"sub": sub,
+			"aud": aud,
+			"exp": exp.Unix(),
+			"jti": jti,
+		})
+	}
+	valid := time.Now().Add(time.Minute)
+	reused := assertion(privKey, external, "alice", issuer, valid, uuid.New().String())
+
+	tt := []struct {
+		Name         string
+		Assertion    string
+		ExpectClient string
+		ExpectUser   string
+		ExpectErr    bool
+	}{
+		{"user mapping", reused, "", "admin", false},
+		{"replayed assertion", reused, "", "", true},
+		{"client mapping with prefix", assertion(privKey, external, "system:serviceaccount:default:app", issuer, valid, uuid.New().String()), "workload", "", false},
+		{"token endpoint audience", assertion(privKey, external, "alice", issuer+"/openid-connect/token", valid, uuid.New().String()), "", "admin", false},
+		{"untrusted issuer", assertion(privKey, "https://other.example.com", "alice", issuer, valid, uuid.New().String()), "", "", true},
+		{"signed by other key", assertion(otherKey, external, "alice", issuer, valid, uuid.New().String()), "", "", true},
+		{"wrong audience", assertion(privKey, external, "alice", "https://other.example.com", valid, uuid.New().String()), "", "", true},
+		{"expired assertion", assertion(privKey, external, "alice", issuer, time.Now().Add(-time.Hour), uuid.New().String()), "", "", true},
+		{"without jti", assertion(privKey, external, "alice", issuer, valid, ""), "", "", true},
+		{"unmapped subject", assertion(privKey, external, "bob", issuer, valid, uuid.New().String()), "", "", true},
+	}
+
+	for _, tc := range tt {
+		res, err := VerifyJWTBearerAssertion(project, tc.Assertion, issuer)
+		if tc.ExpectErr {
+			if !isOAuthError(err, errors.ErrInvalidGrant) {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidGrant, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to verify assertion: %v", tc.Name, err)
+			continue
+		}
+		if res.ClientID != tc.ExpectClient || res.UserName != tc.ExpectUser {
+			t.Errorf("Test %s: Expect mapping to client %q and user %q, but got %v", tc.Name, tc.ExpectClient, tc.ExpectUser, res)
+		}
+	}
+}
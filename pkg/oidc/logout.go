@@ -0,0 +1,201 @@
+package oidc
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+const (
+	// logoutTokenLifeSpan is a lifespan of the logout token in seconds
+	logoutTokenLifeSpan = 120
+	// backchannelLogoutTimeout is a timeout to send the logout token to the client
+	backchannelLogoutTimeout = 5 * time.Second
+)
+
+var (
+	// backchannelLogoutRetryMax is a max number of retries to send the logout token
+	backchannelLogoutRetryMax = 3
+	// backchannelLogoutRetryInterval is an interval of the first retry, it is doubled in each retry
+	backchannelLogoutRetryInterval = 1 * time.Second
+)
+
+// frontchannelLogoutTemplate is a page to load the front-channel logout URIs of the clients
+// defined in https://openid.net/specs/openid-connect-frontchannel-1_0.html
+var frontchannelLogoutTemplate = template.Must(template.New("frontchannel_logout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8">
+  <title>Logout</title>
+</head>
+<body{{ if .RedirectURI }} onload="javascript:window.location.replace({{ .RedirectURI }})"{{ end }}>
+  {{- range .URIs }}
+  <iframe src="{{ . }}" style="display:none"></iframe>
+  {{- end }}
+  <p>Logged out</p>
+  {{- if .RedirectURI }}
+  <noscript>
+    <a href="{{ .RedirectURI }}">Continue</a>
+  </noscript>
+  {{- end }}
+</body>
+</html>
+`))
+
+// logoutTarget is a session in the client which is notified of the logout
+type logoutTarget struct {
+	clientID  string
+	userID    string
+	sessionID string
+}
+
+// NotifyBackchannelLogout sends the logout token to the back-channel logout URI of the clients which received tokens in the sessions
+// the tokens are sent in background, so the delivery failure is only logged
+func NotifyBackchannelLogout(projectName, issuer string, sessions []*model.Session) *errors.Error {
+	for _, t := range logoutTargets(sessions) {
+		cli, err := getLogoutClient(projectName, t.clientID)
+		if err != nil {
+			return errors.Append(err, "Failed to get client")
+		}
+		if cli == nil || cli.BackchannelLogoutURI == "" {
+			continue
+		}
+
+		req := token.Request{
+			Issuer:      issuer,
+			ExpiresIn:   logoutTokenLifeSpan,
+			ProjectName: projectName,
+			UserID:      t.userID,
+			SessionID:   t.sessionID,
+		}
+		tkn, err := token.GenerateLogoutToken([]string{t.clientID}, req)
+		if err != nil {
+			return errors.Append(err, "Failed to generate logout token")
+		}
+		go sendLogoutToken(cli.BackchannelLogoutURI, tkn)
+	}
+	return nil
+}
+
+// FrontchannelLogoutURIs returns the front-channel logout URIs of the clients which received tokens in the sessions
+// iss and sid are added to the query to identify the session in the client
+func FrontchannelLogoutURIs(projectName, issuer string, sessions []*model.Session) ([]string, *errors.Error) {
+	res := []string{}
+	for _, t := range logoutTargets(sessions) {
+		cli, err := getLogoutClient(projectName, t.clientID)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get client")
+		}
+		if cli == nil || cli.FrontchannelLogoutURI == "" {
+			continue
+		}
+
+		u, perr := url.Parse(cli.FrontchannelLogoutURI)
+		if perr != nil {
+			return nil, errors.New("Invalid client", "Failed to parse front-channel logout URI of client %s: %v", t.clientID, perr)
+		}
+		q := u.Query()
+		q.Set("iss", issuer)
+		q.Set("sid", t.sessionID)
+		u.RawQuery = q.Encode()
+		res = append(res, u.String())
+	}
+	return res, nil
+}
+
+// WriteFrontchannelLogoutPage writes the page which loads the front-channel logout URIs in iframes
+// the browser goes to the redirect URI after all of the URIs are loaded if it is not empty
+func WriteFrontchannelLogoutPage(w http.ResponseWriter, uris []string, redirectURI string) {
+	d := map[string]interface{}{
+		"URIs":        uris,
+		"RedirectURI": redirectURI,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err := frontchannelLogoutTemplate.Execute(w, d); err != nil {
+		errors.Print(errors.New("Internal server error", "Failed to write front-channel logout page: %v", err))
+	}
+}
+
+// logoutTargets returns the pairs of the client and the session without duplication
+// the family id is used as the session id because it is the sid claim in the ID token
+func logoutTargets(sessions []*model.Session) []logoutTarget {
+	res := []logoutTarget{}
+	exists := make(map[logoutTarget]bool)
+	for _, s := range sessions {
+		if s.ClientID == "" {
+			// the session is created before recording the client
+			continue
+		}
+		t := logoutTarget{
+			clientID:  s.ClientID,
+			userID:    s.UserID,
+			sessionID: s.FamilyID,
+		}
+		if t.sessionID == "" {
+			t.sessionID = s.SessionID
+		}
+		if !exists[t] {
+			exists[t] = true
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// getLogoutClient returns nil without error if the client is already deleted
+func getLogoutClient(projectName, clientID string) (*model.ClientInfo, *errors.Error) {
+	cli, err := db.GetInst().ClientGet(projectName, clientID)
+	if err != nil {
+		if errors.Contains(err, model.ErrNoSuchClient) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cli, nil
+}
+
+func sendLogoutToken(uri, tkn string) {
+	interval := backchannelLogoutRetryInterval
+	for i := 0; ; i++ {
+		err := postLogoutToken(uri, tkn)
+		if err == nil {
+			logger.Debug("Successfully sent logout token to %s", uri)
+			return
+		}
+		if i >= backchannelLogoutRetryMax {
+			errors.Print(errors.Append(err, "Failed to send logout token to %s", uri))
+			return
+		}
+
+		logger.Info("Failed to send logout token to %s, so retry after %v: %v", uri, interval, err)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+func postLogoutToken(uri, tkn string) *errors.Error {
+	if err := checkExternalURL(uri); err != nil {
+		return errors.Append(err, "Failed to check back-channel logout URI")
+	}
+
+	res, err := newExternalClient(backchannelLogoutTimeout).PostForm(uri, url.Values{"logout_token": {tkn}})
+	if err != nil {
+		return errors.New("Logout notification failed", "Failed to post %s: %v", uri, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return errors.New("Logout notification failed", "Failed to post %s: status %d", uri, res.StatusCode)
+	}
+	return nil
+}
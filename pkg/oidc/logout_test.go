@@ -0,0 +1,147 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/oidc/token"
+)
+
+func TestLogoutNotification(t *testing.T) {
+	const projectName = "logout"
+	const issuer = "https://localhost/authapi/v1/project/logout"
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	backchannelLogoutRetryInterval = 10 * time.Millisecond
+
+	// the first request fails to check the retry
+	received := make(chan string, 10)
+	calls := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.ParseForm()
+		received <- r.Form.Get("logout_token")
+	}))
+	defer srv.Close()
+
+	// the test server listens on the loopback address
+	orgTransport := externalTransport
+	externalTransport = srv.Client().Transport
+	defer func() {
+		externalTransport = orgTransport
+	}()
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	clients := []*model.ClientInfo{
+		{ID: "back-rp", ProjectName: projectName, AccessType: "confidential", Secret: secret, BackchannelLogoutURI: srv.URL + "/logout"},
+		{ID: "front-rp", ProjectName: projectName, AccessType: "confidential", Secret: secret, FrontchannelLogoutURI: "https://front.example.com/logout?app=1"},
+	}
+	for _, c := range clients {
+		if err := db.GetInst().ClientAdd(projectName, c); err != nil {
+			t.Fatalf("Failed to add client %s: %v", c.ID, err)
+		}
+	}
+
+	sessions := []*model.Session{
+		{SessionID: "s1", FamilyID: "family1", UserID: "user1", ClientID: "back-rp"},
+		// the rotated session in the same family is notified only once
+		{SessionID: "s2", FamilyID: "family1", UserID: "user1", ClientID: "back-rp"},
+		{SessionID: "s3", FamilyID: "family3", UserID: "user1", ClientID: "front-rp"},
+		{SessionID: "s4", FamilyID: "family4", UserID: "user1", ClientID: "deleted-rp"},
+		{SessionID: "s5", FamilyID: "family5", UserID: "user1"},
+	}
+
+	if err := NotifyBackchannelLogout(projectName, issuer, sessions); err != nil {
+		t.Fatalf("Failed to notify back-channel logout: %v", err)
+	}
+
+	var tkn string
+	select {
+	case tkn = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Logout token is not received")
+	}
+
+	var claims token.LogoutTokenClaims
+	_, err := jwt.ParseWithClaims(tkn, &claims, func(t *jwt.Token) (interface{}, error) {
+		project, err := db.GetInst().ProjectGet(projectName)
+		if err != nil {
+			return nil, err
+		}
+		key, err := token.GetVerifyKey(t, project)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify logout token: %v", err)
+	}
+	if claims.Issuer != issuer || claims.Subject != "user1" || claims.SessionID != "family1" {
+		t.Errorf("Wrong logout token claims: %v", claims)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != "back-rp" {
+		t.Errorf("Logout token audience should be back-rp, but got %v", claims.Audience)
+	}
+	if _, ok := claims.Events["http://schemas.openid.net/event/backchannel-logout"]; !ok {
+		t.Errorf("Logout token does not contain back-channel logout event: %v", claims.Events)
+	}
+
+	select {
+	case tkn = <-received:
+		t.Errorf("Logout token should be sent only once, but got another token")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	uris, ferr := FrontchannelLogoutURIs(projectName, issuer, sessions)
+	if ferr != nil {
+		t.Fatalf("Failed to get front-channel logout URIs: %v", ferr)
+	}
+	if len(uris) != 1 {
+		t.Fatalf("Front-channel logout URIs should be only front-rp, but got %v", uris)
+	}
+	u, _ := url.Parse(uris[0])
+	if u.Host != "front.example.com" || u.Query().Get("app") != "1" || u.Query().Get("iss") != issuer || u.Query().Get("sid") != "family3" {
+		t.Errorf("Wrong front-channel logout URI: %s", uris[0])
+	}
+}
+
+func TestWriteFrontchannelLogoutPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteFrontchannelLogoutPage(w, []string{"https://front.example.com/logout?iss=a&sid=b"}, `https://client.example.com/bye?state="><script>`)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<iframe src="https://front.example.com/logout?iss=a&amp;sid=b"`) {
+		t.Errorf("Page does not contain the front-channel logout URI: %s", body)
+	}
+	if !strings.Contains(body, "window.location.replace(") {
+		t.Errorf("Page does not redirect to the post logout redirect URI: %s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Errorf("Redirect URI is not escaped: %s", body)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Front-channel logout page should not be cached, but got Cache-Control: %s", w.Header().Get("Cache-Control"))
+	}
+}
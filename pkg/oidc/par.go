@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+const (
+	// RequestURIPrefix is a prefix of request_uri issued by the pushed authorization request endpoint
+	RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+	// pushedAuthRequestExpiresIn is a lifetime of request_uri in seconds
+	pushedAuthRequestExpiresIn = 60
+)
+
+// PushedAuthResponse is a response of the pushed authorization request defined in RFC 9126
+type PushedAuthResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// PushAuthRequest validates the authorization request of the authenticated client and stores it
+// the parameters are resolved from the request object if values contain request
+func PushAuthRequest(projectName string, clientID string, issuer string, values url.Values) (*PushedAuthResponse, *errors.Error) {
+	if values.Get("request_uri") != "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "request_uri must not be specified in pushed authorization request")
+	}
+	if values.Get("client_id") != "" && values.Get("client_id") != clientID {
+		return nil, errors.Append(errors.ErrInvalidRequest, "client_id %s does not match the authenticated client", values.Get("client_id"))
+	}
+	values.Set("client_id", clientID)
+
+	values, err := ResolveRequestObject(projectName, issuer, values)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to resolve request object")
+	}
+
+	authReq := NewAuthRequest(values)
+	if err := CheckRedirectURL(projectName, authReq.ClientID, authReq.RedirectURI); err != nil {
+		if errors.Contains(err, ErrNoRedirectURL) {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Redirect URL %s is not in Allowed list", authReq.RedirectURI)
+		}
+		return nil, errors.Append(err, "Failed to get allowed callback urls in client")
+	}
+	if err := authReq.Validate(); err != nil {
+		return nil, errors.Append(err, "Failed to validate request")
+	}
+
+	ent := &model.PushedAuthRequest{
+		RequestURI:  RequestURIPrefix + uuid.New().String(),
+		ProjectName: projectName,
+		ClientID:    clientID,
+		Parameters:  values,
+		ExpiresIn:   pushedAuthRequestExpiresIn,
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().PushedAuthRequestAdd(projectName, ent); err != nil {
+		return nil, errors.Append(err, "Failed to add pushed authorization request")
+	}
+
+	return &PushedAuthResponse{
+		RequestURI: ent.RequestURI,
+		ExpiresIn:  ent.ExpiresIn,
+	}, nil
+}
+
+// ResolvePushedAuthRequest returns the parameters pushed by the client if values contain request_uri issued by PushAuthRequest
+// the other parameters in values are ignored except for client_id
+// it returns an error if the client requires the pushed authorization request, but values is not pushed
+func ResolvePushedAuthRequest(projectName string, values url.Values) (url.Values, *errors.Error) {
+	requestURI := values.Get("request_uri")
+	if !strings.HasPrefix(requestURI, RequestURIPrefix) {
+		clientID := values.Get("client_id")
+		if clientID == "" {
+			// client_id is checked in validation of the authorization request
+			return values, nil
+		}
+		client, err := db.GetInst().ClientGet(projectName, clientID)
+		if err != nil {
+			if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
+				return nil, errors.Append(errors.ErrInvalidClient, err.Error())
+			}
+			return nil, errors.Append(err, "Failed to get client")
+		}
+		if client.RequirePushedAuthRequests {
+			return nil, errors.Append(errors.ErrInvalidRequest, "Client %s requires pushed authorization request", clientID)
+		}
+		return values, nil
+	}
+
+	ent, err := db.GetInst().PushedAuthRequestUse(projectName, requestURI)
+	if err != nil {
+		if errors.Contains(err, model.ErrNoSuchPushedAuthRequest) {
+			return nil, errors.Append(errors.ErrInvalidRequestURI, "No such request_uri %s", requestURI)
+		}
+		return nil, errors.Append(err, "Failed to get pushed authorization request")
+	}
+
+	if ent.ClientID != values.Get("client_id") {
+		return nil, errors.Append(errors.ErrInvalidRequestURI, "request_uri is issued for the other client %s", ent.ClientID)
+	}
+	expire := ent.CreatedAt.Add(time.Second * time.Duration(ent.ExpiresIn))
+	if !time.Now().Before(expire) {
+		return nil, errors.Append(errors.ErrInvalidRequestURI, "request_uri is expired at %v", expire)
+	}
+
+	logger.Debug("Pushed authorization request: %v", ent.Parameters)
+	return url.Values(ent.Parameters), nil
+}
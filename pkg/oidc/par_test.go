@@ -0,0 +1,112 @@
+package oidc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/config"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// isOAuthError returns true if err is created from the OAuth error such as errors.ErrInvalidRequest
+func isOAuthError(err *errors.Error, oauthErr *errors.Error) bool {
+	return err != nil && err.Error() == oauthErr.Error()
+}
+
+func TestPushedAuthRequest(t *testing.T) {
+	const projectName = "master"
+	const issuer = "https://localhost/authapi/v1/project/master"
+	const redirectURL = "https://client.example.com/callback"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	config.Get().SupportedResponseType = []string{"code"}
+	config.Get().SupportedScope = []string{"openid"}
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	for _, id := range []string{"client1", "client2"} {
+		err := db.GetInst().ClientAdd(projectName, &model.ClientInfo{
+			ID:                        id,
+			ProjectName:               projectName,
+			AccessType:                "public",
+			CreatedAt:                 time.Now(),
+			AllowedCallbackURLs:       []string{redirectURL},
+			RequirePushedAuthRequests: id == "client2",
+		})
+		if err != nil {
+			t.Fatalf("Failed to add client %s: %v", id, err)
+		}
+	}
+
+	values := func() url.Values {
+		return url.Values{
+			"scope":         {"openid"},
+			"response_type": {"code"},
+			"redirect_uri":  {redirectURL},
+			"state":         {"test-state"},
+		}
+	}
+
+	// Invalid requests are rejected in the PAR endpoint
+	invalid := values()
+	invalid.Set("redirect_uri", "https://attacker.example.com/callback")
+	if _, err := PushAuthRequest(projectName, "client1", issuer, invalid); !isOAuthError(err, errors.ErrInvalidRequest) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidRequest, err)
+	}
+	invalid = values()
+	invalid.Set("request_uri", RequestURIPrefix+"test")
+	if _, err := PushAuthRequest(projectName, "client1", issuer, invalid); !isOAuthError(err, errors.ErrInvalidRequest) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidRequest, err)
+	}
+
+	res, err := PushAuthRequest(projectName, "client1", issuer, values())
+	if err != nil {
+		t.Fatalf("Failed to push authorization request: %v", err)
+	}
+	if !strings.HasPrefix(res.RequestURI, RequestURIPrefix) || res.ExpiresIn <= 0 {
+		t.Errorf("Invalid pushed authorization response: %v", res)
+	}
+
+	// request_uri issued for the other client
+	req := url.Values{"client_id": {"client2"}, "request_uri": {res.RequestURI}}
+	if _, err := ResolvePushedAuthRequest(projectName, req); !isOAuthError(err, errors.ErrInvalidRequestURI) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidRequestURI, err)
+	}
+
+	res, _ = PushAuthRequest(projectName, "client1", issuer, values())
+	req = url.Values{"client_id": {"client1"}, "request_uri": {res.RequestURI}, "state": {"overwritten"}}
+	params, err := ResolvePushedAuthRequest(projectName, req)
+	if err != nil {
+		t.Fatalf("Failed to resolve pushed authorization request: %v", err)
+	}
+	if params.Get("state") != "test-state" || params.Get("client_id") != "client1" {
+		t.Errorf("Parameters should be the pushed values, but got %v", params)
+	}
+
+	// request_uri can be used only once
+	if _, err := ResolvePushedAuthRequest(projectName, req); !isOAuthError(err, errors.ErrInvalidRequestURI) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidRequestURI, err)
+	}
+
+	// the client which requires PAR
+	req = values()
+	req.Set("client_id", "client2")
+	if _, err := ResolvePushedAuthRequest(projectName, req); !isOAuthError(err, errors.ErrInvalidRequest) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidRequest, err)
+	}
+	req.Set("client_id", "client1")
+	if _, err := ResolvePushedAuthRequest(projectName, req); err != nil {
+		t.Errorf("Client which does not require PAR can use the normal request, but got %v", err)
+	}
+}
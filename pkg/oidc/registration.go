@@ -0,0 +1,210 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/util"
+)
+
+const (
+	// registrationClientIDPrefix is a prefix of client id issued by the client registration endpoint
+	registrationClientIDPrefix = "client-"
+	// registrationSecretLength is a length of client secret and registration access token
+	registrationSecretLength = 32
+)
+
+// ClientMetadata is a client metadata defined in RFC 7591
+type ClientMetadata struct {
+	RedirectURIs              []string        `json:"redirect_uris"`
+	TokenEndpointAuthMethod   string          `json:"token_endpoint_auth_method,omitempty"`
+	JWKS                      json.RawMessage `json:"jwks,omitempty"`
+	JWKSURI                   string          `json:"jwks_uri,omitempty"`
+	RequestURIs               []string        `json:"request_uris,omitempty"`
+	PostLogoutRedirectURIs    []string        `json:"post_logout_redirect_uris,omitempty"`
+	BackchannelLogoutURI      string          `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI     string          `json:"frontchannel_logout_uri,omitempty"`
+	RequestObjectSigningAlg   string          `json:"request_object_signing_alg,omitempty"`
+	RequirePushedAuthRequests bool            `json:"require_pushed_authorization_requests,omitempty"`
+	TLSClientAuthSubjectDN    string          `json:"tls_client_auth_subject_dn,omitempty"`
+	DPoPBoundAccessTokens     bool            `json:"dpop_bound_access_tokens,omitempty"`
+}
+
+// ClientInformation is a response of the client registration defined in RFC 7591 and RFC 7592
+type ClientInformation struct {
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64  `json:"client_id_issued_at,omitempty"`
+	ClientSecretExpiresAt   *int64 `json:"client_secret_expires_at,omitempty"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri,omitempty"`
+
+	ClientMetadata
+}
+
+// RegisterClient creates a new client from the metadata
+// registrationURI is a url of the client registration endpoint
+func RegisterClient(projectName string, registrationURI string, meta *ClientMetadata) (*ClientInformation, *errors.Error) {
+	client := &model.ClientInfo{
+		ID:          registrationClientIDPrefix + uuid.New().String(),
+		ProjectName: projectName,
+		CreatedAt:   time.Now(),
+	}
+	if err := applyClientMetadata(client, meta); err != nil {
+		return nil, errors.Append(err, "Failed to apply client metadata")
+	}
+
+	accessToken := util.RandomString(registrationSecretLength, util.CharTypeDigit|util.CharTypeLower|util.CharTypeUpper)
+	client.RegistrationAccessTokenHash = util.CreateHash(accessToken)
+
+	if err := db.GetInst().ClientAdd(projectName, client); err != nil {
+		if errors.Contains(err, model.ErrClientValidateFailed) {
+			return nil, errors.Append(errors.ErrInvalidClientMetadata, err.Error())
+		}
+		return nil, errors.Append(err, "Failed to add client")
+	}
+
+	res := NewClientInformation(client, registrationURI)
+	res.RegistrationAccessToken = accessToken
+	return res, nil
+}
+
+// GetRegisteredClient returns the client which is authenticated by the registration access token
+func GetRegisteredClient(projectName string, clientID string, accessToken string) (*model.ClientInfo, *errors.Error) {
+	client, err := db.GetInst().ClientGet(projectName, clientID)
+	if err != nil {
+		if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
+			return nil, errors.Append(errors.ErrInvalidToken, err.Error())
+		}
+		return nil, errors.Append(err, "Failed to get client")
+	}
+
+	// the client created by the admin can not be managed by the registration access token
+	if client.RegistrationAccessTokenHash == "" {
+		return nil, errors.Append(errors.ErrInvalidToken, "Client %s is not registered dynamically", clientID)
+	}
+	hash := util.CreateHash(accessToken)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(client.RegistrationAccessTokenHash)) != 1 {
+		return nil, errors.Append(errors.ErrInvalidToken, "Registration access token does not match")
+	}
+
+	return client, nil
+}
+
+// UpdateRegisteredClient replaces the metadata of the client with the request
+func UpdateRegisteredClient(projectName string, client *model.ClientInfo, registrationURI string, req *ClientInformation) (*ClientInformation, *errors.Error) {
+	if req.ClientID != client.ID {
+		return nil, errors.Append(errors.ErrInvalidClientMetadata, "client_id %s does not match", req.ClientID)
+	}
+	if req.ClientSecret != "" && req.ClientSecret != client.Secret {
+		return nil, errors.Append(errors.ErrInvalidClientMetadata, "client_secret does not match")
+	}
+
+	if err := applyClientMetadata(client, &req.ClientMetadata); err != nil {
+		return nil, errors.Append(err, "Failed to apply client metadata")
+	}
+
+	if err := db.GetInst().ClientUpdate(projectName, client); err != nil {
+		if errors.Contains(err, model.ErrClientValidateFailed) {
+			return nil, errors.Append(errors.ErrInvalidClientMetadata, err.Error())
+		}
+		return nil, errors.Append(err, "Failed to update client")
+	}
+
+	return NewClientInformation(client, registrationURI), nil
+}
+
+// NewClientInformation returns the registered metadata of the client
+// registration access token is not included because only the hash is stored
+func NewClientInformation(client *model.ClientInfo, registrationURI string) *ClientInformation {
+	res := &ClientInformation{
+		ClientID:              client.ID,
+		ClientIDIssuedAt:      client.CreatedAt.Unix(),
+		RegistrationClientURI: registrationURI + "/" + client.ID,
+		ClientMetadata: ClientMetadata{
+			RedirectURIs:              client.AllowedCallbackURLs,
+			TokenEndpointAuthMethod:   "none",
+			JWKSURI:                   client.JWKSURI,
+			RequestURIs:               client.RequestURIs,
+			PostLogoutRedirectURIs:    client.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:      client.BackchannelLogoutURI,
+			FrontchannelLogoutURI:     client.FrontchannelLogoutURI,
+			RequestObjectSigningAlg:   client.RequestObjectSigningAlg,
+			RequirePushedAuthRequests: client.RequirePushedAuthRequests,
+			TLSClientAuthSubjectDN:    client.TLSClientAuthSubjectDN,
+			DPoPBoundAccessTokens:     client.RequireDPoP,
+		},
+	}
+	if client.JWKS != "" {
+		res.JWKS = json.RawMessage(client.JWKS)
+	}
+	if client.AccessType == "confidential" {
+		// the secret never expires
+		var expiresAt int64
+		res.ClientSecret = client.Secret
+		res.ClientSecretExpiresAt = &expiresAt
+		res.TokenEndpointAuthMethod = "client_secret_basic"
+	}
+	if client.TokenEndpointAuthMethod != "" {
+		res.TokenEndpointAuthMethod = client.TokenEndpointAuthMethod
+	}
+	return res
+}
+
+func applyClientMetadata(client *model.ClientInfo, meta *ClientMetadata) *errors.Error {
+	if len(meta.RedirectURIs) == 0 {
+		return errors.Append(errors.ErrInvalidRedirectURI, "redirect_uris is required")
+	}
+	for _, u := range meta.RedirectURIs {
+		if !govalidator.IsRequestURL(u) {
+			return errors.Append(errors.ErrInvalidRedirectURI, "Invalid redirect uri %s", u)
+		}
+	}
+	if len(meta.JWKS) > 0 && meta.JWKSURI != "" {
+		return errors.Append(errors.ErrInvalidClientMetadata, "jwks and jwks_uri must not be used together")
+	}
+
+	method := meta.TokenEndpointAuthMethod
+	if method == "" {
+		// default value defined in RFC 7591
+		method = "client_secret_basic"
+	}
+	switch method {
+	case "client_secret_basic", "client_secret_post", "client_secret_jwt", "private_key_jwt", "tls_client_auth", "self_signed_tls_client_auth":
+		// keep the current secret when the metadata is updated
+		if client.AccessType != "confidential" {
+			client.Secret = util.RandomString(registrationSecretLength, util.CharTypeDigit|util.CharTypeLower|util.CharTypeUpper)
+		}
+		client.AccessType = "confidential"
+	case "none":
+		client.Secret = ""
+		client.AccessType = "public"
+	default:
+		return errors.Append(errors.ErrInvalidClientMetadata, "Unsupported token endpoint auth method %s", method)
+	}
+	client.TokenEndpointAuthMethod = method
+
+	client.AllowedCallbackURLs = meta.RedirectURIs
+	client.JWKS = string(meta.JWKS)
+	client.JWKSURI = meta.JWKSURI
+	client.RequestURIs = meta.RequestURIs
+	client.PostLogoutRedirectURIs = meta.PostLogoutRedirectURIs
+	client.BackchannelLogoutURI = meta.BackchannelLogoutURI
+	client.FrontchannelLogoutURI = meta.FrontchannelLogoutURI
+	client.RequestObjectSigningAlg = meta.RequestObjectSigningAlg
+	client.RequirePushedAuthRequests = meta.RequirePushedAuthRequests
+	client.TLSClientAuthSubjectDN = meta.TLSClientAuthSubjectDN
+	client.RequireDPoP = meta.DPoPBoundAccessTokens
+
+	// validate the client here to return invalid_client_metadata
+	if err := client.Validate(); err != nil {
+		return errors.Append(errors.ErrInvalidClientMetadata, err.Error())
+	}
+	return nil
+}
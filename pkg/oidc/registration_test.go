@@ -0,0 +1,99 @@
+package oidc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+func TestClientRegistration(t *testing.T) {
+	const projectName = "master"
+	const registrationURI = "https://localhost/authapi/v1/project/master/openid-connect/register"
+	const redirectURL = "https://client.example.com/callback"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	db.GetInst().ClientAdd(projectName, &model.ClientInfo{
+		ID:                  "admin-client",
+		ProjectName:         projectName,
+		AccessType:          "public",
+		CreatedAt:           time.Now(),
+		AllowedCallbackURLs: []string{redirectURL},
+	})
+
+	// Invalid metadata
+	tt := []struct {
+		Name      string
+		Meta      ClientMetadata
+		ExpectErr *errors.Error
+	}{
+		{"no redirect uri", ClientMetadata{}, errors.ErrInvalidRedirectURI},
+		{"invalid redirect uri", ClientMetadata{RedirectURIs: []string{"invalid-url"}}, errors.ErrInvalidRedirectURI},
+		{"unsupported auth method", ClientMetadata{RedirectURIs: []string{redirectURL}, TokenEndpointAuthMethod: "unknown_method"}, errors.ErrInvalidClientMetadata},
+		{"private_key_jwt without keys", ClientMetadata{RedirectURIs: []string{redirectURL}, TokenEndpointAuthMethod: "private_key_jwt"}, errors.ErrInvalidClientMetadata},
+		{"both jwks and jwks_uri", ClientMetadata{RedirectURIs: []string{redirectURL}, JWKS: []byte(`{"keys":[]}`), JWKSURI: "https://client.example.com/jwks"}, errors.ErrInvalidClientMetadata},
+		{"invalid signing alg", ClientMetadata{RedirectURIs: []string{redirectURL}, RequestObjectSigningAlg: "none"}, errors.ErrInvalidClientMetadata},
+	}
+	for _, tc := range tt {
+		if _, err := RegisterClient(projectName, registrationURI, &tc.Meta); !isOAuthError(err, tc.ExpectErr) {
+			t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, tc.ExpectErr, err)
+		}
+	}
+
+	res, err := RegisterClient(projectName, registrationURI, &ClientMetadata{RedirectURIs: []string{redirectURL}})
+	if err != nil {
+		t.Fatalf("Failed to register client: %v", err)
+	}
+	if res.ClientSecret == "" || res.TokenEndpointAuthMethod != "client_secret_basic" {
+		t.Errorf("Default client should be confidential, but got %v", res)
+	}
+	if res.RegistrationAccessToken == "" || res.RegistrationClientURI != registrationURI+"/"+res.ClientID {
+		t.Errorf("Invalid registration response: %v", res)
+	}
+
+	// Authenticate with the registration access token
+	if _, err := GetRegisteredClient(projectName, res.ClientID, "wrong-token"); !isOAuthError(err, errors.ErrInvalidToken) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidToken, err)
+	}
+	if _, err := GetRegisteredClient(projectName, "admin-client", res.RegistrationAccessToken); !isOAuthError(err, errors.ErrInvalidToken) {
+		t.Errorf("Client created by admin should not be managed, but got %v", err)
+	}
+	client, err := GetRegisteredClient(projectName, res.ClientID, res.RegistrationAccessToken)
+	if err != nil {
+		t.Fatalf("Failed to get registered client: %v", err)
+	}
+
+	// Update the metadata
+	req := &ClientInformation{
+		ClientID:       "other-client",
+		ClientMetadata: ClientMetadata{RedirectURIs: []string{redirectURL}, TokenEndpointAuthMethod: "none"},
+	}
+	if _, err := UpdateRegisteredClient(projectName, client, registrationURI, req); !isOAuthError(err, errors.ErrInvalidClientMetadata) {
+		t.Errorf("Expect error is %v, but got %v", errors.ErrInvalidClientMetadata, err)
+	}
+	req.ClientID = res.ClientID
+	updated, err := UpdateRegisteredClient(projectName, client, registrationURI, req)
+	if err != nil {
+		t.Fatalf("Failed to update registered client: %v", err)
+	}
+	if updated.ClientSecret != "" || updated.TokenEndpointAuthMethod != "none" || updated.RegistrationAccessToken != "" {
+		t.Errorf("Client should be public, but got %v", updated)
+	}
+
+	// Registration access token is still valid after the update
+	if _, err := GetRegisteredClient(projectName, res.ClientID, res.RegistrationAccessToken); err != nil {
+		t.Errorf("Failed to get updated client: %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// RequestObjectSigningAlgs are the algorithms to sign the request object
+	RequestObjectSigningAlgs = []string{"RS256", "PS256", "ES256", "EdDSA", "HS256"}
+
+	// RequestObjectEncryptionAlgs are the key management algorithms to encrypt the request object
+	// the content encryption key is derived from the client secret
+	RequestObjectEncryptionAlgs = []string{"dir"}
+
+	// RequestObjectEncryptionEncs are the content encryption algorithms of the request object
+	RequestObjectEncryptionEncs = []string{"A128CBC-HS256", "A192CBC-HS384", "A256CBC-HS512", "A128GCM", "A192GCM", "A256GCM"}
+
+	// encryptionKeySize is a size of the content encryption key in bytes
+	encryptionKeySize = map[string]int{
+		"A128CBC-HS256": 32,
+		"A192CBC-HS384": 48,
+		"A256CBC-HS512": 64,
+		"A128GCM":       16,
+		"A192GCM":       24,
+		"A256GCM":       32,
+	}
+)
+
+// clockSkew is an allowed time difference to validate exp and nbf of the request object
+const clockSkew = 30 * time.Second
+
+// ResolveRequestObject returns the authorization request parameters merged with the request object
+// the request object is passed by value in request or by reference in request_uri (OpenID Connect Core 1.0 section 6)
+// the parameters in the request object override the same parameters in values
+func ResolveRequestObject(projectName string, issuer string, values url.Values) (url.Values, *errors.Error) {
+	request := values.Get("request")
+	requestURI := values.Get("request_uri")
+	if request == "" && requestURI == "" {
+		return values, nil
+	}
+	if request != "" && requestURI != "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "Both request and request_uri are specified")
+	}
+
+	clientID := values.Get("client_id")
+	if clientID == "" {
+		return nil, errors.Append(errors.ErrInvalidRequest, "client_id is required with request object")
+	}
+	client, err := db.GetInst().ClientGet(projectName, clientID)
+	if err != nil {
+		if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
+			return nil, errors.Append(errors.ErrInvalidClient, err.Error())
+		}
+		return nil, errors.Append(err, "Failed to get client")
+	}
+
+	if requestURI != "" {
+		if request, err = getRequestObjectByURI(client, requestURI); err != nil {
+			return nil, err
+		}
+	}
+
+	claims, err := parseRequestObject(client, request)
+	if err != nil {
+		return nil, errors.Append(errors.ErrInvalidRequestObject, err.Error())
+	}
+	if err := validateRequestObjectClaims(claims, client.ID, issuer, values); err != nil {
+		return nil, errors.Append(errors.ErrInvalidRequestObject, err.Error())
+	}
+
+	res := url.Values{}
+	for k, v := range values {
+		if k != "request" && k != "request_uri" {
+			res[k] = v
+		}
+	}
+	for k, v := range claims {
+		switch k {
+		case "iss", "aud", "exp", "nbf", "iat", "jti":
+			// claims of JWT are not the authorization request parameters
+			continue
+		}
+		val, err := claimToParameter(v)
+		if err != nil {
+			return nil, errors.Append(errors.ErrInvalidRequestObject, "Invalid claim %s: %v", k, err)
+		}
+		res.Set(k, val)
+	}
+
+	logger.Debug("Request parameters with request object: %v", res)
+	return res, nil
+}
+
+func getRequestObjectByURI(client *model.ClientInfo, requestURI string) (string, *errors.Error) {
+	// the fragment is used only to identify the content by the client
+	u, e := url.Parse(requestURI)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidRequestURI, "Failed to parse request_uri: %v", e)
+	}
+	u.Fragment = ""
+	registered := false
+	for _, r := range client.RequestURIs {
+		if r == requestURI || r == u.String() {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return "", errors.Append(errors.ErrInvalidRequestURI, "request_uri %s is not registered in client %s", requestURI, client.ID)
+	}
+
+	data, err := fetchClientResource(requestURI)
+	if err != nil {
+		return "", errors.Append(errors.ErrInvalidRequestURI, err.Error())
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// parseRequestObject decrypts and verifies the request object, and returns its claims
+func parseRequestObject(client *model.ClientInfo, request string) (map[string]interface{}, *errors.Error) {
+	// JWE compact serialization has 5 parts
+	if strings.Count(request, ".") == 4 {
+		jws, err := decryptRequestObject(client, request)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to decrypt request object")
+		}
+		request = jws
+	}
+
+	jws, e := jose.ParseSigned(request)
+	if e != nil {
+		return nil, errors.New("Invalid request object", "Failed to parse request object: %v", e)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.New("Invalid request object", "Request object must have one signature")
+	}
+
+	header := jws.Signatures[0].Header
+	if !slice.Contains(RequestObjectSigningAlgs, header.Algorithm) {
+		return nil, errors.New("Invalid request object", "Signing algorithm %s is not supported", header.Algorithm)
+	}
+	if client.RequestObjectSigningAlg != "" && client.RequestObjectSigningAlg != header.Algorithm {
+		return nil, errors.New("Invalid request object", "Request object must be signed by %s, but got %s", client.RequestObjectSigningAlg, header.Algorithm)
+	}
+
+	payload, err := verifyClientSignature(client, jws)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to verify request object")
+	}
+
+	claims := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, errors.New("Invalid request object", "Failed to decode claims: %v", err)
+	}
+	return claims, nil
+}
+
+// verifyClientSignature verifies the JWS signed by the client and returns its payload
+// HS256 uses the client secret, and the others use the public keys registered by the client
+func verifyClientSignature(client *model.ClientInfo, jws *jose.JSONWebSignature) ([]byte, *errors.Error) {
+	header := jws.Signatures[0].Header
+	if header.Algorithm == "HS256" {
+		if client.Secret == "" {
+			return nil, errors.New("Verify failed", "Client %s does not have secret", client.ID)
+		}
+		payload, err := jws.Verify([]byte(client.Secret))
+		if err != nil {
+			return nil, errors.New("Verify failed", "Failed to verify signature: %v", err)
+		}
+		return payload, nil
+	}
+
+	keys, err := GetClientJWKSet(client)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to get client keys")
+	}
+
+	payload, err := verifyJWSByKeySet(keys, jws)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to verify by the keys of client %s", client.ID)
+	}
+	return payload, nil
+}
+
+// verifyJWSByKeySet verifies the JWS by the signing key in the key set and returns its payload
+// the key is selected by kid if the JWS has it
+func verifyJWSByKeySet(keys *jose.JSONWebKeySet, jws *jose.JSONWebSignature) ([]byte, *errors.Error) {
+	header := jws.Signatures[0].Header
+	candidates := keys.Keys
+	if header.KeyID != "" {
+		candidates = keys.Key(header.KeyID)
+	}
+	for _, k := range candidates {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Algorithm != "" && k.Algorithm != header.Algorithm {
+			continue
+		}
+		if payload, err := jws.Verify(k.Key); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, errors.New("Verify failed", "No key can verify the signature with kid %q", header.KeyID)
+}
+
+// decryptRequestObject decrypts the request object by the key derived from the client secret
+// defined in OpenID Connect Core 1.0 section 10.2
+func decryptRequestObject(client *model.ClientInfo, request string) (string, *errors.Error) {
+	jwe, e := jose.ParseEncrypted(request)
+	if e != nil {
+		return "", errors.New("Invalid request object", "Failed to parse encrypted request object: %v", e)
+	}
+
+	if !slice.Contains(RequestObjectEncryptionAlgs, jwe.Header.Algorithm) {
+		return "", errors.New("Invalid request object", "Encryption algorithm %s is not supported", jwe.Header.Algorithm)
+	}
+	enc, _ := jwe.Header.ExtraHeaders["enc"].(string)
+	size, ok := encryptionKeySize[enc]
+	if !ok {
+		return "", errors.New("Invalid request object", "Content encryption algorithm %s is not supported", enc)
+	}
+	if client.Secret == "" {
+		return "", errors.New("Invalid request object", "Client %s does not have secret to decrypt request object", client.ID)
+	}
+
+	res, e := jwe.Decrypt(deriveEncryptionKey(client.Secret, size))
+	if e != nil {
+		return "", errors.New("Invalid request object", "Failed to decrypt request object: %v", e)
+	}
+	return string(res), nil
+}
+
+// deriveEncryptionKey returns the left-most size bytes of SHA-2 hash of the client secret
+func deriveEncryptionKey(secret string, size int) []byte {
+	var hash []byte
+	switch {
+	case size <= sha256.Size:
+		h := sha256.Sum256([]byte(secret))
+		hash = h[:]
+	case size <= sha512.Size384:
+		h := sha512.Sum384([]byte(secret))
+		hash = h[:]
+	default:
+		h := sha512.Sum512([]byte(secret))
+		hash = h[:]
+	}
+	return hash[:size]
+}
+
+func validateRequestObjectClaims(claims map[string]interface{}, clientID string, issuer string, values url.Values) *errors.Error {
+	if _, ok := claims["request"]; ok {
+		return errors.New("Invalid request object", "Request object must not contain request")
+	}
+	if _, ok := claims["request_uri"]; ok {
+		return errors.New("Invalid request object", "Request object must not contain request_uri")
+	}
+
+	if iss, ok := claims["iss"]; ok && iss != clientID {
+		return errors.New("Invalid request object", "Issuer %v is not client %s", iss, clientID)
+	}
+	if cid, ok := claims["client_id"]; ok && cid != clientID {
+		return errors.New("Invalid request object", "client_id %v does not match the request parameter", cid)
+	}
+	if typ, ok := claims["response_type"]; ok && values.Get("response_type") != "" && typ != values.Get("response_type") {
+		return errors.New("Invalid request object", "response_type %v does not match the request parameter", typ)
+	}
+
+	if aud, ok := claims["aud"]; ok {
+		matched := false
+		switch v := aud.(type) {
+		case string:
+			matched = v == issuer
+		case []interface{}:
+			for _, a := range v {
+				if a == issuer {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return errors.New("Invalid request object", "Audience %v does not contain %s", aud, issuer)
+		}
+	}
+
+	now := time.Now()
+	if exp, ok := claims["exp"]; ok {
+		t, err := numericDate(exp)
+		if err != nil {
+			return errors.New("Invalid request object", "Invalid exp: %v", err)
+		}
+		if now.After(t.Add(clockSkew)) {
+			return errors.New("Invalid request object", "Request object is expired at %v", t)
+		}
+	}
+	if nbf, ok := claims["nbf"]; ok {
+		t, err := numericDate(nbf)
+		if err != nil {
+			return errors.New("Invalid request object", "Invalid nbf: %v", err)
+		}
+		if now.Add(clockSkew).Before(t) {
+			return errors.New("Invalid request object", "Request object is not valid until %v", t)
+		}
+	}
+
+	return nil
+}
+
+func numericDate(v interface{}) (time.Time, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("value %v is not number", v)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(f), 0), nil
+}
+
+// claimToParameter converts the claim value to the request parameter
+// JSON object such as claims parameter is passed as JSON string
+func claimToParameter(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		return fmt.Sprintf("%t", val), nil
+	case nil:
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,150 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func signRequestObject(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	payload, _ := json.Marshal(claims)
+	obj, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Failed to sign request object: %v", err)
+	}
+	res, _ := obj.CompactSerialize()
+	return res
+}
+
+func TestParseRequestObject(t *testing.T) {
+	privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &privKey.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"},
+	}})
+
+	client := &model.ClientInfo{
+		ID:     "client1",
+		Secret: "0123456789abcdef0123456789abcdef",
+		JWKS:   string(jwks),
+	}
+	claims := map[string]interface{}{
+		"client_id": "client1",
+		"scope":     "openid",
+	}
+
+	tt := []struct {
+		Name      string
+		Request   string
+		SignAlg   string
+		ExpectErr bool
+	}{
+		{"rs256 signed by the client key", signRequestObject(t, jose.RS256, privKey, "key1", claims), "", false},
+		{"rs256 without kid", signRequestObject(t, jose.RS256, privKey, "", claims), "", false},
+		{"hs256 signed by the client secret", signRequestObject(t, jose.HS256, []byte(client.Secret), "", claims), "", false},
+		{"signed by unknown key", signRequestObject(t, jose.RS256, otherKey, "key1", claims), "", true},
+		{"unknown kid", signRequestObject(t, jose.RS256, privKey, "key2", claims), "", true},
+		{"hs256 signed by wrong secret", signRequestObject(t, jose.HS256, []byte("wrong-secret-wrong-secret-wrong!"), "", claims), "", true},
+		{"unexpected algorithm", signRequestObject(t, jose.HS256, []byte(client.Secret), "", claims), "RS256", true},
+		{"invalid format", "invalid.request.object", "", true},
+	}
+
+	for _, tc := range tt {
+		client.RequestObjectSigningAlg = tc.SignAlg
+		res, err := parseRequestObject(client, tc.Request)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Errorf("Test %s: parseRequestObject should return error, but got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to parse request object: %v", tc.Name, err)
+			continue
+		}
+		if res["scope"] != "openid" {
+			t.Errorf("Test %s: Wrong claims. expect scope openid, but got %v", tc.Name, res)
+		}
+	}
+}
+
+func TestParseEncryptedRequestObject(t *testing.T) {
+	client := &model.ClientInfo{
+		ID:     "client1",
+		Secret: "test-secret",
+	}
+	request := signRequestObject(t, jose.HS256, []byte(client.Secret), "", map[string]interface{}{"scope": "openid"})
+
+	for _, enc := range []jose.ContentEncryption{jose.A128GCM, jose.A256CBC_HS512} {
+		key := deriveEncryptionKey(client.Secret, encryptionKeySize[string(enc)])
+		encrypter, err := jose.NewEncrypter(enc, jose.Recipient{Algorithm: jose.DIRECT, Key: key}, nil)
+		if err != nil {
+			t.Fatalf("Failed to create encrypter: %v", err)
+		}
+		obj, err := encrypter.Encrypt([]byte(request))
+		if err != nil {
+			t.Fatalf("Failed to encrypt request object: %v", err)
+		}
+		jwe, _ := obj.CompactSerialize()
+
+		res, e := parseRequestObject(client, jwe)
+		if e != nil {
+			t.Errorf("Failed to parse request object encrypted by %s: %v", enc, e)
+		} else if res["scope"] != "openid" {
+			t.Errorf("Wrong claims in request object encrypted by %s: %v", enc, res)
+		}
+	}
+}
+
+func TestValidateRequestObjectClaims(t *testing.T) {
+	const issuer = "https://localhost/authapi/v1/project/master"
+	now := json.Number("0")
+	future := func(d time.Duration) json.Number {
+		b, _ := json.Marshal(time.Now().Add(d).Unix())
+		return json.Number(b)
+	}
+	values := url.Values{"response_type": {"code"}}
+
+	tt := []struct {
+		Name      string
+		Claims    map[string]interface{}
+		ExpectErr bool
+	}{
+		{"valid claims", map[string]interface{}{"iss": "client1", "aud": issuer, "exp": future(time.Minute)}, false},
+		{"audience array", map[string]interface{}{"aud": []interface{}{"other", issuer}}, false},
+		{"wrong issuer", map[string]interface{}{"iss": "client2"}, true},
+		{"wrong client_id", map[string]interface{}{"client_id": "client2"}, true},
+		{"wrong response_type", map[string]interface{}{"response_type": "token"}, true},
+		{"wrong audience", map[string]interface{}{"aud": "https://other.example.com"}, true},
+		{"expired", map[string]interface{}{"exp": now}, true},
+		{"not yet valid", map[string]interface{}{"nbf": future(time.Hour)}, true},
+		{"nested request", map[string]interface{}{"request": "xxx"}, true},
+		{"nested request_uri", map[string]interface{}{"request_uri": "https://client.example.com/req"}, true},
+	}
+
+	for _, tc := range tt {
+		err := validateRequestObjectClaims(tc.Claims, "client1", issuer, values)
+		if tc.ExpectErr && err == nil {
+			t.Errorf("Test %s: validateRequestObjectClaims should return error, but got nil", tc.Name)
+		}
+		if !tc.ExpectErr && err != nil {
+			t.Errorf("Test %s: validateRequestObjectClaims returns unexpected error: %v", tc.Name, err)
+		}
+	}
+}
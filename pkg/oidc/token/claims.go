@@ -0,0 +1,90 @@
+package token
+
+import (
+	"strings"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+// StandardClaimsSupported is a list of the standard claims which are released by the scopes
+// defined in https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
+var StandardClaimsSupported = []string{
+	"name",
+	"given_name",
+	"family_name",
+	"middle_name",
+	"nickname",
+	"profile",
+	"picture",
+	"website",
+	"gender",
+	"birthdate",
+	"zoneinfo",
+	"locale",
+	"email",
+	"email_verified",
+	"phone_number",
+	"phone_number_verified",
+	"address",
+}
+
+// UserClaims returns the claims of the user which are allowed by the scope
+// the empty values are omitted, and the custom attributes are released with the profile scope
+func UserClaims(user *model.UserInfo, scope string) map[string]interface{} {
+	res := map[string]interface{}{}
+	set := func(name, value string) {
+		if value != "" {
+			res[name] = value
+		}
+	}
+
+	p := &user.Profile
+	for _, s := range strings.Split(scope, " ") {
+		switch s {
+		case "profile":
+			for k, v := range user.Attributes {
+				res[k] = v
+			}
+			set("name", p.FullName)
+			set("given_name", p.GivenName)
+			set("family_name", p.FamilyName)
+			set("middle_name", p.MiddleName)
+			set("nickname", p.Nickname)
+			set("profile", p.ProfileURL)
+			set("picture", p.Picture)
+			set("website", p.Website)
+			set("gender", p.Gender)
+			set("birthdate", p.Birthdate)
+			set("zoneinfo", p.Zoneinfo)
+			set("locale", p.Locale)
+		case "email":
+			if p.Email != "" {
+				res["email"] = p.Email
+				res["email_verified"] = p.EmailVerified
+			}
+		case "phone":
+			if p.PhoneNumber != "" {
+				res["phone_number"] = p.PhoneNumber
+				res["phone_number_verified"] = p.PhoneNumberVerified
+			}
+		case "address":
+			addr := map[string]interface{}{}
+			for k, v := range map[string]string{
+				"formatted":      p.Address.Formatted,
+				"street_address": p.Address.StreetAddress,
+				"locality":       p.Address.Locality,
+				"region":         p.Address.Region,
+				"postal_code":    p.Address.PostalCode,
+				"country":        p.Address.Country,
+			} {
+				if v != "" {
+					addr[k] = v
+				}
+			}
+			if len(addr) > 0 {
+				res["address"] = addr
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+package token
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+)
+
+func TestUserClaims(t *testing.T) {
+	user := &model.UserInfo{
+		Profile: model.UserProfile{
+			GivenName:     "Taro",
+			Email:         "taro@example.com",
+			EmailVerified: true,
+			Address:       model.Address{Country: "Japan"},
+		},
+		Attributes: map[string]string{"department": "dev"},
+	}
+
+	res := UserClaims(user, "openid")
+	if len(res) != 0 {
+		t.Errorf("Claims should be empty without profile scopes, but got %v", res)
+	}
+
+	res = UserClaims(user, "openid profile email")
+	if res["given_name"] != "Taro" || res["department"] != "dev" {
+		t.Errorf("Profile claims are not released: %v", res)
+	}
+	if res["email"] != "taro@example.com" || res["email_verified"] != true {
+		t.Errorf("Email claims are not released: %v", res)
+	}
+	if _, ok := res["family_name"]; ok {
+		t.Errorf("Empty claim should be omitted, but got %v", res)
+	}
+	if _, ok := res["address"]; ok {
+		t.Errorf("Address should not be released without address scope, but got %v", res)
+	}
+	if _, ok := res["phone_number_verified"]; ok {
+		t.Errorf("Phone number verified should not be released without phone number, but got %v", res)
+	}
+
+	res = UserClaims(user, "openid address")
+	addr, ok := res["address"].(map[string]interface{})
+	if !ok || addr["country"] != "Japan" || len(addr) != 1 {
+		t.Errorf("Wrong address claim: %v", res)
+	}
+}
+
+func TestIDTokenClaimsMarshalJSON(t *testing.T) {
+	claims := IDTokenClaims{
+		Nonce:  "nonce",
+		Format: "id",
+		UserClaims: map[string]interface{}{
+			"email": "user@example.com",
+			"nonce": "overridden",
+		},
+	}
+	b, err := claims.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Failed to marshal claims: %v", err)
+	}
+
+	var res IDTokenClaims
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("Failed to unmarshal claims: %v", err)
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatalf("Failed to unmarshal claims: %v", err)
+	}
+	if m["email"] != "user@example.com" {
+		t.Errorf("User claim is not added: %s", string(b))
+	}
+	if res.Nonce != "nonce" {
+		t.Errorf("User claim should not override the ID token claim, but got nonce %s", res.Nonce)
+	}
+}
@@ -0,0 +1,161 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/stretchr/stew/slice"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// dpopProofType is a typ header of the DPoP proof
+	dpopProofType = "dpop+jwt"
+	// dpopProofLifetime is a max age of the DPoP proof from iat
+	dpopProofLifetime = 60 * time.Second
+	// dpopClockSkew is an allowed time difference of iat in the DPoP proof
+	dpopClockSkew = 30 * time.Second
+	// dpopJTIIssuerPrefix is a prefix of the issuer of used jti to separate it from the client assertion
+	dpopJTIIssuerPrefix = "dpop:"
+)
+
+var (
+	// DPoPSigningAlgs are the algorithms to sign the DPoP proof
+	// the proof must be signed by the asymmetric key
+	DPoPSigningAlgs = []string{"RS256", "PS256", "ES256", "EdDSA"}
+)
+
+type dpopKeyContextKey struct{}
+
+type dpopProofClaims struct {
+	JTI      string      `json:"jti"`
+	HTM      string      `json:"htm"`
+	HTU      string      `json:"htu"`
+	IssuedAt json.Number `json:"iat"`
+	ATH      string      `json:"ath"`
+}
+
+// VerifyDPoPProof verifies the DPoP proof in the request defined in RFC 9449 section 4.3
+// accessToken is a token sent with the proof to the protected resource, and it is empty in the token endpoint
+// it returns the JWK thumbprint of the proof key, or empty string if the request does not have the proof
+func VerifyDPoPProof(projectName string, r *http.Request, accessToken string) (string, *errors.Error) {
+	proofs := r.Header.Values("DPoP")
+	if len(proofs) == 0 {
+		return "", nil
+	}
+	if len(proofs) > 1 {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Request must have only one DPoP proof")
+	}
+
+	jws, e := jose.ParseSigned(proofs[0])
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Failed to parse DPoP proof: %v", e)
+	}
+	if len(jws.Signatures) != 1 {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof must have one signature")
+	}
+
+	header := jws.Signatures[0].Header
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != dpopProofType {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Invalid typ header %v", header.ExtraHeaders[jose.HeaderType])
+	}
+	if !slice.Contains(DPoPSigningAlgs, header.Algorithm) {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Signing algorithm %s is not supported", header.Algorithm)
+	}
+	key := header.JSONWebKey
+	if key == nil || !key.Valid() || !key.IsPublic() {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof must have the public key in jwk header")
+	}
+
+	payload, e := jws.Verify(key)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Failed to verify DPoP proof: %v", e)
+	}
+	var claims dpopProofClaims
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if e := dec.Decode(&claims); e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Failed to decode claims: %v", e)
+	}
+
+	if claims.HTM != r.Method {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "htm %s does not match the request method", claims.HTM)
+	}
+	// the query and fragment parts are ignored in the comparison
+	htu, e := url.Parse(claims.HTU)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Invalid htu: %v", e)
+	}
+	htu.RawQuery = ""
+	htu.Fragment = ""
+	if expect := GetExpectIssuer(r) + r.URL.Path; htu.String() != expect {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "htu %s does not match the request url %s", claims.HTU, expect)
+	}
+
+	iat, e := claims.IssuedAt.Float64()
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Invalid iat: %v", e)
+	}
+	sec, frac := math.Modf(iat)
+	issuedAt := time.Unix(int64(sec), int64(frac*1e9))
+	now := time.Now()
+	if now.Add(dpopClockSkew).Before(issuedAt) || now.After(issuedAt.Add(dpopProofLifetime+dpopClockSkew)) {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof issued at %v is not acceptable", issuedAt)
+	}
+
+	if accessToken != "" {
+		// the proof for the protected resource must have the hash of the access token
+		sum := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			return "", errors.Append(errors.ErrInvalidDPoPProof, "ath does not match the access token")
+		}
+	}
+
+	tp, e := key.Thumbprint(crypto.SHA256)
+	if e != nil {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "Failed to get JWK thumbprint: %v", e)
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(tp)
+
+	// the proof can be used only once
+	if claims.JTI == "" {
+		return "", errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof must have jti")
+	}
+	ent := &model.UsedJTI{
+		JTI:         claims.JTI,
+		Issuer:      dpopJTIIssuerPrefix + jkt,
+		ProjectName: projectName,
+		ExpiresAt:   issuedAt.Add(dpopProofLifetime + dpopClockSkew),
+	}
+	if err := db.GetInst().UsedJTIAdd(projectName, ent); err != nil {
+		if errors.Contains(err, model.ErrJTIAlreadyUsed) || errors.Contains(err, model.ErrUsedJTIValidateFailed) {
+			return "", errors.Append(errors.ErrInvalidDPoPProof, "DPoP proof with jti %s is already used", claims.JTI)
+		}
+		return "", errors.Append(err, "Failed to add used jti")
+	}
+
+	return jkt, nil
+}
+
+// WithDPoPKey returns a shallow copy of the request which has the JWK thumbprint of the verified DPoP proof key
+func WithDPoPKey(r *http.Request, jkt string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), dpopKeyContextKey{}, jkt))
+}
+
+// GetDPoPKey returns the JWK thumbprint set by WithDPoPKey
+// it returns empty string if the request does not have the DPoP proof
+func GetDPoPKey(r *http.Request) string {
+	jkt, _ := r.Context().Value(dpopKeyContextKey{}).(string)
+	return jkt
+}
@@ -0,0 +1,111 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func signDPoPProof(t *testing.T, key interface{}, typ jose.ContentType, claims map[string]interface{}) string {
+	t.Helper()
+
+	opts := (&jose.SignerOptions{EmbedJWK: true}).WithType(typ)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	payload, _ := json.Marshal(claims)
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Failed to sign DPoP proof: %v", err)
+	}
+	res, _ := jws.CompactSerialize()
+	return res
+}
+
+func TestVerifyDPoPProof(t *testing.T) {
+	const projectName = "master"
+	const tokenURL = "http://localhost:18443/authapi/v1/project/master/openid-connect/token"
+	const accessToken = "test-access-token"
+
+	db.InitDBManager("memory", "")
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sum := sha256.Sum256([]byte(accessToken))
+	ath := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	proof := func(htm, htu string, iat time.Time, jti string, ath string) map[string]interface{} {
+		res := map[string]interface{}{
+			"htm": htm,
+			"htu": htu,
+			"iat": iat.Unix(),
+			"jti": jti,
+		}
+		if ath != "" {
+			res["ath"] = ath
+		}
+		return res
+	}
+	reused := signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now(), uuid.New().String(), ""))
+
+	tt := []struct {
+		Name        string
+		Proofs      []string
+		AccessToken string
+		ExpectErr   bool
+	}{
+		{"no proof", nil, "", false},
+		{"valid proof", []string{reused}, "", false},
+		{"replayed proof", []string{reused}, "", true},
+		{"valid proof with query in htu", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL+"?foo=bar", time.Now(), uuid.New().String(), ""))}, "", false},
+		{"valid proof with access token", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now(), uuid.New().String(), ath))}, accessToken, false},
+		{"multiple proofs", []string{reused, reused}, "", true},
+		{"wrong typ", []string{signDPoPProof(t, key, "JWT", proof("POST", tokenURL, time.Now(), uuid.New().String(), ""))}, "", true},
+		{"wrong htm", []string{signDPoPProof(t, key, "dpop+jwt", proof("GET", tokenURL, time.Now(), uuid.New().String(), ""))}, "", true},
+		{"wrong htu", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", "http://localhost:18443/other", time.Now(), uuid.New().String(), ""))}, "", true},
+		{"old proof", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now().Add(-10*time.Minute), uuid.New().String(), ""))}, "", true},
+		{"future proof", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now().Add(10*time.Minute), uuid.New().String(), ""))}, "", true},
+		{"no jti", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now(), "", ""))}, "", true},
+		{"no ath", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now(), uuid.New().String(), ""))}, accessToken, true},
+		{"wrong ath", []string{signDPoPProof(t, key, "dpop+jwt", proof("POST", tokenURL, time.Now(), uuid.New().String(), "wrong-hash"))}, accessToken, true},
+	}
+
+	expectJKT, _ := (&jose.JSONWebKey{Key: &key.PublicKey}).Thumbprint(crypto.SHA256)
+	for _, tc := range tt {
+		r, _ := http.NewRequest("POST", tokenURL, nil)
+		for _, p := range tc.Proofs {
+			r.Header.Add("DPoP", p)
+		}
+
+		jkt, err := VerifyDPoPProof(projectName, r, tc.AccessToken)
+		if tc.ExpectErr {
+			if err == nil || err.Error() != errors.ErrInvalidDPoPProof.Error() {
+				t.Errorf("Test %s: Expect error is %v, but got %v", tc.Name, errors.ErrInvalidDPoPProof, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %s: Failed to verify DPoP proof: %v", tc.Name, err)
+			continue
+		}
+		if tc.Proofs == nil {
+			if jkt != "" {
+				t.Errorf("Test %s: Expect empty thumbprint, but got %s", tc.Name, jkt)
+			}
+		} else if jkt != base64.RawURLEncoding.EncodeToString(expectJKT) {
+			t.Errorf("Test %s: Wrong thumbprint %s", tc.Name, jkt)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package token
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -12,8 +14,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/sh-miyoshi/hekate/pkg/config"
 	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 	"github.com/sh-miyoshi/hekate/pkg/errors"
 	"github.com/sh-miyoshi/hekate/pkg/logger"
+	"github.com/sh-miyoshi/hekate/pkg/secret"
 )
 
 func signToken(projectName string, claims jwt.Claims) (string, *errors.Error) {
@@ -21,21 +25,118 @@ func signToken(projectName string, claims jwt.Claims) (string, *errors.Error) {
 	if err != nil {
 		return "", errors.Append(err, "Failed to get project")
 	}
-	switch project.TokenConfig.SigningAlgorithm {
-	case "RS256":
-		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-		key, err := x509.ParsePKCS1PrivateKey(project.TokenConfig.SignSecretKey)
+	signKey := project.TokenConfig.ActiveSignKey()
+	if signKey == nil {
+		return "", errors.New("Invalid request", "No active sign key in project %s", projectName)
+	}
+
+	method := jwt.GetSigningMethod(signKey.SigningAlgorithm)
+	if method == nil {
+		return "", errors.New("Invalid request", "Unexpected Token Signing Algorithm %s", signKey.SigningAlgorithm)
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = signKey.KeyID
+	str, e := token.SigningString()
+	if e != nil {
+		return "", errors.New("Invalid request", "Failed to get signing string: %v", e)
+	}
+
+	// private key is protected by key provider, so ask it to sign
+	sig, err := secret.GetKeyProvider().Sign(signKey.SigningAlgorithm, signKey.PrivateKey, str)
+	if err != nil {
+		return "", errors.Append(err, "Failed to signing token")
+	}
+	return str + "." + sig, nil
+}
+
+// GetVerifyKey returns a key to verify the token signed by the project key
+func GetVerifyKey(token *jwt.Token, project *model.ProjectInfo) (interface{}, *errors.Error) {
+	var signKey *model.SignKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		signKey = project.TokenConfig.FindSignKey(kid, time.Now())
+	} else {
+		signKey = project.TokenConfig.ActiveSignKey()
+	}
+	if signKey == nil {
+		return nil, errors.New("Invalid request", "No such sign key in project %s", project.Name)
+	}
+
+	// Check the algorithm explicitly to prevent the algorithm confusion attack
+	if token.Method.Alg() != signKey.SigningAlgorithm {
+		return nil, errors.New("Invalid request", "unexpected token sigining method %s", token.Method.Alg())
+	}
+
+	keys := &secret.Keys{
+		Public: signKey.PublicKey,
+	}
+	if signKey.SigningAlgorithm == "HS256" {
+		// symmetric key verifies the signature by the protected secret
+		key, err := secret.GetKeyProvider().SharedSecret(signKey.SigningAlgorithm, signKey.PrivateKey)
 		if err != nil {
-			return "", errors.New("Invalid request", "Failed to parse private key: %v", err)
+			return nil, errors.Append(err, "Failed to get shared secret")
 		}
-		str, err := token.SignedString(key)
+		keys.Private = key
+	}
+	return secret.ParseVerifyKey(signKey.SigningAlgorithm, keys)
+}
+
+// signIDToken signs the ID token to the client
+// the ID token signed by HS256 uses the client secret as the key defined in OpenID Connect Core 1.0 section 10.1,
+// because the client can not verify the token signed by the project secret
+func signIDToken(projectName, clientID string, claims jwt.Claims) (string, *errors.Error) {
+	project, err := db.GetInst().ProjectGet(projectName)
+	if err != nil {
+		return "", errors.Append(err, "Failed to get project")
+	}
+	signKey := project.TokenConfig.ActiveSignKey()
+	if signKey == nil {
+		return "", errors.New("Invalid request", "No active sign key in project %s", projectName)
+	}
+	if signKey.SigningAlgorithm != "HS256" {
+		return signToken(projectName, claims)
+	}
+
+	cli, err := db.GetInst().ClientGet(projectName, clientID)
+	if err != nil {
+		return "", errors.Append(err, "Failed to get client")
+	}
+	if cli.Secret == "" {
+		return "", errors.New("Invalid request", "Client %s without secret can not receive the ID token signed by HS256", clientID)
+	}
+
+	// the kid of the project key is kept to check the algorithm in the verification
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = signKey.KeyID
+	str, e := token.SignedString([]byte(cli.Secret))
+	if e != nil {
+		return "", errors.New("Invalid request", "Failed to sign ID token: %v", e)
+	}
+	return str, nil
+}
+
+// getIDTokenVerifyKey returns a key to verify the ID token
+// the key of HS256 is the secret of the client in the audiences
+func getIDTokenVerifyKey(token *jwt.Token, claims *IDTokenClaims, project *model.ProjectInfo) (interface{}, *errors.Error) {
+	key, err := GetVerifyKey(token, project)
+	if err != nil || token.Method.Alg() != "HS256" {
+		return key, err
+	}
+
+	for _, aud := range claims.Audience {
+		cli, err := db.GetInst().ClientGet(project.Name, aud)
 		if err != nil {
-			return "", errors.New("Invalid request", "Failed to signing token: %v", err)
+			// the audiences also contain the user id
+			if errors.Contains(err, model.ErrNoSuchClient) || errors.Contains(err, model.ErrClientValidateFailed) {
+				continue
+			}
+			return nil, errors.Append(err, "Failed to get client")
+		}
+		if cli.Secret != "" {
+			return []byte(cli.Secret), nil
 		}
-		return str, nil
-	default:
-		return "", errors.New("Invalid request", "Unexpected Token Signing Algorithm")
 	}
+	return nil, errors.New("Invalid request", "No client secret to verify the ID token")
 }
 
 // GenerateAccessToken ...
@@ -65,6 +166,17 @@ func GenerateAccessToken(audiences []string, request Request) (string, *errors.E
 		},
 		user.Name,
 		"access",
+		request.ClientID,
+		request.SessionID,
+		request.Scope,
+		nil,
+		request.Actor,
+	}
+	if request.CertThumbprint != "" || request.DPoPKeyThumbprint != "" {
+		claims.Confirmation = &Confirmation{
+			X5TS256: request.CertThumbprint,
+			JKT:     request.DPoPKeyThumbprint,
+		}
 	}
 
 	for _, role := range user.SystemRoles {
@@ -98,6 +210,15 @@ func GenerateRefreshToken(sessionID string, audiences []string, request Request)
 		sessionID,
 		audiences,
 		"refresh",
+		request.ClientID,
+		request.Scope,
+		request.FamilyID,
+		nil,
+	}
+	if request.DPoPKeyThumbprint != "" {
+		claims.Confirmation = &Confirmation{
+			JKT: request.DPoPKeyThumbprint,
+		}
 	}
 
 	return signToken(request.ProjectName, claims)
@@ -120,8 +241,41 @@ func GenerateIDToken(audiences []string, request Request) (string, *errors.Error
 		request.Nonce,
 		request.EndUserAuthTime.Unix(),
 		"id",
+		request.SessionID,
+		nil,
 	}
 
+	if request.Scope != "" {
+		user, err := db.GetInst().UserGet(request.ProjectName, request.UserID)
+		if err != nil {
+			return "", errors.Append(err, "Failed to get user to set claims")
+		}
+		claims.UserClaims = UserClaims(user, request.Scope)
+	}
+
+	return signIDToken(request.ProjectName, request.ClientID, claims)
+}
+
+// GenerateLogoutToken returns a token which is sent to the back-channel logout URI of the client
+func GenerateLogoutToken(audiences []string, request Request) (string, *errors.Error) {
+	now := time.Now()
+	expires := time.Second * time.Duration(request.ExpiresIn)
+	claims := &LogoutTokenClaims{
+		jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    request.Issuer,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expires).Unix(),
+			NotBefore: 0,
+			Subject:   request.UserID,
+		},
+		audiences,
+		request.SessionID,
+		map[string]interface{}{
+			backchannelLogoutEvent: map[string]interface{}{},
+		},
+		"logout",
+	}
 	return signToken(request.ProjectName, claims)
 }
 
@@ -140,6 +294,43 @@ func GenerateSSOToken(request Request) (string, *errors.Error) {
 	return signToken(request.ProjectName, claims)
 }
 
+// GenerateInitialAccessToken returns a token to register the client in the dynamic client registration
+func GenerateInitialAccessToken(request Request) (string, *errors.Error) {
+	now := time.Now()
+	expires := time.Second * time.Duration(request.ExpiresIn)
+	claims := &InitialAccessTokenClaims{
+		jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    request.Issuer,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expires).Unix(),
+			NotBefore: 0,
+		},
+		request.ProjectName,
+		"initial_access",
+	}
+	return signToken(request.ProjectName, claims)
+}
+
+// GenerateLogoutConfirmToken returns a token which is embedded in the logout confirmation page
+func GenerateLogoutConfirmToken(request Request) (string, *errors.Error) {
+	now := time.Now()
+	expires := time.Second * time.Duration(request.ExpiresIn)
+	claims := &LogoutConfirmTokenClaims{
+		jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    request.Issuer,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expires).Unix(),
+			NotBefore: 0,
+			Subject:   request.UserID,
+		},
+		request.ProjectName,
+		"logout_confirm",
+	}
+	return signToken(request.ProjectName, claims)
+}
+
 // ValidateAccessToken ...
 func ValidateAccessToken(claims *AccessTokenClaims, tokenString string, expectIssuer string) *errors.Error {
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -165,16 +356,11 @@ func ValidateAccessToken(claims *AccessTokenClaims, tokenString string, expectIs
 			return nil, errors.New("Invalid request", "Token is expired")
 		}
 
-		switch token.Method {
-		case jwt.SigningMethodRS256:
-			key, err := x509.ParsePKCS1PublicKey(project.TokenConfig.SignPublicKey)
-			if err != nil {
-				return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
-			}
-			return key, nil
+		key, err := GetVerifyKey(token, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
 		}
-
-		return nil, errors.New("Invalid request", "unknown token sigining method")
+		return key, nil
 	})
 
 	if err != nil {
@@ -188,6 +374,28 @@ func ValidateAccessToken(claims *AccessTokenClaims, tokenString string, expectIs
 	if !token.Valid {
 		return errors.New("Invalid request", "Invalid token is specified")
 	}
+
+	revoked, e := db.GetInst().IsTokenRevoked(claims.Project, claims.Id)
+	if e != nil {
+		return errors.Append(e, "Failed to check token revocation")
+	}
+	if revoked {
+		return errors.New("Invalid request", "Token is already revoked")
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds the access token to the revoked token list
+func RevokeAccessToken(claims *AccessTokenClaims) *errors.Error {
+	ent := &model.RevokedToken{
+		TokenID:     claims.Id,
+		ProjectName: claims.Project,
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0),
+	}
+	if err := db.GetInst().RevokedTokenAdd(claims.Project, ent); err != nil {
+		return errors.Append(err, "Failed to add revoked token")
+	}
 	return nil
 }
 
@@ -216,16 +424,11 @@ func ValidateRefreshToken(claims *RefreshTokenClaims, tokenString string, expect
 			return nil, errors.New("Invalid request", "Token is expired")
 		}
 
-		switch token.Method {
-		case jwt.SigningMethodRS256:
-			key, err := x509.ParsePKCS1PublicKey(project.TokenConfig.SignPublicKey)
-			if err != nil {
-				return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
-			}
-			return key, nil
+		key, err := GetVerifyKey(token, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
 		}
-
-		return nil, errors.New("Invalid request", "unknown token sigining method")
+		return key, nil
 	})
 
 	if err != nil {
@@ -268,16 +471,148 @@ func ValidateIDToken(claims *IDTokenClaims, tokenString string, projectName stri
 			return nil, errors.New("Invalid request", "Token is expired")
 		}
 
-		switch token.Method {
-		case jwt.SigningMethodRS256:
-			key, err := x509.ParsePKCS1PublicKey(project.TokenConfig.SignPublicKey)
-			if err != nil {
-				return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
+		key, err := getIDTokenVerifyKey(token, claims, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
+		}
+		return key, nil
+	})
+
+	if err != nil {
+		e, ok := err.(*errors.Error)
+		if !ok {
+			return errors.New("Invalid request", err.Error())
+		}
+		return errors.Append(e, "Failed to parse token")
+	}
+
+	if !token.Valid {
+		return errors.New("Invalid request", "Invalid token is specified")
+	}
+
+	return nil
+}
+
+// idTokenHintMaxExpiredAge is a max time since the expiration of the ID token accepted as id_token_hint
+const idTokenHintMaxExpiredAge = 24 * time.Hour
+
+// ValidateIDTokenHint validates the id_token_hint in the logout request
+// the expired ID token is also accepted because the user may logout after the token is expired
+// but the token expired before idTokenHintMaxExpiredAge is rejected
+func ValidateIDTokenHint(claims *IDTokenClaims, tokenString string, projectName string, expectIssuer string) *errors.Error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		project, err := db.GetInst().ProjectGet(projectName)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get project")
+		}
+
+		if claims.Format != "id" {
+			return nil, errors.New("Invalid request", "Invalid token format: %s", claims.Format)
+		}
+		if claims.Issuer != expectIssuer {
+			logger.Debug("Unexpected token issuer: want %s, got %s", expectIssuer, claims.Issuer)
+			return nil, errors.New("Invalid request", "Unexpected token issuer")
+		}
+
+		key, err := getIDTokenVerifyKey(token, claims, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
+		}
+		return key, nil
+	})
+
+	if err != nil {
+		// the signature is verified if the only error is the expiration
+		if e, ok := err.(*jwt.ValidationError); ok && e.Errors == jwt.ValidationErrorExpired {
+			expiredAt := time.Unix(claims.ExpiresAt, 0)
+			if time.Since(expiredAt) > idTokenHintMaxExpiredAge {
+				return errors.New("Invalid request", "Token is expired at %v", expiredAt)
 			}
-			return key, nil
+			return nil
 		}
+		return errors.New("Invalid request", "Failed to parse token: %v", err)
+	}
 
-		return nil, errors.New("Invalid request", "unknown token sigining method")
+	return nil
+}
+
+// ValidateInitialAccessToken ...
+func ValidateInitialAccessToken(claims *InitialAccessTokenClaims, tokenString string, expectIssuer string) *errors.Error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		project, err := db.GetInst().ProjectGet(claims.Project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get project")
+		}
+
+		if claims.Format != "initial_access" {
+			return nil, errors.New("Invalid request", "Invalid token format: %s", claims.Format)
+		}
+
+		ti := claims.Issuer
+		if len(claims.Issuer) > len(expectIssuer) {
+			ti = claims.Issuer[:len(expectIssuer)]
+		}
+		if ti != expectIssuer {
+			logger.Debug("Unexpected token issuer: want %s, got %s", expectIssuer, ti)
+			return nil, errors.New("Invalid request", "Unexpected token issuer")
+		}
+		now := time.Now().Unix()
+		if now > claims.ExpiresAt {
+			return nil, errors.New("Invalid request", "Token is expired")
+		}
+
+		key, err := GetVerifyKey(token, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
+		}
+		return key, nil
+	})
+
+	if err != nil {
+		e, ok := err.(*errors.Error)
+		if !ok {
+			return errors.New("Invalid request", err.Error())
+		}
+		return errors.Append(e, "Failed to parse token")
+	}
+
+	if !token.Valid {
+		return errors.New("Invalid request", "Invalid token is specified")
+	}
+
+	return nil
+}
+
+// ValidateLogoutConfirmToken ...
+func ValidateLogoutConfirmToken(claims *LogoutConfirmTokenClaims, tokenString string, expectIssuer string) *errors.Error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		project, err := db.GetInst().ProjectGet(claims.Project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get project")
+		}
+
+		if claims.Format != "logout_confirm" {
+			return nil, errors.New("Invalid request", "Invalid token format: %s", claims.Format)
+		}
+
+		ti := claims.Issuer
+		if len(claims.Issuer) > len(expectIssuer) {
+			ti = claims.Issuer[:len(expectIssuer)]
+		}
+		if ti != expectIssuer {
+			logger.Debug("Unexpected token issuer: want %s, got %s", expectIssuer, ti)
+			return nil, errors.New("Invalid request", "Unexpected token issuer")
+		}
+		now := time.Now().Unix()
+		if now > claims.ExpiresAt {
+			return nil, errors.New("Invalid request", "Token is expired")
+		}
+
+		key, err := GetVerifyKey(token, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
+		}
+		return key, nil
 	})
 
 	if err != nil {
@@ -317,3 +652,18 @@ func GetExpectIssuer(r *http.Request) string {
 
 	return fmt.Sprintf("%s://%s", proto, r.Host)
 }
+
+// GetClientCertificates returns the certificate chain sent by the client in the mutual-TLS connection
+// the first element is the client certificate, and it returns nil if the client does not send the certificate
+func GetClientCertificates(r *http.Request) []*x509.Certificate {
+	if !config.Get().HTTPSConfig.ClientCertRequest || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates
+}
+
+// CertThumbprint returns the base64url-encoded SHA-256 thumbprint of the certificate
+func CertThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -3,6 +3,12 @@ package token
 import (
 	"net/http"
 	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/sh-miyoshi/hekate/pkg/db"
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
 )
 
 func TestGetFullIssuer(t *testing.T) {
@@ -50,3 +56,165 @@ func TestGetExpectIssuer(t *testing.T) {
 		}
 	}
 }
+
+func TestRevokeAccessToken(t *testing.T) {
+	const projectName = "revoke"
+	const issuer = "http://localhost/authapi/v1/project/revoke"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+	user := &model.UserInfo{
+		ID:          uuid.New().String(),
+		ProjectName: projectName,
+		Name:        "user",
+		CreatedAt:   time.Now(),
+	}
+	if err := db.GetInst().UserAdd(projectName, user, "password"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	req := Request{
+		Issuer:      issuer,
+		ExpiresIn:   model.DefaultAccessTokenExpiresInSec,
+		ProjectName: projectName,
+		UserID:      user.ID,
+	}
+	tkn, err := GenerateAccessToken([]string{user.ID}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	other, err := GenerateAccessToken([]string{user.ID}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	claims := &AccessTokenClaims{}
+	if err := ValidateAccessToken(claims, tkn, "http://localhost"); err != nil {
+		t.Fatalf("Failed to validate access token: %v", err)
+	}
+	if err := RevokeAccessToken(claims); err != nil {
+		t.Fatalf("Failed to revoke access token: %v", err)
+	}
+
+	if err := ValidateAccessToken(&AccessTokenClaims{}, tkn, "http://localhost"); err == nil {
+		t.Errorf("Revoked access token should be rejected")
+	}
+	if err := ValidateAccessToken(&AccessTokenClaims{}, other, "http://localhost"); err != nil {
+		t.Errorf("Revocation should not affect the other token, but got %v", err)
+	}
+}
+
+func TestValidateIDTokenHint(t *testing.T) {
+	const projectName = "hint"
+	const issuer = "http://localhost/authapi/v1/project/hint"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "RS256",
+		},
+	})
+
+	tt := []struct {
+		name          string
+		expiresIn     int64
+		expectSuccess bool
+	}{
+		{"valid token", 3600, true},
+		{"recently expired token", -60, true},
+		{"too old token", -int64((idTokenHintMaxExpiredAge + time.Hour) / time.Second), false},
+	}
+
+	for _, tc := range tt {
+		req := Request{
+			Issuer:      issuer,
+			ExpiresIn:   tc.expiresIn,
+			ProjectName: projectName,
+			UserID:      uuid.New().String(),
+		}
+		tkn, err := GenerateIDToken([]string{"client"}, req)
+		if err != nil {
+			t.Fatalf("Test %s: Failed to generate ID token: %v", tc.name, err)
+		}
+
+		var claims IDTokenClaims
+		err = ValidateIDTokenHint(&claims, tkn, projectName, issuer)
+		if tc.expectSuccess && err != nil {
+			t.Errorf("Test %s: Expect success, but got error %v", tc.name, err)
+		}
+		if !tc.expectSuccess && err == nil {
+			t.Errorf("Test %s: Expect error, but got nil", tc.name)
+		}
+	}
+}
+
+func TestHS256IDToken(t *testing.T) {
+	const projectName = "hs256"
+	const issuer = "http://localhost/authapi/v1/project/hs256"
+	const secret = "client-secret"
+
+	// Initialize test DB
+	db.InitDBManager("memory", "")
+	if err := db.GetInst().ProjectAdd(&model.ProjectInfo{
+		Name:      projectName,
+		CreatedAt: time.Now(),
+		TokenConfig: &model.TokenConfig{
+			AccessTokenLifeSpan:  model.DefaultAccessTokenExpiresInSec,
+			RefreshTokenLifeSpan: model.DefaultRefreshTokenExpiresInSec,
+			SigningAlgorithm:     "HS256",
+		},
+	}); err != nil {
+		t.Fatalf("Failed to add project: %v", err)
+	}
+	clients := []*model.ClientInfo{
+		{ID: "confidential", ProjectName: projectName, Secret: secret, AccessType: "confidential", CreatedAt: time.Now()},
+		{ID: "public", ProjectName: projectName, AccessType: "public", CreatedAt: time.Now()},
+	}
+	for _, c := range clients {
+		if err := db.GetInst().ClientAdd(projectName, c); err != nil {
+			t.Fatalf("Failed to add client %s: %v", c.ID, err)
+		}
+	}
+
+	req := Request{
+		Issuer:      issuer,
+		ExpiresIn:   3600,
+		ProjectName: projectName,
+		UserID:      uuid.New().String(),
+		ClientID:    "confidential",
+	}
+	tkn, err := GenerateIDToken([]string{req.UserID, "confidential"}, req)
+	if err != nil {
+		t.Fatalf("Failed to generate ID token: %v", err)
+	}
+
+	// the client can verify the token by own secret
+	_, e := jwt.Parse(tkn, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if e != nil {
+		t.Errorf("Failed to verify ID token by the client secret: %v", e)
+	}
+	if err := ValidateIDToken(&IDTokenClaims{}, tkn, projectName, issuer); err != nil {
+		t.Errorf("Failed to validate ID token: %v", err)
+	}
+
+	req.ClientID = "public"
+	if _, err := GenerateIDToken([]string{req.UserID, "public"}, req); err == nil {
+		t.Errorf("ID token signed by HS256 is generated to the public client")
+	}
+}
@@ -1,19 +1,33 @@
 package token
 
 import (
+	"encoding/json"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
+// backchannelLogoutEvent is a member name of the events claim in the logout token
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
 // Request ...
 type Request struct {
 	Issuer          string
 	ExpiresIn       int64
 	ProjectName     string
 	UserID          string
+	ClientID        string
+	SessionID       string
+	FamilyID        string
+	Scope           string
 	Nonce           string
 	EndUserAuthTime time.Time
+	// CertThumbprint is a thumbprint of the client certificate to bind the access token
+	CertThumbprint string
+	// DPoPKeyThumbprint is a JWK thumbprint of the DPoP proof key to bind the token
+	DPoPKeyThumbprint string
+	// Actor is a party which acts on behalf of the user in the token exchange
+	Actor *Actor
 }
 
 // RoleValue ...
@@ -27,35 +41,111 @@ type RoleSet struct {
 	User             RoleValue `json:"user"`
 }
 
+// Confirmation is a key which the token is bound to
+type Confirmation struct {
+	// X5TS256 is a thumbprint of the client certificate defined in RFC 8705
+	X5TS256 string `json:"x5t#S256,omitempty"`
+	// JKT is a JWK thumbprint of the DPoP proof key defined in RFC 9449
+	JKT string `json:"jkt,omitempty"`
+}
+
+// Actor is a party which acts on behalf of the subject defined in RFC 8693 section 4.1
+type Actor struct {
+	Subject  string `json:"sub,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// Actor is a prior actor in the delegation chain
+	Actor *Actor `json:"act,omitempty"`
+}
+
 // AccessTokenClaims ...
 type AccessTokenClaims struct {
 	jwt.StandardClaims
 
-	Project        string   `json:"project"`
-	Audience       []string `json:"aud"`
-	ResourceAccess RoleSet  `json:"resource_access"`
-	UserName       string   `json:"preferred_username"`
-	Format         string   `json:"format"`
+	Project        string        `json:"project"`
+	Audience       []string      `json:"aud"`
+	ResourceAccess RoleSet       `json:"resource_access"`
+	UserName       string        `json:"preferred_username"`
+	Format         string        `json:"format"`
+	ClientID       string        `json:"azp,omitempty"`
+	SessionID      string        `json:"sid,omitempty"`
+	Scope          string        `json:"scope,omitempty"`
+	Confirmation   *Confirmation `json:"cnf,omitempty"`
+	Actor          *Actor        `json:"act,omitempty"`
 }
 
 // RefreshTokenClaims ...
 type RefreshTokenClaims struct {
 	jwt.StandardClaims
 
-	Project   string   `json:"project"`
-	SessionID string   `json:"sessionID"`
-	Audience  []string `json:"aud"`
-	Format    string   `json:"format"`
+	Project      string        `json:"project"`
+	SessionID    string        `json:"sessionID"`
+	Audience     []string      `json:"aud"`
+	Format       string        `json:"format"`
+	ClientID     string        `json:"azp,omitempty"`
+	Scope        string        `json:"scope,omitempty"`
+	FamilyID     string        `json:"family_id,omitempty"`
+	Confirmation *Confirmation `json:"cnf,omitempty"`
 }
 
 // IDTokenClaims ...
 type IDTokenClaims struct {
 	jwt.StandardClaims
 
-	Audience []string `json:"aud"`
-	Nonce    string   `json:"nonce"`
-	AuthTime int64    `json:"auth_time"`
-	Format   string   `json:"format"`
+	Audience  []string `json:"aud"`
+	Nonce     string   `json:"nonce"`
+	AuthTime  int64    `json:"auth_time"`
+	Format    string   `json:"format"`
+	SessionID string   `json:"sid,omitempty"`
 	// TODO(acr, amr, azp)
 	// ref. https://openid-foundation-japan.github.io/openid-connect-core-1_0.ja.html#IDToken
+
+	// UserClaims are the claims of the user released by the scope
+	UserClaims map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON adds the user claims to the ID token without overriding the other claims
+func (c IDTokenClaims) MarshalJSON() ([]byte, error) {
+	type idTokenClaims IDTokenClaims
+	b, err := json.Marshal(idTokenClaims(c))
+	if err != nil || len(c.UserClaims) == 0 {
+		return b, err
+	}
+
+	res := map[string]interface{}{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	for k, v := range c.UserClaims {
+		if _, ok := res[k]; !ok {
+			res[k] = v
+		}
+	}
+	return json.Marshal(res)
+}
+
+// LogoutTokenClaims is a token to notify the client of the logout defined in OpenID Connect Back-Channel Logout 1.0
+type LogoutTokenClaims struct {
+	jwt.StandardClaims
+
+	Audience  []string               `json:"aud"`
+	SessionID string                 `json:"sid,omitempty"`
+	Events    map[string]interface{} `json:"events"`
+	Format    string                 `json:"format"`
+}
+
+// InitialAccessTokenClaims ...
+type InitialAccessTokenClaims struct {
+	jwt.StandardClaims
+
+	Project string `json:"project"`
+	Format  string `json:"format"`
+}
+
+// LogoutConfirmTokenClaims is a one-time token to accept the answer in the logout confirmation page
+// the subject is the user logged in the browser which shows the page, and it is empty without login
+type LogoutConfirmTokenClaims struct {
+	jwt.StandardClaims
+
+	Project string `json:"project"`
+	Format  string `json:"format"`
 }
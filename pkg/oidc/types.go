@@ -30,8 +30,6 @@ type AuthRequest struct {
 	CodeChallenge       string
 	CodeChallengeMethod string
 
-	Request string
-
 	// TODO(implement this)
 	// Display string // display(OPTIONAL)
 	// UILocales string // ui_locales(OPTIONAL)
@@ -76,8 +74,7 @@ func validateResponseType(types, supportedTypes []string) *errors.Error {
 }
 
 func validateResponseMode(mode string) *errors.Error {
-	// TODO(add support form_post)
-	modes := []string{"query", "fragment"}
+	modes := []string{"query", "fragment", "form_post"}
 	if !slice.Contains(modes, mode) {
 		return errors.ErrInvalidRequest
 	}
@@ -115,11 +112,6 @@ func (r *AuthRequest) Validate() *errors.Error {
 		return errors.Append(errors.ErrInvalidRequest, err.Error())
 	}
 
-	// TODO(support request)
-	if r.Request != "" {
-		return errors.ErrRequestNotSupported
-	}
-
 	cfg := config.Get()
 
 	// Check Scope
@@ -156,10 +148,11 @@ type JWKInfo struct {
 	KeyID        string `json:"kid"`
 	Algorithm    string `json:"alg"`
 	PublicKeyUse string `json:"use"`
-	N            string `json:"n,omitempty"` // Use in RSA
-	E            string `json:"e,omitempty"` // Use in RSA
-	X            string `json:"x,omitempty"` // Use in EC
-	Y            string `json:"y,omitempty"` // Use in EC
+	N            string `json:"n,omitempty"`   // Use in RSA
+	E            string `json:"e,omitempty"`   // Use in RSA
+	Curve        string `json:"crv,omitempty"` // Use in EC and OKP
+	X            string `json:"x,omitempty"`   // Use in EC and OKP
+	Y            string `json:"y,omitempty"`   // Use in EC
 }
 
 // JWKSet ...
@@ -175,4 +168,6 @@ type TokenResponse struct {
 	RefreshToken     string
 	RefreshExpiresIn uint
 	IDToken          string
+	// IssuedTokenType is a type of the token issued in the token exchange
+	IssuedTokenType string
 }
@@ -14,10 +14,8 @@ import (
 )
 
 // NewAuthRequest ...
+// the request object in values must be resolved by ResolveRequestObject before calling this
 func NewAuthRequest(values url.Values) *AuthRequest {
-	request := values.Get("request")
-	// TODO(parse request and set params)
-
 	maxAge, _ := strconv.Atoi(values.Get("max_age"))
 	prompt := []string{}
 	if values.Get("prompt") != "" {
@@ -47,7 +45,6 @@ func NewAuthRequest(values url.Values) *AuthRequest {
 		ResponseMode:        resMode,
 		CodeChallenge:       values.Get("code_challenge"),
 		CodeChallengeMethod: values.Get("code_challenge_method"),
-		Request:             request,
 		IDTokenHint:         values.Get("id_token_hint"),
 	}
 }
@@ -77,8 +74,10 @@ func CreateLoggedInResponse(session *model.LoginSession, state, tokenIssuer stri
 				ExpiresIn:       int64(prj.TokenConfig.AccessTokenLifeSpan),
 				ProjectName:     session.ProjectName,
 				UserID:          session.UserID,
+				ClientID:        session.ClientID,
 				Nonce:           session.Nonce,
 				EndUserAuthTime: session.LoginDate,
+				Scope:           session.Scope,
 			}
 			tkn, err := token.GenerateIDToken(audiences, tokenReq)
 			if err != nil {
@@ -97,6 +96,8 @@ func CreateLoggedInResponse(session *model.LoginSession, state, tokenIssuer stri
 				ExpiresIn:   int64(prj.TokenConfig.AccessTokenLifeSpan),
 				ProjectName: session.ProjectName,
 				UserID:      session.UserID,
+				ClientID:    session.ClientID,
+				Scope:       session.Scope,
 			}
 			tkn, err := token.GenerateAccessToken(audiences, tokenReq)
 			if err != nil {
@@ -108,6 +109,17 @@ func CreateLoggedInResponse(session *model.LoginSession, state, tokenIssuer stri
 		}
 	}
 
+	if session.ResponseMode == "form_post" {
+		// the values are sent by the auto-submitting form in WriteLoggedInResponse
+		req, err := http.NewRequest("POST", session.RedirectURI, strings.NewReader(values.Encode()))
+		if err != nil {
+			return nil, errors.New("Internal server error", "Failed to create response: %v", err)
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.PostForm = values
+		return req, nil
+	}
+
 	req, err := http.NewRequest("GET", session.RedirectURI, nil)
 	if err != nil {
 		return nil, errors.New("Internal server error", "Failed to create response: %v", err)
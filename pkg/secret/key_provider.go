@@ -0,0 +1,165 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+// KeyProvider protects the private keys of project and signs the token by them
+// the private key is stored in database as the protected format returned by Protect
+type KeyProvider interface {
+	// Protect converts the raw private key to the format stored in database
+	Protect(alg string, privateKey []byte) ([]byte, *errors.Error)
+	// Sign returns the signature of signingString by the protected key
+	Sign(alg string, protectedKey []byte, signingString string) (string, *errors.Error)
+	// SharedSecret returns the raw secret of symmetric key such as HS256 to verify the token
+	SharedSecret(alg string, protectedKey []byte) ([]byte, *errors.Error)
+	// Unprotect returns the raw private key to export it
+	Unprotect(alg string, protectedKey []byte) ([]byte, *errors.Error)
+	// IsProtected returns false if the key is stored as raw bytes before enabling the provider
+	IsProtected(protectedKey []byte) bool
+	// Validate returns an error if the key is protected by the other provider which this provider can not use
+	Validate(protectedKey []byte) *errors.Error
+}
+
+const (
+	// KeyProviderNone stores the private key as raw bytes
+	KeyProviderNone = "none"
+	// KeyProviderLocal encrypts the private key by the master key
+	KeyProviderLocal = "local"
+	// KeyProviderKMS delegates the key operations to the external KMS or HSM
+	KeyProviderKMS = "kms"
+
+	masterKeyEnvName = "HEKATE_MASTER_KEY"
+)
+
+var provider KeyProvider = &noneKeyProvider{}
+
+// InitKeyProvider initializes the key provider
+// the master key of local provider is read from OS env HEKATE_MASTER_KEY or masterKeyFile
+func InitKeyProvider(typ string, masterKeyFile string) *errors.Error {
+	switch typ {
+	case "", KeyProviderNone:
+		logger.Info("Private keys of project are stored without encryption")
+		provider = &noneKeyProvider{}
+	case KeyProviderLocal:
+		key, err := LoadMasterKey(masterKeyFile)
+		if err != nil {
+			return errors.Append(err, "Failed to load master key")
+		}
+		p, err := NewLocalKeyProvider(key)
+		if err != nil {
+			return errors.Append(err, "Failed to create local key provider")
+		}
+		provider = p
+	case KeyProviderKMS:
+		provider = NewKMSKeyProvider(nil)
+	default:
+		return errors.New("Invalid config", "Key provider type %s is not supported", typ)
+	}
+	return nil
+}
+
+// GetKeyProvider ...
+func GetKeyProvider() KeyProvider {
+	return provider
+}
+
+// LoadMasterKey returns the master key from OS env HEKATE_MASTER_KEY or fileName
+func LoadMasterKey(fileName string) ([]byte, *errors.Error) {
+	data := os.Getenv(masterKeyEnvName)
+	if data == "" {
+		if fileName == "" {
+			return nil, errors.New("Invalid config", "Neither %s nor master key file is set", masterKeyEnvName)
+		}
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, errors.New("Invalid config", "Failed to read master key file: %v", err)
+		}
+		data = string(b)
+	}
+
+	// master key is base64 encoded value
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, errors.New("Invalid config", "Failed to decode master key: %v", err)
+	}
+	return key, nil
+}
+
+// protectedBy returns the type of key provider which protected the key
+func protectedBy(protectedKey []byte) string {
+	switch {
+	case bytes.HasPrefix(protectedKey, envelopePrefix):
+		return KeyProviderLocal
+	case bytes.HasPrefix(protectedKey, kmsKeyRefPrefix):
+		return KeyProviderKMS
+	}
+	return KeyProviderNone
+}
+
+// validateProtectedBy returns an error if the key is protected by the provider other than typ or none
+func validateProtectedBy(protectedKey []byte, typ string) *errors.Error {
+	if by := protectedBy(protectedKey); by != KeyProviderNone && by != typ {
+		return errors.New("Invalid config", "Private key is protected by %s key provider, but the key provider is %s", by, typ)
+	}
+	return nil
+}
+
+func signByRawKey(alg string, privateKey []byte, signingString string) (string, *errors.Error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return "", errors.New("Invalid algorithm", "Algorithm %s is not defined", alg)
+	}
+
+	key, err := ParseSignKey(alg, &Keys{Private: privateKey})
+	if err != nil {
+		return "", errors.Append(err, "Failed to parse sign key")
+	}
+
+	sig, e := method.Sign(signingString, key)
+	if e != nil {
+		return "", errors.New("Sign failed", "Failed to sign by %s: %v", alg, e)
+	}
+	return sig, nil
+}
+
+// noneKeyProvider stores the private key as it is
+type noneKeyProvider struct{}
+
+// Protect ...
+func (p *noneKeyProvider) Protect(alg string, privateKey []byte) ([]byte, *errors.Error) {
+	return privateKey, nil
+}
+
+// Sign ...
+func (p *noneKeyProvider) Sign(alg string, protectedKey []byte, signingString string) (string, *errors.Error) {
+	return signByRawKey(alg, protectedKey, signingString)
+}
+
+// SharedSecret ...
+func (p *noneKeyProvider) SharedSecret(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return protectedKey, nil
+}
+
+// Unprotect ...
+func (p *noneKeyProvider) Unprotect(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return protectedKey, nil
+}
+
+// IsProtected ...
+func (p *noneKeyProvider) IsProtected(protectedKey []byte) bool {
+	return protectedBy(protectedKey) == KeyProviderNone
+}
+
+// Validate ...
+func (p *noneKeyProvider) Validate(protectedKey []byte) *errors.Error {
+	return validateProtectedBy(protectedKey, KeyProviderNone)
+}
@@ -0,0 +1,65 @@
+package secret
+
+import (
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+)
+
+// kmsKeyRefPrefix is a marker of the key reference stored by KMSKeyProvider
+var kmsKeyRefPrefix = []byte("hekate-kms:")
+
+// KMSClient is an interface of the external key management service or HSM(e.g. PKCS#11 module)
+// the private key never leaves the service, so the database stores only the key reference
+type KMSClient interface {
+	// ImportKey imports the private key and returns the reference to it
+	ImportKey(alg string, privateKey []byte) (keyRef string, err error)
+	// Sign signs the data by the key in the service
+	Sign(keyRef string, alg string, data []byte) ([]byte, error)
+}
+
+// KMSKeyProvider signs the token by KMSClient
+type KMSKeyProvider struct {
+	client KMSClient
+}
+
+// NewKMSKeyProvider ...
+func NewKMSKeyProvider(client KMSClient) *KMSKeyProvider {
+	return &KMSKeyProvider{client: client}
+}
+
+// Protect ...
+func (p *KMSKeyProvider) Protect(alg string, privateKey []byte) ([]byte, *errors.Error) {
+	if p.client == nil {
+		return nil, errors.New("Not implemented", "KMS client is not implemented yet")
+	}
+	ref, err := p.client.ImportKey(alg, privateKey)
+	if err != nil {
+		return nil, errors.New("Key protect failed", "Failed to import key to KMS: %v", err)
+	}
+	return append(append([]byte{}, kmsKeyRefPrefix...), ref...), nil
+}
+
+// Sign ...
+func (p *KMSKeyProvider) Sign(alg string, protectedKey []byte, signingString string) (string, *errors.Error) {
+	// TODO(implement by p.client.Sign with the digest format of each algorithm)
+	return "", errors.New("Not implemented", "Signing by KMS is not implemented yet")
+}
+
+// SharedSecret ...
+func (p *KMSKeyProvider) SharedSecret(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return nil, errors.New("Not supported", "KMS does not export the shared secret, so %s is not supported", alg)
+}
+
+// Unprotect ...
+func (p *KMSKeyProvider) Unprotect(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return nil, errors.New("Not supported", "KMS does not export the private key")
+}
+
+// IsProtected ...
+func (p *KMSKeyProvider) IsProtected(protectedKey []byte) bool {
+	return protectedBy(protectedKey) == KeyProviderKMS
+}
+
+// Validate ...
+func (p *KMSKeyProvider) Validate(protectedKey []byte) *errors.Error {
+	return validateProtectedBy(protectedKey, KeyProviderKMS)
+}
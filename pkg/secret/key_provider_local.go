@@ -0,0 +1,168 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/logger"
+)
+
+const (
+	// masterKeyLength is a length of master key for AES-256 in bytes
+	masterKeyLength = 32
+)
+
+// envelopePrefix is a marker of the key encrypted by LocalKeyProvider
+var envelopePrefix = []byte("hekate-envelope-v1:")
+
+// envelope is a format of the encrypted private key
+// Data is encrypted by the random data encryption key(DEK),
+// and the DEK is encrypted by the master key.
+type envelope struct {
+	EncryptedDEK []byte `json:"dek"`
+	Data         []byte `json:"data"`
+}
+
+// LocalKeyProvider encrypts the private key by envelope encryption with the master key
+type LocalKeyProvider struct {
+	masterKey []byte
+}
+
+// NewLocalKeyProvider ...
+func NewLocalKeyProvider(masterKey []byte) (*LocalKeyProvider, *errors.Error) {
+	if len(masterKey) != masterKeyLength {
+		return nil, errors.New("Invalid master key", "Master key length must be %d bytes, but got %d", masterKeyLength, len(masterKey))
+	}
+	return &LocalKeyProvider{masterKey: masterKey}, nil
+}
+
+// Protect ...
+func (p *LocalKeyProvider) Protect(alg string, privateKey []byte) ([]byte, *errors.Error) {
+	dek := make([]byte, masterKeyLength)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, errors.New("Key protect failed", "Failed to generate data encryption key: %v", err)
+	}
+
+	data, err := encryptAESGCM(dek, privateKey)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to encrypt private key")
+	}
+	encDEK, err := encryptAESGCM(p.masterKey, dek)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to encrypt data encryption key")
+	}
+
+	b, e := json.Marshal(&envelope{EncryptedDEK: encDEK, Data: data})
+	if e != nil {
+		return nil, errors.New("Key protect failed", "Failed to marshal envelope: %v", e)
+	}
+	return append(append([]byte{}, envelopePrefix...), b...), nil
+}
+
+// Sign ...
+func (p *LocalKeyProvider) Sign(alg string, protectedKey []byte, signingString string) (string, *errors.Error) {
+	key, err := p.unwrap(protectedKey)
+	if err != nil {
+		return "", errors.Append(err, "Failed to decrypt private key")
+	}
+	return signByRawKey(alg, key, signingString)
+}
+
+// SharedSecret ...
+func (p *LocalKeyProvider) SharedSecret(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return p.unwrap(protectedKey)
+}
+
+// Unprotect ...
+func (p *LocalKeyProvider) Unprotect(alg string, protectedKey []byte) ([]byte, *errors.Error) {
+	return p.unwrap(protectedKey)
+}
+
+// IsProtected ...
+func (p *LocalKeyProvider) IsProtected(protectedKey []byte) bool {
+	return protectedBy(protectedKey) == KeyProviderLocal
+}
+
+// Validate ...
+func (p *LocalKeyProvider) Validate(protectedKey []byte) *errors.Error {
+	return validateProtectedBy(protectedKey, KeyProviderLocal)
+}
+
+func (p *LocalKeyProvider) unwrap(protectedKey []byte) ([]byte, *errors.Error) {
+	if !p.IsProtected(protectedKey) {
+		// the key was created before enabling the encryption
+		// it is encrypted by ProjectProtectSignKeys at the server start
+		logger.Debug("Private key is not encrypted, so use it as raw key")
+		return protectedKey, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(protectedKey[len(envelopePrefix):], &env); err != nil {
+		return nil, errors.New("Key unwrap failed", "Failed to unmarshal envelope: %v", err)
+	}
+	dek, err := decryptAESGCM(p.masterKey, env.EncryptedDEK)
+	if err != nil {
+		return nil, errors.Append(err, "Failed to decrypt data encryption key")
+	}
+	return decryptAESGCM(dek, env.Data)
+}
+
+// EncryptByKey encrypts data by AES-256-GCM with the key such as the master key
+func EncryptByKey(key, data []byte) ([]byte, *errors.Error) {
+	if len(key) != masterKeyLength {
+		return nil, errors.New("Invalid key", "Key length must be %d bytes, but got %d", masterKeyLength, len(key))
+	}
+	return encryptAESGCM(key, data)
+}
+
+// DecryptByKey decrypts data encrypted by EncryptByKey
+func DecryptByKey(key, data []byte) ([]byte, *errors.Error) {
+	if len(key) != masterKeyLength {
+		return nil, errors.New("Invalid key", "Key length must be %d bytes, but got %d", masterKeyLength, len(key))
+	}
+	return decryptAESGCM(key, data)
+}
+
+// encryptAESGCM returns nonce + ciphertext
+func encryptAESGCM(key, plaintext []byte) ([]byte, *errors.Error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.New("Encrypt failed", "Failed to generate nonce: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptAESGCM(key, ciphertext []byte) ([]byte, *errors.Error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("Decrypt failed", "Ciphertext is too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	res, e := gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+	if e != nil {
+		return nil, errors.New("Decrypt failed", "Failed to decrypt data: %v", e)
+	}
+	return res, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, *errors.Error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.New("Cipher init failed", "Failed to create AES cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.New("Cipher init failed", "Failed to create GCM: %v", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,96 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func TestLocalKeyProvider(t *testing.T) {
+	masterKey := bytes.Repeat([]byte{1}, masterKeyLength)
+	p, err := NewLocalKeyProvider(masterKey)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	for _, alg := range []string{"RS256", "PS256", "ES256", "EdDSA", "HS256"} {
+		keys, _ := GetSignKey(alg)
+		protected, err := p.Protect(alg, keys.Private)
+		if err != nil {
+			t.Errorf("Failed to protect %s key: %v", alg, err)
+			continue
+		}
+		if bytes.Contains(protected, keys.Private) {
+			t.Errorf("Protected %s key contains raw private key", alg)
+		}
+
+		sig, err := p.Sign(alg, protected, "header.payload")
+		if err != nil {
+			t.Errorf("Failed to sign by %s key: %v", alg, err)
+			continue
+		}
+
+		if alg == "HS256" {
+			keys.Private, err = p.SharedSecret(alg, protected)
+			if err != nil {
+				t.Errorf("Failed to get shared secret: %v", err)
+				continue
+			}
+		}
+		verifyKey, _ := ParseVerifyKey(alg, keys)
+		if e := jwt.GetSigningMethod(alg).Verify("header.payload", sig, verifyKey); e != nil {
+			t.Errorf("Failed to verify the signature by %s key: %v", alg, e)
+		}
+	}
+
+	// Raw key created before enabling encryption can be used
+	keys, _ := GetSignKey("RS256")
+	if _, err := p.Sign("RS256", keys.Private, "header.payload"); err != nil {
+		t.Errorf("Failed to sign by raw key: %v", err)
+	}
+
+	// Other master key can not decrypt the key
+	protected, _ := p.Protect("RS256", keys.Private)
+	other, _ := NewLocalKeyProvider(bytes.Repeat([]byte{2}, masterKeyLength))
+	if _, err := other.Sign("RS256", protected, "header.payload"); err == nil {
+		t.Errorf("Sign by wrong master key should be failed")
+	}
+
+	// Invalid master key length
+	if _, err := NewLocalKeyProvider([]byte("short")); err == nil {
+		t.Errorf("Short master key should be rejected")
+	}
+}
+
+func TestKeyProviderValidate(t *testing.T) {
+	local, _ := NewLocalKeyProvider(bytes.Repeat([]byte{1}, masterKeyLength))
+	keys, _ := GetSignKey("RS256")
+	encrypted, _ := local.Protect("RS256", keys.Private)
+	kmsRef := append(append([]byte{}, kmsKeyRefPrefix...), "key-ref"...)
+
+	tt := []struct {
+		name          string
+		provider      KeyProvider
+		key           []byte
+		expectSuccess bool
+	}{
+		{"raw key by none", &noneKeyProvider{}, keys.Private, true},
+		{"encrypted key by none", &noneKeyProvider{}, encrypted, false},
+		{"kms key by none", &noneKeyProvider{}, kmsRef, false},
+		{"raw key by local", local, keys.Private, true},
+		{"encrypted key by local", local, encrypted, true},
+		{"kms key by local", local, kmsRef, false},
+		{"encrypted key by kms", NewKMSKeyProvider(nil), encrypted, false},
+	}
+
+	for _, tc := range tt {
+		err := tc.provider.Validate(tc.key)
+		if tc.expectSuccess && err != nil {
+			t.Errorf("Test %s: Expect success, but got error %v", tc.name, err)
+		}
+		if !tc.expectSuccess && err == nil {
+			t.Errorf("Test %s: Expect error, but got nil", tc.name)
+		}
+	}
+}
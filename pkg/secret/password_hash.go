@@ -0,0 +1,233 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/errors"
+	"github.com/sh-miyoshi/hekate/pkg/util"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher creates and verifies the hash of user password
+// the hash is encoded in PHC string format which contains the algorithm and its parameters
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password
+	Hash(password string) (string, *errors.Error)
+	// Verify reports whether the password matches the encoded hash
+	Verify(password string, encoded string) (bool, *errors.Error)
+	// NeedsRehash reports whether the encoded hash was created by other algorithm or parameters
+	NeedsRehash(encoded string) bool
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// minArgon2KeyLength is a minimum length of the hash to be verified
+	minArgon2KeyLength = 16
+)
+
+var (
+	// ErrInvalidPasswordHash ...
+	ErrInvalidPasswordHash = errors.New("Invalid password hash", "Invalid password hash")
+
+	legacyHashRegExp = regexp.MustCompile(`^[0-9a-f]{128}$`)
+)
+
+// NewPasswordHasher returns the hasher of cfg.Algorithm
+func NewPasswordHasher(cfg model.PasswordHashConfig) (PasswordHasher, *errors.Error) {
+	cfg = cfg.WithDefault()
+	switch cfg.Algorithm {
+	case model.PasswordHashArgon2id:
+		return &argon2idHasher{
+			iterations:  uint32(cfg.Argon2Iterations),
+			memory:      uint32(cfg.Argon2Memory),
+			parallelism: uint8(cfg.Argon2Parallelism),
+		}, nil
+	case model.PasswordHashBcrypt:
+		return &bcryptHasher{cost: int(cfg.BcryptCost)}, nil
+	}
+	return nil, errors.New("Invalid algorithm", "Password hash algorithm %s is not supported", cfg.Algorithm)
+}
+
+// HashPassword returns the encoded hash of the password by the project config
+func HashPassword(password string, cfg model.PasswordHashConfig) (string, *errors.Error) {
+	h, err := NewPasswordHasher(cfg)
+	if err != nil {
+		return "", err
+	}
+	return h.Hash(password)
+}
+
+// VerifyPassword reports whether the password matches the encoded hash
+// the hasher is selected by the prefix of the hash, and legacy SHA-512 hex digest is also accepted
+func VerifyPassword(password string, encoded string) (bool, *errors.Error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return (&argon2idHasher{}).Verify(password, encoded)
+	case isBcryptHash(encoded):
+		return (&bcryptHasher{}).Verify(password, encoded)
+	case IsLegacyPasswordHash(encoded):
+		hash := util.CreateHash(password)
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(encoded)) == 1, nil
+	}
+	return false, ErrInvalidPasswordHash
+}
+
+// PasswordNeedsRehash reports whether the encoded hash should be recreated by the project config
+func PasswordNeedsRehash(encoded string, cfg model.PasswordHashConfig) bool {
+	h, err := NewPasswordHasher(cfg)
+	if err != nil {
+		return false
+	}
+	return h.NeedsRehash(encoded)
+}
+
+// IsLegacyPasswordHash reports whether the hash is unsalted SHA-512 hex digest created by old version
+func IsLegacyPasswordHash(encoded string) bool {
+	return legacyHashRegExp.MatchString(encoded)
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// argon2idHasher hashes the password by argon2id
+// format: $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type argon2idHasher struct {
+	iterations  uint32
+	memory      uint32
+	parallelism uint8
+}
+
+type argon2idHash struct {
+	iterations  uint32
+	memory      uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func parseArgon2idHash(encoded string) (*argon2idHash, *errors.Error) {
+	vals := strings.Split(encoded, "$")
+	if len(vals) != 6 || vals[1] != "argon2id" {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(vals[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Unsupported argon2 version %s", vals[2])
+	}
+
+	res := &argon2idHash{}
+	var memory, iterations, parallelism uint64
+	if _, err := fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Invalid argon2id parameters: %v", err)
+	}
+	// the parameters come from the stored hash, so check them not to panic or exhaust resources in argon2.IDKey
+	if iterations < 1 || iterations > model.MaxArgon2Iterations {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Argon2id iterations %d is out of range", iterations)
+	}
+	if memory < 1 || memory > model.MaxArgon2Memory {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Argon2id memory %d is out of range", memory)
+	}
+	if parallelism < 1 || parallelism > model.MaxArgon2Parallelism {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Argon2id parallelism %d is out of range", parallelism)
+	}
+	res.memory = uint32(memory)
+	res.iterations = uint32(iterations)
+	res.parallelism = uint8(parallelism)
+
+	var err error
+	if res.salt, err = base64.RawStdEncoding.DecodeString(vals[4]); err != nil {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Failed to decode salt: %v", err)
+	}
+	if res.key, err = base64.RawStdEncoding.DecodeString(vals[5]); err != nil {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Failed to decode hash: %v", err)
+	}
+	if len(res.key) < minArgon2KeyLength {
+		return nil, errors.Append(ErrInvalidPasswordHash, "Argon2id hash is too short")
+	}
+	return res, nil
+}
+
+// Hash ...
+func (h *argon2idHasher) Hash(password string) (string, *errors.Error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.New("Hash failed", "Failed to generate salt: %v", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify ...
+func (h *argon2idHasher) Verify(password string, encoded string) (bool, *errors.Error) {
+	p, err := parseArgon2idHash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+// NeedsRehash ...
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	p, err := parseArgon2idHash(encoded)
+	if err != nil {
+		return true
+	}
+	return p.iterations != h.iterations || p.memory != h.memory || p.parallelism != h.parallelism
+}
+
+// bcryptHasher hashes the password by bcrypt
+// format: $2a$<cost>$<salt and hash>
+type bcryptHasher struct {
+	cost int
+}
+
+// Hash ...
+func (h *bcryptHasher) Hash(password string) (string, *errors.Error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", errors.New("Hash failed", "Failed to generate bcrypt hash: %v", err)
+	}
+	return string(b), nil
+}
+
+// Verify ...
+func (h *bcryptHasher) Verify(password string, encoded string) (bool, *errors.Error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == nil {
+		return true, nil
+	}
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return false, errors.Append(ErrInvalidPasswordHash, "Failed to verify bcrypt hash: %v", err)
+}
+
+// NeedsRehash ...
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcryptHash(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/sh-miyoshi/hekate/pkg/db/model"
+	"github.com/sh-miyoshi/hekate/pkg/util"
+)
+
+func TestPasswordHash(t *testing.T) {
+	const password = "test-password"
+
+	tt := []model.PasswordHashConfig{
+		{Algorithm: model.PasswordHashArgon2id, Argon2Iterations: 1, Argon2Memory: 1024, Argon2Parallelism: 1},
+		{Algorithm: model.PasswordHashBcrypt, BcryptCost: 4},
+	}
+
+	for _, cfg := range tt {
+		hash, err := HashPassword(password, cfg)
+		if err != nil {
+			t.Errorf("Failed to hash password by %s: %v", cfg.Algorithm, err)
+			continue
+		}
+
+		if ok, err := VerifyPassword(password, hash); !ok || err != nil {
+			t.Errorf("Verify by %s returns wrong response. got %v, %v, want true, nil", cfg.Algorithm, ok, err)
+		}
+		if ok, err := VerifyPassword("wrong-password", hash); ok || err != nil {
+			t.Errorf("Verify wrong password by %s returns wrong response. got %v, %v, want false, nil", cfg.Algorithm, ok, err)
+		}
+
+		if PasswordNeedsRehash(hash, cfg) {
+			t.Errorf("Hash by %s requires rehash by same config", cfg.Algorithm)
+		}
+		changed := cfg
+		changed.Argon2Iterations++
+		changed.BcryptCost++
+		if !PasswordNeedsRehash(hash, changed) {
+			t.Errorf("Hash by %s does not require rehash by changed config", cfg.Algorithm)
+		}
+	}
+}
+
+func TestVerifyLegacyPasswordHash(t *testing.T) {
+	const password = "test-password"
+	hash := util.CreateHash(password)
+
+	if ok, err := VerifyPassword(password, hash); !ok || err != nil {
+		t.Errorf("Verify legacy hash returns wrong response. got %v, %v, want true, nil", ok, err)
+	}
+	if ok, _ := VerifyPassword("wrong-password", hash); ok {
+		t.Errorf("Verify legacy hash with wrong password returns true")
+	}
+	if !PasswordNeedsRehash(hash, model.PasswordHashConfig{}) {
+		t.Errorf("Legacy hash does not require rehash")
+	}
+
+	if _, err := VerifyPassword(password, "invalid-hash"); err == nil {
+		t.Errorf("Verify invalid hash returns nil error")
+	}
+}
+
+func TestVerifyArgon2idHashParameters(t *testing.T) {
+	const salt = "c29tZXNhbHRzb21lc2FsdA"
+	const key = "c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhcw"
+
+	tt := []struct {
+		name   string
+		params string
+	}{
+		{"zero iterations", "m=1024,t=0,p=1"},
+		{"too many iterations", "m=1024,t=11,p=1"},
+		{"zero memory", "m=0,t=1,p=1"},
+		{"too large memory", "m=4294967295,t=1,p=1"},
+		{"zero parallelism", "m=1024,t=1,p=0"},
+		{"too large parallelism", "m=1024,t=1,p=256"},
+	}
+
+	for _, tc := range tt {
+		hash := "$argon2id$v=19$" + tc.params + "$" + salt + "$" + key
+		if _, err := VerifyPassword("test-password", hash); err == nil {
+			t.Errorf("Test %s: Verify hash with invalid parameters returns nil error", tc.name)
+		}
+	}
+
+	if _, err := VerifyPassword("test-password", "$argon2id$v=19$m=1024,t=1,p=1$"+salt+"$"); err == nil {
+		t.Errorf("Verify hash without key returns nil error")
+	}
+}
@@ -1,6 +1,9 @@
 package secret
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -14,10 +17,15 @@ type Keys struct {
 	Private []byte
 }
 
+const (
+	// hmacKeyLength is a length of secret key for HS256 in bytes
+	hmacKeyLength = 64
+)
+
 // GetSignKey ...
 func GetSignKey(alg string) (*Keys, *errors.Error) {
 	switch alg {
-	case "RS256":
+	case "RS256", "PS256":
 		key, err := rsa.GenerateKey(rand.Reader, 2048) // fixed key length is ok?
 		if err != nil {
 			return nil, errors.New("RSA key generate failed", "Failed to generate RSA private key: %v", err)
@@ -25,7 +33,129 @@ func GetSignKey(alg string) (*Keys, *errors.Error) {
 		privateKey := x509.MarshalPKCS1PrivateKey(key)
 		publicKey := x509.MarshalPKCS1PublicKey(&key.PublicKey)
 		return &Keys{Public: publicKey, Private: privateKey}, nil
+	case "ES256":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, errors.New("ECDSA key generate failed", "Failed to generate ECDSA private key: %v", err)
+		}
+		privateKey, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, errors.New("ECDSA key generate failed", "Failed to marshal ECDSA private key: %v", err)
+		}
+		publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, errors.New("ECDSA key generate failed", "Failed to marshal ECDSA public key: %v", err)
+		}
+		return &Keys{Public: publicKey, Private: privateKey}, nil
+	case "EdDSA":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, errors.New("Ed25519 key generate failed", "Failed to generate Ed25519 private key: %v", err)
+		}
+		privateKey, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, errors.New("Ed25519 key generate failed", "Failed to marshal Ed25519 private key: %v", err)
+		}
+		publicKey, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, errors.New("Ed25519 key generate failed", "Failed to marshal Ed25519 public key: %v", err)
+		}
+		return &Keys{Public: publicKey, Private: privateKey}, nil
+	case "HS256":
+		// HMAC uses a shared secret, so there is no public key
+		key := make([]byte, hmacKeyLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.New("HMAC key generate failed", "Failed to generate HMAC secret key: %v", err)
+		}
+		return &Keys{Private: key}, nil
+	}
+
+	return nil, errors.New("Invalid algorithm", "Algorithm %s is not defined", alg)
+}
+
+// ParseSignKey returns a key to sign the token
+func ParseSignKey(alg string, keys *Keys) (interface{}, *errors.Error) {
+	switch alg {
+	case "RS256", "PS256":
+		key, err := x509.ParsePKCS1PrivateKey(keys.Private)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse private key: %v", err)
+		}
+		return key, nil
+	case "ES256":
+		key, err := x509.ParseECPrivateKey(keys.Private)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse private key: %v", err)
+		}
+		return key, nil
+	case "EdDSA":
+		key, err := x509.ParsePKCS8PrivateKey(keys.Private)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse private key: %v", err)
+		}
+		res, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("Invalid request", "Private key is not Ed25519 key")
+		}
+		return res, nil
+	case "HS256":
+		if len(keys.Private) == 0 {
+			return nil, errors.New("Invalid request", "Secret key is empty")
+		}
+		return keys.Private, nil
 	}
 
 	return nil, errors.New("Invalid algorithm", "Algorithm %s is not defined", alg)
 }
+
+// ParseVerifyKey returns a key to verify the token signature
+func ParseVerifyKey(alg string, keys *Keys) (interface{}, *errors.Error) {
+	switch alg {
+	case "RS256", "PS256":
+		key, err := x509.ParsePKCS1PublicKey(keys.Public)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
+		}
+		return key, nil
+	case "ES256":
+		key, err := x509.ParsePKIXPublicKey(keys.Public)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
+		}
+		res, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New("Invalid request", "Public key is not ECDSA key")
+		}
+		return res, nil
+	case "EdDSA":
+		key, err := x509.ParsePKIXPublicKey(keys.Public)
+		if err != nil {
+			return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
+		}
+		res, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("Invalid request", "Public key is not Ed25519 key")
+		}
+		return res, nil
+	case "HS256":
+		// HMAC verifies the signature by the same secret key
+		return ParseSignKey(alg, keys)
+	}
+
+	return nil, errors.New("Invalid algorithm", "Algorithm %s is not defined", alg)
+}
+
+// IsValidSignKey returns true if the keys can be used in the algorithm
+func IsValidSignKey(alg string, keys *Keys) bool {
+	if _, err := ParseSignKey(alg, keys); err != nil {
+		return false
+	}
+	if alg == "HS256" {
+		// HMAC secret key must not have public key
+		return len(keys.Public) == 0
+	}
+	if _, err := ParseVerifyKey(alg, keys); err != nil {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package secret
+
+import (
+	"testing"
+)
+
+func TestGetSignKey(t *testing.T) {
+	tt := []struct {
+		alg    string
+		expect bool
+	}{
+		{"RS256", true},
+		{"PS256", true},
+		{"ES256", true},
+		{"EdDSA", true},
+		{"HS256", true},
+		{"none", false},
+	}
+
+	for _, tc := range tt {
+		keys, err := GetSignKey(tc.alg)
+		if tc.expect != (err == nil) {
+			t.Errorf("GetSignKey(%s) returns wrong result. want ok: %v, got error: %v", tc.alg, tc.expect, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if !IsValidSignKey(tc.alg, keys) {
+			t.Errorf("Generated key for %s is not valid", tc.alg)
+		}
+	}
+}
+
+func TestIsValidSignKey(t *testing.T) {
+	rsaKeys, _ := GetSignKey("RS256")
+	ecKeys, _ := GetSignKey("ES256")
+	hmacKeys, _ := GetSignKey("HS256")
+
+	tt := []struct {
+		alg    string
+		keys   *Keys
+		expect bool
+	}{
+		{"PS256", rsaKeys, true},
+		{"ES256", rsaKeys, false},
+		{"EdDSA", ecKeys, false},
+		{"HS256", rsaKeys, false}, // public key must be empty
+		{"RS256", hmacKeys, false},
+		{"HS256", &Keys{}, false},
+	}
+
+	for _, tc := range tt {
+		res := IsValidSignKey(tc.alg, tc.keys)
+		if res != tc.expect {
+			t.Errorf("IsValidSignKey(%s) returns wrong result. want %v, got %v", tc.alg, tc.expect, res)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package sso
 
 import (
-	"crypto/x509"
 	"net/http"
 	"time"
 
@@ -34,6 +33,7 @@ func SetSSOSessionToCookie(w http.ResponseWriter, projectName, userID, issuer st
 	cookie := &http.Cookie{
 		Name:     "HEKATE_LOGIN_SESSION",
 		Value:    tkn,
+		Path:     cookiePath(projectName),
 		MaxAge:   int(req.ExpiresIn),
 		Secure:   cfg.HTTPSConfig.Enabled,
 		HttpOnly: true,
@@ -43,25 +43,33 @@ func SetSSOSessionToCookie(w http.ResponseWriter, projectName, userID, issuer st
 	return nil
 }
 
+// ClearSSOSessionCookie removes the SSO session cookie from the browser
+func ClearSSOSessionCookie(w http.ResponseWriter, projectName string) {
+	cookie := &http.Cookie{
+		Name:     "HEKATE_LOGIN_SESSION",
+		Value:    "",
+		Path:     cookiePath(projectName),
+		MaxAge:   -1,
+		Secure:   config.Get().HTTPSConfig.Enabled,
+		HttpOnly: true,
+	}
+	http.SetCookie(w, cookie)
+}
+
 // GetLoginUserIDFromSSOSessionCookie ...
 func GetLoginUserIDFromSSOSessionCookie(cookie *http.Cookie, projectName string) (string, *errors.Error) {
 	var claims jwt.StandardClaims
-	tkn, err := jwt.ParseWithClaims(cookie.Value, &claims, func(token *jwt.Token) (interface{}, error) {
+	tkn, err := jwt.ParseWithClaims(cookie.Value, &claims, func(t *jwt.Token) (interface{}, error) {
 		project, err := db.GetInst().ProjectGet(projectName)
 		if err != nil {
 			return nil, errors.Append(err, "Failed to get project")
 		}
 
-		switch token.Method {
-		case jwt.SigningMethodRS256:
-			key, err := x509.ParsePKCS1PublicKey(project.TokenConfig.SignPublicKey)
-			if err != nil {
-				return nil, errors.New("Invalid request", "Failed to parse public key: %v", err)
-			}
-			return key, nil
+		key, err := token.GetVerifyKey(t, project)
+		if err != nil {
+			return nil, errors.Append(err, "Failed to get verify key")
 		}
-
-		return nil, errors.New("Invalid request", "unknown token sigining method")
+		return key, nil
 	})
 
 	if err != nil || !tkn.Valid {
@@ -124,3 +132,9 @@ func Handle(method string, projectName string, userID string, tokenIssuer string
 
 	return nil, errors.Append(errors.ErrLoginRequired, "No valid session, so return login_required")
 }
+
+// cookiePath returns the path of the SSO session cookie
+// the cookie must be sent to both the authorization endpoint and the logout endpoint in the project
+func cookiePath(projectName string) string {
+	return "/authapi/v1/project/" + projectName
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 )
 
-// CreateHash ...
+// CreateHash returns SHA-512 hex digest of data
+// it is not suitable for user password, use secret.HashPassword instead
 func CreateHash(data string) string {
 	byteHash := sha512.Sum512([]byte(data))
 	return fmt.Sprintf("%x", byteHash)
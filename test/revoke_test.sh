@@ -58,7 +58,8 @@ echo "successfully denied access"
 # revoke refresh token, get access token by revoked refresh token
 curl --insecure -s -X POST $URL/project/$PROJECT_NAME/openid-connect/revoke \
   -H "Content-Type: application/x-www-form-urlencoded" \
-  -d "token=$new_refresh_token"
+  -d "token=$new_refresh_token" \
+  -d "client_id=portal"
 status=`curl --insecure -s -X POST $URL/project/$PROJECT_NAME/openid-connect/token \
   -H "Content-Type: application/x-www-form-urlencoded" \
   -d "refresh_token=$new_refresh_token" \